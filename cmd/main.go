@@ -14,18 +14,26 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"gorm.io/gorm"
 	"io"
 	"khrllwTest/internal/db"
 	"khrllwTest/internal/handlers"
+	"khrllwTest/internal/mail"
 	"khrllwTest/internal/middleware"
+	"khrllwTest/internal/models"
+	"khrllwTest/internal/oidc"
 	"khrllwTest/internal/repository"
 	service "khrllwTest/internal/services"
+	"khrllwTest/internal/storage"
 	"khrllwTest/internal/utils"
 	"log"
+	"net/http"
 	"os"
+	"time"
 
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -47,6 +55,14 @@ func getPort() string {
 	return "8080"
 }
 
+// getTOTPIssuer получает название сервиса для приложений-аутентификаторов
+func getTOTPIssuer() string {
+	if issuer := os.Getenv("TOTP_ISSUER"); issuer != "" {
+		return issuer
+	}
+	return "khrllwTest"
+}
+
 // initDatabase инициализирует подключение к БД
 func initDatabase(logConfig *middleware.LoggerConfig) *gorm.DB {
 	db, err := db.SetupDatabase(logConfig)
@@ -59,20 +75,70 @@ func initDatabase(logConfig *middleware.LoggerConfig) *gorm.DB {
 // setupRouter настраивает маршруты API
 func setupRouter(userHandler *handlers.UserHandler,
 	orderHandler *handlers.OrderHandler,
+	avatarHandler *handlers.AvatarHandler,
 	loginHandler *handlers.LoginHandler,
+	jwksHandler *handlers.JWKSHandler,
+	apiKeyHandler *handlers.APIKeyHandler,
+	twoFactorHandler *handlers.TwoFactorHandler,
+	passwordHandler *handlers.PasswordHandler,
+	verificationHandler *handlers.EmailVerificationHandler,
+	sessionHandler *handlers.SessionHandler,
+	oidcHandler *handlers.OIDCHandler,
+	impersonationHandler *handlers.ImpersonationHandler,
+	statsHandler *handlers.StatsHandler,
 	authorization *middleware.Authorization,
+	auditRecorder middleware.AuditRecorder,
 	logConfig *middleware.LoggerConfig) *gin.Engine {
 
 	router := gin.Default()
 
+	// Журнал аудита запросов с токенами имперсонации
+	router.Use(middleware.ImpersonationAudit(auditRecorder))
+
 	// Swagger
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// ------------------------- Обработка запросов -------------------------
 
+	// Роут с открытыми ключами для проверки токенов другими сервисами
+	router.GET("/.well-known/jwks.json", jwksHandler.GetJWKS)
+
 	// Роут для авторизации пользователя
 	router.POST("/auth/login", loginHandler.Login)
 
+	// Роут для второго шага входа с 2FA
+	router.POST("/auth/login/2fa", loginHandler.LoginTwoFactor)
+
+	// Роут для обновления пары токенов по refresh токену
+	router.POST("/auth/refresh", loginHandler.Refresh)
+
+	// Роуты для входа через OpenID Connect
+	router.GET("/auth/oidc/providers", oidcHandler.GetProviders)
+	router.GET("/auth/oidc/:provider/login", oidcHandler.Login)
+	router.GET("/auth/oidc/:provider/callback", oidcHandler.Callback)
+
+	// Роуты для восстановления пароля
+	router.POST("/auth/password/forgot", passwordHandler.ForgotPassword)
+	router.POST("/auth/password/reset", passwordHandler.ResetPassword)
+
+	// Роут для подтверждения email по ссылке из письма
+	router.POST("/auth/verify-email", verificationHandler.VerifyEmail)
+
+	// Группа для завершения сессий (требует авторизации)
+	authGroup := router.Group("/auth")
+	authGroup.Use(authorization.Middleware(), authorization.Allow(models.RoleUser, models.RoleAdmin), authorization.RequireToken())
+	{
+		authGroup.POST("/logout", loginHandler.Logout)
+		authGroup.POST("/logout-all", authorization.DenyImpersonation(), loginHandler.LogoutAll)
+		authGroup.POST("/verify-email/resend", verificationHandler.ResendVerification)
+	}
+
+	// Журнал аудита (только администраторы)
+	router.GET("/audit-logs", authorization.Middleware(), authorization.Allow(models.RoleAdmin), impersonationHandler.GetAuditLogs)
+
+	// Статистика кеша пользователей (только администраторы)
+	router.GET("/stats/user-cache", authorization.Middleware(), authorization.Allow(models.RoleAdmin), statsHandler.GetUserCacheStats)
+
 	// Роут для создания пользователя (без авторизации)
 	router.POST("/users", userHandler.CreateUser)
 
@@ -80,32 +146,126 @@ func setupRouter(userHandler *handlers.UserHandler,
 	usersGroup := router.Group("/users")
 	usersGroup.Use(authorization.Middleware())
 	{
-		usersGroup.GET("", userHandler.GetUsers)
+		// Каждый маршрут объявляет допустимые роли: middleware.Self - владелец аккаунта.
+		// API ключи допускаются только на маршруты с RequireScopes
+		usersGroup.GET("",
+			authorization.RequireScopes(models.ScopeUsersRead),
+			authorization.Allow(models.RoleUser, models.RoleAdmin),
+			userHandler.GetUsers)
 
 		usersIDGroup := usersGroup.Group("/:user_id")
 
 		// Подключение логирования для всех запросов группы
 		usersIDGroup.Use(middleware.RequestLogger(logConfig))
 		{
-			usersIDGroup.GET("", userHandler.GetUserByID)
-			usersIDGroup.PUT("", userHandler.UpdateUser)
-			usersIDGroup.DELETE("", userHandler.DeleteUser)
-			usersIDGroup.GET("/orders", orderHandler.GetUserOrders)
-			usersIDGroup.POST("/orders", orderHandler.CreateOrder)
+			selfOrAdmin := authorization.Allow(middleware.Self, models.RoleAdmin)
+			adminOnly := authorization.Allow(models.RoleAdmin)
+			selfOnly := authorization.Allow(middleware.Self)
+
+			// Учетными данными и сессиями управляют только по access токену (не API ключом и не сертификатом)
+			tokenOnly := authorization.RequireToken()
+
+			// Чувствительные действия недоступны администратору, вошедшему от имени пользователя
+			noImpersonation := authorization.DenyImpersonation()
+
+			usersIDGroup.GET("", authorization.RequireScopes(models.ScopeUsersRead), selfOrAdmin, userHandler.GetUserByID)
+			usersIDGroup.PUT("", authorization.RequireScopes(models.ScopeUsersWrite), selfOrAdmin, userHandler.UpdateUser)
+			usersIDGroup.PATCH("", authorization.RequireScopes(models.ScopeUsersWrite), selfOrAdmin, userHandler.PatchUser)
+			usersIDGroup.DELETE("", noImpersonation, selfOrAdmin, userHandler.DeleteUser)
+			usersIDGroup.PUT("/role", noImpersonation, adminOnly, userHandler.UpdateUserRole)
+			usersIDGroup.POST("/restore", tokenOnly, noImpersonation, adminOnly, userHandler.RestoreUser)
+			usersIDGroup.PUT("/password", tokenOnly, noImpersonation, selfOnly, userHandler.ChangePassword)
+			usersIDGroup.GET("/avatar", authorization.RequireScopes(models.ScopeUsersRead), selfOrAdmin, avatarHandler.GetAvatar)
+			usersIDGroup.PUT("/avatar", authorization.RequireScopes(models.ScopeUsersWrite), selfOrAdmin, avatarHandler.UploadAvatar)
+			usersIDGroup.GET("/orders", authorization.RequireScopes(models.ScopeOrdersRead), selfOrAdmin, orderHandler.GetUserOrders)
+			usersIDGroup.POST("/orders", authorization.RequireScopes(models.ScopeOrdersWrite), selfOrAdmin, orderHandler.CreateOrder)
+			usersIDGroup.GET("/orders/:order_id", authorization.RequireScopes(models.ScopeOrdersRead), selfOrAdmin, orderHandler.GetUserOrder)
+			usersIDGroup.PUT("/orders/:order_id", authorization.RequireScopes(models.ScopeOrdersWrite), selfOrAdmin, orderHandler.UpdateOrder)
+			usersIDGroup.DELETE("/orders/:order_id", authorization.RequireScopes(models.ScopeOrdersWrite), selfOrAdmin, orderHandler.DeleteOrder)
+
+			// Управление API ключами доступно только по access токену
+			usersIDGroup.POST("/api-keys", tokenOnly, noImpersonation, selfOrAdmin, apiKeyHandler.CreateAPIKey)
+			usersIDGroup.GET("/api-keys", tokenOnly, selfOrAdmin, apiKeyHandler.GetAPIKeys)
+			usersIDGroup.DELETE("/api-keys/:key_id", tokenOnly, noImpersonation, selfOrAdmin, apiKeyHandler.RevokeAPIKey)
+
+			// Управление 2FA доступно только самому пользователю
+			usersIDGroup.POST("/2fa/enroll", tokenOnly, noImpersonation, selfOnly, twoFactorHandler.Enroll)
+			usersIDGroup.POST("/2fa/confirm", tokenOnly, noImpersonation, selfOnly, twoFactorHandler.Confirm)
+			usersIDGroup.POST("/2fa/recovery-codes", tokenOnly, noImpersonation, selfOnly, twoFactorHandler.RegenerateRecoveryCodes)
+			usersIDGroup.POST("/2fa/disable", tokenOnly, noImpersonation, selfOnly, twoFactorHandler.Disable)
+
+			// Сессии доступны только по access токену
+			usersIDGroup.GET("/sessions", tokenOnly, selfOrAdmin, sessionHandler.GetSessions)
+			usersIDGroup.DELETE("/sessions/:session_id", tokenOnly, noImpersonation, selfOrAdmin, sessionHandler.RevokeSession)
+
+			// Вход от имени пользователя: только администратор по собственному access токену
+			usersIDGroup.POST("/impersonate", tokenOnly, noImpersonation, adminOnly, impersonationHandler.Impersonate)
 		}
 	}
 
 	return router
 }
 
-// startServer запускает HTTP сервер
-func startServer(router *gin.Engine, port string) {
-	log.Printf("Starting server on :%s", port)
-	if err := router.Run(":" + port); err != nil {
+// loadClientCertMapper загружает таблицу соответствия клиентских сертификатов, если сервер их запрашивает
+func loadClientCertMapper(tlsConfig *utils.TLSConfig) *middleware.ClientCertMapper {
+	path := os.Getenv("MTLS_IDENTITIES_FILE")
+	if !tlsConfig.ClientCertsEnabled() {
+		if path != "" {
+			log.Fatalf("MTLS_IDENTITIES_FILE требует TLS_CLIENT_CA_FILE")
+		}
+		return nil
+	}
+	if path == "" {
+		log.Fatalf("MTLS_IDENTITIES_FILE переменная окружения не установлена")
+	}
+
+	mapper, err := middleware.LoadClientCertMapper(path)
+	if err != nil {
+		log.Fatalf("Ошибка загрузки MTLS_IDENTITIES_FILE: %v", err)
+	}
+	return mapper
+}
+
+// startServer запускает HTTP сервер; при заданных сертификатах - HTTPS с проверкой клиентских сертификатов
+func startServer(router *gin.Engine, port string, tlsConfig *utils.TLSConfig) {
+	if tlsConfig == nil {
+		log.Printf("Starting server on :%s", port)
+		if err := router.Run(":" + port); err != nil {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+		return
+	}
+
+	serverTLS, err := tlsConfig.ServerConfig()
+	if err != nil {
+		log.Fatalf("Ошибка инициализации TLS: %v", err)
+	}
+
+	server := &http.Server{
+		Addr:              ":" + port,
+		Handler:           router,
+		TLSConfig:         serverTLS,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	log.Printf("Starting HTTPS server on :%s (client certificates: %s)", port, clientAuthName(serverTLS.ClientAuth))
+	if err := server.ListenAndServeTLS("", ""); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
 
+// clientAuthName возвращает режим запроса клиентских сертификатов для журнала
+func clientAuthName(mode tls.ClientAuthType) string {
+	switch mode {
+	case tls.VerifyClientCertIfGiven:
+		return "optional"
+	case tls.RequireAndVerifyClientCert:
+		return "required"
+	default:
+		return "disabled"
+	}
+}
+
 func main() {
 	// Настройка логирования
 	logFile, _ := os.OpenFile("api.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
@@ -123,15 +283,51 @@ func main() {
 	// Инициализация БД
 	db := initDatabase(logConfig)
 
-	userRepo := repository.NewUserRepository(db)
+	// Поиск пользователей по ID (выполняется на каждый аутентифицированный запрос) обслуживается кешем
+	userCacheConfig, err := repository.NewUserCacheConfig()
+	if err != nil {
+		log.Fatalf("Ошибка инициализации кеша пользователей: %v", err)
+	}
+	userCache := repository.NewUserCache(userCacheConfig)
+
+	userRepo := repository.NewInvalidatingUserRepository(repository.NewUserRepository(db), userCache)
+	authUserRepo := repository.NewCachedUserRepository(repository.NewUserRepository(db), userCache)
 	orderRepo := repository.NewOrderRepository(db)
+	refreshRepo := repository.NewRefreshTokenRepository(db)
+	revokedRepo := repository.NewRevokedTokenRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	recoveryRepo := repository.NewRecoveryCodeRepository(db)
+	resetRepo := repository.NewPasswordResetRepository(db)
+	historyRepo := repository.NewPasswordHistoryRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	oidcStateRepo := repository.NewOIDCStateRepository(db)
+	identityRepo := repository.NewUserIdentityRepository(db)
+	auditRepo := repository.NewAuditLogRepository(db)
 
 	// Инициализация обработчиков
-	passHasher := utils.NewPasswordHasher(0)
-	userService := service.NewUserService(userRepo, passHasher)
-	userHandler := handlers.NewUserHandler(userService)
+	hasherConfig, err := utils.NewPasswordHasherConfig()
+	if err != nil {
+		log.Fatalf("Ошибка инициализации хеширования паролей: %v", err)
+	}
+	passHasher := utils.NewPasswordHasherFromConfig(hasherConfig)
+
+	policyConfig, err := service.NewPasswordPolicyConfig()
+	if err != nil {
+		log.Fatalf("Ошибка инициализации политики паролей: %v", err)
+	}
+
+	passwordPolicy, err := service.NewPasswordPolicy(policyConfig, historyRepo, passHasher)
+	if err != nil {
+		log.Fatalf("Ошибка инициализации политики паролей: %v", err)
+	}
+
+	// Подпись курсоров keyset-пагинации списков пользователей и заказов
+	cursorSigner, err := utils.NewCursorSignerFromEnv()
+	if err != nil {
+		log.Fatalf("Ошибка инициализации курсоров пагинации: %v", err)
+	}
 
-	orderService := service.NewOrderService(orderRepo, userRepo)
+	orderService := service.NewOrderService(orderRepo, userRepo, cursorSigner)
 	orderHandler := handlers.NewOrderHandler(orderService)
 
 	authConfig, err := utils.NewJWTConfig()
@@ -139,17 +335,130 @@ func main() {
 		log.Fatalf("Ошибка инициализации конфигурации аутентификации: %v", err)
 	}
 
+	limiterConfig, err := service.NewLoginLimiterConfig()
+	if err != nil {
+		log.Fatalf("Ошибка инициализации защиты от перебора паролей: %v", err)
+	}
+
+	cookieConfig, err := middleware.NewCookieAuthConfig(authConfig.RefreshExpiration)
+	if err != nil {
+		log.Fatalf("Ошибка инициализации аутентификации через cookie: %v", err)
+	}
+
 	tokenManager := utils.NewTokenManager(authConfig)
-	authService := service.NewLoginService(userRepo, tokenManager, passHasher)
-	authHandler := handlers.NewLoginHandler(authService)
+	cookieAuth := middleware.NewCookieAuth(cookieConfig)
+	loginLimiter := service.NewLoginLimiter(limiterConfig)
+	sessionService := service.NewSessionService(sessionRepo, refreshRepo)
+	sessionHandler := handlers.NewSessionHandler(sessionService)
+	twoFactorService := service.NewTwoFactorService(userRepo, recoveryRepo, getTOTPIssuer())
+	twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorService)
+	authService := service.NewLoginService(userRepo, refreshRepo, revokedRepo, tokenManager, passHasher, authConfig, loginLimiter, twoFactorService, sessionService)
+	authHandler := handlers.NewLoginHandler(authService, cookieAuth)
+	jwksHandler := handlers.NewJWKSHandler(tokenManager)
+
+	oidcConfig, err := service.NewOIDCConfig()
+	if err != nil {
+		log.Fatalf("Ошибка инициализации входа через OpenID Connect: %v", err)
+	}
+
+	providerConfigs, err := oidc.LoadProviderConfigs(authConfig.Leeway)
+	if err != nil {
+		log.Fatalf("Ошибка инициализации провайдеров OpenID Connect: %v", err)
+	}
+
+	oidcProviders := make([]*oidc.Provider, 0, len(providerConfigs))
+	for _, providerConfig := range providerConfigs {
+		oidcProviders = append(oidcProviders, oidc.NewProvider(providerConfig, nil))
+	}
+
+	oidcService := service.NewOIDCService(oidcProviders, oidcStateRepo, identityRepo, userRepo, authService, passHasher, oidcConfig, userCache)
+	oidcHandler := handlers.NewOIDCHandler(oidcService, cookieAuth)
+
+	resetConfig, err := service.NewPasswordResetConfig()
+	if err != nil {
+		log.Fatalf("Ошибка инициализации конфигурации сброса пароля: %v", err)
+	}
+
+	mailSender, err := mail.NewSenderFromEnv()
+	if err != nil {
+		log.Fatalf("Ошибка инициализации отправки писем: %v", err)
+	}
+
+	resetService := service.NewPasswordResetService(userRepo, resetRepo, sessionService, passHasher, mailSender, loginLimiter, passwordPolicy, resetConfig)
+	passwordHandler := handlers.NewPasswordHandler(resetService)
+
+	verificationConfig, err := service.NewEmailVerificationConfig()
+	if err != nil {
+		log.Fatalf("Ошибка инициализации конфигурации подтверждения email: %v", err)
+	}
+
+	verificationService := service.NewEmailVerificationService(userRepo, tokenManager, mailSender, verificationConfig)
+	verificationHandler := handlers.NewEmailVerificationHandler(verificationService)
+
+	deletionConfig, err := service.NewUserDeletionConfig()
+	if err != nil {
+		log.Fatalf("Ошибка инициализации конфигурации удаления пользователей: %v", err)
+	}
+
+	// Сервис пользователей сам сбрасывает кеш после изменений
+	userService := service.NewUserService(repository.NewUserRepository(db), sessionService, passHasher, loginLimiter, verificationService, passwordPolicy, deletionConfig, cursorSigner, userCache)
+	userHandler := handlers.NewUserHandler(userService)
+
+	avatarConfig, err := service.NewAvatarConfig()
+	if err != nil {
+		log.Fatalf("Ошибка инициализации конфигурации аватаров: %v", err)
+	}
+
+	fileStorage, err := storage.NewStorageFromEnv()
+	if err != nil {
+		log.Fatalf("Ошибка инициализации файлового хранилища: %v", err)
+	}
+
+	avatarService := service.NewAvatarService(userRepo, fileStorage, avatarConfig)
+	avatarHandler := handlers.NewAvatarHandler(avatarService)
+
+	// Маршруты для пользователей с неподтвержденным email; выход и повторная отправка письма доступны всегда
+	verificationPolicy, err := middleware.NewVerificationPolicy(os.Getenv("UNVERIFIED_ALLOWED_ROUTES"),
+		"POST /auth/verify-email/resend", "POST /auth/logout", "POST /auth/logout-all")
+	if err != nil {
+		log.Fatalf("Ошибка разбора UNVERIFIED_ALLOWED_ROUTES: %v", err)
+	}
+
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, userRepo)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+
+	tlsConfig, err := utils.NewTLSConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Ошибка инициализации TLS: %v", err)
+	}
+
+	clientCerts := loadClientCertMapper(tlsConfig)
+
+	impersonationConfig, err := service.NewImpersonationConfig()
+	if err != nil {
+		log.Fatalf("Ошибка инициализации входа от имени пользователя: %v", err)
+	}
+
+	auditService := service.NewAuditService(auditRepo)
+	impersonationService := service.NewImpersonationService(userRepo, tokenManager, auditService, impersonationConfig)
+	impersonationHandler := handlers.NewImpersonationHandler(impersonationService, auditService)
+	statsHandler := handlers.NewStatsHandler(userCache)
+
+	authorizationMiddleware := middleware.NewAuthorization(tokenManager, authUserRepo, revokedRepo, sessionRepo, apiKeyService, verificationPolicy, cookieAuth, clientCerts)
+
+	// Фоновая очистка истекших записей об отозванных и refresh токенах
+	tokenCleanup := service.NewTokenCleanupService(revokedRepo, refreshRepo, resetRepo, sessionRepo, oidcStateRepo, authConfig.CleanupInterval, authConfig.RefreshExpiration)
+	tokenCleanup.Start(context.Background())
 
-	authorizationMiddleware := middleware.NewAuthorization(tokenManager, userRepo)
+	// Фоновое окончательное удаление пользователей с истекшим сроком восстановления
+	userPurge := service.NewUserPurgeService(userRepo, deletionConfig)
+	userPurge.Start(context.Background())
 
 	// ----------------- ROUTER -----------------
 	// Настройка роутера
-	router := setupRouter(userHandler, orderHandler, authHandler, authorizationMiddleware, logConfig)
+	router := setupRouter(userHandler, orderHandler, avatarHandler, authHandler, jwksHandler, apiKeyHandler, twoFactorHandler, passwordHandler, verificationHandler, sessionHandler, oidcHandler, impersonationHandler, statsHandler, authorizationMiddleware, auditService, logConfig)
 
 	// ------------------ RUN ------------------
 	// Запуск сервера
-	startServer(router, port)
+	startServer(router, port, tlsConfig)
 }
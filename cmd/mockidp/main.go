@@ -0,0 +1,46 @@
+// Тестовый OpenID Connect провайдер для локальной разработки.
+// Сразу одобряет вход пользователя из MOCK_IDP_EMAIL (или из параметра login_hint).
+//
+// Пример:
+//
+//	go run ./cmd/mockidp
+//	OIDC_PROVIDERS=mock OIDC_MOCK_ISSUER=http://localhost:9000 OIDC_MOCK_CLIENT_ID=khrllwTest \
+//	OIDC_MOCK_CLIENT_SECRET=secret OIDC_MOCK_REDIRECT_URL=http://localhost:8080/auth/oidc/mock/callback go run ./cmd
+package main
+
+import (
+	"khrllwTest/internal/oidc"
+	"log"
+	"net/http"
+	"os"
+)
+
+// getEnv возвращает значение переменной окружения или значение по умолчанию
+func getEnv(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func main() {
+	addr := getEnv("MOCK_IDP_ADDR", ":9000")
+	issuer := getEnv("MOCK_IDP_ISSUER", "http://localhost:9000")
+
+	idp, err := oidc.NewMockIdP(issuer, getEnv("MOCK_IDP_CLIENT_ID", "khrllwTest"), getEnv("MOCK_IDP_CLIENT_SECRET", "secret"),
+		oidc.MockUser{
+			Subject:       getEnv("MOCK_IDP_SUBJECT", "mock-user-1"),
+			Email:         getEnv("MOCK_IDP_EMAIL", "sso.user@example.com"),
+			EmailVerified: true,
+			Name:          getEnv("MOCK_IDP_NAME", "SSO User"),
+			Birthdate:     getEnv("MOCK_IDP_BIRTHDATE", "1990-01-01"),
+		})
+	if err != nil {
+		log.Fatalf("Ошибка создания тестового провайдера: %v", err)
+	}
+
+	log.Printf("Тестовый OpenID Connect провайдер %s запущен на %s", issuer, addr)
+	if err := http.ListenAndServe(addr, idp.Handler()); err != nil {
+		log.Fatalf("Failed to start server: %v", err)
+	}
+}
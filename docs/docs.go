@@ -15,71 +15,41 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/auth/login": {
-            "post": {
-                "description": "Вход в систему с email и паролем",
-                "consumes": [
-                    "application/json"
-                ],
+        "/.well-known/jwks.json": {
+            "get": {
+                "description": "Возвращает JSON Web Key Set с активным ключом и ключами в периоде ротации. При подписи HS256 набор пуст",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Authorization"
                 ],
-                "summary": "Авторизация пользователя",
-                "parameters": [
-                    {
-                        "description": "Данные для входа",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.LoginRequest"
-                        }
-                    }
-                ],
+                "summary": "Открытые ключи подписи токенов",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.LoginResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Неверный формат запроса",
-                        "schema": {
-                            "$ref": "#/definitions/models.ErrorLoginResponse"
-                        }
-                    },
-                    "401": {
-                        "description": "Некорректные данные",
-                        "schema": {
-                            "$ref": "#/definitions/models.ErrorLoginResponse"
-                        }
-                    },
-                    "500": {
-                        "description": "Внутрення ошибка сервера",
-                        "schema": {
-                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                            "$ref": "#/definitions/models.JWKSResponse"
                         }
                     }
                 }
             }
         },
-        "/users": {
+        "/audit-logs": {
             "get": {
-                "description": "Возвращает список пользователей с пагинацией и фильтрацией по возрасту",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Возвращает записи журнала аудита (выпуск токенов имперсонации и запросы с ними), новые первыми",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Impersonation"
                 ],
-                "summary": "Получить список пользователей",
+                "summary": "Журнал аудита",
                 "parameters": [
                     {
                         "type": "integer",
@@ -97,14 +67,14 @@ const docTemplate = `{
                     },
                     {
                         "type": "integer",
-                        "description": "Min Age",
-                        "name": "min_age",
+                        "description": "ID администратора",
+                        "name": "actor_id",
                         "in": "query"
                     },
                     {
                         "type": "integer",
-                        "description": "Max Age",
-                        "name": "max_age",
+                        "description": "ID пользователя",
+                        "name": "user_id",
                         "in": "query"
                     }
                 ],
@@ -112,7 +82,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.UsersListResponse"
+                            "$ref": "#/definitions/models.AuditLogsListResponse"
                         }
                     },
                     "400": {
@@ -121,6 +91,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorLoginResponse"
                         }
                     },
+                    "401": {
+                        "description": "Неверный токен авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
@@ -128,9 +110,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/auth/login": {
             "post": {
-                "description": "Создает нового пользователя в системе",
+                "description": "Вход в систему с email и паролем. Для пользователей с 2FA возвращает challenge_token вместо токенов.\nПри use_cookie=true токены устанавливаются в HttpOnly cookie, а в ответе возвращается csrf_token",
                 "consumes": [
                     "application/json"
                 ],
@@ -138,35 +122,53 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Authorization"
                 ],
-                "summary": "Создать нового пользователя",
+                "summary": "Авторизация пользователя",
                 "parameters": [
                     {
-                        "description": "Данные пользователя",
-                        "name": "user",
+                        "description": "Данные для входа",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.CreateUserRequest"
+                            "$ref": "#/definitions/models.LoginRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.UserResponse"
+                            "$ref": "#/definitions/models.LoginResponse"
                         }
                     },
                     "400": {
-                        "description": "Неверный формат запроса/некорректные данные",
+                        "description": "Неверный формат запроса или режим cookie отключен",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Некорректные данные",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "423": {
+                        "description": "Аккаунт временно заблокирован (заголовок Retry-After)",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много попыток входа (заголовок Retry-After)",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorLoginResponse"
                         }
                     },
                     "500": {
-                        "description": "Внутренняя ошибка сервера",
+                        "description": "Внутрення ошибка сервера",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorLoginResponse"
                         }
@@ -174,9 +176,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/{user_id}": {
-            "get": {
-                "description": "Возвращает данные пользователя по его ID",
+        "/auth/login/2fa": {
+            "post": {
+                "description": "Обменивает challenge токен и код из приложения-аутентификатора (или код восстановления) на пару токенов.\nПри use_cookie=true токены устанавливаются в HttpOnly cookie",
                 "consumes": [
                     "application/json"
                 ],
@@ -184,47 +186,68 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Authorization"
                 ],
-                "summary": "Получить пользователя по ID",
+                "summary": "Второй шаг входа с 2FA",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "User ID",
-                        "name": "user_id",
-                        "in": "path",
-                        "required": true
+                        "description": "Challenge токен и код",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.LoginTwoFactorRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.UserResponse"
+                            "$ref": "#/definitions/models.LoginResponse"
                         }
                     },
                     "400": {
-                        "description": "Неверный формат запроса/некорректные данные",
+                        "description": "Неверный формат запроса или режим cookie отключен",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorLoginResponse"
                         }
                     },
-                    "404": {
-                        "description": "Пользователь не найден",
+                    "401": {
+                        "description": "Неверный код или challenge токен",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "423": {
+                        "description": "Аккаунт временно заблокирован (заголовок Retry-After)",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много попыток входа (заголовок Retry-After)",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorLoginResponse"
                         }
                     },
                     "500": {
-                        "description": "Внутренняя ошибка сервера",
+                        "description": "Внутрення ошибка сервера",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorLoginResponse"
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Обновляет информацию о пользователе",
+            }
+        },
+        "/auth/logout": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Отзывает текущий access токен и завершает его сессию. Если передан refresh токен, отзывается и его семейство.\nCookie аутентификации удаляются; refresh токен из cookie используется, если он не передан в теле",
                 "consumes": [
                     "application/json"
                 ],
@@ -232,75 +255,62 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Authorization"
                 ],
-                "summary": "Обновить данные пользователя",
+                "summary": "Выход из системы",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "User ID",
-                        "name": "user_id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "User Data",
-                        "name": "user",
+                        "description": "Refresh токен текущей сессии",
+                        "name": "request",
                         "in": "body",
-                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.UpdateUserRequest"
+                            "$ref": "#/definitions/models.LogoutRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "204": {
+                        "description": "No Content",
                         "schema": {
-                            "$ref": "#/definitions/models.UserResponse"
+                            "type": "string"
                         }
                     },
                     "400": {
-                        "description": "Неверный формат запроса/некорректные данные",
+                        "description": "Неверный формат запроса",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorLoginResponse"
                         }
                     },
-                    "404": {
-                        "description": "Пользователь не найден",
+                    "401": {
+                        "description": "Неверный токен авторизации",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorLoginResponse"
                         }
                     },
                     "500": {
-                        "description": "Внутренняя ошибка сервера",
+                        "description": "Внутрення ошибка сервера",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorLoginResponse"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Удаляет пользователя по его ID",
-                "consumes": [
-                    "application/json"
+            }
+        },
+        "/auth/logout-all": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Завершает все сессии пользователя и делает недействительными все выданные ему access и refresh токены",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
-                ],
-                "summary": "Удалить пользователя",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "User ID",
-                        "name": "user_id",
-                        "in": "path",
-                        "required": true
-                    }
+                    "Authorization"
                 ],
+                "summary": "Выход со всех устройств",
                 "responses": {
                     "204": {
                         "description": "No Content",
@@ -308,20 +318,14 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
-                    "400": {
-                        "description": "Неверный формат запроса/некорректные данные",
-                        "schema": {
-                            "$ref": "#/definitions/models.ErrorLoginResponse"
-                        }
-                    },
-                    "404": {
-                        "description": "Пользователь не найден",
+                    "401": {
+                        "description": "Неверный токен авторизации",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorLoginResponse"
                         }
                     },
                     "500": {
-                        "description": "Внутренняя ошибка сервера",
+                        "description": "Внутрення ошибка сервера",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorLoginResponse"
                         }
@@ -329,153 +333,2863 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/{user_id}/orders": {
+        "/auth/oidc/providers": {
             "get": {
-                "description": "Возвращает все заказы для конкретного пользователя по его ID",
-                "consumes": [
+                "description": "Возвращает имена провайдеров, через которых доступен вход",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "Authorization"
+                ],
+                "summary": "Список провайдеров OpenID Connect",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.OIDCProvidersResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/oidc/{provider}/callback": {
+            "get": {
+                "description": "Проверяет state, обменивает код авторизации на ID токен, проверяет его по JWKS провайдера\nи выдает токены сервиса. Аккаунт находится по привязке провайдера, привязывается по подтвержденному email\nили создается. В режиме cookie токены устанавливаются в HttpOnly cookie",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Orders"
+                    "Authorization"
                 ],
-                "summary": "Получить все заказы пользователя",
+                "summary": "Обратный вызов OpenID Connect",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "User ID",
-                        "name": "user_id",
+                        "type": "string",
+                        "description": "Имя провайдера",
+                        "name": "provider",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Код авторизации",
+                        "name": "code",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Значение state из запроса авторизации",
+                        "name": "state",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ошибка, возвращенная провайдером",
+                        "name": "error",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.OrderResponse"
-                            }
+                            "$ref": "#/definitions/models.LoginResponse"
                         }
                     },
-                    "400": {
-                        "description": "Неверный формат запроса/некорректные данные",
+                    "401": {
+                        "description": "Неверный state, код или ID токен",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Email не подтвержден провайдером или аккаунт не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Провайдер не найден",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorLoginResponse"
                         }
                     },
                     "500": {
-                        "description": "Внутренняя ошибка сервера",
+                        "description": "Внутрення ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Провайдер недоступен",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorLoginResponse"
                         }
                     }
                 }
-            },
-            "post": {
-                "description": "Создает новый заказ для пользователя",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/auth/oidc/{provider}/login": {
+            "get": {
+                "description": "Перенаправляет на страницу входа провайдера (authorization code с PKCE S256).\nЗначение state привязывается к браузеру HttpOnly cookie",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Orders"
+                    "Authorization"
                 ],
-                "summary": "Создать новый заказ",
+                "summary": "Вход через OpenID Connect",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID пользователя",
-                        "name": "user_id",
+                        "type": "string",
+                        "description": "Имя провайдера",
+                        "name": "provider",
                         "in": "path",
                         "required": true
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "Перенаправление на страницу входа провайдера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Провайдер не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутрення ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
                     },
+                    "502": {
+                        "description": "Провайдер недоступен",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/password/forgot": {
+            "post": {
+                "description": "Отправляет на email ссылку с одноразовым токеном сброса пароля. Ответ не зависит от наличия аккаунта",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authorization"
+                ],
+                "summary": "Запрос сброса пароля",
+                "parameters": [
                     {
-                        "description": "Данные заказа",
-                        "name": "order",
+                        "description": "Email пользователя",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.CreateOrderRequest"
+                            "$ref": "#/definitions/models.ForgotPasswordRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "202": {
+                        "description": "Accepted",
                         "schema": {
-                            "$ref": "#/definitions/models.OrderResponse"
+                            "type": "string"
                         }
                     },
                     "400": {
-                        "description": "Неверный формат запроса/некорректные данные",
+                        "description": "Неверный формат запроса",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorLoginResponse"
                         }
                     },
                     "500": {
-                        "description": "Внутренняя ошибка сервера",
+                        "description": "Внутрення ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/password/reset": {
+            "post": {
+                "description": "Устанавливает новый пароль по токену из письма и завершает все сессии пользователя",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authorization"
+                ],
+                "summary": "Установка нового пароля",
+                "parameters": [
+                    {
+                        "description": "Токен и новый пароль",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ResetPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат запроса/недействительный токен/пароль не соответствует политике",
+                        "schema": {
+                            "$ref": "#/definitions/models.PasswordPolicyErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутрення ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/refresh": {
+            "post": {
+                "description": "Обменивает refresh токен на новую пару токенов. Refresh токен ротируется при каждом использовании, повторное использование отзывает все семейство токенов.\nЕсли тело не передано, refresh токен берется из cookie; в этом случае обязателен заголовок X-CSRF-Token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authorization"
+                ],
+                "summary": "Обновление токенов",
+                "parameters": [
+                    {
+                        "description": "Refresh токен",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.RefreshRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "CSRF токен (при обновлении через cookie)",
+                        "name": "X-CSRF-Token",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат запроса",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Недействительный или повторно использованный refresh токен",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Отсутствует или неверный CSRF токен",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутрення ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/verify-email": {
+            "post": {
+                "description": "Подтверждает email по токену из ссылки в письме",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authorization"
+                ],
+                "summary": "Подтверждение email",
+                "parameters": [
+                    {
+                        "description": "Токен подтверждения",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.VerifyEmailRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат запроса/недействительный токен",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутрення ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/verify-email/resend": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Отправляет новую ссылку подтверждения на email текущего пользователя. Частота отправки ограничена",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authorization"
+                ],
+                "summary": "Повторная отправка письма подтверждения",
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверный токен авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Email уже подтвержден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Письмо уже отправлено недавно (заголовок Retry-After)",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутрення ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/stats/user-cache": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает попадания и промахи кеша поиска пользователей по ID, вытеснения, сбросы и размер кеша.\nСчетчики накапливаются с момента запуска экземпляра сервиса",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Stats"
+                ],
+                "summary": "Статистика кеша пользователей",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/repository.UserCacheStats"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверный токен авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorLoginResponse"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.CreateOrderRequest": {
-            "description": "Структура для запроса на создание нового заказа",
+        },
+        "/users": {
+            "get": {
+                "description": "Возвращает список пользователей с пагинацией, поиском по имени и email, фильтрацией по домену email и возрасту и сортировкой. Примененные фильтры и сортировка возвращаются в ответе.\nОтвет содержит подписанные курсоры next_cursor/prev_cursor: запрос с cursor (и теми же фильтрами и сортировкой) выбирает соседнюю страницу по границе (keyset) без OFFSET; total при этом подсчитывается только с include_total=true",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Получить список пользователей",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page (offset-пагинация, не используется вместе с cursor)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор next_cursor или prev_cursor из предыдущего ответа",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Подсчитать total при пагинации курсором",
+                        "name": "include_total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подстрока имени или email без учета регистра",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Домен email, например example.com",
+                        "name": "email_domain",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Min Age",
+                        "name": "min_age",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Max Age",
+                        "name": "max_age",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "id",
+                        "description": "Поля сортировки через запятую (id, name, email, age, role), '-' - по убыванию",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UsersListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат запроса/некорректные данные",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Создает нового пользователя в системе",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Создать нового пользователя",
+                "parameters": [
+                    {
+                        "description": "Данные пользователя",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат запроса/некорректные данные/пароль не соответствует политике",
+                        "schema": {
+                            "$ref": "#/definitions/models.PasswordPolicyErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}": {
+            "get": {
+                "description": "Возвращает данные пользователя по его ID. Версия пользователя передается в заголовке ETag",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Получить пользователя по ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Версия пользователя для If-Match"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат запроса/некорректные данные",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Обновляет информацию о пользователе. С заголовком If-Match обновление выполняется, только если версия пользователя совпадает с ETag",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Обновить данные пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag версии пользователя",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "User Data",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Новая версия пользователя"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат запроса/некорректные данные",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Email уже занят/пользователь изменен параллельным запросом",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия пользователя не совпадает с If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Мягко удаляет пользователя по его ID и завершает его сессии. Администратор может восстановить пользователя в течение USER_DELETE_GRACE_PERIOD, после чего пользователь и его заказы удаляются окончательно",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Удалить пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag версии пользователя",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат запроса/некорректные данные",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия пользователя не совпадает с If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Обновляет только переданные поля (JSON Merge Patch, RFC 7386): name, email, age. Остальные поля (id, role и т.д.) и значение null отклоняются. Изменение email сбрасывает его подтверждение.\nС заголовком If-Match патч применяется, только если версия пользователя совпадает с ETag",
+                "consumes": [
+                    "application/merge-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Частично обновить данные пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag версии пользователя",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Изменяемые поля",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PatchUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Новая версия пользователя"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат запроса/некорректные данные/неизменяемое поле",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Email уже занят",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия пользователя не совпадает с If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Тип содержимого не application/merge-patch+json",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/2fa/confirm": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Проверяет код из приложения-аутентификатора, включает 2FA и возвращает одноразовые коды восстановления",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Two-Factor Authentication"
+                ],
+                "summary": "Подтвердить подключение 2FA",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Код из приложения",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TwoFactorCodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.RecoveryCodesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат запроса/неверный код",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверный токен авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "2FA уже подключена или не начато подключение",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/2fa/disable": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Отключает 2FA после проверки кода из приложения или кода восстановления",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Two-Factor Authentication"
+                ],
+                "summary": "Отключить 2FA",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Код из приложения или код восстановления",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TwoFactorCodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат запроса/неверный код",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверный токен авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "2FA не подключена",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/2fa/enroll": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создает секрет TOTP и возвращает otpauth URI. 2FA включается после подтверждения кодом",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Two-Factor Authentication"
+                ],
+                "summary": "Подключить 2FA",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TwoFactorEnrollResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат запроса/некорректные данные",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверный токен авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "2FA уже подключена",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/2fa/recovery-codes": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Заменяет все коды восстановления новыми. Требует код из приложения-аутентификатора",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Two-Factor Authentication"
+                ],
+                "summary": "Перевыпустить коды восстановления",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Код из приложения",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TwoFactorCodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.RecoveryCodesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат запроса/неверный код",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверный токен авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "2FA не подключена",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/api-keys": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает активные API ключи пользователя без их значений",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API Keys"
+                ],
+                "summary": "Получить API ключи пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.APIKeyResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат запроса/некорректные данные",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверный токен авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создает именованный API ключ с областями доступа. Значение ключа возвращается только в этом ответе",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API Keys"
+                ],
+                "summary": "Создать API ключ",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Параметры ключа",
+                        "name": "key",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateAPIKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateAPIKeyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат запроса/некорректные данные",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверный токен авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/api-keys/{key_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Отзывает API ключ пользователя, после чего ключ перестает приниматься",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API Keys"
+                ],
+                "summary": "Отозвать API ключ",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "API Key ID",
+                        "name": "key_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат запроса/некорректные данные",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверный токен авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "API ключ не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/avatar": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает миниатюру аватара в JPEG. Без size - наибольшую из AVATAR_SIZES.\nОтвет содержит ETag, с If-None-Match возвращается 304, если аватар не менялся",
+                "produces": [
+                    "image/jpeg"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Получить аватар",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Сторона миниатюры в пикселях (одно из AVATAR_SIZES)",
+                        "name": "size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Изображение",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID пользователя/недоступный размер",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь или аватар не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Принимает изображение JPEG, PNG или GIF (тип определяется по содержимому файла) размером до AVATAR_MAX_BYTES\nи сохраняет из его центра квадратные миниатюры AVATAR_SIZES в JPEG. Заменяет прежний аватар",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Загрузить аватар",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag версии пользователя",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "file",
+                        "description": "Изображение",
+                        "name": "avatar",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Новая версия пользователя"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Файл не передан/некорректное изображение/слишком большое разрешение",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия пользователя не совпадает с If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Файл превышает допустимый размер",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Файл не является изображением JPEG, PNG или GIF",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/impersonate": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Выпускает короткоживущий access токен пользователя с claim act администратора. Refresh токен не выдается.\nТокен не допускается к смене пароля, удалению аккаунта, управлению 2FA, API ключами и сессиями.\nВыпуск токена и каждый запрос с ним записываются в журнал аудита",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Impersonation"
+                ],
+                "summary": "Войти от имени пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Причина входа",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ImpersonationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.ImpersonationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат запроса/некорректные данные",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверный токен авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав или пользователь - администратор",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/orders": {
+            "get": {
+                "description": "Возвращает все заказы для конкретного пользователя по его ID.\nЕсли задан limit или cursor, возвращается страница models.OrdersListResponse в порядке создания заказов с подписанными курсорами next_cursor/prev_cursor",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Orders"
+                ],
+                "summary": "Получить все заказы пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Количество заказов на странице (1-100), включает постраничный ответ",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор next_cursor или prev_cursor из предыдущего ответа",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Подсчитать общее количество заказов",
+                        "name": "include_total",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Все заказы (без limit и cursor) или models.OrdersListResponse",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.OrderResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат запроса/некорректные данные",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Создает новый заказ для пользователя",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Orders"
+                ],
+                "summary": "Создать новый заказ",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Данные заказа",
+                        "name": "order",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateOrderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrderResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат запроса/некорректные данные",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/orders/{order_id}": {
+            "get": {
+                "description": "Возвращает заказ пользователя по ID. Версия заказа передается в заголовке ETag",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Orders"
+                ],
+                "summary": "Получить заказ пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrderResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Версия заказа для If-Match"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID пользователя или заказа",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь или заказ не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Заменяет данные заказа. С заголовком If-Match обновление выполняется, только если версия заказа совпадает с ETag",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Orders"
+                ],
+                "summary": "Обновить заказ",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag версии заказа",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Данные заказа",
+                        "name": "order",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateOrderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrderResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Новая версия заказа"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат запроса/некорректные данные",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь или заказ не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Заказ изменен параллельным запросом",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия заказа не совпадает с If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет заказ пользователя. С заголовком If-Match заказ удаляется, только если его версия совпадает с ETag",
+                "tags": [
+                    "Orders"
+                ],
+                "summary": "Удалить заказ",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag версии заказа",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID пользователя или заказа",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь или заказ не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия заказа не совпадает с If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/password": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Меняет пароль после проверки текущего. Все ранее выданные токены пользователя, включая текущий, перестают действовать",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Сменить пароль",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Текущий и новый пароль",
+                        "name": "password",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ChangePasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат запроса/неверный текущий пароль/пароль не соответствует политике",
+                        "schema": {
+                            "$ref": "#/definitions/models.PasswordPolicyErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверный токен авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "423": {
+                        "description": "Аккаунт временно заблокирован (заголовок Retry-After)",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много попыток (заголовок Retry-After)",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Восстанавливает мягко удаленного пользователя, пока не истек срок восстановления. Доступно только администраторам. Токены, выданные до удаления, не начинают действовать снова",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Восстановить удаленного пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Удаленный пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Email уже занят другим пользователем",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Срок восстановления истек",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/role": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Назначает пользователю роль user или admin. Доступно только администраторам. Ранее выданные пользователю токены перестают действовать",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Изменить роль пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag версии пользователя",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Новая роль",
+                        "name": "role",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateUserRoleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Новая версия пользователя"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат запроса/некорректные данные",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Пользователь изменен параллельным запросом",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия пользователя не совпадает с If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/sessions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает устройства, на которых выполнен вход: время входа, IP, User-Agent и время последней активности. Сессия текущего запроса отмечена полем current",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sessions"
+                ],
+                "summary": "Получить активные сессии",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.SessionResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат запроса/некорректные данные",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверный токен авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/sessions/{session_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Завершает сессию на устройстве: ее access токены перестают приниматься, refresh токены отзываются",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sessions"
+                ],
+                "summary": "Завершить сессию",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "session_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат запроса/некорректные данные",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверный токен авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Сессия не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorLoginResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "models.APIKeyResponse": {
+            "description": "Структура ответа с информацией об API ключе (без самого ключа)",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "Дата и время создания ключа",
+                    "type": "string",
+                    "example": "2025-05-07T12:34:56Z"
+                },
+                "expires_at": {
+                    "description": "Дата и время истечения ключа",
+                    "type": "string",
+                    "example": "2026-01-01T00:00:00Z"
+                },
+                "id": {
+                    "description": "Уникальный идентификатор ключа",
+                    "type": "integer",
+                    "example": 1
+                },
+                "last_used_at": {
+                    "description": "Дата и время последнего использования",
+                    "type": "string",
+                    "example": "2025-05-08T09:00:00Z"
+                },
+                "name": {
+                    "description": "Название ключа",
+                    "type": "string",
+                    "example": "billing-export"
+                },
+                "prefix": {
+                    "description": "Начало ключа для распознавания",
+                    "type": "string",
+                    "example": "kvt_3fA9xQ"
+                },
+                "scopes": {
+                    "description": "Области доступа ключа",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "orders:read"
+                    ]
+                }
+            }
+        },
+        "models.AuditLog": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "Действие (impersonation.start, impersonation.request)",
+                    "type": "string"
+                },
+                "actor_id": {
+                    "description": "Администратор, выполнивший действие",
+                    "type": "integer"
+                },
+                "created_at": {
+                    "description": "Дата и время действия",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "Уникальный идентификатор записи",
+                    "type": "integer"
+                },
+                "ip_address": {
+                    "description": "IP адрес клиента",
+                    "type": "string"
+                },
+                "method": {
+                    "description": "HTTP метод запроса",
+                    "type": "string"
+                },
+                "path": {
+                    "description": "Путь запроса",
+                    "type": "string"
+                },
+                "reason": {
+                    "description": "Причина входа от имени пользователя (для impersonation.start)",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Код ответа",
+                    "type": "integer"
+                },
+                "token_id": {
+                    "description": "Идентификатор (jti) токена имперсонации",
+                    "type": "string"
+                },
+                "user_agent": {
+                    "description": "User-Agent клиента",
+                    "type": "string"
+                },
+                "user_id": {
+                    "description": "Пользователь, от имени которого выполнено действие",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.AuditLogsListResponse": {
+            "description": "Записи журнала аудита с пагинацией, новые первыми",
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "description": "Количество записей на странице",
+                    "type": "integer",
+                    "example": 10
+                },
+                "logs": {
+                    "description": "Записи журнала",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AuditLog"
+                    }
+                },
+                "page": {
+                    "description": "Номер страницы",
+                    "type": "integer",
+                    "example": 1
+                },
+                "total": {
+                    "description": "Общее количество записей",
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "models.ChangePasswordRequest": {
+            "description": "Структура для запроса на смену пароля. Требует текущий пароль",
+            "type": "object",
+            "required": [
+                "current_password",
+                "new_password"
+            ],
+            "properties": {
+                "current_password": {
+                    "description": "Текущий пароль",
+                    "type": "string",
+                    "example": "securepassword123"
+                },
+                "new_password": {
+                    "description": "Новый пароль (проверяется политикой паролей)",
+                    "type": "string",
+                    "example": "newsecurepassword123"
+                }
+            }
+        },
+        "models.CreateAPIKeyRequest": {
+            "description": "Структура для запроса на создание API ключа",
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "expires_at": {
+                    "description": "Дата и время истечения ключа (необязательно)",
+                    "type": "string",
+                    "example": "2026-01-01T00:00:00Z"
+                },
+                "name": {
+                    "description": "Название ключа",
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "billing-export"
+                },
+                "scopes": {
+                    "description": "Области доступа ключа",
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "orders:read"
+                    ]
+                }
+            }
+        },
+        "models.CreateAPIKeyResponse": {
+            "description": "Структура ответа с новым API ключом. Ключ показывается только один раз",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "Дата и время создания ключа",
+                    "type": "string",
+                    "example": "2025-05-07T12:34:56Z"
+                },
+                "expires_at": {
+                    "description": "Дата и время истечения ключа",
+                    "type": "string",
+                    "example": "2026-01-01T00:00:00Z"
+                },
+                "id": {
+                    "description": "Уникальный идентификатор ключа",
+                    "type": "integer",
+                    "example": 1
+                },
+                "key": {
+                    "description": "Полное значение ключа (больше не будет показано)",
+                    "type": "string",
+                    "example": "kvt_3fA9xQ..."
+                },
+                "last_used_at": {
+                    "description": "Дата и время последнего использования",
+                    "type": "string",
+                    "example": "2025-05-08T09:00:00Z"
+                },
+                "name": {
+                    "description": "Название ключа",
+                    "type": "string",
+                    "example": "billing-export"
+                },
+                "prefix": {
+                    "description": "Начало ключа для распознавания",
+                    "type": "string",
+                    "example": "kvt_3fA9xQ"
+                },
+                "scopes": {
+                    "description": "Области доступа ключа",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "orders:read"
+                    ]
+                }
+            }
+        },
+        "models.CreateOrderRequest": {
+            "description": "Структура для запроса на создание нового заказа",
+            "type": "object",
+            "required": [
+                "price",
+                "product",
+                "quantity"
+            ],
+            "properties": {
+                "price": {
+                    "description": "Цена товара",
+                    "type": "number",
+                    "minimum": 0,
+                    "example": 1500.5
+                },
+                "product": {
+                    "description": "Название продукта, заказанного пользователем",
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "Laptop"
+                },
+                "quantity": {
+                    "description": "Количество заказанных единиц товара",
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 2
+                }
+            }
+        },
+        "models.CreateUserRequest": {
+            "description": "Структура для запроса на создание нового пользователя",
+            "type": "object",
+            "required": [
+                "age",
+                "email",
+                "name",
+                "password"
+            ],
+            "properties": {
+                "age": {
+                    "description": "Возраст пользователя",
+                    "type": "integer",
+                    "maximum": 150,
+                    "minimum": 1,
+                    "example": 30
+                },
+                "email": {
+                    "description": "Email пользователя",
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "john@example.com"
+                },
+                "name": {
+                    "description": "Имя пользователя",
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "John Doe"
+                },
+                "password": {
+                    "description": "Введенный пользователем пароль (проверяется политикой паролей)",
+                    "type": "string",
+                    "example": "securepassword123"
+                }
+            }
+        },
+        "models.ErrorLoginResponse": {
+            "description": "Структура, которая содержит сообщение об ошибке",
+            "type": "object",
+            "properties": {
+                "error": {
+                    "description": "Сообщение об ошибке",
+                    "type": "string"
+                }
+            }
+        },
+        "models.ForgotPasswordRequest": {
+            "description": "Email, на который будет отправлена ссылка для сброса пароля",
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "description": "Email пользователя",
+                    "type": "string",
+                    "example": "john@example.com"
+                }
+            }
+        },
+        "models.ImpersonationRequest": {
+            "description": "Причина входа сохраняется в журнале аудита",
+            "type": "object",
+            "required": [
+                "reason"
+            ],
+            "properties": {
+                "reason": {
+                    "description": "Причина входа от имени пользователя",
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "Тикет #1234: заказ не отображается"
+                }
+            }
+        },
+        "models.ImpersonationResponse": {
+            "description": "Короткоживущий access токен пользователя с claim act администратора. Refresh токен не выдается",
+            "type": "object",
+            "properties": {
+                "actor_id": {
+                    "description": "Администратор, выполняющий вход",
+                    "type": "integer",
+                    "example": 1
+                },
+                "expires_in": {
+                    "description": "Время жизни токена в секундах",
+                    "type": "integer",
+                    "example": 900
+                },
+                "token": {
+                    "description": "Access токен пользователя с claim act",
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                },
+                "user_id": {
+                    "description": "Пользователь, от имени которого выполняется вход",
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "models.JWK": {
+            "description": "Открытый ключ для проверки подписи JWT (RFC 7517)",
+            "type": "object",
+            "properties": {
+                "alg": {
+                    "description": "Алгоритм подписи",
+                    "type": "string",
+                    "example": "RS256"
+                },
+                "crv": {
+                    "description": "Кривая для ключей OKP",
+                    "type": "string",
+                    "example": "Ed25519"
+                },
+                "e": {
+                    "description": "Открытая экспонента RSA ключа (base64url)",
+                    "type": "string",
+                    "example": "AQAB"
+                },
+                "kid": {
+                    "description": "Идентификатор ключа, совпадает с заголовком kid токена",
+                    "type": "string",
+                    "example": "2025-01"
+                },
+                "kty": {
+                    "description": "Тип ключа (RSA или OKP)",
+                    "type": "string",
+                    "example": "RSA"
+                },
+                "n": {
+                    "description": "Модуль RSA ключа (base64url)",
+                    "type": "string",
+                    "example": "0vx7agoebGc..."
+                },
+                "use": {
+                    "description": "Назначение ключа",
+                    "type": "string",
+                    "example": "sig"
+                },
+                "x": {
+                    "description": "Открытый ключ Ed25519 (base64url)",
+                    "type": "string",
+                    "example": "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"
+                }
+            }
+        },
+        "models.JWKSResponse": {
+            "description": "Набор открытых ключей для проверки JWT без общего секрета",
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "description": "Список принимаемых в данный момент ключей",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.JWK"
+                    }
+                }
+            }
+        },
+        "models.LoginRequest": {
+            "description": "Структура данных для аутентификации пользователя через email и пароль",
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "description": "Email пользователя для аутентификации",
+                    "type": "string",
+                    "example": "user@example.com"
+                },
+                "password": {
+                    "description": "Пароль пользователя",
+                    "type": "string",
+                    "minLength": 8,
+                    "example": "securepassword123"
+                },
+                "use_cookie": {
+                    "description": "Выдать токены в HttpOnly cookie вместо тела ответа (для браузерных клиентов)",
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "models.LoginResponse": {
+            "description": "Структура, которая возвращает access и refresh токены для аутентифицированного пользователя. Для пользователей с 2FA вместо токенов возвращается challenge_token для второго шага входа. В режиме cookie токены передаются в HttpOnly cookie, а в ответе возвращается csrf_token",
+            "type": "object",
+            "properties": {
+                "challenge_token": {
+                    "description": "Промежуточный токен для POST /auth/login/2fa",
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                },
+                "csrf_token": {
+                    "description": "CSRF токен для заголовка X-CSRF-Token (только в режиме cookie)",
+                    "type": "string",
+                    "example": "Y3NyZnRva2Vu..."
+                },
+                "expires_in": {
+                    "description": "Время жизни access токена в секундах",
+                    "type": "integer",
+                    "example": 900
+                },
+                "refresh_token": {
+                    "description": "Непрозрачный refresh токен для получения новой пары токенов",
+                    "type": "string",
+                    "example": "Zm9vYmFyYmF6..."
+                },
+                "token": {
+                    "description": "Короткоживущий access токен аутентифицированного пользователя",
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                },
+                "two_factor_required": {
+                    "description": "Признак того, что для входа требуется код 2FA",
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "models.LoginTwoFactorRequest": {
+            "description": "Структура данных для обмена challenge токена и кода 2FA на пару токенов",
             "type": "object",
             "required": [
-                "price",
-                "product",
-                "quantity"
+                "challenge_token",
+                "code"
             ],
             "properties": {
+                "challenge_token": {
+                    "description": "Промежуточный токен, полученный на первом шаге входа",
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                },
+                "code": {
+                    "description": "Код из приложения-аутентификатора или код восстановления",
+                    "type": "string",
+                    "example": "123456"
+                },
+                "use_cookie": {
+                    "description": "Выдать токены в HttpOnly cookie вместо тела ответа (для браузерных клиентов)",
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "models.LogoutRequest": {
+            "description": "Структура данных для выхода. Если передан refresh токен, отзывается и его семейство",
+            "type": "object",
+            "properties": {
+                "refresh_token": {
+                    "description": "Refresh токен текущей сессии (необязательный)",
+                    "type": "string",
+                    "example": "Zm9vYmFyYmF6..."
+                }
+            }
+        },
+        "models.OIDCProvidersResponse": {
+            "description": "Провайдеры OpenID Connect, через которых доступен вход",
+            "type": "object",
+            "properties": {
+                "providers": {
+                    "description": "Имена провайдеров для /auth/oidc/{provider}/login",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "corp"
+                    ]
+                }
+            }
+        },
+        "models.OrderResponse": {
+            "description": "Структура для ответа, содержащая информацию о заказе",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "Дата и время создания заказа",
+                    "type": "string",
+                    "example": "2025-05-07T12:34:56Z"
+                },
+                "id": {
+                    "description": "Уникальный идентификатор заказа",
+                    "type": "integer",
+                    "example": 1
+                },
                 "price": {
                     "description": "Цена товара",
                     "type": "number",
-                    "minimum": 0,
                     "example": 1500.5
                 },
                 "product": {
                     "description": "Название продукта, заказанного пользователем",
                     "type": "string",
-                    "maxLength": 255,
                     "example": "Laptop"
                 },
                 "quantity": {
                     "description": "Количество заказанных единиц товара",
                     "type": "integer",
-                    "minimum": 1,
                     "example": 2
+                },
+                "user_id": {
+                    "description": "Идентификатор пользователя, который сделал заказ",
+                    "type": "integer",
+                    "example": 123
+                },
+                "version": {
+                    "description": "Версия заказа (значение для If-Match при изменении)",
+                    "type": "integer",
+                    "example": 1
                 }
             }
         },
-        "models.CreateUserRequest": {
-            "description": "Структура для запроса на создание нового пользователя",
+        "models.PasswordPolicyErrorResponse": {
+            "description": "Пароль не соответствует политике; перечислены все нарушенные правила",
+            "type": "object",
+            "properties": {
+                "error": {
+                    "description": "Текст ошибки",
+                    "type": "string",
+                    "example": "Пароль не соответствует требованиям. "
+                },
+                "violations": {
+                    "description": "Нарушенные правила",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PasswordPolicyViolation"
+                    }
+                }
+            }
+        },
+        "models.PasswordPolicyViolation": {
+            "description": "Нарушенное правило политики паролей",
+            "type": "object",
+            "properties": {
+                "message": {
+                    "description": "Описание нарушения",
+                    "type": "string",
+                    "example": "Пароль должен содержать не менее 8 символов"
+                },
+                "rule": {
+                    "description": "Код правила",
+                    "type": "string",
+                    "example": "min_length"
+                }
+            }
+        },
+        "models.PatchUserRequest": {
+            "description": "Изменяемые поля пользователя; обновляются только переданные поля. Значение null и другие поля не допускаются",
             "type": "object",
-            "required": [
-                "age",
-                "email",
-                "name",
-                "password"
-            ],
             "properties": {
                 "age": {
                     "description": "Возраст пользователя",
                     "type": "integer",
-                    "minimum": 0,
-                    "example": 30
+                    "maximum": 150,
+                    "minimum": 1,
+                    "example": 31
                 },
                 "email": {
-                    "description": "Email пользователя",
+                    "description": "Email пользователя (изменение сбрасывает подтверждение email)",
                     "type": "string",
                     "maxLength": 255,
                     "example": "john@example.com"
@@ -484,91 +3198,150 @@ const docTemplate = `{
                     "description": "Имя пользователя",
                     "type": "string",
                     "maxLength": 255,
+                    "minLength": 1,
                     "example": "John Doe"
-                },
-                "password": {
-                    "description": "Введенный пользователем пароль",
-                    "type": "string",
-                    "minLength": 8,
-                    "example": "securepassword123"
                 }
             }
         },
-        "models.ErrorLoginResponse": {
-            "description": "Структура, которая содержит сообщение об ошибке",
+        "models.RecoveryCodesResponse": {
+            "description": "Одноразовые коды восстановления. Показываются только один раз",
             "type": "object",
             "properties": {
-                "error": {
-                    "description": "Сообщение об ошибке",
-                    "type": "string"
+                "recovery_codes": {
+                    "description": "Коды восстановления",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "abcd-efgh"
+                    ]
                 }
             }
         },
-        "models.LoginRequest": {
-            "description": "Структура данных для аутентификации пользователя через email и пароль",
+        "models.RefreshRequest": {
+            "description": "Структура данных для обмена refresh токена на новую пару токенов. В режиме cookie тело не передается: refresh токен берется из cookie",
             "type": "object",
-            "required": [
-                "email",
-                "password"
-            ],
             "properties": {
-                "email": {
-                    "description": "Email пользователя для аутентификации",
-                    "type": "string",
-                    "example": "user@example.com"
-                },
-                "password": {
-                    "description": "Пароль пользователя",
+                "refresh_token": {
+                    "description": "Refresh токен, полученный при входе или предыдущем обновлении (обязателен вне режима cookie)",
                     "type": "string",
-                    "minLength": 8,
-                    "example": "securepassword123"
+                    "example": "Zm9vYmFyYmF6..."
                 }
             }
         },
-        "models.LoginResponse": {
-            "description": "Структура, которая возвращает токен для аутентифицированного пользователя",
+        "models.ResetPasswordRequest": {
+            "description": "Токен из письма и новый пароль",
             "type": "object",
+            "required": [
+                "password",
+                "token"
+            ],
             "properties": {
+                "password": {
+                    "description": "Новый пароль (проверяется политикой паролей)",
+                    "type": "string",
+                    "example": "newsecurepassword123"
+                },
                 "token": {
-                    "description": "Токен аутентифицированного пользователя",
+                    "description": "Токен сброса пароля из письма",
                     "type": "string",
-                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                    "example": "Zm9vYmFyYmF6..."
                 }
             }
         },
-        "models.OrderResponse": {
-            "description": "Структура для ответа, содержащая информацию о заказе",
+        "models.SessionResponse": {
+            "description": "Активная сессия пользователя",
             "type": "object",
             "properties": {
                 "created_at": {
-                    "description": "Дата и время создания заказа",
+                    "description": "Дата и время входа",
                     "type": "string",
                     "example": "2025-05-07T12:34:56Z"
                 },
+                "current": {
+                    "description": "Признак сессии, из которой выполнен запрос",
+                    "type": "boolean",
+                    "example": true
+                },
                 "id": {
-                    "description": "Уникальный идентификатор заказа",
-                    "type": "integer",
-                    "example": 1
+                    "description": "Идентификатор сессии",
+                    "type": "string",
+                    "example": "0b9c6f0e-5d1f-4a51-9d55-8d7f1f3f8a2e"
+                },
+                "ip_address": {
+                    "description": "IP адрес клиента при входе",
+                    "type": "string",
+                    "example": "203.0.113.10"
+                },
+                "last_seen_at": {
+                    "description": "Дата и время последнего запроса",
+                    "type": "string",
+                    "example": "2025-05-07T13:00:00Z"
+                },
+                "user_agent": {
+                    "description": "User-Agent клиента при входе",
+                    "type": "string",
+                    "example": "Mozilla/5.0"
+                }
+            }
+        },
+        "models.TwoFactorCodeRequest": {
+            "description": "Код из приложения-аутентификатора или код восстановления",
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "description": "Код из приложения-аутентификатора или код восстановления",
+                    "type": "string",
+                    "example": "123456"
+                }
+            }
+        },
+        "models.TwoFactorEnrollResponse": {
+            "description": "Секрет TOTP и otpauth URI для приложения-аутентификатора",
+            "type": "object",
+            "properties": {
+                "otpauth_uri": {
+                    "description": "URI для QR-кода",
+                    "type": "string",
+                    "example": "otpauth://totp/khrllwTest:john@example.com?secret=JBSWY3DPEHPK3PXP\u0026issuer=khrllwTest"
                 },
+                "secret": {
+                    "description": "Секрет TOTP в base32 для ручного ввода",
+                    "type": "string",
+                    "example": "JBSWY3DPEHPK3PXP"
+                }
+            }
+        },
+        "models.UpdateOrderRequest": {
+            "description": "Структура для запроса на обновление заказа (заменяет все поля)",
+            "type": "object",
+            "required": [
+                "price",
+                "product",
+                "quantity"
+            ],
+            "properties": {
                 "price": {
                     "description": "Цена товара",
                     "type": "number",
-                    "example": 1500.5
+                    "minimum": 0,
+                    "example": 1450
                 },
                 "product": {
                     "description": "Название продукта, заказанного пользователем",
                     "type": "string",
+                    "maxLength": 255,
                     "example": "Laptop"
                 },
                 "quantity": {
                     "description": "Количество заказанных единиц товара",
                     "type": "integer",
-                    "example": 2
-                },
-                "user_id": {
-                    "description": "Идентификатор пользователя, который сделал заказ",
-                    "type": "integer",
-                    "example": 123
+                    "minimum": 1,
+                    "example": 3
                 }
             }
         },
@@ -584,7 +3357,8 @@ const docTemplate = `{
                 "age": {
                     "description": "Возраст пользователя",
                     "type": "integer",
-                    "minimum": 0,
+                    "maximum": 150,
+                    "minimum": 1,
                     "example": 30
                 },
                 "email": {
@@ -601,6 +3375,24 @@ const docTemplate = `{
                 }
             }
         },
+        "models.UpdateUserRoleRequest": {
+            "description": "Структура для запроса на изменение роли пользователя (только для администраторов)",
+            "type": "object",
+            "required": [
+                "role"
+            ],
+            "properties": {
+                "role": {
+                    "description": "Новая роль пользователя",
+                    "type": "string",
+                    "enum": [
+                        "user",
+                        "admin"
+                    ],
+                    "example": "admin"
+                }
+            }
+        },
         "models.UserResponse": {
             "description": "Структура ответа, содержащая информацию о пользователе",
             "type": "object",
@@ -610,11 +3402,21 @@ const docTemplate = `{
                     "type": "integer",
                     "example": 30
                 },
+                "avatar_url": {
+                    "description": "Адрес аватара пользователя (нет - аватар не загружен)",
+                    "type": "string",
+                    "example": "/users/1/avatar"
+                },
                 "email": {
                     "description": "Email пользователя",
                     "type": "string",
                     "example": "john@example.com"
                 },
+                "email_verified": {
+                    "description": "Признак подтвержденного email",
+                    "type": "boolean",
+                    "example": true
+                },
                 "id": {
                     "description": "Уникальный идентификатор пользователя",
                     "type": "integer",
@@ -624,23 +3426,77 @@ const docTemplate = `{
                     "description": "Имя пользователя",
                     "type": "string",
                     "example": "John Doe"
+                },
+                "role": {
+                    "description": "Роль пользователя",
+                    "type": "string",
+                    "example": "user"
+                }
+            }
+        },
+        "models.UsersListFilters": {
+            "description": "Фильтры, с которыми выбран список пользователей (пустые не выводятся)",
+            "type": "object",
+            "properties": {
+                "email_domain": {
+                    "description": "Домен email",
+                    "type": "string",
+                    "example": "example.com"
+                },
+                "max_age": {
+                    "description": "Максимальный возраст",
+                    "type": "integer",
+                    "example": 65
+                },
+                "min_age": {
+                    "description": "Минимальный возраст",
+                    "type": "integer",
+                    "example": 18
+                },
+                "search": {
+                    "description": "Подстрока имени или email",
+                    "type": "string",
+                    "example": "john"
                 }
             }
         },
         "models.UsersListResponse": {
-            "description": "Структура ответа с пользователями и информацией о пагинации",
+            "description": "Структура ответа с пользователями, информацией о пагинации, примененными фильтрами и сортировкой. При keyset-пагинации (параметр cursor) page не возвращается, а total - только с include_total=true",
             "type": "object",
             "properties": {
+                "filters": {
+                    "description": "Примененные фильтры",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.UsersListFilters"
+                        }
+                    ]
+                },
                 "limit": {
                     "description": "Количество элементов (пользователей) на одной странице",
                     "type": "integer",
                     "example": 10
                 },
+                "next_cursor": {
+                    "description": "Курсор следующей страницы (нет - страница последняя)",
+                    "type": "string",
+                    "example": "eyJxIjoiM2YyYTFiIiwiaWQiOjEwfQ.c2lnbmF0dXJl"
+                },
                 "page": {
-                    "description": "Номер текущей страницы в результате пагинации",
+                    "description": "Номер текущей страницы при offset-пагинации",
                     "type": "integer",
                     "example": 1
                 },
+                "prev_cursor": {
+                    "description": "Курсор предыдущей страницы (нет - страница первая)",
+                    "type": "string",
+                    "example": "eyJxIjoiM2YyYTFiIiwiYiI6dHJ1ZSwiaWQiOjF9.c2lnbmF0dXJl"
+                },
+                "sort": {
+                    "description": "Примененная сортировка: поля через запятую, \"-\" перед полем - по убыванию",
+                    "type": "string",
+                    "example": "-age,name"
+                },
                 "total": {
                     "description": "Общее количество пользователей, соответствующих запросу (до применения пагинации)",
                     "type": "integer",
@@ -654,6 +3510,59 @@ const docTemplate = `{
                     }
                 }
             }
+        },
+        "models.VerifyEmailRequest": {
+            "description": "Токен из ссылки в письме подтверждения",
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "description": "Токен подтверждения из письма",
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                }
+            }
+        },
+        "repository.UserCacheStats": {
+            "type": "object",
+            "properties": {
+                "capacity": {
+                    "type": "integer",
+                    "example": 10000
+                },
+                "evictions": {
+                    "description": "Записи, вытесненные при переполнении",
+                    "type": "integer",
+                    "example": 0
+                },
+                "hits": {
+                    "description": "Запросы, обслуженные из кеша (включая отметки \"не найден\")",
+                    "type": "integer",
+                    "example": 9500
+                },
+                "invalidations": {
+                    "description": "Записи, сброшенные при изменении пользователя",
+                    "type": "integer",
+                    "example": 40
+                },
+                "misses": {
+                    "description": "Запросы, отправленные в БД",
+                    "type": "integer",
+                    "example": 500
+                },
+                "negative_hits": {
+                    "description": "Из них обслужено отметкой \"не найден\"",
+                    "type": "integer",
+                    "example": 12
+                },
+                "size": {
+                    "description": "Текущее и максимальное количество записей",
+                    "type": "integer",
+                    "example": 480
+                }
+            }
         }
     },
     "securityDefinitions": {
@@ -88,8 +88,11 @@ func connectToDB(config Config, gormLogger logger.Interface) (*gorm.DB, error) {
 		config.Host, config.User, config.Password, config.DBName, config.Port,
 	)
 
+	// TranslateError переводит ошибки нарушения ограничений Postgres в ошибки GORM
+	// (например, gorm.ErrDuplicatedKey), чтобы репозитории не зависели от кодов драйвера
 	return gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: gormLogger,
+		Logger:         gormLogger,
+		TranslateError: true,
 	})
 }
 
@@ -133,5 +136,15 @@ func autoMigrate(db *gorm.DB) error {
 	return db.AutoMigrate(
 		&models.User{},
 		&models.Order{},
+		&models.RefreshToken{},
+		&models.RevokedToken{},
+		&models.APIKey{},
+		&models.RecoveryCode{},
+		&models.PasswordResetToken{},
+		&models.Session{},
+		&models.PasswordHistory{},
+		&models.OIDCLoginState{},
+		&models.UserIdentity{},
+		&models.AuditLog{},
 	)
 }
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"khrllwTest/internal/models"
+	"khrllwTest/internal/services"
+)
+
+// ------------------------------------------------------------
+// Структуры
+// ------------------------------------------------------------
+
+// APIKeyHandler обрабатывает HTTP-запросы для работы с API ключами
+type APIKeyHandler struct {
+	apiKeyService *service.APIKeyService
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewAPIKeyHandler создает новый экземпляр APIKeyHandler
+func NewAPIKeyHandler(apiKeyService *service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// ------------------------------------------------------------
+// Методы обработки запросов
+// ------------------------------------------------------------
+
+// CreateAPIKey обрабатывает запрос на создание API ключа
+// @Tags API Keys
+// @Summary Создать API ключ
+// @Description Создает именованный API ключ с областями доступа. Значение ключа возвращается только в этом ответе
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param user_id path int true "User ID"
+// @Param key body models.CreateAPIKeyRequest true "Параметры ключа"
+// @Success 201 {object} models.CreateAPIKeyResponse
+// @Failure 400 {object} models.ErrorLoginResponse "Неверный формат запроса/некорректные данные"
+// @Failure 401 {object} models.ErrorLoginResponse "Неверный токен авторизации"
+// @Failure 500 {object} models.ErrorLoginResponse "Внутренняя ошибка сервера"
+// @Router /users/{user_id}/api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	userID, err := h.parseUserID(c)
+	if err != nil {
+		h.sendErrorResponse(c, http.StatusBadRequest, models.ErrInvalidUserID)
+		return
+	}
+
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendErrorResponse(c, http.StatusBadRequest, models.ErrInvalidRequestFormat)
+		return
+	}
+
+	key, rawKey, err := h.apiKeyService.CreateAPIKey(userID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrUserNotFound):
+			h.sendErrorResponse(c, http.StatusNotFound, err)
+		case errors.Is(err, models.ErrDatabaseError), errors.Is(err, models.ErrTokenGenerationFailed):
+			h.sendErrorResponse(c, http.StatusInternalServerError, models.ErrInternalServerError)
+		default:
+			h.sendErrorResponse(c, http.StatusBadRequest, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.CreateAPIKeyResponse{
+		APIKeyResponse: h.mapToResponse(key),
+		Key:            rawKey,
+	})
+}
+
+// GetAPIKeys обрабатывает запрос на получение списка API ключей
+// @Tags API Keys
+// @Summary Получить API ключи пользователя
+// @Description Возвращает активные API ключи пользователя без их значений
+// @Produce json
+// @Security BearerAuth
+// @Param user_id path int true "User ID"
+// @Success 200 {array} models.APIKeyResponse
+// @Failure 400 {object} models.ErrorLoginResponse "Неверный формат запроса/некорректные данные"
+// @Failure 401 {object} models.ErrorLoginResponse "Неверный токен авторизации"
+// @Failure 500 {object} models.ErrorLoginResponse "Внутренняя ошибка сервера"
+// @Router /users/{user_id}/api-keys [get]
+func (h *APIKeyHandler) GetAPIKeys(c *gin.Context) {
+	userID, err := h.parseUserID(c)
+	if err != nil {
+		h.sendErrorResponse(c, http.StatusBadRequest, models.ErrInvalidUserID)
+		return
+	}
+
+	keys, err := h.apiKeyService.GetAPIKeys(userID)
+	if err != nil {
+		h.sendErrorResponse(c, http.StatusInternalServerError, models.ErrInternalServerError)
+		return
+	}
+
+	response := make([]models.APIKeyResponse, 0, len(keys))
+	for i := range keys {
+		response = append(response, h.mapToResponse(&keys[i]))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RevokeAPIKey обрабатывает запрос на отзыв API ключа
+// @Tags API Keys
+// @Summary Отозвать API ключ
+// @Description Отзывает API ключ пользователя, после чего ключ перестает приниматься
+// @Produce json
+// @Security BearerAuth
+// @Param user_id path int true "User ID"
+// @Param key_id path int true "API Key ID"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} models.ErrorLoginResponse "Неверный формат запроса/некорректные данные"
+// @Failure 401 {object} models.ErrorLoginResponse "Неверный токен авторизации"
+// @Failure 404 {object} models.ErrorLoginResponse "API ключ не найден"
+// @Failure 500 {object} models.ErrorLoginResponse "Внутренняя ошибка сервера"
+// @Router /users/{user_id}/api-keys/{key_id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	userID, err := h.parseUserID(c)
+	if err != nil {
+		h.sendErrorResponse(c, http.StatusBadRequest, models.ErrInvalidUserID)
+		return
+	}
+
+	keyID, err := strconv.Atoi(c.Param("key_id"))
+	if err != nil {
+		h.sendErrorResponse(c, http.StatusBadRequest, models.ErrAPIKeyNotFound)
+		return
+	}
+
+	if err := h.apiKeyService.RevokeAPIKey(userID, uint(keyID)); err != nil {
+		if errors.Is(err, models.ErrAPIKeyNotFound) {
+			h.sendErrorResponse(c, http.StatusNotFound, err)
+			return
+		}
+		h.sendErrorResponse(c, http.StatusInternalServerError, models.ErrInternalServerError)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ------------------------------------------------------------
+// Вспомогательные методы
+// ------------------------------------------------------------
+
+// parseUserID парсит ID пользователя из URL
+func (h *APIKeyHandler) parseUserID(c *gin.Context) (uint, error) {
+	id, err := strconv.Atoi(c.Param("user_id"))
+	return uint(id), err
+}
+
+// mapToResponse преобразует API ключ в формат ответа
+func (h *APIKeyHandler) mapToResponse(key *models.APIKey) models.APIKeyResponse {
+	return models.APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
+
+// sendErrorResponse отправляет ответ с ошибкой
+func (h *APIKeyHandler) sendErrorResponse(c *gin.Context, status int, err error) {
+	c.JSON(status, models.ErrorLoginResponse{
+		Error: err.Error(),
+	})
+}
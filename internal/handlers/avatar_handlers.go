@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"khrllwTest/internal/models"
+	"khrllwTest/internal/services"
+)
+
+// avatarMultipartOverhead запас на заголовки и границы multipart сверх размера файла аватара
+const avatarMultipartOverhead = 64 << 10
+
+// ------------------------------------------------------------
+// Структуры
+// ------------------------------------------------------------
+
+// AvatarHandler обрабатывает HTTP-запросы загрузки и получения аватаров пользователей
+type AvatarHandler struct {
+	avatarService *service.AvatarService
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewAvatarHandler создает новый экземпляр AvatarHandler
+func NewAvatarHandler(avatarService *service.AvatarService) *AvatarHandler {
+	return &AvatarHandler{
+		avatarService: avatarService,
+	}
+}
+
+// ------------------------------------------------------------
+// Методы обработки запросов
+// ------------------------------------------------------------
+
+// UploadAvatar обрабатывает запрос на загрузку аватара
+// @Tags Users
+// @Summary Загрузить аватар
+// @Description Принимает изображение JPEG, PNG или GIF (тип определяется по содержимому файла) размером до AVATAR_MAX_BYTES
+// @Description и сохраняет из его центра квадратные миниатюры AVATAR_SIZES в JPEG. Заменяет прежний аватар
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param user_id path int true "User ID"
+// @Param If-Match header string false "ETag версии пользователя"
+// @Param avatar formData file true "Изображение"
+// @Success 200 {object} models.UserResponse
+// @Header 200 {string} ETag "Новая версия пользователя"
+// @Failure 400 {object} models.ErrorLoginResponse "Файл не передан/некорректное изображение/слишком большое разрешение"
+// @Failure 404 {object} models.ErrorLoginResponse "Пользователь не найден"
+// @Failure 412 {object} models.ErrorLoginResponse "Версия пользователя не совпадает с If-Match"
+// @Failure 413 {object} models.ErrorLoginResponse "Файл превышает допустимый размер"
+// @Failure 415 {object} models.ErrorLoginResponse "Файл не является изображением JPEG, PNG или GIF"
+// @Failure 500 {object} models.ErrorLoginResponse "Внутренняя ошибка сервера"
+// @Router /users/{user_id}/avatar [put]
+func (h *AvatarHandler) UploadAvatar(c *gin.Context) {
+	userID, err := h.parseUserID(c)
+	if err != nil {
+		h.sendErrorResponse(c, http.StatusBadRequest, models.ErrInvalidUserID)
+		return
+	}
+
+	version, err := parseIfMatch(c)
+	if err != nil {
+		h.sendErrorResponse(c, http.StatusPreconditionFailed, err)
+		return
+	}
+
+	data, err := h.readAvatar(c)
+	if err != nil {
+		if errors.Is(err, models.ErrAvatarTooLarge) {
+			h.sendErrorResponse(c, http.StatusRequestEntityTooLarge, err)
+			return
+		}
+		h.sendErrorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+
+	user, err := h.avatarService.UploadAvatar(userID, data, version)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrUserNotFound):
+			h.sendErrorResponse(c, http.StatusNotFound, err)
+		case errors.Is(err, models.ErrAvatarTooLarge):
+			h.sendErrorResponse(c, http.StatusRequestEntityTooLarge, err)
+		case errors.Is(err, models.ErrUnsupportedAvatarType):
+			h.sendErrorResponse(c, http.StatusUnsupportedMediaType, err)
+		case errors.Is(err, models.ErrVersionConflict):
+			h.sendErrorResponse(c, http.StatusConflict, err)
+		case errors.Is(err, models.ErrPreconditionFailed):
+			h.sendErrorResponse(c, http.StatusPreconditionFailed, err)
+		case errors.Is(err, models.ErrInvalidAvatar), errors.Is(err, models.ErrAvatarDimensions):
+			h.sendErrorResponse(c, http.StatusBadRequest, err)
+		default:
+			h.sendErrorResponse(c, http.StatusInternalServerError, models.ErrInternalServerError)
+		}
+		return
+	}
+
+	sendUserResponse(c, http.StatusOK, user)
+}
+
+// GetAvatar обрабатывает запрос на получение аватара
+// @Tags Users
+// @Summary Получить аватар
+// @Description Возвращает миниатюру аватара в JPEG. Без size - наибольшую из AVATAR_SIZES.
+// @Description Ответ содержит ETag, с If-None-Match возвращается 304, если аватар не менялся
+// @Produce jpeg
+// @Security BearerAuth
+// @Param user_id path int true "User ID"
+// @Param size query int false "Сторона миниатюры в пикселях (одно из AVATAR_SIZES)"
+// @Success 200 {file} binary "Изображение"
+// @Success 304 {string} string "Not Modified"
+// @Failure 400 {object} models.ErrorLoginResponse "Некорректный ID пользователя/недоступный размер"
+// @Failure 404 {object} models.ErrorLoginResponse "Пользователь или аватар не найден"
+// @Failure 500 {object} models.ErrorLoginResponse "Внутренняя ошибка сервера"
+// @Router /users/{user_id}/avatar [get]
+func (h *AvatarHandler) GetAvatar(c *gin.Context) {
+	userID, err := h.parseUserID(c)
+	if err != nil {
+		h.sendErrorResponse(c, http.StatusBadRequest, models.ErrInvalidUserID)
+		return
+	}
+
+	size := 0
+	if value := c.Query("size"); value != "" {
+		if size, err = strconv.Atoi(value); err != nil {
+			h.sendErrorResponse(c, http.StatusBadRequest, models.ErrInvalidAvatarSize)
+			return
+		}
+	}
+
+	object, tag, err := h.avatarService.OpenAvatar(userID, size)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrUserNotFound), errors.Is(err, models.ErrAvatarNotFound):
+			h.sendErrorResponse(c, http.StatusNotFound, err)
+		case errors.Is(err, models.ErrInvalidAvatarSize):
+			h.sendErrorResponse(c, http.StatusBadRequest, err)
+		default:
+			h.sendErrorResponse(c, http.StatusInternalServerError, models.ErrInternalServerError)
+		}
+		return
+	}
+	defer object.Body.Close()
+
+	etag := `"` + tag + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, no-cache")
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.DataFromReader(http.StatusOK, object.Size, object.ContentType, object.Body, nil)
+}
+
+// ------------------------------------------------------------
+// Вспомогательные методы
+// ------------------------------------------------------------
+
+// parseUserID парсит ID пользователя из URL-параметра; ноль и отрицательные значения недопустимы
+func (h *AvatarHandler) parseUserID(c *gin.Context) (uint, error) {
+	id, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		return 0, err
+	}
+	if id <= 0 {
+		return 0, models.ErrInvalidUserID
+	}
+	return uint(id), nil
+}
+
+// readAvatar читает файл из поля avatar multipart формы, не принимая больше AVATAR_MAX_BYTES
+func (h *AvatarHandler) readAvatar(c *gin.Context) ([]byte, error) {
+	maxBytes := int64(h.avatarService.MaxBytes())
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+avatarMultipartOverhead)
+
+	header, err := c.FormFile("avatar")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, models.ErrAvatarTooLarge
+		}
+		return nil, models.ErrInvalidRequestFormat
+	}
+	if header.Size > maxBytes {
+		return nil, models.ErrAvatarTooLarge
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, models.ErrInvalidRequestFormat
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxBytes+1))
+	if err != nil {
+		return nil, models.ErrInvalidRequestFormat
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, models.ErrAvatarTooLarge
+	}
+	return data, nil
+}
+
+// sendErrorResponse отправляет ответ с ошибкой
+func (h *AvatarHandler) sendErrorResponse(c *gin.Context, status int, err error) {
+	c.JSON(status, models.ErrorLoginResponse{
+		Error: err.Error(),
+	})
+}
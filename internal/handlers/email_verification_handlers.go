@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"khrllwTest/internal/middleware"
+	"khrllwTest/internal/models"
+	"khrllwTest/internal/services"
+)
+
+// ------------------------------------------------------------
+// Структуры
+// ------------------------------------------------------------
+
+// EmailVerificationHandler обрабатывает HTTP-запросы подтверждения email
+type EmailVerificationHandler struct {
+	verificationService *service.EmailVerificationService
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewEmailVerificationHandler создает новый экземпляр EmailVerificationHandler
+func NewEmailVerificationHandler(verificationService *service.EmailVerificationService) *EmailVerificationHandler {
+	return &EmailVerificationHandler{
+		verificationService: verificationService,
+	}
+}
+
+// ------------------------------------------------------------
+// Методы обработки запросов
+// ------------------------------------------------------------
+
+// VerifyEmail godoc
+// @Tags Authorization
+// @Summary Подтверждение email
+// @Description Подтверждает email по токену из ссылки в письме
+// @Accept json
+// @Produce json
+// @Param request body models.VerifyEmailRequest true "Токен подтверждения"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} models.ErrorLoginResponse "Неверный формат запроса/недействительный токен"
+// @Failure 500 {object} models.ErrorLoginResponse "Внутрення ошибка сервера"
+// @Router /auth/verify-email [post]
+func (h *EmailVerificationHandler) VerifyEmail(c *gin.Context) {
+	var req models.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendErrorResponse(c, http.StatusBadRequest, models.ErrInvalidRequestFormat)
+		return
+	}
+
+	if err := h.verificationService.Verify(req.Token); err != nil {
+		if errors.Is(err, models.ErrInvalidVerificationToken) {
+			h.sendErrorResponse(c, http.StatusBadRequest, err)
+			return
+		}
+		h.sendErrorResponse(c, http.StatusInternalServerError, models.ErrInternalServerError)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ResendVerification godoc
+// @Tags Authorization
+// @Summary Повторная отправка письма подтверждения
+// @Description Отправляет новую ссылку подтверждения на email текущего пользователя. Частота отправки ограничена
+// @Produce json
+// @Security BearerAuth
+// @Success 202 {string} string "Accepted"
+// @Failure 401 {object} models.ErrorLoginResponse "Неверный токен авторизации"
+// @Failure 409 {object} models.ErrorLoginResponse "Email уже подтвержден"
+// @Failure 429 {object} models.ErrorLoginResponse "Письмо уже отправлено недавно (заголовок Retry-After)"
+// @Failure 500 {object} models.ErrorLoginResponse "Внутрення ошибка сервера"
+// @Router /auth/verify-email/resend [post]
+func (h *EmailVerificationHandler) ResendVerification(c *gin.Context) {
+	userID := c.GetUint(middleware.ContextUserIDKey)
+
+	if err := h.verificationService.Resend(userID); err != nil {
+		var retryErr *models.RetryAfterError
+		switch {
+		case errors.As(err, &retryErr):
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryErr.RetryAfter.Seconds()))))
+			h.sendErrorResponse(c, http.StatusTooManyRequests, err)
+		case errors.Is(err, models.ErrEmailAlreadyVerified):
+			h.sendErrorResponse(c, http.StatusConflict, err)
+		case errors.Is(err, models.ErrUserNotFound):
+			h.sendErrorResponse(c, http.StatusUnauthorized, err)
+		default:
+			h.sendErrorResponse(c, http.StatusInternalServerError, models.ErrInternalServerError)
+		}
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// ------------------------------------------------------------
+// Вспомогательные методы
+// ------------------------------------------------------------
+
+// sendErrorResponse отправляет ответ с ошибкой
+func (h *EmailVerificationHandler) sendErrorResponse(c *gin.Context, status int, err error) {
+	c.JSON(status, models.ErrorLoginResponse{
+		Error: err.Error(),
+	})
+}
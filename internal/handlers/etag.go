@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"khrllwTest/internal/models"
+)
+
+// ------------------------------------------------------------
+// Условные запросы (ETag / If-Match)
+// ------------------------------------------------------------
+
+// versionETag возвращает сильный ETag версии записи ("3")
+func versionETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// parseIfMatch возвращает версию записи из заголовка If-Match (0 - заголовок не передан или равен "*").
+// Поддерживается одно значение ETag. Слабый или некорректный ETag не совпадает ни с одной версией
+// при строгом сравнении (RFC 9110), поэтому возвращается ErrPreconditionFailed.
+func parseIfMatch(c *gin.Context) (int, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	tag, ok := strings.CutPrefix(header, `"`)
+	if !ok {
+		return 0, models.ErrPreconditionFailed
+	}
+	tag, ok = strings.CutSuffix(tag, `"`)
+	if !ok {
+		return 0, models.ErrPreconditionFailed
+	}
+	version, err := strconv.Atoi(tag)
+	if err != nil || version <= 0 {
+		return 0, models.ErrPreconditionFailed
+	}
+	return version, nil
+}
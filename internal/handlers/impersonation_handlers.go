@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"khrllwTest/internal/middleware"
+	"khrllwTest/internal/models"
+	"khrllwTest/internal/services"
+)
+
+// maxAuditLogsLimit максимальное количество записей журнала на странице
+const maxAuditLogsLimit = 100
+
+// ------------------------------------------------------------
+// Структуры
+// ------------------------------------------------------------
+
+// ImpersonationHandler обрабатывает HTTP-запросы входа от имени пользователя и чтения журнала аудита
+type ImpersonationHandler struct {
+	impersonationService *service.ImpersonationService
+	auditService         *service.AuditService
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewImpersonationHandler создает новый экземпляр ImpersonationHandler
+func NewImpersonationHandler(
+	impersonationService *service.ImpersonationService,
+	auditService *service.AuditService,
+) *ImpersonationHandler {
+	return &ImpersonationHandler{
+		impersonationService: impersonationService,
+		auditService:         auditService,
+	}
+}
+
+// ------------------------------------------------------------
+// Методы обработки запросов
+// ------------------------------------------------------------
+
+// Impersonate обрабатывает запрос администратора на вход от имени пользователя
+// @Tags Impersonation
+// @Summary Войти от имени пользователя
+// @Description Выпускает короткоживущий access токен пользователя с claim act администратора. Refresh токен не выдается.
+// @Description Токен не допускается к смене пароля, удалению аккаунта, управлению 2FA, API ключами и сессиями.
+// @Description Выпуск токена и каждый запрос с ним записываются в журнал аудита
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param user_id path int true "User ID"
+// @Param request body models.ImpersonationRequest true "Причина входа"
+// @Success 201 {object} models.ImpersonationResponse
+// @Failure 400 {object} models.ErrorLoginResponse "Неверный формат запроса/некорректные данные"
+// @Failure 401 {object} models.ErrorLoginResponse "Неверный токен авторизации"
+// @Failure 403 {object} models.ErrorLoginResponse "Недостаточно прав или пользователь - администратор"
+// @Failure 404 {object} models.ErrorLoginResponse "Пользователь не найден"
+// @Failure 500 {object} models.ErrorLoginResponse "Внутренняя ошибка сервера"
+// @Router /users/{user_id}/impersonate [post]
+func (h *ImpersonationHandler) Impersonate(c *gin.Context) {
+	targetID, err := h.parseUserID(c)
+	if err != nil {
+		h.sendErrorResponse(c, http.StatusBadRequest, models.ErrInvalidUserID)
+		return
+	}
+
+	var req models.ImpersonationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendErrorResponse(c, http.StatusBadRequest, models.ErrInvalidRequestFormat)
+		return
+	}
+
+	response, err := h.impersonationService.Start(c.GetUint(middleware.ContextUserIDKey), targetID, req.Reason,
+		c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrUserNotFound):
+			h.sendErrorResponse(c, http.StatusNotFound, err)
+		case errors.Is(err, models.ErrCannotImpersonate):
+			h.sendErrorResponse(c, http.StatusForbidden, err)
+		default:
+			h.sendErrorResponse(c, http.StatusInternalServerError, models.ErrInternalServerError)
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// GetAuditLogs обрабатывает запрос на чтение журнала аудита
+// @Tags Impersonation
+// @Summary Журнал аудита
+// @Description Возвращает записи журнала аудита (выпуск токенов имперсонации и запросы с ними), новые первыми
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page" default(1)
+// @Param limit query int false "Limit" default(10)
+// @Param actor_id query int false "ID администратора"
+// @Param user_id query int false "ID пользователя"
+// @Success 200 {object} models.AuditLogsListResponse
+// @Failure 400 {object} models.ErrorLoginResponse "Неверный формат запроса/некорректные данные"
+// @Failure 401 {object} models.ErrorLoginResponse "Неверный токен авторизации"
+// @Failure 403 {object} models.ErrorLoginResponse "Недостаточно прав"
+// @Failure 500 {object} models.ErrorLoginResponse "Внутренняя ошибка сервера"
+// @Router /audit-logs [get]
+func (h *ImpersonationHandler) GetAuditLogs(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		h.sendErrorResponse(c, http.StatusBadRequest, models.ErrInvalidPagination)
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > maxAuditLogsLimit {
+		h.sendErrorResponse(c, http.StatusBadRequest, models.ErrInvalidPagination)
+		return
+	}
+
+	actorID, err := h.parseFilterID(c, "actor_id")
+	if err != nil {
+		h.sendErrorResponse(c, http.StatusBadRequest, models.ErrInvalidFilterParams)
+		return
+	}
+
+	userID, err := h.parseFilterID(c, "user_id")
+	if err != nil {
+		h.sendErrorResponse(c, http.StatusBadRequest, models.ErrInvalidFilterParams)
+		return
+	}
+
+	entries, total, err := h.auditService.List(page, limit, actorID, userID)
+	if err != nil {
+		h.sendErrorResponse(c, http.StatusInternalServerError, models.ErrInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.AuditLogsListResponse{
+		Page:  page,
+		Limit: limit,
+		Total: total,
+		Logs:  entries,
+	})
+}
+
+// ------------------------------------------------------------
+// Вспомогательные методы
+// ------------------------------------------------------------
+
+// parseUserID парсит ID пользователя из URL
+func (h *ImpersonationHandler) parseUserID(c *gin.Context) (uint, error) {
+	id, err := strconv.Atoi(c.Param("user_id"))
+	return uint(id), err
+}
+
+// parseFilterID парсит необязательный ID из параметра запроса (пусто - без фильтра)
+func (h *ImpersonationHandler) parseFilterID(c *gin.Context, name string) (uint, error) {
+	value := c.Query(name)
+	if value == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(value, 10, 64)
+	return uint(id), err
+}
+
+// sendErrorResponse отправляет ответ с ошибкой
+func (h *ImpersonationHandler) sendErrorResponse(c *gin.Context, status int, err error) {
+	c.JSON(status, models.ErrorLoginResponse{
+		Error: err.Error(),
+	})
+}
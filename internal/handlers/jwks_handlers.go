@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"khrllwTest/internal/utils"
+)
+
+// ------------------------------------------------------------
+// Структуры
+// ------------------------------------------------------------
+
+// JWKSHandler публикует открытые ключи подписи JWT
+type JWKSHandler struct {
+	tokenManager utils.TokenManager
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewJWKSHandler создает новый экземпляр JWKSHandler
+func NewJWKSHandler(tokenManager utils.TokenManager) *JWKSHandler {
+	return &JWKSHandler{
+		tokenManager: tokenManager,
+	}
+}
+
+// ------------------------------------------------------------
+// Основные методы
+// ------------------------------------------------------------
+
+// GetJWKS godoc
+// @Tags Authorization
+// @Summary Открытые ключи подписи токенов
+// @Description Возвращает JSON Web Key Set с активным ключом и ключами в периоде ротации. При подписи HS256 набор пуст
+// @Produce json
+// @Success 200 {object} models.JWKSResponse
+// @Router /.well-known/jwks.json [get]
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	// Ключи меняются редко, проверяющие сервисы могут кешировать набор
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.tokenManager.JWKS())
+}
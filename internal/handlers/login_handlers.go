@@ -2,9 +2,12 @@ package handlers
 
 import (
 	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"khrllwTest/internal/middleware"
 	"khrllwTest/internal/models"
 	"khrllwTest/internal/services"
 )
@@ -16,6 +19,7 @@ import (
 // LoginHandler обрабатывает HTTP-запросы для входа пользователя
 type LoginHandler struct {
 	loginService *service.LoginService
+	cookies      *middleware.CookieAuth
 }
 
 // ------------------------------------------------------------
@@ -23,9 +27,10 @@ type LoginHandler struct {
 // ------------------------------------------------------------
 
 // NewLoginHandler создает новый экземпляр LoginHandler
-func NewLoginHandler(authService *service.LoginService) *LoginHandler {
+func NewLoginHandler(authService *service.LoginService, cookies *middleware.CookieAuth) *LoginHandler {
 	return &LoginHandler{
 		loginService: authService,
+		cookies:      cookies,
 	}
 }
 
@@ -36,13 +41,16 @@ func NewLoginHandler(authService *service.LoginService) *LoginHandler {
 // Login godoc
 // @Tags Authorization
 // @Summary Авторизация пользователя
-// @Description Вход в систему с email и паролем
+// @Description Вход в систему с email и паролем. Для пользователей с 2FA возвращает challenge_token вместо токенов.
+// @Description При use_cookie=true токены устанавливаются в HttpOnly cookie, а в ответе возвращается csrf_token
 // @Accept json
 // @Produce json
 // @Param request body models.LoginRequest true "Данные для входа"
 // @Success 200 {object} models.LoginResponse
-// @Failure 400 {object} models.ErrorLoginResponse "Неверный формат запроса"
+// @Failure 400 {object} models.ErrorLoginResponse "Неверный формат запроса или режим cookie отключен"
 // @Failure 401 {object} models.ErrorLoginResponse "Некорректные данные"
+// @Failure 423 {object} models.ErrorLoginResponse "Аккаунт временно заблокирован (заголовок Retry-After)"
+// @Failure 429 {object} models.ErrorLoginResponse "Слишком много попыток входа (заголовок Retry-After)"
 // @Failure 500 {object} models.ErrorLoginResponse "Внутрення ошибка сервера"
 // @Router /auth/login [post]
 func (h *LoginHandler) Login(c *gin.Context) {
@@ -52,17 +60,193 @@ func (h *LoginHandler) Login(c *gin.Context) {
 		return
 	}
 
-	token, err := h.loginService.Login(req.Email, req.Password)
+	if req.UseCookie && !h.cookies.Enabled() {
+		h.sendErrorResponse(c, http.StatusBadRequest, models.ErrCookieAuthDisabled)
+		return
+	}
+
+	tokens, err := h.loginService.Login(req.Email, req.Password, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		h.sendLoginError(c, err)
+		return
+	}
+
+	h.sendTokens(c, tokens, req.UseCookie)
+}
+
+// LoginTwoFactor godoc
+// @Tags Authorization
+// @Summary Второй шаг входа с 2FA
+// @Description Обменивает challenge токен и код из приложения-аутентификатора (или код восстановления) на пару токенов.
+// @Description При use_cookie=true токены устанавливаются в HttpOnly cookie
+// @Accept json
+// @Produce json
+// @Param request body models.LoginTwoFactorRequest true "Challenge токен и код"
+// @Success 200 {object} models.LoginResponse
+// @Failure 400 {object} models.ErrorLoginResponse "Неверный формат запроса или режим cookie отключен"
+// @Failure 401 {object} models.ErrorLoginResponse "Неверный код или challenge токен"
+// @Failure 423 {object} models.ErrorLoginResponse "Аккаунт временно заблокирован (заголовок Retry-After)"
+// @Failure 429 {object} models.ErrorLoginResponse "Слишком много попыток входа (заголовок Retry-After)"
+// @Failure 500 {object} models.ErrorLoginResponse "Внутрення ошибка сервера"
+// @Router /auth/login/2fa [post]
+func (h *LoginHandler) LoginTwoFactor(c *gin.Context) {
+	var req models.LoginTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendErrorResponse(c, http.StatusBadRequest, models.ErrInvalidRequestFormat)
+		return
+	}
+
+	if req.UseCookie && !h.cookies.Enabled() {
+		h.sendErrorResponse(c, http.StatusBadRequest, models.ErrCookieAuthDisabled)
+		return
+	}
+
+	tokens, err := h.loginService.LoginTwoFactor(req.ChallengeToken, req.Code, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		h.sendLoginError(c, err)
+		return
+	}
+
+	h.sendTokens(c, tokens, req.UseCookie)
+}
+
+// Refresh godoc
+// @Tags Authorization
+// @Summary Обновление токенов
+// @Description Обменивает refresh токен на новую пару токенов. Refresh токен ротируется при каждом использовании, повторное использование отзывает все семейство токенов.
+// @Description Если тело не передано, refresh токен берется из cookie; в этом случае обязателен заголовок X-CSRF-Token
+// @Accept json
+// @Produce json
+// @Param request body models.RefreshRequest false "Refresh токен"
+// @Param X-CSRF-Token header string false "CSRF токен (при обновлении через cookie)"
+// @Success 200 {object} models.LoginResponse
+// @Failure 400 {object} models.ErrorLoginResponse "Неверный формат запроса"
+// @Failure 401 {object} models.ErrorLoginResponse "Недействительный или повторно использованный refresh токен"
+// @Failure 403 {object} models.ErrorLoginResponse "Отсутствует или неверный CSRF токен"
+// @Failure 500 {object} models.ErrorLoginResponse "Внутрення ошибка сервера"
+// @Router /auth/refresh [post]
+func (h *LoginHandler) Refresh(c *gin.Context) {
+	var req models.RefreshRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.sendErrorResponse(c, http.StatusBadRequest, models.ErrInvalidRequestFormat)
+			return
+		}
+	}
+
+	// Без токена в теле используется cookie; такой запрос браузер может отправить сам, поэтому нужен CSRF токен
+	useCookie := req.RefreshToken == ""
+	if useCookie {
+		req.RefreshToken = h.cookies.RefreshToken(c)
+		if req.RefreshToken == "" {
+			h.sendErrorResponse(c, http.StatusBadRequest, models.ErrInvalidRequestFormat)
+			return
+		}
+		if !h.cookies.ValidCSRF(c) {
+			h.sendErrorResponse(c, http.StatusForbidden, models.ErrInvalidCSRFToken)
+			return
+		}
+	}
+
+	tokens, err := h.loginService.Refresh(req.RefreshToken, c.ClientIP(), c.Request.UserAgent())
 	if err != nil {
 		if errors.Is(err, models.ErrDatabaseError) || errors.Is(err, models.ErrTokenGenerationFailed) {
 			h.sendErrorResponse(c, http.StatusInternalServerError, models.ErrInternalServerError)
 			return
 		}
+		if useCookie {
+			h.cookies.Clear(c)
+		}
 		h.sendErrorResponse(c, http.StatusUnauthorized, err)
 		return
 	}
 
-	h.sendSuccessResponse(c, token)
+	h.sendTokens(c, tokens, useCookie)
+}
+
+// Logout godoc
+// @Tags Authorization
+// @Summary Выход из системы
+// @Description Отзывает текущий access токен и завершает его сессию. Если передан refresh токен, отзывается и его семейство.
+// @Description Cookie аутентификации удаляются; refresh токен из cookie используется, если он не передан в теле
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.LogoutRequest false "Refresh токен текущей сессии"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} models.ErrorLoginResponse "Неверный формат запроса"
+// @Failure 401 {object} models.ErrorLoginResponse "Неверный токен авторизации"
+// @Failure 500 {object} models.ErrorLoginResponse "Внутрення ошибка сервера"
+// @Router /auth/logout [post]
+func (h *LoginHandler) Logout(c *gin.Context) {
+	var req models.LogoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.sendErrorResponse(c, http.StatusBadRequest, models.ErrInvalidRequestFormat)
+			return
+		}
+	}
+
+	userID := c.GetUint(middleware.ContextUserIDKey)
+	tokenID := c.GetString(middleware.ContextTokenIDKey)
+	expiresAt := c.GetTime(middleware.ContextTokenExpiresAtKey)
+	sessionID := c.GetString(middleware.ContextSessionIDKey)
+	if req.RefreshToken == "" {
+		req.RefreshToken = h.cookies.RefreshToken(c)
+	}
+
+	if err := h.loginService.Logout(userID, tokenID, expiresAt, sessionID, req.RefreshToken); err != nil {
+		h.sendErrorResponse(c, http.StatusInternalServerError, models.ErrInternalServerError)
+		return
+	}
+
+	if h.cookies.Enabled() {
+		h.cookies.Clear(c)
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// LogoutAll godoc
+// @Tags Authorization
+// @Summary Выход со всех устройств
+// @Description Завершает все сессии пользователя и делает недействительными все выданные ему access и refresh токены
+// @Produce json
+// @Security BearerAuth
+// @Success 204 {string} string "No Content"
+// @Failure 401 {object} models.ErrorLoginResponse "Неверный токен авторизации"
+// @Failure 500 {object} models.ErrorLoginResponse "Внутрення ошибка сервера"
+// @Router /auth/logout-all [post]
+func (h *LoginHandler) LogoutAll(c *gin.Context) {
+	userID := c.GetUint(middleware.ContextUserIDKey)
+
+	if err := h.loginService.LogoutAll(userID); err != nil {
+		h.sendErrorResponse(c, http.StatusInternalServerError, models.ErrInternalServerError)
+		return
+	}
+
+	if h.cookies.Enabled() {
+		h.cookies.Clear(c)
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Ответ с ошибкой входа; при ограничении попыток добавляется заголовок Retry-After
+func (h *LoginHandler) sendLoginError(c *gin.Context, err error) {
+	var retryErr *models.RetryAfterError
+	if errors.As(err, &retryErr) {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryErr.RetryAfter.Seconds()))))
+		if errors.Is(err, models.ErrAccountLocked) {
+			h.sendErrorResponse(c, http.StatusLocked, err)
+			return
+		}
+		h.sendErrorResponse(c, http.StatusTooManyRequests, err)
+		return
+	}
+	if errors.Is(err, models.ErrDatabaseError) || errors.Is(err, models.ErrTokenGenerationFailed) {
+		h.sendErrorResponse(c, http.StatusInternalServerError, models.ErrInternalServerError)
+		return
+	}
+	h.sendErrorResponse(c, http.StatusUnauthorized, err)
 }
 
 // Ответ с ошибкой авторизации
@@ -72,9 +256,19 @@ func (h *LoginHandler) sendErrorResponse(c *gin.Context, statusCode int, err err
 	})
 }
 
+// Ответ с токенами; в режиме cookie токены переносятся в cookie.
+// Ответ с challenge токеном 2FA отправляется без изменений.
+func (h *LoginHandler) sendTokens(c *gin.Context, tokens *models.LoginResponse, useCookie bool) {
+	if useCookie && !tokens.TwoFactorRequired {
+		if err := h.cookies.SetTokens(c, tokens); err != nil {
+			h.sendErrorResponse(c, http.StatusInternalServerError, models.ErrInternalServerError)
+			return
+		}
+	}
+	h.sendSuccessResponse(c, tokens)
+}
+
 // Ответ успешным входом в систему
-func (h *LoginHandler) sendSuccessResponse(c *gin.Context, token string) {
-	c.JSON(http.StatusOK, models.LoginResponse{
-		Token: token,
-	})
+func (h *LoginHandler) sendSuccessResponse(c *gin.Context, tokens *models.LoginResponse) {
+	c.JSON(http.StatusOK, tokens)
 }
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"khrllwTest/internal/middleware"
+	"khrllwTest/internal/models"
+	"khrllwTest/internal/services"
+)
+
+const (
+	// oidcStateCookie cookie, привязывающая state входа к браузеру пользователя (защита от login CSRF)
+	oidcStateCookie = "oidc_state"
+
+	// oidcCookiePath путь, для которого браузер отправляет cookie state
+	oidcCookiePath = "/auth/oidc"
+)
+
+// ------------------------------------------------------------
+// Структуры
+// ------------------------------------------------------------
+
+// OIDCHandler обрабатывает HTTP-запросы входа через OpenID Connect
+type OIDCHandler struct {
+	oidcService *service.OIDCService
+	cookies     *middleware.CookieAuth
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewOIDCHandler создает новый экземпляр OIDCHandler
+func NewOIDCHandler(oidcService *service.OIDCService, cookies *middleware.CookieAuth) *OIDCHandler {
+	return &OIDCHandler{
+		oidcService: oidcService,
+		cookies:     cookies,
+	}
+}
+
+// ------------------------------------------------------------
+// Основные методы
+// ------------------------------------------------------------
+
+// GetProviders godoc
+// @Tags Authorization
+// @Summary Список провайдеров OpenID Connect
+// @Description Возвращает имена провайдеров, через которых доступен вход
+// @Produce json
+// @Success 200 {object} models.OIDCProvidersResponse
+// @Router /auth/oidc/providers [get]
+func (h *OIDCHandler) GetProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, models.OIDCProvidersResponse{Providers: h.oidcService.Providers()})
+}
+
+// Login godoc
+// @Tags Authorization
+// @Summary Вход через OpenID Connect
+// @Description Перенаправляет на страницу входа провайдера (authorization code с PKCE S256).
+// @Description Значение state привязывается к браузеру HttpOnly cookie
+// @Produce json
+// @Param provider path string true "Имя провайдера"
+// @Success 302 {string} string "Перенаправление на страницу входа провайдера"
+// @Failure 404 {object} models.ErrorLoginResponse "Провайдер не найден"
+// @Failure 502 {object} models.ErrorLoginResponse "Провайдер недоступен"
+// @Failure 500 {object} models.ErrorLoginResponse "Внутрення ошибка сервера"
+// @Router /auth/oidc/{provider}/login [get]
+func (h *OIDCHandler) Login(c *gin.Context) {
+	authURL, state, err := h.oidcService.Begin(c.Request.Context(), c.Param("provider"))
+	if err != nil {
+		h.sendOIDCError(c, err)
+		return
+	}
+
+	h.cookies.SetFlowCookie(c, oidcStateCookie, state, oidcCookiePath, int(h.oidcService.StateTTL().Seconds()))
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback godoc
+// @Tags Authorization
+// @Summary Обратный вызов OpenID Connect
+// @Description Проверяет state, обменивает код авторизации на ID токен, проверяет его по JWKS провайдера
+// @Description и выдает токены сервиса. Аккаунт находится по привязке провайдера, привязывается по подтвержденному email
+// @Description или создается. В режиме cookie токены устанавливаются в HttpOnly cookie
+// @Produce json
+// @Param provider path string true "Имя провайдера"
+// @Param code query string false "Код авторизации"
+// @Param state query string false "Значение state из запроса авторизации"
+// @Param error query string false "Ошибка, возвращенная провайдером"
+// @Success 200 {object} models.LoginResponse
+// @Failure 401 {object} models.ErrorLoginResponse "Неверный state, код или ID токен"
+// @Failure 403 {object} models.ErrorLoginResponse "Email не подтвержден провайдером или аккаунт не найден"
+// @Failure 404 {object} models.ErrorLoginResponse "Провайдер не найден"
+// @Failure 502 {object} models.ErrorLoginResponse "Провайдер недоступен"
+// @Failure 500 {object} models.ErrorLoginResponse "Внутрення ошибка сервера"
+// @Router /auth/oidc/{provider}/callback [get]
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	// Cookie state одноразовая и удаляется при любом исходе
+	boundState, _ := c.Cookie(oidcStateCookie)
+	h.cookies.SetFlowCookie(c, oidcStateCookie, "", oidcCookiePath, -1)
+
+	if c.Query("error") != "" {
+		h.sendErrorResponse(c, http.StatusUnauthorized, models.ErrOIDCLoginFailed)
+		return
+	}
+
+	state := c.Query("state")
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(boundState)) != 1 {
+		h.sendErrorResponse(c, http.StatusUnauthorized, models.ErrOIDCInvalidState)
+		return
+	}
+
+	tokens, err := h.oidcService.Complete(c.Request.Context(), c.Param("provider"), c.Query("code"), state,
+		c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		h.sendOIDCError(c, err)
+		return
+	}
+
+	if h.cookies.Enabled() && !tokens.TwoFactorRequired {
+		if err := h.cookies.SetTokens(c, tokens); err != nil {
+			h.sendErrorResponse(c, http.StatusInternalServerError, models.ErrInternalServerError)
+			return
+		}
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+// ------------------------------------------------------------
+// Вспомогательные методы
+// ------------------------------------------------------------
+
+// Ответ с ошибкой входа через OpenID Connect
+func (h *OIDCHandler) sendOIDCError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrOIDCProviderNotFound):
+		h.sendErrorResponse(c, http.StatusNotFound, err)
+	case errors.Is(err, models.ErrOIDCProviderUnavailable):
+		h.sendErrorResponse(c, http.StatusBadGateway, err)
+	case errors.Is(err, models.ErrOIDCInvalidState), errors.Is(err, models.ErrOIDCLoginFailed):
+		h.sendErrorResponse(c, http.StatusUnauthorized, err)
+	case errors.Is(err, models.ErrOIDCEmailNotVerified), errors.Is(err, models.ErrOIDCAccountNotFound),
+		errors.Is(err, models.ErrOIDCBirthdateRequired):
+		h.sendErrorResponse(c, http.StatusForbidden, err)
+	default:
+		h.sendErrorResponse(c, http.StatusInternalServerError, models.ErrInternalServerError)
+	}
+}
+
+// Ответ с ошибкой
+func (h *OIDCHandler) sendErrorResponse(c *gin.Context, statusCode int, err error) {
+	c.JSON(statusCode, models.ErrorLoginResponse{
+		Error: err.Error(),
+	})
+}
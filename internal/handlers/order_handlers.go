@@ -10,6 +10,14 @@ import (
 	"khrllwTest/internal/services"
 )
 
+const (
+	// defaultOrdersPageLimit количество заказов на странице, если задан только курсор
+	defaultOrdersPageLimit = 20
+
+	// maxOrdersPageLimit максимальное количество заказов на странице
+	maxOrdersPageLimit = 100
+)
+
 // ------------------------------------------------------------
 // Структуры
 // ------------------------------------------------------------
@@ -75,11 +83,15 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 // GetUserOrders обрабатывает запрос на получение заказов пользователя
 // @Tags Orders
 // @Summary Получить все заказы пользователя
-// @Description Возвращает все заказы для конкретного пользователя по его ID
+// @Description Возвращает все заказы для конкретного пользователя по его ID.
+// @Description Если задан limit или cursor, возвращается страница models.OrdersListResponse в порядке создания заказов с подписанными курсорами next_cursor/prev_cursor
 // @Accept json
 // @Produce json
 // @Param user_id path int true "User ID"
-// @Success 200 {array} models.OrderResponse
+// @Param limit query int false "Количество заказов на странице (1-100), включает постраничный ответ"
+// @Param cursor query string false "Курсор next_cursor или prev_cursor из предыдущего ответа"
+// @Param include_total query bool false "Подсчитать общее количество заказов"
+// @Success 200 {array} models.OrderResponse "Все заказы (без limit и cursor) или models.OrdersListResponse"
 // @Failure 400 {object} models.ErrorLoginResponse "Неверный формат запроса/некорректные данные"
 // @Failure 500 {object} models.ErrorLoginResponse "Внутренняя ошибка сервера"
 // @Router /users/{user_id}/orders [get]
@@ -90,6 +102,12 @@ func (h *OrderHandler) GetUserOrders(c *gin.Context) {
 		return
 	}
 
+	// Постраничный ответ включается параметрами; без них возвращается прежний массив всех заказов
+	if c.Query("limit") != "" || c.Query("cursor") != "" {
+		h.getUserOrdersPage(c, userID)
+		return
+	}
+
 	orders, err := h.orderService.GetUserOrders(userID)
 	if err != nil {
 		if errors.Is(err, models.ErrDatabaseError) {
@@ -103,16 +121,183 @@ func (h *OrderHandler) GetUserOrders(c *gin.Context) {
 	c.JSON(http.StatusOK, h.mapToResponse(orders))
 }
 
+// GetUserOrder обрабатывает запрос на получение заказа пользователя
+// @Tags Orders
+// @Summary Получить заказ пользователя
+// @Description Возвращает заказ пользователя по ID. Версия заказа передается в заголовке ETag
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Param order_id path int true "Order ID"
+// @Success 200 {object} models.OrderResponse
+// @Header 200 {string} ETag "Версия заказа для If-Match"
+// @Failure 400 {object} models.ErrorLoginResponse "Некорректный ID пользователя или заказа"
+// @Failure 404 {object} models.ErrorLoginResponse "Пользователь или заказ не найден"
+// @Failure 500 {object} models.ErrorLoginResponse "Внутренняя ошибка сервера"
+// @Router /users/{user_id}/orders/{order_id} [get]
+func (h *OrderHandler) GetUserOrder(c *gin.Context) {
+	userID, orderID, ok := h.parseOrderPath(c)
+	if !ok {
+		return
+	}
+
+	order, err := h.orderService.GetUserOrder(userID, orderID)
+	if err != nil {
+		h.sendOrderError(c, err)
+		return
+	}
+
+	h.sendOrderResponse(c, http.StatusOK, order)
+}
+
+// UpdateOrder обрабатывает запрос на обновление заказа
+// @Tags Orders
+// @Summary Обновить заказ
+// @Description Заменяет данные заказа. С заголовком If-Match обновление выполняется, только если версия заказа совпадает с ETag
+// @Accept json
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Param order_id path int true "Order ID"
+// @Param If-Match header string false "ETag версии заказа"
+// @Param order body models.UpdateOrderRequest true "Данные заказа"
+// @Success 200 {object} models.OrderResponse
+// @Header 200 {string} ETag "Новая версия заказа"
+// @Failure 400 {object} models.ErrorLoginResponse "Неверный формат запроса/некорректные данные"
+// @Failure 404 {object} models.ErrorLoginResponse "Пользователь или заказ не найден"
+// @Failure 409 {object} models.ErrorLoginResponse "Заказ изменен параллельным запросом"
+// @Failure 412 {object} models.ErrorLoginResponse "Версия заказа не совпадает с If-Match"
+// @Failure 500 {object} models.ErrorLoginResponse "Внутренняя ошибка сервера"
+// @Router /users/{user_id}/orders/{order_id} [put]
+func (h *OrderHandler) UpdateOrder(c *gin.Context) {
+	userID, orderID, ok := h.parseOrderPath(c)
+	if !ok {
+		return
+	}
+
+	version, err := parseIfMatch(c)
+	if err != nil {
+		h.sendErrorResponse(c, http.StatusPreconditionFailed, err)
+		return
+	}
+
+	var req models.UpdateOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendErrorResponse(c, http.StatusBadRequest, models.ErrInvalidRequestFormat)
+		return
+	}
+
+	order, err := h.orderService.UpdateOrder(userID, orderID, &req, version)
+	if err != nil {
+		h.sendOrderError(c, err)
+		return
+	}
+
+	h.sendOrderResponse(c, http.StatusOK, order)
+}
+
+// DeleteOrder обрабатывает запрос на удаление заказа
+// @Tags Orders
+// @Summary Удалить заказ
+// @Description Удаляет заказ пользователя. С заголовком If-Match заказ удаляется, только если его версия совпадает с ETag
+// @Param user_id path int true "User ID"
+// @Param order_id path int true "Order ID"
+// @Param If-Match header string false "ETag версии заказа"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} models.ErrorLoginResponse "Некорректный ID пользователя или заказа"
+// @Failure 404 {object} models.ErrorLoginResponse "Пользователь или заказ не найден"
+// @Failure 412 {object} models.ErrorLoginResponse "Версия заказа не совпадает с If-Match"
+// @Failure 500 {object} models.ErrorLoginResponse "Внутренняя ошибка сервера"
+// @Router /users/{user_id}/orders/{order_id} [delete]
+func (h *OrderHandler) DeleteOrder(c *gin.Context) {
+	userID, orderID, ok := h.parseOrderPath(c)
+	if !ok {
+		return
+	}
+
+	version, err := parseIfMatch(c)
+	if err != nil {
+		h.sendErrorResponse(c, http.StatusPreconditionFailed, err)
+		return
+	}
+
+	if err := h.orderService.DeleteOrder(userID, orderID, version); err != nil {
+		h.sendOrderError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
 // ------------------------------------------------------------
 // Вспомогательные методы
 // ------------------------------------------------------------
 
+// getUserOrdersPage отправляет страницу заказов пользователя (keyset-пагинация)
+func (h *OrderHandler) getUserOrdersPage(c *gin.Context, userID uint) {
+	query, err := h.parsePageParams(c)
+	if err != nil {
+		h.sendErrorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+
+	page, err := h.orderService.GetUserOrdersPage(userID, query)
+	if err != nil {
+		if errors.Is(err, models.ErrDatabaseError) || errors.Is(err, models.ErrInternalServerError) {
+			h.sendErrorResponse(c, http.StatusInternalServerError, models.ErrInternalServerError)
+			return
+		}
+		h.sendErrorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.OrdersListResponse{
+		Limit:      query.Limit,
+		Total:      page.Total,
+		NextCursor: page.NextCursor,
+		PrevCursor: page.PrevCursor,
+		Orders:     h.mapToResponse(page.Orders),
+	})
+}
+
+// parsePageParams парсит параметры постраничного запроса заказов
+func (h *OrderHandler) parsePageParams(c *gin.Context) (*models.OrderListQuery, error) {
+	query := &models.OrderListQuery{Cursor: c.Query("cursor")}
+	var err error
+
+	query.Limit, err = strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultOrdersPageLimit)))
+	if err != nil || query.Limit < 1 || query.Limit > maxOrdersPageLimit {
+		return nil, models.ErrInvalidPagination
+	}
+
+	if includeTotal := c.Query("include_total"); includeTotal != "" {
+		query.IncludeTotal, err = strconv.ParseBool(includeTotal)
+		if err != nil {
+			return nil, models.ErrInvalidPagination
+		}
+	}
+	return query, nil
+}
+
 // parseUserID парсит ID пользователя из URL
 func (h *OrderHandler) parseUserID(c *gin.Context) (uint, error) {
 	id, err := strconv.Atoi(c.Param("user_id"))
 	return uint(id), err
 }
 
+// parseOrderPath парсит ID пользователя и заказа из URL (при ошибке отправляет ответ 400)
+func (h *OrderHandler) parseOrderPath(c *gin.Context) (uint, uint, bool) {
+	userID, err := h.parseUserID(c)
+	if err != nil {
+		h.sendErrorResponse(c, http.StatusBadRequest, models.ErrInvalidUserID)
+		return 0, 0, false
+	}
+	orderID, err := strconv.Atoi(c.Param("order_id"))
+	if err != nil || orderID <= 0 {
+		h.sendErrorResponse(c, http.StatusBadRequest, models.ErrInvalidOrderID)
+		return 0, 0, false
+	}
+	return userID, uint(orderID), true
+}
+
 // mapToResponse преобразует заказы в формат ответа
 func (h *OrderHandler) mapToResponse(orders []models.Order) []models.OrderResponse {
 	response := make([]models.OrderResponse, 0, len(orders))
@@ -124,13 +309,15 @@ func (h *OrderHandler) mapToResponse(orders []models.Order) []models.OrderRespon
 			Quantity:  order.Quantity,
 			Price:     order.Price,
 			CreatedAt: order.CreatedAt,
+			Version:   order.Version,
 		})
 	}
 	return response
 }
 
-// sendOrderResponse отправляет ответ с заказом
+// sendOrderResponse отправляет ответ с заказом и его версией в ETag
 func (h *OrderHandler) sendOrderResponse(c *gin.Context, status int, order *models.Order) {
+	c.Header("ETag", versionETag(order.Version))
 	c.JSON(status, models.OrderResponse{
 		ID:        order.ID,
 		UserID:    order.UserID,
@@ -138,9 +325,26 @@ func (h *OrderHandler) sendOrderResponse(c *gin.Context, status int, order *mode
 		Quantity:  order.Quantity,
 		Price:     order.Price,
 		CreatedAt: order.CreatedAt,
+		Version:   order.Version,
 	})
 }
 
+// sendOrderError отправляет ответ с ошибкой чтения или изменения заказа
+func (h *OrderHandler) sendOrderError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrUserNotFound), errors.Is(err, models.ErrOrderNotFound):
+		h.sendErrorResponse(c, http.StatusNotFound, err)
+	case errors.Is(err, models.ErrVersionConflict):
+		h.sendErrorResponse(c, http.StatusConflict, err)
+	case errors.Is(err, models.ErrPreconditionFailed):
+		h.sendErrorResponse(c, http.StatusPreconditionFailed, err)
+	case errors.Is(err, models.ErrDatabaseError):
+		h.sendErrorResponse(c, http.StatusInternalServerError, models.ErrInternalServerError)
+	default:
+		h.sendErrorResponse(c, http.StatusBadRequest, err)
+	}
+}
+
 // sendErrorResponse отправляет ответ с ошибкой
 func (h *OrderHandler) sendErrorResponse(c *gin.Context, status int, err error) {
 	c.JSON(status, models.ErrorLoginResponse{
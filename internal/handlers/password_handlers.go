@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"khrllwTest/internal/models"
+	"khrllwTest/internal/services"
+)
+
+// ------------------------------------------------------------
+// Структуры
+// ------------------------------------------------------------
+
+// PasswordHandler обрабатывает HTTP-запросы восстановления пароля
+type PasswordHandler struct {
+	resetService *service.PasswordResetService
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewPasswordHandler создает новый экземпляр PasswordHandler
+func NewPasswordHandler(resetService *service.PasswordResetService) *PasswordHandler {
+	return &PasswordHandler{
+		resetService: resetService,
+	}
+}
+
+// ------------------------------------------------------------
+// Методы обработки запросов
+// ------------------------------------------------------------
+
+// ForgotPassword godoc
+// @Tags Authorization
+// @Summary Запрос сброса пароля
+// @Description Отправляет на email ссылку с одноразовым токеном сброса пароля. Ответ не зависит от наличия аккаунта
+// @Accept json
+// @Produce json
+// @Param request body models.ForgotPasswordRequest true "Email пользователя"
+// @Success 202 {string} string "Accepted"
+// @Failure 400 {object} models.ErrorLoginResponse "Неверный формат запроса"
+// @Failure 500 {object} models.ErrorLoginResponse "Внутрення ошибка сервера"
+// @Router /auth/password/forgot [post]
+func (h *PasswordHandler) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendErrorResponse(c, http.StatusBadRequest, models.ErrInvalidRequestFormat)
+		return
+	}
+
+	if err := h.resetService.ForgotPassword(req.Email); err != nil {
+		h.sendErrorResponse(c, http.StatusInternalServerError, models.ErrInternalServerError)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// ResetPassword godoc
+// @Tags Authorization
+// @Summary Установка нового пароля
+// @Description Устанавливает новый пароль по токену из письма и завершает все сессии пользователя
+// @Accept json
+// @Produce json
+// @Param request body models.ResetPasswordRequest true "Токен и новый пароль"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} models.PasswordPolicyErrorResponse "Неверный формат запроса/недействительный токен/пароль не соответствует политике"
+// @Failure 500 {object} models.ErrorLoginResponse "Внутрення ошибка сервера"
+// @Router /auth/password/reset [post]
+func (h *PasswordHandler) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendErrorResponse(c, http.StatusBadRequest, models.ErrInvalidRequestFormat)
+		return
+	}
+
+	if err := h.resetService.ResetPassword(req.Token, req.Password); err != nil {
+		if sendPasswordPolicyError(c, err) {
+			return
+		}
+		if errors.Is(err, models.ErrInvalidResetToken) {
+			h.sendErrorResponse(c, http.StatusBadRequest, err)
+			return
+		}
+		h.sendErrorResponse(c, http.StatusInternalServerError, models.ErrInternalServerError)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ------------------------------------------------------------
+// Вспомогательные методы
+// ------------------------------------------------------------
+
+// sendErrorResponse отправляет ответ с ошибкой
+func (h *PasswordHandler) sendErrorResponse(c *gin.Context, status int, err error) {
+	c.JSON(status, models.ErrorLoginResponse{
+		Error: err.Error(),
+	})
+}
+
+// sendPasswordPolicyError отправляет ответ 400 со списком нарушенных правил политики паролей.
+// Возвращает false, если ошибка не относится к политике паролей.
+func sendPasswordPolicyError(c *gin.Context, err error) bool {
+	var policyErr *models.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+
+	c.JSON(http.StatusBadRequest, models.PasswordPolicyErrorResponse{
+		Error:      models.ErrPasswordPolicy.Error(),
+		Violations: policyErr.Violations,
+	})
+	return true
+}
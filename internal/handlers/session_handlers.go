@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"khrllwTest/internal/middleware"
+	"khrllwTest/internal/models"
+	"khrllwTest/internal/services"
+)
+
+// ------------------------------------------------------------
+// Структуры
+// ------------------------------------------------------------
+
+// SessionHandler обрабатывает HTTP-запросы управления сессиями пользователя
+type SessionHandler struct {
+	sessionService *service.SessionService
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewSessionHandler создает новый экземпляр SessionHandler
+func NewSessionHandler(sessionService *service.SessionService) *SessionHandler {
+	return &SessionHandler{
+		sessionService: sessionService,
+	}
+}
+
+// ------------------------------------------------------------
+// Методы обработки запросов
+// ------------------------------------------------------------
+
+// GetSessions обрабатывает запрос на получение активных сессий пользователя
+// @Tags Sessions
+// @Summary Получить активные сессии
+// @Description Возвращает устройства, на которых выполнен вход: время входа, IP, User-Agent и время последней активности. Сессия текущего запроса отмечена полем current
+// @Produce json
+// @Security BearerAuth
+// @Param user_id path int true "User ID"
+// @Success 200 {array} models.SessionResponse
+// @Failure 400 {object} models.ErrorLoginResponse "Неверный формат запроса/некорректные данные"
+// @Failure 401 {object} models.ErrorLoginResponse "Неверный токен авторизации"
+// @Failure 500 {object} models.ErrorLoginResponse "Внутренняя ошибка сервера"
+// @Router /users/{user_id}/sessions [get]
+func (h *SessionHandler) GetSessions(c *gin.Context) {
+	userID, err := h.parseUserID(c)
+	if err != nil {
+		h.sendErrorResponse(c, http.StatusBadRequest, models.ErrInvalidUserID)
+		return
+	}
+
+	sessions, err := h.sessionService.List(userID, c.GetString(middleware.ContextSessionIDKey))
+	if err != nil {
+		h.sendErrorResponse(c, http.StatusInternalServerError, models.ErrInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSession обрабатывает запрос на завершение сессии
+// @Tags Sessions
+// @Summary Завершить сессию
+// @Description Завершает сессию на устройстве: ее access токены перестают приниматься, refresh токены отзываются
+// @Produce json
+// @Security BearerAuth
+// @Param user_id path int true "User ID"
+// @Param session_id path string true "Session ID"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} models.ErrorLoginResponse "Неверный формат запроса/некорректные данные"
+// @Failure 401 {object} models.ErrorLoginResponse "Неверный токен авторизации"
+// @Failure 404 {object} models.ErrorLoginResponse "Сессия не найдена"
+// @Failure 500 {object} models.ErrorLoginResponse "Внутренняя ошибка сервера"
+// @Router /users/{user_id}/sessions/{session_id} [delete]
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	userID, err := h.parseUserID(c)
+	if err != nil {
+		h.sendErrorResponse(c, http.StatusBadRequest, models.ErrInvalidUserID)
+		return
+	}
+
+	if err := h.sessionService.Revoke(userID, c.Param("session_id")); err != nil {
+		if errors.Is(err, models.ErrSessionNotFound) {
+			h.sendErrorResponse(c, http.StatusNotFound, err)
+			return
+		}
+		h.sendErrorResponse(c, http.StatusInternalServerError, models.ErrInternalServerError)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ------------------------------------------------------------
+// Вспомогательные методы
+// ------------------------------------------------------------
+
+// parseUserID парсит ID пользователя из URL
+func (h *SessionHandler) parseUserID(c *gin.Context) (uint, error) {
+	id, err := strconv.Atoi(c.Param("user_id"))
+	return uint(id), err
+}
+
+// sendErrorResponse отправляет ответ с ошибкой
+func (h *SessionHandler) sendErrorResponse(c *gin.Context, status int, err error) {
+	c.JSON(status, models.ErrorLoginResponse{
+		Error: err.Error(),
+	})
+}
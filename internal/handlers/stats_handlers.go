@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"khrllwTest/internal/repository"
+)
+
+// ------------------------------------------------------------
+// Структуры
+// ------------------------------------------------------------
+
+// StatsHandler обрабатывает HTTP-запросы служебной статистики
+type StatsHandler struct {
+	userCache *repository.UserCache
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewStatsHandler создает новый экземпляр StatsHandler
+func NewStatsHandler(userCache *repository.UserCache) *StatsHandler {
+	return &StatsHandler{
+		userCache: userCache,
+	}
+}
+
+// ------------------------------------------------------------
+// Методы обработки запросов
+// ------------------------------------------------------------
+
+// GetUserCacheStats обрабатывает запрос на получение счетчиков кеша пользователей
+// @Tags Stats
+// @Summary Статистика кеша пользователей
+// @Description Возвращает попадания и промахи кеша поиска пользователей по ID, вытеснения, сбросы и размер кеша.
+// @Description Счетчики накапливаются с момента запуска экземпляра сервиса
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} repository.UserCacheStats
+// @Failure 401 {object} models.ErrorLoginResponse "Неверный токен авторизации"
+// @Failure 403 {object} models.ErrorLoginResponse "Недостаточно прав"
+// @Router /stats/user-cache [get]
+func (h *StatsHandler) GetUserCacheStats(c *gin.Context) {
+	c.JSON(http.StatusOK, h.userCache.Stats())
+}
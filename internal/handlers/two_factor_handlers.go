@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"khrllwTest/internal/models"
+	"khrllwTest/internal/services"
+)
+
+// ------------------------------------------------------------
+// Структуры
+// ------------------------------------------------------------
+
+// TwoFactorHandler обрабатывает HTTP-запросы управления двухфакторной аутентификацией
+type TwoFactorHandler struct {
+	twoFactorService *service.TwoFactorService
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewTwoFactorHandler создает новый экземпляр TwoFactorHandler
+func NewTwoFactorHandler(twoFactorService *service.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactorService: twoFactorService,
+	}
+}
+
+// ------------------------------------------------------------
+// Методы обработки запросов
+// ------------------------------------------------------------
+
+// Enroll обрабатывает запрос на подключение 2FA
+// @Tags Two-Factor Authentication
+// @Summary Подключить 2FA
+// @Description Создает секрет TOTP и возвращает otpauth URI. 2FA включается после подтверждения кодом
+// @Produce json
+// @Security BearerAuth
+// @Param user_id path int true "User ID"
+// @Success 200 {object} models.TwoFactorEnrollResponse
+// @Failure 400 {object} models.ErrorLoginResponse "Неверный формат запроса/некорректные данные"
+// @Failure 401 {object} models.ErrorLoginResponse "Неверный токен авторизации"
+// @Failure 409 {object} models.ErrorLoginResponse "2FA уже подключена"
+// @Failure 500 {object} models.ErrorLoginResponse "Внутренняя ошибка сервера"
+// @Router /users/{user_id}/2fa/enroll [post]
+func (h *TwoFactorHandler) Enroll(c *gin.Context) {
+	userID, err := h.parseUserID(c)
+	if err != nil {
+		h.sendErrorResponse(c, http.StatusBadRequest, models.ErrInvalidUserID)
+		return
+	}
+
+	enrollment, err := h.twoFactorService.Enroll(userID)
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// Confirm обрабатывает запрос на подтверждение 2FA
+// @Tags Two-Factor Authentication
+// @Summary Подтвердить подключение 2FA
+// @Description Проверяет код из приложения-аутентификатора, включает 2FA и возвращает одноразовые коды восстановления
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param user_id path int true "User ID"
+// @Param request body models.TwoFactorCodeRequest true "Код из приложения"
+// @Success 200 {object} models.RecoveryCodesResponse
+// @Failure 400 {object} models.ErrorLoginResponse "Неверный формат запроса/неверный код"
+// @Failure 401 {object} models.ErrorLoginResponse "Неверный токен авторизации"
+// @Failure 409 {object} models.ErrorLoginResponse "2FA уже подключена или не начато подключение"
+// @Failure 500 {object} models.ErrorLoginResponse "Внутренняя ошибка сервера"
+// @Router /users/{user_id}/2fa/confirm [post]
+func (h *TwoFactorHandler) Confirm(c *gin.Context) {
+	userID, req, ok := h.bindCodeRequest(c)
+	if !ok {
+		return
+	}
+
+	codes, err := h.twoFactorService.Confirm(userID, req.Code)
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// RegenerateRecoveryCodes обрабатывает запрос на выпуск новых кодов восстановления
+// @Tags Two-Factor Authentication
+// @Summary Перевыпустить коды восстановления
+// @Description Заменяет все коды восстановления новыми. Требует код из приложения-аутентификатора
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param user_id path int true "User ID"
+// @Param request body models.TwoFactorCodeRequest true "Код из приложения"
+// @Success 200 {object} models.RecoveryCodesResponse
+// @Failure 400 {object} models.ErrorLoginResponse "Неверный формат запроса/неверный код"
+// @Failure 401 {object} models.ErrorLoginResponse "Неверный токен авторизации"
+// @Failure 409 {object} models.ErrorLoginResponse "2FA не подключена"
+// @Failure 500 {object} models.ErrorLoginResponse "Внутренняя ошибка сервера"
+// @Router /users/{user_id}/2fa/recovery-codes [post]
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, req, ok := h.bindCodeRequest(c)
+	if !ok {
+		return
+	}
+
+	codes, err := h.twoFactorService.RegenerateRecoveryCodes(userID, req.Code)
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// Disable обрабатывает запрос на отключение 2FA
+// @Tags Two-Factor Authentication
+// @Summary Отключить 2FA
+// @Description Отключает 2FA после проверки кода из приложения или кода восстановления
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param user_id path int true "User ID"
+// @Param request body models.TwoFactorCodeRequest true "Код из приложения или код восстановления"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} models.ErrorLoginResponse "Неверный формат запроса/неверный код"
+// @Failure 401 {object} models.ErrorLoginResponse "Неверный токен авторизации"
+// @Failure 409 {object} models.ErrorLoginResponse "2FA не подключена"
+// @Failure 500 {object} models.ErrorLoginResponse "Внутренняя ошибка сервера"
+// @Router /users/{user_id}/2fa/disable [post]
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+	userID, req, ok := h.bindCodeRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.twoFactorService.Disable(userID, req.Code); err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ------------------------------------------------------------
+// Вспомогательные методы
+// ------------------------------------------------------------
+
+// bindCodeRequest разбирает ID пользователя и тело запроса с кодом
+func (h *TwoFactorHandler) bindCodeRequest(c *gin.Context) (uint, *models.TwoFactorCodeRequest, bool) {
+	userID, err := h.parseUserID(c)
+	if err != nil {
+		h.sendErrorResponse(c, http.StatusBadRequest, models.ErrInvalidUserID)
+		return 0, nil, false
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendErrorResponse(c, http.StatusBadRequest, models.ErrInvalidRequestFormat)
+		return 0, nil, false
+	}
+
+	return userID, &req, true
+}
+
+// handleServiceError преобразует ошибку сервиса в HTTP ответ
+func (h *TwoFactorHandler) handleServiceError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrUserNotFound):
+		h.sendErrorResponse(c, http.StatusNotFound, err)
+	case errors.Is(err, models.ErrTwoFactorAlreadyEnabled), errors.Is(err, models.ErrTwoFactorNotEnrolled):
+		h.sendErrorResponse(c, http.StatusConflict, err)
+	case errors.Is(err, models.ErrInvalidTwoFactorCode):
+		h.sendErrorResponse(c, http.StatusBadRequest, err)
+	default:
+		h.sendErrorResponse(c, http.StatusInternalServerError, models.ErrInternalServerError)
+	}
+}
+
+// parseUserID парсит ID пользователя из URL
+func (h *TwoFactorHandler) parseUserID(c *gin.Context) (uint, error) {
+	id, err := strconv.Atoi(c.Param("user_id"))
+	return uint(id), err
+}
+
+// sendErrorResponse отправляет ответ с ошибкой
+func (h *TwoFactorHandler) sendErrorResponse(c *gin.Context, status int, err error) {
+	c.JSON(status, models.ErrorLoginResponse{
+		Error: err.Error(),
+	})
+}
@@ -1,15 +1,28 @@
 package handlers
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"math"
 	"net/http"
+	"sort"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"khrllwTest/internal/models"
 	"khrllwTest/internal/services"
 )
 
+// mergePatchContentType тип содержимого JSON Merge Patch (RFC 7386)
+const mergePatchContentType = "application/merge-patch+json"
+
+// patchableUserFields поля пользователя, которые можно изменить через PATCH
+var patchableUserFields = map[string]bool{"name": true, "email": true, "age": true}
+
 // ------------------------------------------------------------
 // Структуры
 // ------------------------------------------------------------
@@ -42,7 +55,7 @@ func NewUserHandler(userService *service.UserService) *UserHandler {
 // @Produce json
 // @Param user body models.CreateUserRequest true "Данные пользователя"
 // @Success 201 {object} models.UserResponse
-// @Failure 400 {object} models.ErrorLoginResponse "Неверный формат запроса/некорректные данные"
+// @Failure 400 {object} models.PasswordPolicyErrorResponse "Неверный формат запроса/некорректные данные/пароль не соответствует политике"
 // @Failure 500 {object} models.ErrorLoginResponse "Внутренняя ошибка сервера"
 // @Router /users [post]
 func (h *UserHandler) CreateUser(c *gin.Context) {
@@ -54,6 +67,9 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 
 	user, err := h.userService.CreateUser(&req)
 	if err != nil {
+		if sendPasswordPolicyError(c, err) {
+			return
+		}
 		if errors.Is(err, models.ErrPasswordHashFailed) || errors.Is(err, models.ErrDatabaseError) {
 			h.sendErrorResponse(c, http.StatusInternalServerError, err)
 			return
@@ -68,35 +84,58 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 // GetUsers обрабатывает запрос на получение списка пользователей
 // @Tags Users
 // @Summary Получить список пользователей
-// @Description Возвращает список пользователей с пагинацией и фильтрацией по возрасту
+// @Description Возвращает список пользователей с пагинацией, поиском по имени и email, фильтрацией по домену email и возрасту и сортировкой. Примененные фильтры и сортировка возвращаются в ответе.
+// @Description Ответ содержит подписанные курсоры next_cursor/prev_cursor: запрос с cursor (и теми же фильтрами и сортировкой) выбирает соседнюю страницу по границе (keyset) без OFFSET; total при этом подсчитывается только с include_total=true
 // @Accept json
 // @Produce json
-// @Param page query int false "Page" default(1)
+// @Param page query int false "Page (offset-пагинация, не используется вместе с cursor)" default(1)
 // @Param limit query int false "Limit" default(10)
+// @Param cursor query string false "Курсор next_cursor или prev_cursor из предыдущего ответа"
+// @Param include_total query bool false "Подсчитать total при пагинации курсором"
+// @Param search query string false "Подстрока имени или email без учета регистра"
+// @Param email_domain query string false "Домен email, например example.com"
 // @Param min_age query int false "Min Age"
 // @Param max_age query int false "Max Age"
+// @Param sort query string false "Поля сортировки через запятую (id, name, email, age, role), '-' - по убыванию" default(id)
 // @Success 200 {object} models.UsersListResponse
 // @Failure 400 {object} models.ErrorLoginResponse "Неверный формат запроса/некорректные данные"
 // @Failure 500 {object} models.ErrorLoginResponse "Внутренняя ошибка сервера"
 // @Router /users [get]
 func (h *UserHandler) GetUsers(c *gin.Context) {
-	page, limit, minAge, maxAge, err := h.parseQueryParams(c)
+	query, err := h.parseQueryParams(c)
 	if err != nil {
 		h.sendErrorResponse(c, http.StatusBadRequest, err)
 		return
 	}
 
-	users, total, err := h.userService.GetUsers(page, limit, minAge, maxAge)
+	page, err := h.userService.GetUsers(query)
 	if err != nil {
+		if errors.Is(err, models.ErrInvalidFilterParams) || errors.Is(err, models.ErrInvalidSortParams) ||
+			errors.Is(err, models.ErrInvalidCursor) {
+			h.sendErrorResponse(c, http.StatusBadRequest, err)
+			return
+		}
 		h.sendErrorResponse(c, http.StatusInternalServerError, err)
 		return
 	}
 
+	filter := &query.Filter
 	response := models.UsersListResponse{
-		Page:  page,
-		Limit: limit,
-		Total: total,
-		Users: h.mapToResponse(users),
+		Limit:      query.Limit,
+		Total:      page.Total,
+		NextCursor: page.NextCursor,
+		PrevCursor: page.PrevCursor,
+		Filters: models.UsersListFilters{
+			Search:      filter.Search,
+			EmailDomain: filter.EmailDomain,
+			MinAge:      filter.MinAge,
+			MaxAge:      filter.MaxAge,
+		},
+		Sort:  service.FormatUserSort(filter.Sort),
+		Users: h.mapToResponse(page.Users),
+	}
+	if query.Cursor == "" {
+		response.Page = query.Page
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -105,11 +144,12 @@ func (h *UserHandler) GetUsers(c *gin.Context) {
 // GetUserByID обрабатывает запрос на получение пользователя по ID
 // @Tags Users
 // @Summary Получить пользователя по ID
-// @Description Возвращает данные пользователя по его ID
+// @Description Возвращает данные пользователя по его ID. Версия пользователя передается в заголовке ETag
 // @Accept json
 // @Produce json
 // @Param user_id path int true "User ID"
 // @Success 200 {object} models.UserResponse
+// @Header 200 {string} ETag "Версия пользователя для If-Match"
 // @Failure 400 {object} models.ErrorLoginResponse "Неверный формат запроса/некорректные данные"
 // @Failure 404 {object} models.ErrorLoginResponse "Пользователь не найден"
 // @Failure 500 {object} models.ErrorLoginResponse "Внутренняя ошибка сервера"
@@ -137,14 +177,18 @@ func (h *UserHandler) GetUserByID(c *gin.Context) {
 // UpdateUser обрабатывает запрос на обновление пользователя
 // @Tags Users
 // @Summary Обновить данные пользователя
-// @Description Обновляет информацию о пользователе
+// @Description Обновляет информацию о пользователе. С заголовком If-Match обновление выполняется, только если версия пользователя совпадает с ETag
 // @Accept json
 // @Produce json
 // @Param user_id path int true "User ID"
+// @Param If-Match header string false "ETag версии пользователя"
 // @Param user body models.UpdateUserRequest true "User Data"
 // @Success 200 {object} models.UserResponse
+// @Header 200 {string} ETag "Новая версия пользователя"
 // @Failure 400 {object} models.ErrorLoginResponse "Неверный формат запроса/некорректные данные"
 // @Failure 404 {object} models.ErrorLoginResponse "Пользователь не найден"
+// @Failure 409 {object} models.ErrorLoginResponse "Email уже занят/пользователь изменен параллельным запросом"
+// @Failure 412 {object} models.ErrorLoginResponse "Версия пользователя не совпадает с If-Match"
 // @Failure 500 {object} models.ErrorLoginResponse "Внутренняя ошибка сервера"
 // @Router /users/{user_id} [put]
 func (h *UserHandler) UpdateUser(c *gin.Context) {
@@ -154,37 +198,197 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
+	version, err := parseIfMatch(c)
+	if err != nil {
+		h.sendErrorResponse(c, http.StatusPreconditionFailed, err)
+		return
+	}
+
 	var req models.UpdateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.sendErrorResponse(c, http.StatusBadRequest, models.ErrInvalidRequestFormat)
 		return
 	}
 
-	user, err := h.userService.UpdateUser(userID, &req)
+	user, err := h.userService.UpdateUser(userID, &req, version)
+	if err != nil {
+		h.sendWriteError(c, err)
+		return
+	}
+
+	h.sendUserResponse(c, http.StatusOK, user)
+}
+
+// PatchUser обрабатывает запрос на частичное обновление пользователя
+// @Tags Users
+// @Summary Частично обновить данные пользователя
+// @Description Обновляет только переданные поля (JSON Merge Patch, RFC 7386): name, email, age. Остальные поля (id, role и т.д.) и значение null отклоняются. Изменение email сбрасывает его подтверждение.
+// @Description С заголовком If-Match патч применяется, только если версия пользователя совпадает с ETag
+// @Accept application/merge-patch+json
+// @Produce json
+// @Security BearerAuth
+// @Param user_id path int true "User ID"
+// @Param If-Match header string false "ETag версии пользователя"
+// @Param user body models.PatchUserRequest true "Изменяемые поля"
+// @Success 200 {object} models.UserResponse
+// @Header 200 {string} ETag "Новая версия пользователя"
+// @Failure 400 {object} models.ErrorLoginResponse "Неверный формат запроса/некорректные данные/неизменяемое поле"
+// @Failure 404 {object} models.ErrorLoginResponse "Пользователь не найден"
+// @Failure 409 {object} models.ErrorLoginResponse "Email уже занят"
+// @Failure 412 {object} models.ErrorLoginResponse "Версия пользователя не совпадает с If-Match"
+// @Failure 415 {object} models.ErrorLoginResponse "Тип содержимого не application/merge-patch+json"
+// @Failure 500 {object} models.ErrorLoginResponse "Внутренняя ошибка сервера"
+// @Router /users/{user_id} [patch]
+func (h *UserHandler) PatchUser(c *gin.Context) {
+	userID, err := h.parseUserID(c)
+	if err != nil {
+		h.sendErrorResponse(c, http.StatusBadRequest, models.ErrInvalidUserID)
+		return
+	}
+
+	if c.ContentType() != mergePatchContentType {
+		c.Header("Accept-Patch", mergePatchContentType)
+		h.sendErrorResponse(c, http.StatusUnsupportedMediaType, models.ErrUnsupportedMediaType)
+		return
+	}
+
+	version, err := parseIfMatch(c)
+	if err != nil {
+		h.sendErrorResponse(c, http.StatusPreconditionFailed, err)
+		return
+	}
+
+	patch, err := h.bindUserPatch(c)
 	if err != nil {
-		if errors.Is(err, models.ErrUserNotFound) {
-			h.sendErrorResponse(c, http.StatusNotFound, err)
-		}
-		if errors.Is(err, models.ErrDatabaseError) {
-			h.sendErrorResponse(c, http.StatusInternalServerError, err)
-		}
 		h.sendErrorResponse(c, http.StatusBadRequest, err)
 		return
 	}
 
+	user, err := h.userService.PatchUser(userID, patch, version)
+	if err != nil {
+		h.sendWriteError(c, err)
+		return
+	}
+
+	h.sendUserResponse(c, http.StatusOK, user)
+}
+
+// UpdateUserRole обрабатывает запрос на изменение роли пользователя
+// @Tags Users
+// @Summary Изменить роль пользователя
+// @Description Назначает пользователю роль user или admin. Доступно только администраторам. Ранее выданные пользователю токены перестают действовать
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param user_id path int true "User ID"
+// @Param If-Match header string false "ETag версии пользователя"
+// @Param role body models.UpdateUserRoleRequest true "Новая роль"
+// @Success 200 {object} models.UserResponse
+// @Header 200 {string} ETag "Новая версия пользователя"
+// @Failure 400 {object} models.ErrorLoginResponse "Неверный формат запроса/некорректные данные"
+// @Failure 403 {object} models.ErrorLoginResponse "Недостаточно прав"
+// @Failure 404 {object} models.ErrorLoginResponse "Пользователь не найден"
+// @Failure 409 {object} models.ErrorLoginResponse "Пользователь изменен параллельным запросом"
+// @Failure 412 {object} models.ErrorLoginResponse "Версия пользователя не совпадает с If-Match"
+// @Failure 500 {object} models.ErrorLoginResponse "Внутренняя ошибка сервера"
+// @Router /users/{user_id}/role [put]
+func (h *UserHandler) UpdateUserRole(c *gin.Context) {
+	userID, err := h.parseUserID(c)
+	if err != nil {
+		h.sendErrorResponse(c, http.StatusBadRequest, models.ErrInvalidUserID)
+		return
+	}
+
+	version, err := parseIfMatch(c)
+	if err != nil {
+		h.sendErrorResponse(c, http.StatusPreconditionFailed, err)
+		return
+	}
+
+	var req models.UpdateUserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendErrorResponse(c, http.StatusBadRequest, models.ErrInvalidRequestFormat)
+		return
+	}
+
+	user, err := h.userService.UpdateUserRole(userID, req.Role, version)
+	if err != nil {
+		h.sendWriteError(c, err)
+		return
+	}
+
 	h.sendUserResponse(c, http.StatusOK, user)
 }
 
+// ChangePassword обрабатывает запрос на смену пароля
+// @Tags Users
+// @Summary Сменить пароль
+// @Description Меняет пароль после проверки текущего. Все ранее выданные токены пользователя, включая текущий, перестают действовать
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param user_id path int true "User ID"
+// @Param password body models.ChangePasswordRequest true "Текущий и новый пароль"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} models.PasswordPolicyErrorResponse "Неверный формат запроса/неверный текущий пароль/пароль не соответствует политике"
+// @Failure 401 {object} models.ErrorLoginResponse "Неверный токен авторизации"
+// @Failure 404 {object} models.ErrorLoginResponse "Пользователь не найден"
+// @Failure 423 {object} models.ErrorLoginResponse "Аккаунт временно заблокирован (заголовок Retry-After)"
+// @Failure 429 {object} models.ErrorLoginResponse "Слишком много попыток (заголовок Retry-After)"
+// @Failure 500 {object} models.ErrorLoginResponse "Внутренняя ошибка сервера"
+// @Router /users/{user_id}/password [put]
+func (h *UserHandler) ChangePassword(c *gin.Context) {
+	userID, err := h.parseUserID(c)
+	if err != nil {
+		h.sendErrorResponse(c, http.StatusBadRequest, models.ErrInvalidUserID)
+		return
+	}
+
+	var req models.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendErrorResponse(c, http.StatusBadRequest, models.ErrInvalidRequestFormat)
+		return
+	}
+
+	if err := h.userService.ChangePassword(userID, &req, c.ClientIP()); err != nil {
+		if sendPasswordPolicyError(c, err) {
+			return
+		}
+		var retryErr *models.RetryAfterError
+		switch {
+		case errors.As(err, &retryErr):
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryErr.RetryAfter.Seconds()))))
+			if errors.Is(err, models.ErrAccountLocked) {
+				h.sendErrorResponse(c, http.StatusLocked, err)
+			} else {
+				h.sendErrorResponse(c, http.StatusTooManyRequests, err)
+			}
+		case errors.Is(err, models.ErrUserNotFound):
+			h.sendErrorResponse(c, http.StatusNotFound, err)
+		case errors.Is(err, models.ErrInvalidCurrentPassword), errors.Is(err, models.ErrPasswordUnchanged):
+			h.sendErrorResponse(c, http.StatusBadRequest, err)
+		default:
+			h.sendErrorResponse(c, http.StatusInternalServerError, models.ErrInternalServerError)
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
 // DeleteUser обрабатывает запрос на удаление пользователя
 // @Tags Users
 // @Summary Удалить пользователя
-// @Description Удаляет пользователя по его ID
+// @Description Мягко удаляет пользователя по его ID и завершает его сессии. Администратор может восстановить пользователя в течение USER_DELETE_GRACE_PERIOD, после чего пользователь и его заказы удаляются окончательно
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param user_id path int true "User ID"
+// @Param If-Match header string false "ETag версии пользователя"
 // @Success 204 {string} string "No Content"
 // @Failure 400 {object} models.ErrorLoginResponse "Неверный формат запроса/некорректные данные"
 // @Failure 404 {object} models.ErrorLoginResponse "Пользователь не найден"
+// @Failure 412 {object} models.ErrorLoginResponse "Версия пользователя не совпадает с If-Match"
 // @Failure 500 {object} models.ErrorLoginResponse "Внутренняя ошибка сервера"
 // @Router /users/{user_id} [delete]
 func (h *UserHandler) DeleteUser(c *gin.Context) {
@@ -193,49 +397,157 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 		h.sendErrorResponse(c, http.StatusBadRequest, models.ErrInvalidUserID)
 		return
 	}
-	if err := h.userService.DeleteUser(userID); err != nil {
-		if errors.Is(err, models.ErrUserNotFound) {
+	version, err := parseIfMatch(c)
+	if err != nil {
+		h.sendErrorResponse(c, http.StatusPreconditionFailed, err)
+		return
+	}
+	if err := h.userService.DeleteUser(userID, version); err != nil {
+		switch {
+		case errors.Is(err, models.ErrUserNotFound):
 			h.sendErrorResponse(c, http.StatusNotFound, err)
+		case errors.Is(err, models.ErrPreconditionFailed):
+			h.sendErrorResponse(c, http.StatusPreconditionFailed, err)
+		default:
+			h.sendErrorResponse(c, http.StatusInternalServerError, err)
 		}
-		h.sendErrorResponse(c, http.StatusInternalServerError, err)
 		return
 	}
 
 	c.Status(http.StatusNoContent)
 }
 
+// RestoreUser обрабатывает запрос на восстановление удаленного пользователя
+// @Tags Users
+// @Summary Восстановить удаленного пользователя
+// @Description Восстанавливает мягко удаленного пользователя, пока не истек срок восстановления. Доступно только администраторам. Токены, выданные до удаления, не начинают действовать снова
+// @Produce json
+// @Security BearerAuth
+// @Param user_id path int true "User ID"
+// @Success 200 {object} models.UserResponse
+// @Failure 400 {object} models.ErrorLoginResponse "Некорректный ID пользователя"
+// @Failure 403 {object} models.ErrorLoginResponse "Недостаточно прав"
+// @Failure 404 {object} models.ErrorLoginResponse "Удаленный пользователь не найден"
+// @Failure 409 {object} models.ErrorLoginResponse "Email уже занят другим пользователем"
+// @Failure 410 {object} models.ErrorLoginResponse "Срок восстановления истек"
+// @Failure 500 {object} models.ErrorLoginResponse "Внутренняя ошибка сервера"
+// @Router /users/{user_id}/restore [post]
+func (h *UserHandler) RestoreUser(c *gin.Context) {
+	userID, err := h.parseUserID(c)
+	if err != nil {
+		h.sendErrorResponse(c, http.StatusBadRequest, models.ErrInvalidUserID)
+		return
+	}
+
+	user, err := h.userService.RestoreUser(userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrUserNotFound):
+			h.sendErrorResponse(c, http.StatusNotFound, err)
+		case errors.Is(err, models.ErrEmailAlreadyExists):
+			h.sendErrorResponse(c, http.StatusConflict, err)
+		case errors.Is(err, models.ErrUserRestoreExpired):
+			h.sendErrorResponse(c, http.StatusGone, err)
+		default:
+			h.sendErrorResponse(c, http.StatusInternalServerError, models.ErrInternalServerError)
+		}
+		return
+	}
+
+	h.sendUserResponse(c, http.StatusOK, user)
+}
+
 // ------------------------------------------------------------
 // Вспомогательные методы
 // ------------------------------------------------------------
 
 // parseQueryParams парсит параметры запроса
-// @Description Парсит параметры запроса для пагинации и фильтрации пользователей
-func (h *UserHandler) parseQueryParams(c *gin.Context) (page, limit, minAge, maxAge int, err error) {
-	page, err = strconv.Atoi(c.DefaultQuery("page", "1"))
-	if err != nil || page < 1 {
-		return 0, 0, 0, 0, models.ErrInvalidPagination
+// @Description Парсит параметры запроса для пагинации, поиска, фильтрации и сортировки пользователей
+func (h *UserHandler) parseQueryParams(c *gin.Context) (*models.UserListQuery, error) {
+	query := &models.UserListQuery{
+		Cursor: c.Query("cursor"),
+		Filter: models.UserFilter{
+			Search:      c.Query("search"),
+			EmailDomain: c.Query("email_domain"),
+		},
 	}
+	var err error
 
-	limit, err = strconv.Atoi(c.DefaultQuery("limit", "10"))
-	if err != nil || limit < 1 {
-		return 0, 0, 0, 0, models.ErrInvalidPagination
+	query.Page, err = strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || query.Page < 1 {
+		return nil, models.ErrInvalidPagination
+	}
+
+	query.Limit, err = strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || query.Limit < 1 {
+		return nil, models.ErrInvalidPagination
+	}
+
+	if includeTotal := c.Query("include_total"); includeTotal != "" {
+		query.IncludeTotal, err = strconv.ParseBool(includeTotal)
+		if err != nil {
+			return nil, models.ErrInvalidPagination
+		}
 	}
 
 	if minAgeStr := c.Query("min_age"); minAgeStr != "" {
-		minAge, err = strconv.Atoi(minAgeStr)
+		query.Filter.MinAge, err = strconv.Atoi(minAgeStr)
 		if err != nil {
-			return 0, 0, 0, 0, models.ErrInvalidFilterParams
+			return nil, models.ErrInvalidFilterParams
 		}
 	}
 
 	if maxAgeStr := c.Query("max_age"); maxAgeStr != "" {
-		maxAge, err = strconv.Atoi(maxAgeStr)
+		query.Filter.MaxAge, err = strconv.Atoi(maxAgeStr)
 		if err != nil {
-			return 0, 0, 0, 0, models.ErrInvalidFilterParams
+			return nil, models.ErrInvalidFilterParams
+		}
+	}
+
+	query.Filter.Sort, err = service.ParseUserSort(c.Query("sort"))
+	if err != nil {
+		return nil, err
+	}
+
+	return query, nil
+}
+
+// bindUserPatch разбирает тело JSON Merge Patch пользователя.
+// Тело должно быть JSON объектом только с изменяемыми полями; null (удаление поля) не допускается,
+// так как все изменяемые поля обязательны.
+func (h *UserHandler) bindUserPatch(c *gin.Context) (*models.PatchUserRequest, error) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, models.ErrInvalidRequestFormat
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body, &members); err != nil || members == nil {
+		return nil, models.ErrInvalidRequestFormat
+	}
+
+	keys := make([]string, 0, len(members))
+	for key := range members {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !patchableUserFields[key] {
+			return nil, fmt.Errorf("%w: %s", models.ErrImmutableUserField, key)
+		}
+		if bytes.Equal(bytes.TrimSpace(members[key]), []byte("null")) {
+			return nil, models.ErrInvalidRequestFormat
 		}
 	}
 
-	return page, limit, minAge, maxAge, nil
+	var patch models.PatchUserRequest
+	if err := json.Unmarshal(body, &patch); err != nil {
+		return nil, models.ErrInvalidRequestFormat
+	}
+	if err := binding.Validator.ValidateStruct(&patch); err != nil {
+		return nil, models.ErrInvalidRequestFormat
+	}
+	return &patch, nil
 }
 
 // parseUserID парсит ID пользователя
@@ -249,26 +561,32 @@ func (h *UserHandler) parseUserID(c *gin.Context) (uint, error) {
 func (h *UserHandler) mapToResponse(users []models.User) []models.UserResponse {
 	response := make([]models.UserResponse, len(users))
 
-	for i, user := range users {
-		response[i] = models.UserResponse{
-			ID:    user.ID,
-			Name:  user.Name,
-			Email: user.Email,
-			Age:   user.Age,
-		}
+	for i := range users {
+		response[i] = newUserResponse(&users[i])
 	}
 
 	return response
 }
 
-// sendUserResponse отправляет успешный ответ с данными пользователя
+// sendUserResponse отправляет успешный ответ с данными пользователя и его версией в ETag
 func (h *UserHandler) sendUserResponse(c *gin.Context, status int, user *models.User) {
-	c.JSON(status, models.UserResponse{
-		ID:    user.ID,
-		Name:  user.Name,
-		Email: user.Email,
-		Age:   user.Age,
-	})
+	sendUserResponse(c, status, user)
+}
+
+// sendWriteError отправляет ответ с ошибкой изменения пользователя
+func (h *UserHandler) sendWriteError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrUserNotFound):
+		h.sendErrorResponse(c, http.StatusNotFound, err)
+	case errors.Is(err, models.ErrEmailAlreadyExists), errors.Is(err, models.ErrVersionConflict):
+		h.sendErrorResponse(c, http.StatusConflict, err)
+	case errors.Is(err, models.ErrPreconditionFailed):
+		h.sendErrorResponse(c, http.StatusPreconditionFailed, err)
+	case errors.Is(err, models.ErrDatabaseError):
+		h.sendErrorResponse(c, http.StatusInternalServerError, models.ErrInternalServerError)
+	default:
+		h.sendErrorResponse(c, http.StatusBadRequest, err)
+	}
 }
 
 // sendErrorResponse отправляет ответ с ошибкой
@@ -277,3 +595,29 @@ func (h *UserHandler) sendErrorResponse(c *gin.Context, status int, err error) {
 		Error: err.Error(),
 	})
 }
+
+// ------------------------------------------------------------
+// Ответ с данными пользователя
+// ------------------------------------------------------------
+
+// newUserResponse преобразует пользователя в формат ответа
+func newUserResponse(user *models.User) models.UserResponse {
+	response := models.UserResponse{
+		ID:            user.ID,
+		Name:          user.Name,
+		Email:         user.Email,
+		Age:           user.Age,
+		Role:          user.Role,
+		EmailVerified: user.EmailVerifiedAt != nil,
+	}
+	if user.AvatarKey != "" {
+		response.AvatarURL = fmt.Sprintf("/users/%d/avatar", user.ID)
+	}
+	return response
+}
+
+// sendUserResponse отправляет данные пользователя с его версией в ETag
+func sendUserResponse(c *gin.Context, status int, user *models.User) {
+	c.Header("ETag", versionETag(user.Version))
+	c.JSON(status, newUserResponse(user))
+}
@@ -0,0 +1,70 @@
+package mail
+
+import (
+	"errors"
+	"log"
+	"os"
+	"strconv"
+)
+
+// ------------------------------------------------------------
+// Структуры
+// ------------------------------------------------------------
+
+// Message письмо для отправки пользователю
+type Message struct {
+	// Адрес получателя
+	To string
+
+	// Тема письма
+	Subject string
+
+	// Текст письма (text/plain)
+	Body string
+}
+
+// ------------------------------------------------------------
+// Интерфейс
+// ------------------------------------------------------------
+
+// Sender определяет контракт для отправки писем
+type Sender interface {
+	// Send отправляет письмо
+	Send(msg Message) error
+}
+
+// ------------------------------------------------------------
+// Выбор реализации
+// ------------------------------------------------------------
+
+// NewSenderFromEnv создает SMTP отправитель, если задан SMTP_HOST,
+// иначе - отправитель, сохраняющий письма в памяти
+func NewSenderFromEnv() (Sender, error) {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		log.Println("SMTP_HOST не задан - письма сохраняются в памяти и не отправляются")
+		return NewMemorySender(), nil
+	}
+
+	port := 587 // значение по умолчанию
+	if value := os.Getenv("SMTP_PORT"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			return nil, errors.New("неверное значение SMTP_PORT. Пример: 587")
+		}
+		port = parsed
+	}
+
+	from := os.Getenv("SMTP_FROM")
+	if from == "" {
+		return nil, errors.New("SMTP_FROM переменная окружения не установлена")
+	}
+
+	return NewSMTPSender(&SMTPConfig{
+		Host:     host,
+		Port:     port,
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     from,
+	}), nil
+}
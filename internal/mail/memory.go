@@ -0,0 +1,56 @@
+package mail
+
+import "sync"
+
+// ------------------------------------------------------------
+// Реализация
+// ------------------------------------------------------------
+
+// MemorySender сохраняет письма в памяти вместо отправки (для тестов и локальной разработки)
+type MemorySender struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewMemorySender создает отправитель, сохраняющий письма в памяти
+func NewMemorySender() *MemorySender {
+	return &MemorySender{}
+}
+
+// ------------------------------------------------------------
+// Методы реализации
+// ------------------------------------------------------------
+
+// Send сохраняет письмо
+func (s *MemorySender) Send(msg Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messages = append(s.messages, msg)
+	return nil
+}
+
+// Messages возвращает копию всех сохраненных писем
+func (s *MemorySender) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Message(nil), s.messages...)
+}
+
+// Last возвращает последнее письмо для адресата
+func (s *MemorySender) Last(to string) (Message, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := len(s.messages) - 1; i >= 0; i-- {
+		if s.messages[i].To == to {
+			return s.messages[i], true
+		}
+	}
+	return Message{}, false
+}
@@ -0,0 +1,74 @@
+package mail
+
+import (
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+)
+
+// ------------------------------------------------------------
+// Конфигурация
+// ------------------------------------------------------------
+
+// SMTPConfig содержит параметры подключения к SMTP серверу
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// ------------------------------------------------------------
+// Реализация
+// ------------------------------------------------------------
+
+// smtpSender отправляет письма через SMTP (STARTTLS, если сервер его поддерживает)
+type smtpSender struct {
+	config *SMTPConfig
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewSMTPSender создает отправитель писем через SMTP
+func NewSMTPSender(config *SMTPConfig) Sender {
+	return &smtpSender{config: config}
+}
+
+// ------------------------------------------------------------
+// Методы реализации
+// ------------------------------------------------------------
+
+// Send отправляет письмо; аутентификация выполняется, только если задан логин
+func (s *smtpSender) Send(msg Message) error {
+	addr := net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
+
+	var auth smtp.Auth
+	if s.config.Username != "" {
+		auth = smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+	}
+
+	if err := smtp.SendMail(addr, auth, s.config.From, []string{msg.To}, s.buildMessage(msg)); err != nil {
+		return fmt.Errorf("ошибка отправки письма: %w", err)
+	}
+	return nil
+}
+
+// buildMessage формирует письмо в формате RFC 5322
+func (s *smtpSender) buildMessage(msg Message) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + s.config.From + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"khrllwTest/internal/models"
+)
+
+// ------------------------------------------------------------
+// Интерфейсы
+// ------------------------------------------------------------
+
+// AuditRecorder сохраняет записи журнала аудита
+type AuditRecorder interface {
+	// Record сохраняет запись журнала
+	Record(entry *models.AuditLog) error
+}
+
+// ------------------------------------------------------------
+// Middleware
+// ------------------------------------------------------------
+
+// ImpersonationAudit записывает в журнал аудита каждый запрос, выполненный с токеном имперсонации:
+// администратора, пользователя, маршрут и код ответа. Подключается к роутеру до Authorization.Middleware,
+// чтобы в журнал попадали и запросы, отклоненные проверками прав.
+func ImpersonationAudit(recorder AuditRecorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		actorID := c.GetUint(ContextActorIDKey)
+		if actorID == 0 {
+			return
+		}
+
+		entry := &models.AuditLog{
+			ActorID:   actorID,
+			UserID:    c.GetUint(ContextUserIDKey),
+			Action:    models.AuditActionImpersonatedRequest,
+			TokenID:   c.GetString(ContextTokenIDKey),
+			Method:    c.Request.Method,
+			Path:      c.Request.URL.Path,
+			Status:    c.Writer.Status(),
+			IPAddress: c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+		}
+
+		// Ответ уже отправлен, поэтому ошибка записи только журналируется
+		if err := recorder.Record(entry); err != nil {
+			log.Printf("Ошибка записи журнала аудита (actor %d, user %d, %s %s): %v",
+				actorID, entry.UserID, entry.Method, entry.Path, err)
+		}
+	}
+}
@@ -1,25 +1,103 @@
 package middleware
 
 import (
+	"crypto/x509"
 	"errors"
 	"khrllwTest/internal/repository"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"khrllwTest/internal/models"
 	"khrllwTest/internal/utils"
 )
 
+// ------------------------------------------------------------
+// Ключи контекста
+// ------------------------------------------------------------
+
+const (
+	// ContextUserIDKey ключ контекста с ID аутентифицированного пользователя
+	ContextUserIDKey = "user_id"
+
+	// ContextTokenIDKey ключ контекста с идентификатором (jti) access токена
+	ContextTokenIDKey = "token_id"
+
+	// ContextSessionIDKey ключ контекста с идентификатором сессии access токена
+	ContextSessionIDKey = "session_id"
+
+	// ContextTokenExpiresAtKey ключ контекста со временем истечения access токена
+	ContextTokenExpiresAtKey = "token_expires_at"
+
+	// ContextUserRoleKey ключ контекста с ролью аутентифицированного пользователя
+	ContextUserRoleKey = "user_role"
+
+	// ContextAuthMethodKey ключ контекста со способом аутентификации
+	ContextAuthMethodKey = "auth_method"
+
+	// ContextScopesKey ключ контекста с областями доступа API ключа
+	ContextScopesKey = "scopes"
+
+	// ContextRolesKey ключ контекста со всеми ролями субъекта клиентского сертификата
+	ContextRolesKey = "roles"
+
+	// ContextPrincipalKey ключ контекста с именем сервисной учетной записи клиентского сертификата
+	ContextPrincipalKey = "principal"
+
+	// ContextActorIDKey ключ контекста с ID администратора, действующего от имени пользователя (claim act)
+	ContextActorIDKey = "actor_id"
+
+	// contextScopesCheckedKey отметка о том, что маршрут объявил области доступа
+	contextScopesCheckedKey = "scopes_checked"
+)
+
+const (
+	// AuthMethodJWT аутентификация по access токену
+	AuthMethodJWT = "jwt"
+
+	// AuthMethodAPIKey аутентификация по API ключу
+	AuthMethodAPIKey = "api_key"
+
+	// AuthMethodCookie аутентификация по access токену из cookie
+	AuthMethodCookie = "cookie"
+
+	// AuthMethodClientCert аутентификация по проверенному клиентскому сертификату (mTLS)
+	AuthMethodClientCert = "client_cert"
+)
+
+// Self псевдороль для Allow: владелец ресурса, чей ID совпадает с параметром :user_id маршрута
+const Self = "self"
+
+// sessionTouchInterval минимальный интервал обновления времени последней активности сессии,
+// чтобы не выполнять запись в БД на каждый запрос
+const sessionTouchInterval = time.Minute
+
+// ------------------------------------------------------------
+// Интерфейсы
+// ------------------------------------------------------------
+
+// APIKeyAuthenticator проверяет API ключи
+type APIKeyAuthenticator interface {
+	// Authenticate возвращает запись активного ключа или ошибку
+	Authenticate(rawKey string) (*models.APIKey, error)
+}
+
 // ------------------------------------------------------------
 // Структуры
 // ------------------------------------------------------------
 
-// Authorization Middleware для авторизации с использованием JWT
+// Authorization Middleware для авторизации с использованием JWT и API ключей
 type Authorization struct {
 	tokenManager utils.TokenManager
 	userRepos    repository.UserRepository
+	revokedRepo  repository.RevokedTokenRepository
+	sessionRepo  repository.SessionRepository
+	apiKeys      APIKeyAuthenticator
+	verification *VerificationPolicy
+	cookies      *CookieAuth
+	clientCerts  *ClientCertMapper
 }
 
 // ------------------------------------------------------------
@@ -27,10 +105,25 @@ type Authorization struct {
 // ------------------------------------------------------------
 
 // NewAuthorization создает новый экземпляр Authorization
-func NewAuthorization(tokenManager utils.TokenManager, userRepos repository.UserRepository) *Authorization {
+func NewAuthorization(
+	tokenManager utils.TokenManager,
+	userRepos repository.UserRepository,
+	revokedRepo repository.RevokedTokenRepository,
+	sessionRepo repository.SessionRepository,
+	apiKeys APIKeyAuthenticator,
+	verification *VerificationPolicy,
+	cookies *CookieAuth,
+	clientCerts *ClientCertMapper,
+) *Authorization {
 	return &Authorization{
 		tokenManager: tokenManager,
 		userRepos:    userRepos,
+		revokedRepo:  revokedRepo,
+		sessionRepo:  sessionRepo,
+		apiKeys:      apiKeys,
+		verification: verification,
+		cookies:      cookies,
+		clientCerts:  clientCerts,
 	}
 }
 
@@ -38,48 +131,320 @@ func NewAuthorization(tokenManager utils.TokenManager, userRepos repository.User
 // Основные методы
 // ------------------------------------------------------------
 
-// Middleware проверяет JWT токен и добавляет user_id в контекст и сверяет его с параметром маршрута
+// Middleware проверяет JWT токен или API ключ и добавляет данные пользователя в контекст.
+// Без заголовка Authorization в режиме cookie используется access токен из cookie;
+// изменяющие запросы с cookie должны пройти double-submit проверку CSRF токена.
+// Проверенный при TLS рукопожатии клиентский сертификат используется, если нет API ключа и заголовка Authorization.
+// Права доступа к конкретному маршруту проверяют Allow и RequireScopes.
 func (m *Authorization) Middleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// API ключ передается в X-API-Key или вместо токена в Authorization
+		if apiKey := m.extractAPIKey(c); apiKey != "" {
+			m.authenticateAPIKey(c, apiKey)
+			return
+		}
+
 		// Извлекаем токен из заголовка
-		tokenString := m.extractToken(c)
-		if tokenString == "" {
-			m.abortWithError(c, http.StatusUnauthorized, models.ErrTokenRequired)
+		if tokenString := m.extractToken(c); tokenString != "" {
+			m.authenticateJWT(c, tokenString, AuthMethodJWT)
 			return
 		}
 
-		// Парсим и валидируем токен
-		token, err := m.tokenManager.Parse(tokenString)
-		if err != nil || !token.Valid {
-			m.abortWithError(c, http.StatusUnauthorized, models.ErrInvalidToken)
+		// Клиентский сертификат уже проверен при TLS рукопожатии по TLS_CLIENT_CA_FILE
+		if cert := verifiedClientCert(c); cert != nil && m.clientCerts != nil {
+			m.authenticateClientCert(c, cert)
 			return
 		}
 
-		// Извлекаем user_id из токена
-		userID, err := m.tokenManager.ExtractUserID(token)
-		if err != nil {
-			m.abortWithError(c, http.StatusUnauthorized, models.ErrInvalidTokenClaims)
+		// Браузер отправляет cookie автоматически, поэтому изменяющие запросы проверяются на CSRF
+		if tokenString := m.cookies.AccessToken(c); tokenString != "" {
+			if !m.cookies.ValidCSRF(c) {
+				m.abortWithError(c, http.StatusForbidden, models.ErrInvalidCSRFToken)
+				return
+			}
+			m.authenticateJWT(c, tokenString, AuthMethodCookie)
 			return
 		}
 
-		// Извлекаем параметр id из маршрута и проверяем его совпадение с user_id
+		m.abortWithError(c, http.StatusUnauthorized, models.ErrTokenRequired)
+	}
+}
+
+// Allow разрешает доступ к маршруту только перечисленным ролям.
+// Псевдороль Self разрешает доступ владельцу ресурса из параметра :user_id.
+// API ключи допускаются только на маршруты, объявившие области доступа через RequireScopes.
+// Подключается после Middleware.
+func (m *Authorization) Allow(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString(ContextAuthMethodKey) == AuthMethodAPIKey && !c.GetBool(contextScopesCheckedKey) {
+			m.abortWithError(c, http.StatusForbidden, models.ErrAPIKeyNotAllowed)
+			return
+		}
+
+		userID := c.GetUint(ContextUserIDKey)
+		role := c.GetString(ContextUserRoleKey)
+		extraRoles := c.GetStringSlice(ContextRolesKey)
 		paramID := c.Param("user_id")
-		if paramID != "" && paramID != strconv.Itoa(int(userID)) {
+
+		selfAllowed := false
+		for _, allowed := range roles {
+			if allowed == Self {
+				selfAllowed = true
+				if paramID == strconv.Itoa(int(userID)) {
+					return
+				}
+				continue
+			}
+			if allowed == role || containsString(extraRoles, allowed) {
+				return
+			}
+		}
+
+		// Обращение к чужому ресурсу сохраняет прежний ответ 401
+		if selfAllowed && paramID != "" {
 			m.abortWithError(c, http.StatusUnauthorized, models.ErrInvalidTokenClaims)
 			return
 		}
+		m.abortWithError(c, http.StatusForbidden, models.ErrAccessDenied)
+	}
+}
+
+// RequireScopes объявляет области доступа маршрута. API ключ должен иметь все перечисленные
+// области; для access токенов ограничение не применяется. Подключается до Allow.
+func (m *Authorization) RequireScopes(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(contextScopesCheckedKey, true)
+
+		if c.GetString(ContextAuthMethodKey) != AuthMethodAPIKey {
+			return
+		}
 
-		_, err = m.userRepos.FindByID(userID)
-		if err != nil {
-			if errors.Is(err, models.ErrDatabaseError) {
-				m.abortWithError(c, http.StatusInternalServerError, err)
+		granted := c.GetStringSlice(ContextScopesKey)
+		for _, scope := range scopes {
+			if !containsString(granted, scope) {
+				m.abortWithError(c, http.StatusForbidden, models.ErrInsufficientScope)
+				return
 			}
+		}
+	}
+}
+
+// RequireToken разрешает маршрут только субъектам, вошедшим по access токену (заголовок или cookie).
+// Используется для маршрутов управления сессией, которые не имеют смысла для API ключей и сертификатов.
+// Подключается после Middleware.
+func (m *Authorization) RequireToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.GetString(ContextAuthMethodKey) {
+		case AuthMethodJWT, AuthMethodCookie:
+			return
+		}
+		m.abortWithError(c, http.StatusForbidden, models.ErrTokenAuthRequired)
+	}
+}
+
+// DenyImpersonation запрещает маршрут токенам имперсонации (claim act).
+// Подключается к чувствительным действиям: смена пароля, удаление аккаунта, управление учетными данными.
+func (m *Authorization) DenyImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetUint(ContextActorIDKey) != 0 {
+			m.abortWithError(c, http.StatusForbidden, models.ErrImpersonationDenied)
+		}
+	}
+}
+
+// ------------------------------------------------------------
+// Аутентификация
+// ------------------------------------------------------------
+
+// authenticateJWT проверяет access токен и сохраняет его данные в контексте
+func (m *Authorization) authenticateJWT(c *gin.Context, tokenString, method string) {
+	// Парсим и валидируем токен; ошибка описывает причину отказа
+	token, err := m.tokenManager.Parse(tokenString)
+	if err != nil {
+		m.abortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	if !token.Valid {
+		m.abortWithError(c, http.StatusUnauthorized, models.ErrInvalidToken)
+		return
+	}
+
+	// Извлекаем содержимое токена
+	claims, err := m.tokenManager.ExtractClaims(token)
+	if err != nil {
+		m.abortWithError(c, http.StatusUnauthorized, models.ErrInvalidTokenClaims)
+		return
+	}
+
+	// Токены с назначением (например, challenge токен 2FA) не дают доступа к API
+	if claims.Purpose != "" {
+		m.abortWithError(c, http.StatusUnauthorized, models.ErrInvalidToken)
+		return
+	}
+
+	// Проверяем, не был ли токен отозван при выходе из системы
+	revoked, err := m.revokedRepo.IsRevoked(claims.ID)
+	if err != nil {
+		m.abortWithError(c, http.StatusInternalServerError, models.ErrInternalServerError)
+		return
+	}
+	if revoked {
+		m.abortWithError(c, http.StatusUnauthorized, models.ErrTokenRevoked)
+		return
+	}
+
+	user, ok := m.loadUser(c, claims.UserID)
+	if !ok {
+		return
+	}
+
+	// Токены, выпущенные до "выхода везде" или смены роли, недействительны
+	if claims.TokenVersion != user.TokenVersion {
+		m.abortWithError(c, http.StatusUnauthorized, models.ErrTokenRevoked)
+		return
+	}
+
+	// Токены завершенной сессии недействительны
+	if claims.SessionID != "" && !m.checkSession(c, claims.SessionID, user.ID) {
+		return
+	}
+
+	if !m.checkEmailVerified(c, user) {
+		return
+	}
+
+	// Токен имперсонации действует, пока его выпустивший остается администратором
+	actorID, impersonated := claims.ActorID()
+	if impersonated {
+		actor, ok := m.loadUser(c, actorID)
+		if !ok {
+			return
+		}
+		if actor.Role != models.RoleAdmin {
+			m.abortWithError(c, http.StatusUnauthorized, models.ErrTokenRevoked)
+			return
+		}
+		c.Set(ContextActorIDKey, actorID)
+	}
+
+	// Добавляем данные токена в контекст
+	c.Set(ContextAuthMethodKey, method)
+	c.Set(ContextUserIDKey, claims.UserID)
+	c.Set(ContextTokenIDKey, claims.ID)
+	c.Set(ContextSessionIDKey, claims.SessionID)
+	c.Set(ContextTokenExpiresAtKey, claims.ExpiresAt.Time)
+	c.Set(ContextUserRoleKey, claims.Role)
+}
+
+// authenticateAPIKey проверяет API ключ и сохраняет его владельца и области доступа в контексте
+func (m *Authorization) authenticateAPIKey(c *gin.Context, rawKey string) {
+	key, err := m.apiKeys.Authenticate(rawKey)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidAPIKey) {
 			m.abortWithError(c, http.StatusUnauthorized, err)
+			return
 		}
+		m.abortWithError(c, http.StatusInternalServerError, models.ErrInternalServerError)
+		return
+	}
+
+	user, ok := m.loadUser(c, key.UserID)
+	if !ok {
+		return
+	}
 
-		// Добавляем user_id в контекст
-		c.Set("user_id", userID)
+	if !m.checkEmailVerified(c, user) {
+		return
 	}
+
+	c.Set(ContextAuthMethodKey, AuthMethodAPIKey)
+	c.Set(ContextUserIDKey, user.ID)
+	c.Set(ContextUserRoleKey, user.Role)
+	c.Set(ContextScopesKey, key.Scopes)
+}
+
+// authenticateClientCert сопоставляет клиентский сертификат субъекту по таблице и сохраняет его роли в контексте
+func (m *Authorization) authenticateClientCert(c *gin.Context, cert *x509.Certificate) {
+	identity, ok := m.clientCerts.Match(cert)
+	if !ok {
+		m.abortWithError(c, http.StatusUnauthorized, models.ErrClientCertNotMapped)
+		return
+	}
+
+	var userID uint
+	roles := identity.Roles
+	if identity.UserID != 0 {
+		user, ok := m.loadUser(c, identity.UserID)
+		if !ok {
+			return
+		}
+		if !m.checkEmailVerified(c, user) {
+			return
+		}
+		userID = user.ID
+		if len(roles) == 0 {
+			roles = []string{user.Role}
+		}
+	}
+
+	c.Set(ContextAuthMethodKey, AuthMethodClientCert)
+	c.Set(ContextUserIDKey, userID)
+	c.Set(ContextUserRoleKey, roles[0])
+	c.Set(ContextRolesKey, roles)
+	c.Set(ContextPrincipalKey, identity.Name)
+}
+
+// loadUser загружает пользователя; при ошибке прерывает запрос и возвращает false
+func (m *Authorization) loadUser(c *gin.Context, userID uint) (*models.User, bool) {
+	user, err := m.userRepos.FindByID(userID)
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			m.abortWithError(c, http.StatusUnauthorized, err)
+			return nil, false
+		}
+		m.abortWithError(c, http.StatusInternalServerError, models.ErrInternalServerError)
+		return nil, false
+	}
+	return user, true
+}
+
+// checkSession проверяет, что сессия токена активна, и обновляет время ее последней активности;
+// при завершенной сессии прерывает запрос и возвращает false
+func (m *Authorization) checkSession(c *gin.Context, sessionID string, userID uint) bool {
+	session, err := m.sessionRepo.FindByID(sessionID)
+	if err != nil {
+		if errors.Is(err, models.ErrRecordNotFound) {
+			m.abortWithError(c, http.StatusUnauthorized, models.ErrSessionRevoked)
+			return false
+		}
+		m.abortWithError(c, http.StatusInternalServerError, models.ErrInternalServerError)
+		return false
+	}
+
+	if session.UserID != userID || session.RevokedAt != nil {
+		m.abortWithError(c, http.StatusUnauthorized, models.ErrSessionRevoked)
+		return false
+	}
+
+	// Ошибка обновления не должна отклонять запрос: время активности носит справочный характер
+	if now := time.Now(); now.Sub(session.LastSeenAt) >= sessionTouchInterval {
+		_ = m.sessionRepo.TouchLastSeen(session.ID, now)
+	}
+	return true
+}
+
+// checkEmailVerified ограничивает пользователей с неподтвержденным email маршрутами из политики;
+// при запрете прерывает запрос и возвращает false
+func (m *Authorization) checkEmailVerified(c *gin.Context, user *models.User) bool {
+	if user.EmailVerifiedAt != nil || m.verification == nil {
+		return true
+	}
+
+	if !m.verification.Allows(c.Request.Method, c.FullPath()) {
+		m.abortWithError(c, http.StatusForbidden, models.ErrEmailNotVerified)
+		return false
+	}
+	return true
 }
 
 // ------------------------------------------------------------
@@ -102,9 +467,41 @@ func (m *Authorization) extractToken(c *gin.Context) string {
 	return parts[1]
 }
 
+// verifiedClientCert возвращает клиентский сертификат, прошедший проверку цепочки при TLS рукопожатии
+func verifiedClientCert(c *gin.Context) *x509.Certificate {
+	state := c.Request.TLS
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.PeerCertificates) == 0 {
+		return nil
+	}
+	return state.PeerCertificates[0]
+}
+
+// extractAPIKey извлекает API ключ из X-API-Key или из Authorization: Bearer kvt_...
+func (m *Authorization) extractAPIKey(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+
+	if token := m.extractToken(c); strings.HasPrefix(token, models.APIKeyPrefix) {
+		return token
+	}
+
+	return ""
+}
+
 // abortWithError отправляет ошибку и прерывает выполнение
 func (m *Authorization) abortWithError(c *gin.Context, status int, err error) {
 	c.AbortWithStatusJSON(status, models.ErrorLoginResponse{
 		Error: err.Error(),
 	})
 }
+
+// containsString проверяет наличие строки в срезе
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"khrllwTest/internal/models"
+	"os"
+	"strings"
+)
+
+// ------------------------------------------------------------
+// Структуры
+// ------------------------------------------------------------
+
+// ClientCertIdentity строка таблицы соответствия клиентских сертификатов.
+// Условие задается ровно одним из полей Subject, CommonName, DNSName, URI, Email.
+// Сертификат сопоставляется либо пользователю (UserID), либо сервисной учетной записи (Name и Roles).
+type ClientCertIdentity struct {
+	// Полное имя субъекта сертификата в формате RFC 2253, например "CN=billing,O=Internal"
+	Subject string `json:"subject,omitempty"`
+
+	// Common Name субъекта
+	CommonName string `json:"common_name,omitempty"`
+
+	// DNS имя из SAN
+	DNSName string `json:"dns,omitempty"`
+
+	// URI из SAN (например, SPIFFE ID)
+	URI string `json:"uri,omitempty"`
+
+	// Email из SAN
+	Email string `json:"email,omitempty"`
+
+	// Имя сервисной учетной записи
+	Name string `json:"name,omitempty"`
+
+	// Пользователь, от имени которого выполняются запросы
+	UserID uint `json:"user_id,omitempty"`
+
+	// Роли субъекта; для пользователя по умолчанию используется его роль
+	Roles []string `json:"roles,omitempty"`
+}
+
+// ClientCertMapper сопоставляет проверенные клиентские сертификаты субъектам по таблице
+type ClientCertMapper struct {
+	identities []ClientCertIdentity
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// LoadClientCertMapper загружает таблицу соответствия из JSON файла (массив ClientCertIdentity)
+func LoadClientCertMapper(path string) (*ClientCertMapper, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения таблицы клиентских сертификатов: %w", err)
+	}
+
+	var identities []ClientCertIdentity
+	if err := json.Unmarshal(data, &identities); err != nil {
+		return nil, fmt.Errorf("неверный формат таблицы клиентских сертификатов: %w", err)
+	}
+
+	return NewClientCertMapper(identities)
+}
+
+// NewClientCertMapper проверяет строки таблицы и создает новый экземпляр ClientCertMapper.
+// При проверке сертификата используется первая подходящая строка.
+func NewClientCertMapper(identities []ClientCertIdentity) (*ClientCertMapper, error) {
+	for i, identity := range identities {
+		conditions := 0
+		for _, value := range []string{identity.Subject, identity.CommonName, identity.DNSName, identity.URI, identity.Email} {
+			if value != "" {
+				conditions++
+			}
+		}
+		if conditions != 1 {
+			return nil, fmt.Errorf("строка %d: нужно ровно одно из полей subject, common_name, dns, uri, email", i+1)
+		}
+
+		switch {
+		case identity.UserID != 0 && identity.Name != "":
+			return nil, fmt.Errorf("строка %d: укажите либо user_id, либо name", i+1)
+		case identity.UserID == 0 && identity.Name == "":
+			return nil, fmt.Errorf("строка %d: укажите user_id или name", i+1)
+		case identity.UserID == 0 && len(identity.Roles) == 0:
+			return nil, fmt.Errorf("строка %d: для сервисной учетной записи нужны roles", i+1)
+		}
+
+		for _, role := range identity.Roles {
+			if role != models.RoleUser && role != models.RoleAdmin {
+				return nil, fmt.Errorf("строка %d: неизвестная роль %q", i+1, role)
+			}
+		}
+	}
+
+	return &ClientCertMapper{identities: identities}, nil
+}
+
+// ------------------------------------------------------------
+// Основные методы
+// ------------------------------------------------------------
+
+// Match возвращает первую строку таблицы, которой соответствует сертификат
+func (m *ClientCertMapper) Match(cert *x509.Certificate) (*ClientCertIdentity, bool) {
+	if m == nil || cert == nil {
+		return nil, false
+	}
+
+	for i := range m.identities {
+		if m.identities[i].matches(cert) {
+			return &m.identities[i], true
+		}
+	}
+	return nil, false
+}
+
+// ------------------------------------------------------------
+// Вспомогательные методы
+// ------------------------------------------------------------
+
+// matches проверяет условие строки таблицы
+func (i *ClientCertIdentity) matches(cert *x509.Certificate) bool {
+	switch {
+	case i.Subject != "":
+		return cert.Subject.String() == i.Subject
+	case i.CommonName != "":
+		return cert.Subject.CommonName == i.CommonName
+	case i.DNSName != "":
+		for _, name := range cert.DNSNames {
+			if strings.EqualFold(name, i.DNSName) {
+				return true
+			}
+		}
+	case i.URI != "":
+		for _, uri := range cert.URIs {
+			if uri.String() == i.URI {
+				return true
+			}
+		}
+	case i.Email != "":
+		for _, email := range cert.EmailAddresses {
+			if strings.EqualFold(email, i.Email) {
+				return true
+			}
+		}
+	}
+	return false
+}
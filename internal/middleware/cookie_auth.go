@@ -0,0 +1,220 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"khrllwTest/internal/models"
+	"khrllwTest/internal/utils"
+)
+
+const (
+	// AccessTokenCookie cookie с access токеном (HttpOnly)
+	AccessTokenCookie = "access_token"
+
+	// RefreshTokenCookie cookie с refresh токеном (HttpOnly, отправляется только на /auth)
+	RefreshTokenCookie = "refresh_token"
+
+	// CSRFTokenCookie cookie с CSRF токеном (доступна JavaScript для double-submit)
+	CSRFTokenCookie = "csrf_token"
+
+	// CSRFHeader заголовок, в котором клиент повторяет значение CSRF cookie
+	CSRFHeader = "X-CSRF-Token"
+
+	// refreshCookiePath путь, для которого браузер отправляет refresh cookie
+	refreshCookiePath = "/auth"
+)
+
+// ------------------------------------------------------------
+// Конфигурация
+// ------------------------------------------------------------
+
+// CookieAuthConfig содержит настройки аутентификации через cookie для браузерных клиентов
+type CookieAuthConfig struct {
+	// Включен ли режим cookie
+	Enabled bool
+
+	// Домен cookie (пусто - только текущий хост)
+	Domain string
+
+	// Отправлять cookie только по HTTPS
+	Secure bool
+
+	// Политика SameSite
+	SameSite http.SameSite
+
+	// Время жизни refresh и CSRF cookie
+	RefreshTTL time.Duration
+}
+
+// NewCookieAuthConfig создает конфигурацию режима cookie из переменных окружения
+func NewCookieAuthConfig(refreshTTL time.Duration) (*CookieAuthConfig, error) {
+	config := &CookieAuthConfig{
+		Domain:     os.Getenv("AUTH_COOKIE_DOMAIN"),
+		Secure:     true,
+		SameSite:   http.SameSiteStrictMode,
+		RefreshTTL: refreshTTL,
+	}
+
+	var err error
+	if value := os.Getenv("AUTH_COOKIE_ENABLED"); value != "" {
+		if config.Enabled, err = strconv.ParseBool(value); err != nil {
+			return nil, errors.New("неверное значение AUTH_COOKIE_ENABLED. Ожидается true или false")
+		}
+	}
+
+	if value := os.Getenv("AUTH_COOKIE_SECURE"); value != "" {
+		if config.Secure, err = strconv.ParseBool(value); err != nil {
+			return nil, errors.New("неверное значение AUTH_COOKIE_SECURE. Ожидается true или false")
+		}
+	}
+
+	switch strings.ToLower(os.Getenv("AUTH_COOKIE_SAMESITE")) {
+	case "", "strict":
+		config.SameSite = http.SameSiteStrictMode
+	case "lax":
+		config.SameSite = http.SameSiteLaxMode
+	case "none":
+		config.SameSite = http.SameSiteNoneMode
+	default:
+		return nil, errors.New("неверное значение AUTH_COOKIE_SAMESITE. Допустимо: strict, lax, none")
+	}
+
+	// Браузеры отбрасывают cookie с SameSite=None без Secure
+	if config.SameSite == http.SameSiteNoneMode && !config.Secure {
+		return nil, errors.New("AUTH_COOKIE_SAMESITE=none требует AUTH_COOKIE_SECURE=true")
+	}
+
+	return config, nil
+}
+
+// ------------------------------------------------------------
+// Структуры
+// ------------------------------------------------------------
+
+// CookieAuth выдает, читает и удаляет cookie аутентификации и проверяет CSRF токен
+type CookieAuth struct {
+	config *CookieAuthConfig
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewCookieAuth создает новый экземпляр CookieAuth
+func NewCookieAuth(config *CookieAuthConfig) *CookieAuth {
+	return &CookieAuth{config: config}
+}
+
+// ------------------------------------------------------------
+// Основные методы
+// ------------------------------------------------------------
+
+// Enabled сообщает, включен ли режим cookie
+func (a *CookieAuth) Enabled() bool {
+	return a != nil && a.config.Enabled
+}
+
+// SetTokens записывает токены в HttpOnly cookie и выпускает новый CSRF токен.
+// Токены удаляются из ответа, чтобы они не попадали в JavaScript; в ответ добавляется CSRF токен.
+func (a *CookieAuth) SetTokens(c *gin.Context, tokens *models.LoginResponse) error {
+	csrfToken, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return models.ErrTokenGenerationFailed
+	}
+
+	refreshMaxAge := int(a.config.RefreshTTL.Seconds())
+	a.setCookie(c, AccessTokenCookie, tokens.Token, "/", int(tokens.ExpiresIn), true)
+	a.setCookie(c, RefreshTokenCookie, tokens.RefreshToken, refreshCookiePath, refreshMaxAge, true)
+	a.setCookie(c, CSRFTokenCookie, csrfToken, "/", refreshMaxAge, false)
+
+	tokens.Token = ""
+	tokens.RefreshToken = ""
+	tokens.CSRFToken = csrfToken
+	return nil
+}
+
+// Clear удаляет все cookie аутентификации
+func (a *CookieAuth) Clear(c *gin.Context) {
+	a.setCookie(c, AccessTokenCookie, "", "/", -1, true)
+	a.setCookie(c, RefreshTokenCookie, "", refreshCookiePath, -1, true)
+	a.setCookie(c, CSRFTokenCookie, "", "/", -1, false)
+}
+
+// SetFlowCookie записывает HttpOnly cookie промежуточного шага входа (например, state OpenID Connect)
+// независимо от режима cookie. Используется SameSite=Lax: cookie должна отправляться
+// при возврате пользователя со страницы внешнего провайдера.
+func (a *CookieAuth) SetFlowCookie(c *gin.Context, name, value, path string, maxAge int) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   a.config.Domain,
+		MaxAge:   maxAge,
+		Secure:   a.config.Secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// AccessToken возвращает access токен из cookie (пусто, если режим выключен или cookie нет)
+func (a *CookieAuth) AccessToken(c *gin.Context) string {
+	return a.cookie(c, AccessTokenCookie)
+}
+
+// RefreshToken возвращает refresh токен из cookie (пусто, если режим выключен или cookie нет)
+func (a *CookieAuth) RefreshToken(c *gin.Context) string {
+	return a.cookie(c, RefreshTokenCookie)
+}
+
+// ValidCSRF выполняет double-submit проверку: значение заголовка X-CSRF-Token
+// должно совпадать с CSRF cookie. Безопасные методы (GET, HEAD, OPTIONS) не проверяются.
+func (a *CookieAuth) ValidCSRF(c *gin.Context) bool {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+
+	cookie := a.cookie(c, CSRFTokenCookie)
+	header := c.GetHeader(CSRFHeader)
+	if cookie == "" || header == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) == 1
+}
+
+// ------------------------------------------------------------
+// Вспомогательные методы
+// ------------------------------------------------------------
+
+// cookie читает значение cookie, если режим включен
+func (a *CookieAuth) cookie(c *gin.Context, name string) string {
+	if !a.Enabled() {
+		return ""
+	}
+	value, err := c.Cookie(name)
+	if err != nil {
+		return ""
+	}
+	return value
+}
+
+// setCookie записывает cookie с настройками Domain, Secure и SameSite из конфигурации
+func (a *CookieAuth) setCookie(c *gin.Context, name, value, path string, maxAge int, httpOnly bool) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   a.config.Domain,
+		MaxAge:   maxAge,
+		Secure:   a.config.Secure,
+		HttpOnly: httpOnly,
+		SameSite: a.config.SameSite,
+	})
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ------------------------------------------------------------
+// Структуры
+// ------------------------------------------------------------
+
+// VerificationPolicy определяет маршруты, доступные пользователям с неподтвержденным email
+type VerificationPolicy struct {
+	rules []routeRule
+}
+
+// routeRule правило вида "METHOD /path"; "*" в методе - любой метод,
+// "*" в конце пути - любой путь с указанным префиксом
+type routeRule struct {
+	method string
+	path   string
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewVerificationPolicy разбирает список правил через запятую, например
+// "GET *,POST /auth/*,PUT /users/:user_id". Пути указываются в формате маршрутов gin.
+// Пустая спецификация или "*" разрешает все маршруты.
+// Правила из always разрешены всегда, независимо от спецификации.
+func NewVerificationPolicy(spec string, always ...string) (*VerificationPolicy, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" || spec == "*" {
+		spec = "* *"
+	}
+
+	policy := &VerificationPolicy{}
+	for _, entry := range append(strings.Split(spec, ","), always...) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		fields := strings.Fields(entry)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("неверное правило %q. Пример: POST /users/:user_id/orders", entry)
+		}
+
+		policy.rules = append(policy.rules, routeRule{
+			method: strings.ToUpper(fields[0]),
+			path:   fields[1],
+		})
+	}
+
+	return policy, nil
+}
+
+// ------------------------------------------------------------
+// Методы
+// ------------------------------------------------------------
+
+// Allows проверяет, разрешен ли маршрут пользователю с неподтвержденным email
+func (p *VerificationPolicy) Allows(method, path string) bool {
+	for _, rule := range p.rules {
+		if rule.matches(method, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches проверяет соответствие метода и шаблона маршрута правилу
+func (r routeRule) matches(method, path string) bool {
+	if r.method != "*" && r.method != method {
+		return false
+	}
+
+	if prefix, ok := strings.CutSuffix(r.path, "*"); ok {
+		return strings.HasPrefix(path, prefix)
+	}
+	return r.path == path
+}
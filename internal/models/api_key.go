@@ -0,0 +1,132 @@
+package models
+
+import "time"
+
+// -------------------------- API KEY -------------------------
+// Определение структур данных API ключей и их отношений к БД
+
+// ------------------------------------------------------------
+// Области доступа
+// ------------------------------------------------------------
+
+const (
+	// APIKeyPrefix префикс, по которому API ключ отличается от JWT
+	APIKeyPrefix = "kvt_"
+
+	// ScopeUsersRead чтение данных пользователей
+	ScopeUsersRead = "users:read"
+
+	// ScopeUsersWrite изменение данных пользователей
+	ScopeUsersWrite = "users:write"
+
+	// ScopeOrdersRead чтение заказов
+	ScopeOrdersRead = "orders:read"
+
+	// ScopeOrdersWrite создание заказов
+	ScopeOrdersWrite = "orders:write"
+)
+
+// ------------------------------------------------------------
+// Структуры API ключей
+// ------------------------------------------------------------
+
+// APIKey
+// Персональный API ключ пользователя (хранится только хеш)
+type APIKey struct {
+	// Уникальный идентификатор ключа
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// Идентификатор пользователя - владельца ключа
+	UserID uint `gorm:"not null;index" json:"user_id"`
+
+	// Название ключа, заданное пользователем
+	Name string `gorm:"type:varchar(255);not null" json:"name"`
+
+	// Начало ключа для отображения в списке (сам ключ не хранится)
+	Prefix string `gorm:"type:varchar(32);not null" json:"prefix"`
+
+	// SHA-256 хеш ключа
+	KeyHash string `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+
+	// Области доступа ключа
+	Scopes []string `gorm:"serializer:json;type:text;not null" json:"scopes"`
+
+	// Дата и время истечения ключа (nil - бессрочный)
+	ExpiresAt *time.Time `json:"expires_at"`
+
+	// Дата и время последнего использования
+	LastUsedAt *time.Time `json:"last_used_at"`
+
+	// Дата и время отзыва ключа (nil - ключ активен)
+	RevokedAt *time.Time `json:"revoked_at"`
+
+	// Дата и время создания ключа
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// HasScope проверяет, выдана ли ключу область доступа
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// ------------------------------------------------------------
+// Request/Response
+// ------------------------------------------------------------
+
+// CreateAPIKeyRequest (DTO)
+// Структура данных для создания API ключа
+// @Description Структура для запроса на создание API ключа
+// @Schema example: {"name": "billing-export", "scopes": ["orders:read"], "expires_at": "2026-01-01T00:00:00Z"}
+type CreateAPIKeyRequest struct {
+	// Название ключа
+	Name string `json:"name" binding:"required,max=255" example:"billing-export"`
+
+	// Области доступа ключа
+	Scopes []string `json:"scopes" binding:"required,min=1,dive,oneof=users:read users:write orders:read orders:write" example:"orders:read"`
+
+	// Дата и время истечения ключа (необязательно)
+	ExpiresAt *time.Time `json:"expires_at" example:"2026-01-01T00:00:00Z"`
+}
+
+// APIKeyResponse (DTO)
+// Структура данных для ответа с информацией об API ключе
+// @Description Структура ответа с информацией об API ключе (без самого ключа)
+// @Schema example: {"id": 1, "name": "billing-export", "prefix": "kvt_3fA9xQ", "scopes": ["orders:read"], "expires_at": "2026-01-01T00:00:00Z", "last_used_at": null, "created_at": "2025-05-07T12:34:56Z"}
+type APIKeyResponse struct {
+	// Уникальный идентификатор ключа
+	ID uint `json:"id" example:"1"`
+
+	// Название ключа
+	Name string `json:"name" example:"billing-export"`
+
+	// Начало ключа для распознавания
+	Prefix string `json:"prefix" example:"kvt_3fA9xQ"`
+
+	// Области доступа ключа
+	Scopes []string `json:"scopes" example:"orders:read"`
+
+	// Дата и время истечения ключа
+	ExpiresAt *time.Time `json:"expires_at" example:"2026-01-01T00:00:00Z"`
+
+	// Дата и время последнего использования
+	LastUsedAt *time.Time `json:"last_used_at" example:"2025-05-08T09:00:00Z"`
+
+	// Дата и время создания ключа
+	CreatedAt time.Time `json:"created_at" example:"2025-05-07T12:34:56Z"`
+}
+
+// CreateAPIKeyResponse (DTO)
+// Структура ответа на создание API ключа
+// @Description Структура ответа с новым API ключом. Ключ показывается только один раз
+// @Schema example: {"id": 1, "name": "billing-export", "prefix": "kvt_3fA9xQ", "scopes": ["orders:read"], "key": "kvt_3fA9xQ..."}
+type CreateAPIKeyResponse struct {
+	APIKeyResponse
+
+	// Полное значение ключа (больше не будет показано)
+	Key string `json:"key" example:"kvt_3fA9xQ..."`
+}
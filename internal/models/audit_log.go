@@ -0,0 +1,111 @@
+package models
+
+import "time"
+
+// ------------------------- AUDIT LOG ------------------------
+// Определение структур журнала аудита и входа от имени пользователя
+
+const (
+	// AuditActionImpersonationStart выпуск токена имперсонации
+	AuditActionImpersonationStart = "impersonation.start"
+
+	// AuditActionImpersonatedRequest запрос, выполненный с токеном имперсонации
+	AuditActionImpersonatedRequest = "impersonation.request"
+)
+
+// ------------------------------------------------------------
+// Структуры
+// ------------------------------------------------------------
+
+// AuditLog
+// Запись журнала аудита: кто (actor) и от чьего имени (user) выполнил действие
+type AuditLog struct {
+	// Уникальный идентификатор записи
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// Администратор, выполнивший действие
+	ActorID uint `gorm:"not null;index" json:"actor_id"`
+
+	// Пользователь, от имени которого выполнено действие
+	UserID uint `gorm:"not null;index" json:"user_id"`
+
+	// Действие (impersonation.start, impersonation.request)
+	Action string `gorm:"type:varchar(64);not null" json:"action"`
+
+	// Идентификатор (jti) токена имперсонации
+	TokenID string `gorm:"type:varchar(64);index" json:"token_id"`
+
+	// HTTP метод запроса
+	Method string `gorm:"type:varchar(16)" json:"method,omitempty"`
+
+	// Путь запроса
+	Path string `gorm:"type:varchar(512)" json:"path,omitempty"`
+
+	// Код ответа
+	Status int `json:"status,omitempty"`
+
+	// Причина входа от имени пользователя (для impersonation.start)
+	Reason string `gorm:"type:varchar(255)" json:"reason,omitempty"`
+
+	// IP адрес клиента
+	IPAddress string `gorm:"type:varchar(64)" json:"ip_address"`
+
+	// User-Agent клиента
+	UserAgent string `gorm:"type:varchar(512)" json:"user_agent"`
+
+	// Дата и время действия
+	CreatedAt time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+// TableName задает имя таблицы журнала аудита
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+// ------------------------------------------------------------
+// Request/Response
+// ------------------------------------------------------------
+
+// ImpersonationRequest (DTO)
+// Структура запроса на вход от имени пользователя
+// @Description Причина входа сохраняется в журнале аудита
+// @Schema example: {"reason": "Тикет #1234: заказ не отображается"}
+type ImpersonationRequest struct {
+	// Причина входа от имени пользователя
+	Reason string `json:"reason" binding:"required,max=255" example:"Тикет #1234: заказ не отображается"`
+}
+
+// ImpersonationResponse (DTO)
+// Структура ответа с токеном имперсонации
+// @Description Короткоживущий access токен пользователя с claim act администратора. Refresh токен не выдается
+// @Schema example: {"token": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...", "expires_in": 900, "user_id": 42, "actor_id": 1}
+type ImpersonationResponse struct {
+	// Access токен пользователя с claim act
+	Token string `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+
+	// Время жизни токена в секундах
+	ExpiresIn int64 `json:"expires_in" example:"900"`
+
+	// Пользователь, от имени которого выполняется вход
+	UserID uint `json:"user_id" example:"42"`
+
+	// Администратор, выполняющий вход
+	ActorID uint `json:"actor_id" example:"1"`
+}
+
+// AuditLogsListResponse (DTO)
+// Структура ответа со списком записей журнала аудита
+// @Description Записи журнала аудита с пагинацией, новые первыми
+type AuditLogsListResponse struct {
+	// Номер страницы
+	Page int `json:"page" example:"1"`
+
+	// Количество записей на странице
+	Limit int `json:"limit" example:"10"`
+
+	// Общее количество записей
+	Total int64 `json:"total" example:"1"`
+
+	// Записи журнала
+	Logs []AuditLog `json:"logs"`
+}
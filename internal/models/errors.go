@@ -1,6 +1,10 @@
 package models
 
-import "errors"
+import (
+	"errors"
+	"strings"
+	"time"
+)
 
 // ---------------------------------- ОШИБКИ API ----------------------------------
 
@@ -10,6 +14,10 @@ var (
 	ErrInvalidRequestFormat = errors.New("Неверный формат запроса. ")
 	ErrInternalServerError  = errors.New("Внутренняя ошибка сервера. ")
 	ErrDatabaseError        = errors.New("Ошибка базы данных. ")
+	ErrInvalidCursor        = errors.New("Некорректный курсор пагинации. ")
+	ErrUnsupportedMediaType = errors.New("Неподдерживаемый тип содержимого запроса. ")
+	ErrPreconditionFailed   = errors.New("Версия ресурса не совпадает с If-Match. ")
+	ErrVersionConflict      = errors.New("Ресурс был изменен другим запросом. ")
 
 	// ------------------------ Ошибки авторизации -----------------------
 
@@ -22,6 +30,59 @@ var (
 
 	ErrInvalidTokenClaims = errors.New("некорректное содержимое токена")
 
+	ErrTokenMalformed        = errors.New("Неверный формат токена авторизации. ")
+	ErrTokenSignatureInvalid = errors.New("Неверная подпись токена авторизации. ")
+	ErrTokenExpired          = errors.New("Срок действия токена авторизации истек. ")
+	ErrTokenNotYetValid      = errors.New("Токен авторизации еще не действителен. ")
+	ErrTokenIssuedInFuture   = errors.New("Время выпуска токена авторизации в будущем. ")
+	ErrTokenInvalidIssuer    = errors.New("Токен авторизации выпущен неизвестным издателем. ")
+	ErrTokenInvalidAudience  = errors.New("Токен авторизации выпущен для другого получателя. ")
+	ErrTokenInvalidSubject   = errors.New("Субъект токена авторизации не совпадает с пользователем. ")
+	ErrTokenMissingClaims    = errors.New("В токене авторизации отсутствуют обязательные поля. ")
+
+	ErrInvalidRefreshToken = errors.New("Неверный или просроченный refresh токен. ")
+	ErrRefreshTokenReused  = errors.New("Refresh токен уже был использован. Все сессии семейства отозваны. ")
+	ErrTokenRevoked        = errors.New("Токен авторизации отозван. ")
+	ErrAccessDenied        = errors.New("Недостаточно прав для выполнения операции. ")
+	ErrSessionRevoked      = errors.New("Сессия завершена. Войдите заново. ")
+	ErrSessionNotFound     = errors.New("Сессия не найдена. ")
+	ErrInvalidCSRFToken    = errors.New("Отсутствует или неверный CSRF токен. ")
+	ErrCookieAuthDisabled  = errors.New("Аутентификация через cookie отключена. ")
+
+	ErrAccountLocked        = errors.New("Аккаунт временно заблокирован из-за неудачных попыток входа. ")
+	ErrTooManyLoginAttempts = errors.New("Слишком много попыток входа. Повторите позже. ")
+
+	ErrInvalidChallengeToken   = errors.New("Неверный или просроченный токен второго шага входа. ")
+	ErrInvalidTwoFactorCode    = errors.New("Неверный код двухфакторной аутентификации. ")
+	ErrTwoFactorAlreadyEnabled = errors.New("Двухфакторная аутентификация уже подключена. ")
+	ErrTwoFactorNotEnrolled    = errors.New("Двухфакторная аутентификация не подключена. ")
+
+	ErrInvalidResetToken = errors.New("Неверный, просроченный или уже использованный токен сброса пароля. ")
+
+	ErrInvalidVerificationToken = errors.New("Неверный или просроченный токен подтверждения email. ")
+	ErrEmailNotVerified         = errors.New("Подтвердите email, чтобы выполнить эту операцию. ")
+	ErrEmailAlreadyVerified     = errors.New("Email уже подтвержден. ")
+	ErrVerificationResendLimit  = errors.New("Письмо подтверждения уже отправлено. Повторите позже. ")
+
+	ErrOIDCProviderNotFound    = errors.New("Провайдер OpenID Connect не найден. ")
+	ErrOIDCProviderUnavailable = errors.New("Провайдер OpenID Connect недоступен. ")
+	ErrOIDCInvalidState        = errors.New("Неверный или просроченный запрос входа через OpenID Connect. ")
+	ErrOIDCLoginFailed         = errors.New("Не удалось выполнить вход через OpenID Connect. ")
+	ErrOIDCEmailNotVerified    = errors.New("Провайдер OpenID Connect не подтвердил email пользователя. ")
+	ErrOIDCAccountNotFound     = errors.New("Аккаунт с email провайдера OpenID Connect не найден. ")
+	ErrOIDCBirthdateRequired   = errors.New("Провайдер OpenID Connect не передал дату рождения: зарегистрируйтесь и войдите через провайдера повторно. ")
+
+	ErrInvalidAPIKey       = errors.New("Неверный, просроченный или отозванный API ключ. ")
+	ErrAPIKeyNotFound      = errors.New("API ключ не найден. ")
+	ErrInvalidAPIKeyExpiry = errors.New("Срок действия API ключа должен быть в будущем. ")
+	ErrInsufficientScope   = errors.New("API ключу не выдана необходимая область доступа. ")
+	ErrAPIKeyNotAllowed    = errors.New("Маршрут недоступен для API ключей. ")
+
+	ErrClientCertNotMapped = errors.New("Клиентский сертификат не сопоставлен ни одному субъекту. ")
+	ErrTokenAuthRequired   = errors.New("Маршрут доступен только по access токену. ")
+	ErrImpersonationDenied = errors.New("Действие недоступно при входе от имени пользователя. ")
+	ErrCannotImpersonate   = errors.New("Нельзя войти от имени себя или другого администратора. ")
+
 	// ---------------------- Ошибки пользователей -----------------------
 
 	ErrUserNotFound        = errors.New("Пользователь не найден. ")
@@ -31,9 +92,24 @@ var (
 	ErrInvalidUserEmail    = errors.New("Некорректные email пользователя. ")
 	ErrInvalidUserPassword = errors.New("Некорректные пароль пользователя. ")
 	ErrInvalidUserAge      = errors.New("Некорректный возраст пользователя. ")
+	ErrInvalidUserRole     = errors.New("Некорректная роль пользователя. ")
+	ErrUserRestoreExpired  = errors.New("Срок восстановления удаленного пользователя истек. ")
+	ErrImmutableUserField  = errors.New("Поле пользователя не может быть изменено. ")
+
+	ErrAvatarNotFound        = errors.New("Аватар пользователя не найден. ")
+	ErrAvatarTooLarge        = errors.New("Файл аватара превышает допустимый размер. ")
+	ErrUnsupportedAvatarType = errors.New("Аватар должен быть изображением JPEG, PNG или GIF. ")
+	ErrInvalidAvatar         = errors.New("Некорректное изображение аватара. ")
+	ErrAvatarDimensions      = errors.New("Ширина или высота изображения аватара превышает допустимую. ")
+	ErrInvalidAvatarSize     = errors.New("Недоступный размер аватара. ")
+
+	ErrInvalidCurrentPassword = errors.New("Неверный текущий пароль. ")
+	ErrPasswordUnchanged      = errors.New("Новый пароль должен отличаться от текущего. ")
+	ErrPasswordPolicy         = errors.New("Пароль не соответствует требованиям. ")
 
 	ErrInvalidPagination   = errors.New("Некорректные параметры пагинации. ")
 	ErrInvalidFilterParams = errors.New("Некорректные параметры фильтрации. ")
+	ErrInvalidSortParams   = errors.New("Некорректные параметры сортировки. ")
 
 	// ------------------------- Репозитории -----------------------------
 
@@ -44,4 +120,53 @@ var (
 	ErrInvalidPrice    = errors.New("Некорректная цена. ")
 	ErrInvalidQuantity = errors.New("Некорректное количество. ")
 	ErrProductRequired = errors.New("Некорректное название продукта. ")
+	ErrOrderNotFound   = errors.New("Заказ не найден. ")
+	ErrInvalidOrderID  = errors.New("Неверный ID заказа. ")
 )
+
+// ------------------------------------------------------------
+// Ошибки с задержкой повтора
+// ------------------------------------------------------------
+
+// RetryAfterError оборачивает ошибку и сообщает, через сколько можно повторить запрос
+type RetryAfterError struct {
+	// Исходная ошибка
+	Err error
+
+	// Время до следующей допустимой попытки
+	RetryAfter time.Duration
+}
+
+// Error возвращает текст исходной ошибки
+func (e *RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap позволяет сравнивать ошибку через errors.Is
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// ------------------------------------------------------------
+// Ошибки политики паролей
+// ------------------------------------------------------------
+
+// PasswordPolicyError перечисляет все правила политики паролей, которым не соответствует пароль
+type PasswordPolicyError struct {
+	// Нарушенные правила
+	Violations []PasswordPolicyViolation
+}
+
+// Error возвращает текст ошибки со списком нарушений
+func (e *PasswordPolicyError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		messages = append(messages, violation.Message)
+	}
+	return ErrPasswordPolicy.Error() + strings.Join(messages, "; ")
+}
+
+// Unwrap позволяет сравнивать ошибку с ErrPasswordPolicy через errors.Is
+func (e *PasswordPolicyError) Unwrap() error {
+	return ErrPasswordPolicy
+}
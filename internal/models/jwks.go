@@ -0,0 +1,40 @@
+package models
+
+// --------------------------- JWKS ---------------------------
+// Определение структур JSON Web Key Set для проверки токенов другими сервисами
+
+// JWK представляет открытый ключ подписи в формате JSON Web Key
+// @Description Открытый ключ для проверки подписи JWT (RFC 7517)
+// @Schema example: {"kty": "RSA", "kid": "2025-01", "use": "sig", "alg": "RS256", "n": "0vx7agoebGc...", "e": "AQAB"}
+type JWK struct {
+	// Тип ключа (RSA или OKP)
+	KeyType string `json:"kty" example:"RSA"`
+
+	// Идентификатор ключа, совпадает с заголовком kid токена
+	KeyID string `json:"kid" example:"2025-01"`
+
+	// Назначение ключа
+	Use string `json:"use" example:"sig"`
+
+	// Алгоритм подписи
+	Algorithm string `json:"alg" example:"RS256"`
+
+	// Модуль RSA ключа (base64url)
+	Modulus string `json:"n,omitempty" example:"0vx7agoebGc..."`
+
+	// Открытая экспонента RSA ключа (base64url)
+	Exponent string `json:"e,omitempty" example:"AQAB"`
+
+	// Кривая для ключей OKP
+	Curve string `json:"crv,omitempty" example:"Ed25519"`
+
+	// Открытый ключ Ed25519 (base64url)
+	X string `json:"x,omitempty" example:"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"`
+}
+
+// JWKSResponse представляет набор открытых ключей
+// @Description Набор открытых ключей для проверки JWT без общего секрета
+type JWKSResponse struct {
+	// Список принимаемых в данный момент ключей
+	Keys []JWK `json:"keys"`
+}
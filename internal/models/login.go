@@ -12,14 +12,65 @@ type LoginRequest struct {
 
 	// Пароль пользователя
 	Password string `json:"password" binding:"required,min=8" example:"securepassword123"`
+
+	// Выдать токены в HttpOnly cookie вместо тела ответа (для браузерных клиентов)
+	UseCookie bool `json:"use_cookie" example:"false"`
 }
 
-// LoginResponse представляет структуру ответа с токеном
-// @Description Структура, которая возвращает токен для аутентифицированного пользователя
-// @Schema example: {"token": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."}
+// LoginResponse представляет структуру ответа с токенами
+// @Description Структура, которая возвращает access и refresh токены для аутентифицированного пользователя.
+// @Description Для пользователей с 2FA вместо токенов возвращается challenge_token для второго шага входа.
+// @Description В режиме cookie токены передаются в HttpOnly cookie, а в ответе возвращается csrf_token
+// @Schema example: {"token": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...", "refresh_token": "Zm9vYmFyYmF6...", "expires_in": 900}
 type LoginResponse struct {
-	// Токен аутентифицированного пользователя
-	Token string `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	// Короткоживущий access токен аутентифицированного пользователя
+	Token string `json:"token,omitempty" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+
+	// Непрозрачный refresh токен для получения новой пары токенов
+	RefreshToken string `json:"refresh_token,omitempty" example:"Zm9vYmFyYmF6..."`
+
+	// Время жизни access токена в секундах
+	ExpiresIn int64 `json:"expires_in,omitempty" example:"900"`
+
+	// Признак того, что для входа требуется код 2FA
+	TwoFactorRequired bool `json:"two_factor_required,omitempty" example:"false"`
+
+	// Промежуточный токен для POST /auth/login/2fa
+	ChallengeToken string `json:"challenge_token,omitempty" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+
+	// CSRF токен для заголовка X-CSRF-Token (только в режиме cookie)
+	CSRFToken string `json:"csrf_token,omitempty" example:"Y3NyZnRva2Vu..."`
+}
+
+// LoginTwoFactorRequest представляет структуру второго шага входа
+// @Description Структура данных для обмена challenge токена и кода 2FA на пару токенов
+// @Schema example: {"challenge_token": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...", "code": "123456"}
+type LoginTwoFactorRequest struct {
+	// Промежуточный токен, полученный на первом шаге входа
+	ChallengeToken string `json:"challenge_token" binding:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+
+	// Код из приложения-аутентификатора или код восстановления
+	Code string `json:"code" binding:"required" example:"123456"`
+
+	// Выдать токены в HttpOnly cookie вместо тела ответа (для браузерных клиентов)
+	UseCookie bool `json:"use_cookie" example:"false"`
+}
+
+// RefreshRequest представляет структуру запроса на обновление токенов
+// @Description Структура данных для обмена refresh токена на новую пару токенов.
+// @Description В режиме cookie тело не передается: refresh токен берется из cookie
+// @Schema example: {"refresh_token": "Zm9vYmFyYmF6..."}
+type RefreshRequest struct {
+	// Refresh токен, полученный при входе или предыдущем обновлении (обязателен вне режима cookie)
+	RefreshToken string `json:"refresh_token" example:"Zm9vYmFyYmF6..."`
+}
+
+// LogoutRequest представляет структуру запроса на выход из системы
+// @Description Структура данных для выхода. Если передан refresh токен, отзывается и его семейство
+// @Schema example: {"refresh_token": "Zm9vYmFyYmF6..."}
+type LogoutRequest struct {
+	// Refresh токен текущей сессии (необязательный)
+	RefreshToken string `json:"refresh_token" example:"Zm9vYmFyYmF6..."`
 }
 
 // ErrorLoginResponse представляет структуру для возвращаемых ошибок
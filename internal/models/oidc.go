@@ -0,0 +1,76 @@
+package models
+
+import "time"
+
+// --------------------------- OIDC ---------------------------
+// Определение структур входа через OpenID Connect и их отношений к БД
+
+// ------------------------------------------------------------
+// Структуры
+// ------------------------------------------------------------
+
+// OIDCLoginState
+// Незавершенный вход через OpenID Connect: параметры запроса авторизации,
+// которые проверяются при обратном вызове (хранится только хеш state)
+type OIDCLoginState struct {
+	// Уникальный идентификатор записи
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// SHA-256 хеш параметра state
+	StateHash string `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+
+	// Имя провайдера
+	Provider string `gorm:"type:varchar(32);not null" json:"provider"`
+
+	// Значение nonce, которое должно вернуться в ID токене
+	Nonce string `gorm:"type:varchar(64);not null" json:"-"`
+
+	// PKCE code_verifier для обмена кода авторизации
+	CodeVerifier string `gorm:"type:varchar(128);not null" json:"-"`
+
+	// Дата и время истечения запроса
+	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
+
+	// Дата и время начала входа
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName задает имя таблицы незавершенных входов
+func (OIDCLoginState) TableName() string {
+	return "oidc_login_states"
+}
+
+// UserIdentity
+// Связь пользователя с учетной записью внешнего провайдера (provider + sub)
+type UserIdentity struct {
+	// Уникальный идентификатор записи
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// Идентификатор пользователя
+	UserID uint `gorm:"not null;index" json:"user_id"`
+
+	// Имя провайдера
+	Provider string `gorm:"type:varchar(32);not null;uniqueIndex:idx_user_identities_provider_subject" json:"provider"`
+
+	// Идентификатор пользователя у провайдера (claim sub)
+	Subject string `gorm:"type:varchar(255);not null;uniqueIndex:idx_user_identities_provider_subject" json:"subject"`
+
+	// Email, подтвержденный провайдером при привязке
+	Email string `gorm:"type:varchar(255)" json:"email"`
+
+	// Дата и время привязки
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// ------------------------------------------------------------
+// Request/Response
+// ------------------------------------------------------------
+
+// OIDCProvidersResponse (DTO)
+// Структура ответа со списком провайдеров
+// @Description Провайдеры OpenID Connect, через которых доступен вход
+// @Schema example: {"providers": ["corp"]}
+type OIDCProvidersResponse struct {
+	// Имена провайдеров для /auth/oidc/{provider}/login
+	Providers []string `json:"providers" example:"corp"`
+}
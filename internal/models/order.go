@@ -29,6 +29,9 @@ type Order struct {
 
 	// Дата и время создания заказа
 	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	// Версия записи для оптимистичной блокировки (увеличивается при каждом изменении заказа)
+	Version int `gorm:"not null;default:1" json:"version"`
 }
 
 // ------------------------------------------------------------
@@ -50,6 +53,21 @@ type CreateOrderRequest struct {
 	Price float64 `json:"price"    binding:"required,gte=0" example:"1500.50"`
 }
 
+// UpdateOrderRequest (DTO)
+// Структура данных для обновления заказа
+// @Description Структура для запроса на обновление заказа (заменяет все поля)
+// @Schema example: {"product": "Laptop", "quantity": 3, "price": 1450.00}
+type UpdateOrderRequest struct {
+	// Название продукта, заказанного пользователем
+	Product string `json:"product"  binding:"required,max=255" example:"Laptop"`
+
+	// Количество заказанных единиц товара
+	Quantity int `json:"quantity" binding:"required,gte=1" example:"3"`
+
+	// Цена товара
+	Price float64 `json:"price"    binding:"required,gte=0" example:"1450.00"`
+}
+
 // OrderResponse (DTO)
 // Структура данных для ответа
 // @Description Структура для ответа, содержащая информацию о заказе
@@ -72,4 +90,59 @@ type OrderResponse struct {
 
 	// Дата и время создания заказа
 	CreatedAt time.Time `json:"created_at" example:"2025-05-07T12:34:56Z"`
+
+	// Версия заказа (значение для If-Match при изменении)
+	Version int `json:"version" example:"1"`
+}
+
+// OrdersListResponse (DTO)
+// Страница заказов пользователя при keyset-пагинации
+// @Description Заказы пользователя в порядке создания с курсорами соседних страниц (возвращается, если задан limit или cursor)
+// @Schema example: {"limit": 20, "next_cursor": "eyJxIjoi...", "orders": [{"id": 1, "user_id": 123, "product": "Laptop", "quantity": 2, "price": 1500.50, "created_at": "2025-05-07T12:34:56Z"}]}
+type OrdersListResponse struct {
+	// Количество заказов на странице
+	Limit int `json:"limit" example:"20"`
+
+	// Общее количество заказов пользователя (только с include_total=true)
+	Total *int64 `json:"total,omitempty" example:"42"`
+
+	// Курсор следующей страницы (нет - страница последняя)
+	NextCursor string `json:"next_cursor,omitempty" example:"eyJxIjoiOWMxZTRhIiwiaWQiOjIwfQ.c2lnbmF0dXJl"`
+
+	// Курсор предыдущей страницы (нет - страница первая)
+	PrevCursor string `json:"prev_cursor,omitempty" example:"eyJxIjoiOWMxZTRhIiwiYiI6dHJ1ZSwiaWQiOjF9.c2lnbmF0dXJl"`
+
+	// Заказы страницы
+	Orders []OrderResponse `json:"orders"`
+}
+
+// ------------------------------------------------------------
+// Пагинация заказов
+// ------------------------------------------------------------
+
+// OrderListQuery
+// Параметры постраничного запроса заказов пользователя
+type OrderListQuery struct {
+	// Количество заказов на странице
+	Limit int
+
+	// Курсор из next_cursor или prev_cursor предыдущего ответа (пусто - первая страница)
+	Cursor string
+
+	// Подсчитывать общее количество заказов пользователя
+	IncludeTotal bool
+}
+
+// OrderPage
+// Страница заказов пользователя
+type OrderPage struct {
+	// Заказы страницы в порядке создания
+	Orders []Order
+
+	// Общее количество заказов (nil - не подсчитывалось)
+	Total *int64
+
+	// Курсоры следующей и предыдущей страницы (пусто - страницы нет)
+	NextCursor string
+	PrevCursor string
 }
@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// ---------------------- PASSWORD POLICY ---------------------
+// Определение структур политики паролей и истории паролей
+
+// Правила политики паролей (значения поля rule в ответе)
+const (
+	PasswordRuleMinLength    = "min_length"
+	PasswordRuleMaxLength    = "max_length"
+	PasswordRuleUppercase    = "uppercase"
+	PasswordRuleLowercase    = "lowercase"
+	PasswordRuleDigit        = "digit"
+	PasswordRuleSymbol       = "symbol"
+	PasswordRulePersonalInfo = "personal_info"
+	PasswordRuleBreached     = "breached"
+	PasswordRuleReused       = "reused"
+)
+
+// ------------------------------------------------------------
+// Структуры истории паролей
+// ------------------------------------------------------------
+
+// PasswordHistory
+// Хеш одного из прежних паролей пользователя
+type PasswordHistory struct {
+	// Уникальный идентификатор записи
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// Идентификатор пользователя
+	UserID uint `gorm:"not null;index" json:"user_id"`
+
+	// Хеш прежнего пароля
+	PasswordHash string `gorm:"type:varchar(255);not null" json:"-"`
+
+	// Дата и время смены пароля
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName задает имя таблицы истории паролей
+func (PasswordHistory) TableName() string {
+	return "password_history"
+}
+
+// ------------------------------------------------------------
+// Request/Response
+// ------------------------------------------------------------
+
+// PasswordPolicyViolation (DTO)
+// Нарушенное правило политики паролей
+// @Description Нарушенное правило политики паролей
+type PasswordPolicyViolation struct {
+	// Код правила
+	Rule string `json:"rule" example:"min_length"`
+
+	// Описание нарушения
+	Message string `json:"message" example:"Пароль должен содержать не менее 8 символов"`
+}
+
+// PasswordPolicyErrorResponse (DTO)
+// Ответ с ошибкой политики паролей
+// @Description Пароль не соответствует политике; перечислены все нарушенные правила
+// @Schema example: {"error": "Пароль не соответствует требованиям. ", "violations": [{"rule": "min_length", "message": "Пароль должен содержать не менее 8 символов"}]}
+type PasswordPolicyErrorResponse struct {
+	// Текст ошибки
+	Error string `json:"error" example:"Пароль не соответствует требованиям. "`
+
+	// Нарушенные правила
+	Violations []PasswordPolicyViolation `json:"violations"`
+}
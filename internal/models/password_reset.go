@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// ---------------------- PASSWORD RESET ----------------------
+// Определение структур сброса пароля и их отношений к БД
+
+// ------------------------------------------------------------
+// Структуры токенов сброса пароля
+// ------------------------------------------------------------
+
+// PasswordResetToken
+// Одноразовый токен сброса пароля (хранится только хеш)
+type PasswordResetToken struct {
+	// Уникальный идентификатор записи
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// Идентификатор пользователя, запросившего сброс
+	UserID uint `gorm:"not null;index" json:"user_id"`
+
+	// SHA-256 хеш непрозрачного токена
+	TokenHash string `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+
+	// Дата и время истечения токена
+	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
+
+	// Дата и время использования токена (nil - токен не использован)
+	UsedAt *time.Time `json:"used_at"`
+
+	// Дата и время выдачи токена
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// ------------------------------------------------------------
+// Request/Response
+// ------------------------------------------------------------
+
+// ForgotPasswordRequest (DTO)
+// Структура запроса на сброс пароля
+// @Description Email, на который будет отправлена ссылка для сброса пароля
+// @Schema example: {"email": "john@example.com"}
+type ForgotPasswordRequest struct {
+	// Email пользователя
+	Email string `json:"email" binding:"required,email" example:"john@example.com"`
+}
+
+// ResetPasswordRequest (DTO)
+// Структура запроса на установку нового пароля
+// @Description Токен из письма и новый пароль
+// @Schema example: {"token": "Zm9vYmFyYmF6...", "password": "newsecurepassword123"}
+type ResetPasswordRequest struct {
+	// Токен сброса пароля из письма
+	Token string `json:"token" binding:"required" example:"Zm9vYmFyYmF6..."`
+
+	// Новый пароль (проверяется политикой паролей)
+	Password string `json:"password" binding:"required" example:"newsecurepassword123"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// ---------------------- REFRESH TOKEN -----------------------
+// Определение структуры refresh токена и ее отношения к БД
+
+// ------------------------------------------------------------
+// Структуры refresh токенов
+// ------------------------------------------------------------
+
+// RefreshToken
+// Серверная запись refresh токена (хранится только хеш)
+type RefreshToken struct {
+	// Уникальный идентификатор записи
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// Идентификатор пользователя, которому выдан токен
+	UserID uint `gorm:"not null;index" json:"user_id"`
+
+	// Идентификатор семейства токенов (цепочки ротаций одного входа)
+	FamilyID string `gorm:"type:varchar(64);not null;index" json:"family_id"`
+
+	// SHA-256 хеш непрозрачного токена
+	TokenHash string `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+
+	// Дата и время истечения токена
+	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
+
+	// Дата и время отзыва или ротации токена (nil - токен активен)
+	RevokedAt *time.Time `json:"revoked_at"`
+
+	// Дата и время выдачи токена
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// ---------------------- REVOKED TOKEN -----------------------
+// Определение структуры отозванного access токена и ее отношения к БД
+
+// ------------------------------------------------------------
+// Структуры отозванных токенов
+// ------------------------------------------------------------
+
+// RevokedToken
+// Запись об отозванном до истечения срока access токене
+type RevokedToken struct {
+	// Уникальный идентификатор токена (claim jti)
+	JTI string `gorm:"column:jti;type:varchar(64);primaryKey" json:"jti"`
+
+	// Идентификатор пользователя, которому был выдан токен
+	UserID uint `gorm:"not null" json:"user_id"`
+
+	// Время истечения токена, после которого запись можно удалить
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+
+	// Дата и время отзыва токена
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// ------------------------- SESSION --------------------------
+// Определение структур сессий пользователя и их отношений к БД
+
+// ------------------------------------------------------------
+// Структуры сессий
+// ------------------------------------------------------------
+
+// Session
+// Сессия входа на одном устройстве. Идентификатор совпадает с семейством refresh токенов
+type Session struct {
+	// Идентификатор сессии (семейство refresh токенов, claim sid в access токене)
+	ID string `gorm:"type:varchar(64);primaryKey" json:"id"`
+
+	// Идентификатор пользователя
+	UserID uint `gorm:"not null;index" json:"user_id"`
+
+	// IP адрес клиента при входе
+	IPAddress string `gorm:"type:varchar(64)" json:"ip_address"`
+
+	// User-Agent клиента при входе
+	UserAgent string `gorm:"type:varchar(512)" json:"user_agent"`
+
+	// Дата и время входа
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	// Дата и время последнего запроса в рамках сессии
+	LastSeenAt time.Time `gorm:"not null" json:"last_seen_at"`
+
+	// Дата и время завершения сессии (nil - сессия активна)
+	RevokedAt *time.Time `json:"revoked_at"`
+}
+
+// ------------------------------------------------------------
+// Request/Response
+// ------------------------------------------------------------
+
+// SessionResponse (DTO)
+// Структура данных для ответа с информацией о сессии
+// @Description Активная сессия пользователя
+// @Schema example: {"id": "0b9c6f0e-5d1f-4a51-9d55-8d7f1f3f8a2e", "ip_address": "203.0.113.10", "user_agent": "Mozilla/5.0", "created_at": "2025-05-07T12:34:56Z", "last_seen_at": "2025-05-07T13:00:00Z", "current": true}
+type SessionResponse struct {
+	// Идентификатор сессии
+	ID string `json:"id" example:"0b9c6f0e-5d1f-4a51-9d55-8d7f1f3f8a2e"`
+
+	// IP адрес клиента при входе
+	IPAddress string `json:"ip_address" example:"203.0.113.10"`
+
+	// User-Agent клиента при входе
+	UserAgent string `json:"user_agent" example:"Mozilla/5.0"`
+
+	// Дата и время входа
+	CreatedAt time.Time `json:"created_at" example:"2025-05-07T12:34:56Z"`
+
+	// Дата и время последнего запроса
+	LastSeenAt time.Time `json:"last_seen_at" example:"2025-05-07T13:00:00Z"`
+
+	// Признак сессии, из которой выполнен запрос
+	Current bool `json:"current" example:"true"`
+}
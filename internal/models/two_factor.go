@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// ------------------------ TWO FACTOR ------------------------
+// Определение структур двухфакторной аутентификации и их отношений к БД
+
+// ------------------------------------------------------------
+// Структуры кодов восстановления
+// ------------------------------------------------------------
+
+// RecoveryCode
+// Одноразовый код восстановления для входа без приложения-аутентификатора (хранится только хеш)
+type RecoveryCode struct {
+	// Уникальный идентификатор записи
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// Идентификатор пользователя - владельца кода
+	UserID uint `gorm:"not null;index" json:"user_id"`
+
+	// SHA-256 хеш кода
+	CodeHash string `gorm:"type:varchar(64);not null" json:"-"`
+
+	// Дата и время использования кода (nil - код не использован)
+	UsedAt *time.Time `json:"used_at"`
+
+	// Дата и время создания кода
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// ------------------------------------------------------------
+// Request/Response
+// ------------------------------------------------------------
+
+// TwoFactorEnrollResponse (DTO)
+// Структура ответа на подключение 2FA
+// @Description Секрет TOTP и otpauth URI для приложения-аутентификатора
+// @Schema example: {"secret": "JBSWY3DPEHPK3PXP", "otpauth_uri": "otpauth://totp/khrllwTest:john@example.com?secret=JBSWY3DPEHPK3PXP&issuer=khrllwTest"}
+type TwoFactorEnrollResponse struct {
+	// Секрет TOTP в base32 для ручного ввода
+	Secret string `json:"secret" example:"JBSWY3DPEHPK3PXP"`
+
+	// URI для QR-кода
+	OTPAuthURI string `json:"otpauth_uri" example:"otpauth://totp/khrllwTest:john@example.com?secret=JBSWY3DPEHPK3PXP&issuer=khrllwTest"`
+}
+
+// TwoFactorCodeRequest (DTO)
+// Структура запроса с кодом 2FA
+// @Description Код из приложения-аутентификатора или код восстановления
+// @Schema example: {"code": "123456"}
+type TwoFactorCodeRequest struct {
+	// Код из приложения-аутентификатора или код восстановления
+	Code string `json:"code" binding:"required" example:"123456"`
+}
+
+// RecoveryCodesResponse (DTO)
+// Структура ответа с кодами восстановления
+// @Description Одноразовые коды восстановления. Показываются только один раз
+// @Schema example: {"recovery_codes": ["abcd-efgh", "ijkl-mnop"]}
+type RecoveryCodesResponse struct {
+	// Коды восстановления
+	RecoveryCodes []string `json:"recovery_codes" example:"abcd-efgh"`
+}
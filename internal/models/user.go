@@ -1,8 +1,121 @@
 package models
 
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
 // --------------------------- USER ---------------------------
 // Определение структур данных пользователя и их отношений к БД
 
+// ------------------------------------------------------------
+// Роли пользователей
+// ------------------------------------------------------------
+
+const (
+	// RoleUser обычный пользователь, управляющий только своим аккаунтом
+	RoleUser = "user"
+
+	// RoleAdmin администратор (поддержка), управляющий любыми аккаунтами
+	RoleAdmin = "admin"
+)
+
+// ------------------------------------------------------------
+// Ограничения полей
+// ------------------------------------------------------------
+
+const (
+	// MinUserAge и MaxUserAge допустимый возраст пользователя. Одинаковы для создания, обновления
+	// и частичного обновления; теги binding запросов ниже должны совпадать с ними
+	MinUserAge = 1
+	MaxUserAge = 150
+)
+
+// ------------------------------------------------------------
+// Поиск и сортировка
+// ------------------------------------------------------------
+
+// UserSortColumns белый список полей сортировки списка пользователей:
+// публичное имя поля (как в UserResponse) -> колонка таблицы users
+var UserSortColumns = map[string]string{
+	"id":    "id",
+	"name":  "name",
+	"email": "email",
+	"age":   "age",
+	"role":  "role",
+}
+
+// UserSortField
+// Поле сортировки списка пользователей
+type UserSortField struct {
+	// Публичное имя поля из UserSortColumns
+	Field string
+
+	// Сортировка по убыванию
+	Desc bool
+}
+
+// UserFilter
+// Параметры поиска, фильтрации и сортировки списка пользователей
+type UserFilter struct {
+	// Подстрока имени или email (без учета регистра)
+	Search string
+
+	// Домен email (часть после @, без учета регистра)
+	EmailDomain string
+
+	// Минимальный и максимальный возраст (0 - без ограничения)
+	MinAge int
+	MaxAge int
+
+	// Порядок сортировки (пусто - по ID)
+	Sort []UserSortField
+}
+
+// UserKeyset
+// Граница страницы keyset-пагинации списка пользователей
+type UserKeyset struct {
+	// Крайняя строка соседней страницы (используются только поля сортировки и ID)
+	Boundary User
+
+	// Выбор строк перед границей (предыдущая страница)
+	Backward bool
+}
+
+// UserListQuery
+// Параметры запроса списка пользователей
+type UserListQuery struct {
+	// Номер страницы (offset-пагинация, если курсор не задан)
+	Page int
+
+	// Количество пользователей на странице
+	Limit int
+
+	// Курсор keyset-пагинации из next_cursor или prev_cursor предыдущего ответа
+	Cursor string
+
+	// Подсчитывать общее количество пользователей при keyset-пагинации
+	IncludeTotal bool
+
+	// Поиск, фильтрация и сортировка
+	Filter UserFilter
+}
+
+// UserPage
+// Страница списка пользователей
+type UserPage struct {
+	// Пользователи страницы в порядке сортировки
+	Users []User
+
+	// Общее количество пользователей (nil - не подсчитывалось)
+	Total *int64
+
+	// Курсоры следующей и предыдущей страницы (пусто - страницы нет)
+	NextCursor string
+	PrevCursor string
+}
+
 // ------------------------------------------------------------
 // Структуры пользователя
 // ------------------------------------------------------------
@@ -16,8 +129,8 @@ type User struct {
 	// Имя пользователя
 	Name string `gorm:"type:varchar(255);not null" json:"name"`
 
-	// Email пользователя
-	Email string `gorm:"type:varchar(255);uniqueIndex;not null" json:"email"`
+	// Email пользователя (уникален среди неудаленных пользователей)
+	Email string `gorm:"type:varchar(255);uniqueIndex:idx_users_email_active,where:deleted_at IS NULL;not null" json:"email"`
 
 	// Возраст пользователя
 	Age int `gorm:"not null" json:"age"`
@@ -25,6 +138,36 @@ type User struct {
 	// Хэш пароля пользователя
 	PasswordHash string `gorm:"type:varchar(255);not null" json:"-"`
 
+	// Роль пользователя
+	Role string `gorm:"type:varchar(32);not null;default:user" json:"role"`
+
+	// Версия учетных данных (увеличивается при смене пароля, роли и "выходе везде";
+	// токены с прежней версией отклоняются)
+	TokenVersion int `gorm:"not null;default:0" json:"-"`
+
+	// Версия записи для оптимистичной блокировки (увеличивается при каждом изменении строки,
+	// отдается в заголовке ETag и сверяется с If-Match)
+	Version int `gorm:"not null;default:1" json:"-"`
+
+	// Секрет TOTP в base32 (задается при подключении 2FA)
+	TOTPSecret string `gorm:"column:totp_secret;type:varchar(64)" json:"-"`
+
+	// Признак подтвержденной двухфакторной аутентификации
+	TOTPEnabled bool `gorm:"column:totp_enabled;not null;default:false" json:"-"`
+
+	// Шаг TOTP последнего принятого кода (коды этого и более ранних шагов повторно не принимаются)
+	TOTPLastStep int64 `gorm:"column:totp_last_step;not null;default:0" json:"-"`
+
+	// Дата и время подтверждения email (nil - email не подтвержден)
+	EmailVerifiedAt *time.Time `json:"-"`
+
+	// Префикс ключей миниатюр аватара в файловом хранилище (пусто - аватар не загружен)
+	AvatarKey string `gorm:"type:varchar(255)" json:"-"`
+
+	// Дата и время мягкого удаления (пусто - аккаунт активен). Удаленный пользователь скрыт
+	// из выборок GORM и окончательно удаляется вместе с заказами после срока восстановления
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
 	// Список заказов пользователя
 	Orders []Order `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;" json:"-"`
 }
@@ -45,10 +188,10 @@ type CreateUserRequest struct {
 	Email string `json:"email"    binding:"required,email,max=255" example:"john@example.com"`
 
 	// Возраст пользователя
-	Age int `json:"age"      binding:"required,gte=0" example:"30"`
+	Age int `json:"age"      binding:"required,gte=1,lte=150" example:"30"`
 
-	// Введенный пользователем пароль
-	Password string `json:"password" binding:"required,min=8" example:"securepassword123"`
+	// Введенный пользователем пароль (проверяется политикой паролей)
+	Password string `json:"password" binding:"required" example:"securepassword123"`
 }
 
 // UpdateUserRequest
@@ -63,13 +206,28 @@ type UpdateUserRequest struct {
 	Email string `json:"email"    binding:"required,email,max=255" example:"john@example.com"`
 
 	// Возраст пользователя
-	Age int `json:"age"      binding:"required,gte=0" example:"30"`
+	Age int `json:"age"      binding:"required,gte=1,lte=150" example:"30"`
+}
+
+// PatchUserRequest
+// Частичное обновление пользователя (JSON Merge Patch, RFC 7386)
+// @Description Изменяемые поля пользователя; обновляются только переданные поля. Значение null и другие поля не допускаются
+// @Schema example: {"age": 31}
+type PatchUserRequest struct {
+	// Имя пользователя
+	Name *string `json:"name,omitempty"  binding:"omitempty,min=1,max=255" example:"John Doe"`
+
+	// Email пользователя (изменение сбрасывает подтверждение email)
+	Email *string `json:"email,omitempty" binding:"omitempty,email,max=255" example:"john@example.com"`
+
+	// Возраст пользователя
+	Age *int `json:"age,omitempty"   binding:"omitempty,gte=1,lte=150" example:"31"`
 }
 
 // UserResponse (DTO)
 // Структура данных для ответа на запросы о пользователях
 // @Description Структура ответа, содержащая информацию о пользователе
-// @Schema example: {"id": 1, "name": "John Doe", "email": "john@example.com", "age": 30}
+// @Schema example: {"id": 1, "name": "John Doe", "email": "john@example.com", "age": 30, "role": "user", "email_verified": true}
 type UserResponse struct {
 	// Уникальный идентификатор пользователя
 	ID uint `json:"id" example:"1"`
@@ -82,21 +240,90 @@ type UserResponse struct {
 
 	// Возраст пользователя
 	Age int `json:"age" example:"30"`
+
+	// Роль пользователя
+	Role string `json:"role" example:"user"`
+
+	// Признак подтвержденного email
+	EmailVerified bool `json:"email_verified" example:"true"`
+
+	// Адрес аватара пользователя (нет - аватар не загружен)
+	AvatarURL string `json:"avatar_url,omitempty" example:"/users/1/avatar"`
+}
+
+// UpdateUserRoleRequest
+// Структура данных для изменения роли пользователя
+// @Description Структура для запроса на изменение роли пользователя (только для администраторов)
+// @Schema example: {"role": "admin"}
+type UpdateUserRoleRequest struct {
+	// Новая роль пользователя
+	Role string `json:"role" binding:"required,oneof=user admin" example:"admin"`
+}
+
+// ChangePasswordRequest
+// Структура данных для смены пароля
+// @Description Структура для запроса на смену пароля. Требует текущий пароль
+// @Schema example: {"current_password": "securepassword123", "new_password": "newsecurepassword123"}
+type ChangePasswordRequest struct {
+	// Текущий пароль
+	CurrentPassword string `json:"current_password" binding:"required" example:"securepassword123"`
+
+	// Новый пароль (проверяется политикой паролей)
+	NewPassword string `json:"new_password" binding:"required" example:"newsecurepassword123"`
+}
+
+// VerifyEmailRequest
+// Структура данных для подтверждения email
+// @Description Токен из ссылки в письме подтверждения
+// @Schema example: {"token": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."}
+type VerifyEmailRequest struct {
+	// Токен подтверждения из письма
+	Token string `json:"token" binding:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+}
+
+// UsersListFilters
+// Примененные к списку пользователей фильтры
+// @Description Фильтры, с которыми выбран список пользователей (пустые не выводятся)
+type UsersListFilters struct {
+	// Подстрока имени или email
+	Search string `json:"search,omitempty" example:"john"`
+
+	// Домен email
+	EmailDomain string `json:"email_domain,omitempty" example:"example.com"`
+
+	// Минимальный возраст
+	MinAge int `json:"min_age,omitempty" example:"18"`
+
+	// Максимальный возраст
+	MaxAge int `json:"max_age,omitempty" example:"65"`
 }
 
 // UsersListResponse
 // Ответ со списком пользователей и метаданными пагинации
-// @Description Структура ответа с пользователями и информацией о пагинации
-// @Schema example: {"page": 1, "limit": 10, "total": 100, "users": [{"id": 1, "name": "John Doe", "email": "john@example.com", "age": 30}]}
+// @Description Структура ответа с пользователями, информацией о пагинации, примененными фильтрами и сортировкой.
+// @Description При keyset-пагинации (параметр cursor) page не возвращается, а total - только с include_total=true
+// @Schema example: {"page": 1, "limit": 10, "total": 100, "filters": {"search": "john"}, "sort": "-age,name", "next_cursor": "eyJxIjoi...", "users": [{"id": 1, "name": "John Doe", "email": "john@example.com", "age": 30}]}
 type UsersListResponse struct {
-	// Номер текущей страницы в результате пагинации
-	Page int `json:"page" example:"1"`
+	// Номер текущей страницы при offset-пагинации
+	Page int `json:"page,omitempty" example:"1"`
 
 	// Количество элементов (пользователей) на одной странице
 	Limit int `json:"limit" example:"10"`
 
 	// Общее количество пользователей, соответствующих запросу (до применения пагинации)
-	Total int64 `json:"total" example:"100"`
+	Total *int64 `json:"total,omitempty" example:"100"`
+
+	// Курсор следующей страницы (нет - страница последняя)
+	NextCursor string `json:"next_cursor,omitempty" example:"eyJxIjoiM2YyYTFiIiwiaWQiOjEwfQ.c2lnbmF0dXJl"`
+
+	// Курсор предыдущей страницы (нет - страница первая)
+	PrevCursor string `json:"prev_cursor,omitempty" example:"eyJxIjoiM2YyYTFiIiwiYiI6dHJ1ZSwiaWQiOjF9.c2lnbmF0dXJl"`
+
+	// Примененные фильтры
+	Filters UsersListFilters `json:"filters"`
+
+	// Примененная сортировка: поля через запятую, "-" перед полем - по убыванию
+	Sort string `json:"sort" example:"-age,name"`
 
 	// Список пользователей на текущей странице
 	Users []UserResponse `json:"users"`
@@ -0,0 +1,134 @@
+package oidc
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultScopes области доступа, запрашиваемые по умолчанию
+const defaultScopes = "openid email profile"
+
+// providerNamePattern допустимые имена провайдеров (используются в URL и именах переменных окружения)
+var providerNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// ------------------------------------------------------------
+// Конфигурация
+// ------------------------------------------------------------
+
+// ProviderConfig содержит настройки OpenID Connect провайдера
+type ProviderConfig struct {
+	// Имя провайдера в URL: /auth/oidc/{name}/login
+	Name string
+
+	// Издатель (iss); документ discovery загружается с {Issuer}/.well-known/openid-configuration
+	Issuer string
+
+	// Идентификатор и секрет клиента (секрет необязателен для публичных клиентов с PKCE)
+	ClientID     string
+	ClientSecret string
+
+	// Адрес обратного вызова, зарегистрированный у провайдера
+	RedirectURL string
+
+	// Запрашиваемые области доступа
+	Scopes []string
+
+	// Создавать пользователя при первом входе, если аккаунт с таким email не найден
+	AutoCreate bool
+
+	// Допуск расхождения часов при проверке ID токена
+	Leeway time.Duration
+}
+
+// LoadProviderConfigs читает провайдеров из переменных окружения.
+// OIDC_PROVIDERS содержит имена через запятую, настройки каждого провайдера задаются
+// переменными OIDC_{ИМЯ}_ISSUER, _CLIENT_ID, _CLIENT_SECRET, _REDIRECT_URL, _SCOPES, _AUTO_CREATE.
+func LoadProviderConfigs(leeway time.Duration) ([]*ProviderConfig, error) {
+	raw := os.Getenv("OIDC_PROVIDERS")
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	var configs []*ProviderConfig
+	seen := make(map[string]bool)
+
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !providerNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("неверное имя провайдера OIDC %q. Допустимы строчные латинские буквы, цифры, '-' и '_'", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("провайдер OIDC %q указан дважды", name)
+		}
+		seen[name] = true
+
+		config, err := loadProviderConfig(name, leeway)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, config)
+	}
+
+	return configs, nil
+}
+
+// loadProviderConfig читает настройки одного провайдера
+func loadProviderConfig(name string, leeway time.Duration) (*ProviderConfig, error) {
+	prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+
+	config := &ProviderConfig{
+		Name:         name,
+		Issuer:       strings.TrimSuffix(os.Getenv(prefix+"ISSUER"), "/"),
+		ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+		ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+		RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+		AutoCreate:   true,
+		Leeway:       leeway,
+	}
+
+	for variable, value := range map[string]string{
+		"ISSUER":       config.Issuer,
+		"CLIENT_ID":    config.ClientID,
+		"REDIRECT_URL": config.RedirectURL,
+	} {
+		if value == "" {
+			return nil, fmt.Errorf("%s%s переменная окружения не установлена", prefix, variable)
+		}
+	}
+
+	scopes := os.Getenv(prefix + "SCOPES")
+	if scopes == "" {
+		scopes = defaultScopes // значение по умолчанию
+	}
+	config.Scopes = strings.Fields(strings.ReplaceAll(scopes, ",", " "))
+	if !containsScope(config.Scopes, "openid") {
+		config.Scopes = append([]string{"openid"}, config.Scopes...)
+	}
+
+	if value := os.Getenv(prefix + "AUTO_CREATE"); value != "" {
+		autoCreate, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("неверное значение %sAUTO_CREATE. Ожидается true или false", prefix)
+		}
+		config.AutoCreate = autoCreate
+	}
+
+	return config, nil
+}
+
+// containsScope проверяет наличие области доступа в списке
+func containsScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,190 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"khrllwTest/internal/models"
+)
+
+// keysRefreshInterval минимальный интервал повторной загрузки JWKS при неизвестном kid,
+// чтобы токены с произвольным kid не вызывали запрос к провайдеру на каждый вход
+const keysRefreshInterval = time.Minute
+
+// ------------------------------------------------------------
+// Структуры
+// ------------------------------------------------------------
+
+// jsonWebKey открытый ключ провайдера в формате JWK (RFC 7517, 7518, 8037)
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+	Curve   string `json:"crv"`
+	X       string `json:"x"`
+	Y       string `json:"y"`
+}
+
+// remoteKeySet кэширует открытые ключи провайдера и обновляет их при появлении нового kid
+type remoteKeySet struct {
+	client  *http.Client
+	jwksURI string
+
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// newRemoteKeySet создает набор ключей, загружаемый по адресу jwks_uri
+func newRemoteKeySet(client *http.Client, jwksURI string) *remoteKeySet {
+	return &remoteKeySet{client: client, jwksURI: jwksURI}
+}
+
+// ------------------------------------------------------------
+// Основные методы
+// ------------------------------------------------------------
+
+// Lookup возвращает открытый ключ по kid. Токен без kid допускается, если у провайдера один ключ.
+func (s *remoteKeySet) Lookup(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key, ok := s.find(kid); ok {
+		return key, nil
+	}
+
+	// Ключа нет в кэше: провайдер мог выполнить ротацию
+	if s.keys != nil && time.Since(s.fetchedAt) < keysRefreshInterval {
+		return nil, fmt.Errorf("неизвестный ключ подписи %q", kid)
+	}
+	if err := s.refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	if key, ok := s.find(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("неизвестный ключ подписи %q", kid)
+}
+
+// ------------------------------------------------------------
+// Вспомогательные методы
+// ------------------------------------------------------------
+
+// find ищет ключ в кэше
+func (s *remoteKeySet) find(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+// refresh загружает JWKS провайдера; ключи шифрования и неподдерживаемые типы пропускаются
+func (s *remoteKeySet) refresh(ctx context.Context) error {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := getJSON(ctx, s.client, s.jwksURI, &set); err != nil {
+		return err
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.KeyID] = key
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("%w: JWKS провайдера не содержит ключей подписи", models.ErrOIDCProviderUnavailable)
+	}
+
+	s.keys = keys
+	s.fetchedAt = time.Now()
+	return nil
+}
+
+// publicKey преобразует JWK в открытый ключ
+func (k *jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil || !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("неверная экспонента RSA ключа %q", k.KeyID)
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("неподдерживаемая кривая %q", k.Curve)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("точка ключа %q не лежит на кривой", k.KeyID)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+
+	case "OKP":
+		if k.Curve != "Ed25519" {
+			return nil, fmt.Errorf("неподдерживаемая кривая %q", k.Curve)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("неверный Ed25519 ключ %q", k.KeyID)
+		}
+		return ed25519.PublicKey(x), nil
+	}
+
+	return nil, fmt.Errorf("неподдерживаемый тип ключа %q", k.KeyType)
+}
+
+// decodeBigInt декодирует целое число из base64url
+func decodeBigInt(value string) (*big.Int, error) {
+	bytes, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(bytes) == 0 {
+		return nil, fmt.Errorf("неверное значение ключа")
+	}
+	return new(big.Int).SetBytes(bytes), nil
+}
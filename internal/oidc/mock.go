@@ -0,0 +1,314 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// mockCodeTTL время жизни кода авторизации тестового провайдера
+const mockCodeTTL = time.Minute
+
+// mockKeyID идентификатор ключа подписи тестового провайдера
+const mockKeyID = "mock-idp-key"
+
+// ------------------------------------------------------------
+// Структуры
+// ------------------------------------------------------------
+
+// MockUser пользователь тестового провайдера
+type MockUser struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Birthdate     string
+}
+
+// mockAuthCode выданный код авторизации и параметры запроса, к которому он привязан
+type mockAuthCode struct {
+	clientID      string
+	redirectURI   string
+	codeChallenge string
+	nonce         string
+	user          MockUser
+	expiresAt     time.Time
+}
+
+// MockIdP минимальный OpenID Connect провайдер для тестов и локальной разработки.
+// Страница авторизации сразу одобряет вход: пользователь выбирается параметром login_hint (email),
+// иначе используется пользователь по умолчанию. Поддерживается только authorization code с PKCE S256.
+type MockIdP struct {
+	issuer       string
+	clientID     string
+	clientSecret string
+	key          *rsa.PrivateKey
+
+	mu          sync.Mutex
+	users       map[string]MockUser
+	defaultUser MockUser
+	codes       map[string]*mockAuthCode
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewMockIdP создает тестовый провайдер с новым RSA ключом подписи
+func NewMockIdP(issuer, clientID, clientSecret string, defaultUser MockUser) (*MockIdP, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	idp := &MockIdP{
+		issuer:       strings.TrimSuffix(issuer, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		key:          key,
+		users:        make(map[string]MockUser),
+		codes:        make(map[string]*mockAuthCode),
+	}
+	idp.AddUser(defaultUser)
+	idp.defaultUser = defaultUser
+	return idp, nil
+}
+
+// ------------------------------------------------------------
+// Основные методы
+// ------------------------------------------------------------
+
+// SetIssuer задает издателя (нужно, когда адрес сервера известен только после запуска)
+func (m *MockIdP) SetIssuer(issuer string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.issuer = strings.TrimSuffix(issuer, "/")
+}
+
+// AddUser добавляет пользователя, которого можно выбрать через login_hint
+func (m *MockIdP) AddUser(user MockUser) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.users[strings.ToLower(user.Email)] = user
+}
+
+// Handler возвращает HTTP обработчик провайдера
+func (m *MockIdP) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", m.handleDiscovery)
+	mux.HandleFunc("/authorize", m.handleAuthorize)
+	mux.HandleFunc("/token", m.handleToken)
+	mux.HandleFunc("/jwks", m.handleJWKS)
+	return mux
+}
+
+// SignIDToken подписывает ID токен ключом провайдера (для проверки обработки некорректных токенов)
+func (m *MockIdP) SignIDToken(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = mockKeyID
+	return token.SignedString(m.key)
+}
+
+// ------------------------------------------------------------
+// Обработчики
+// ------------------------------------------------------------
+
+// handleDiscovery отдает документ OpenID Provider Metadata
+func (m *MockIdP) handleDiscovery(w http.ResponseWriter, r *http.Request) {
+	issuer := m.currentIssuer()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                issuer,
+		"authorization_endpoint":                issuer + "/authorize",
+		"token_endpoint":                        issuer + "/token",
+		"jwks_uri":                              issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+// handleAuthorize сразу одобряет вход и перенаправляет на redirect_uri с кодом авторизации
+func (m *MockIdP) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("response_type") != "code" || query.Get("client_id") != m.clientID {
+		http.Error(w, "unsupported response_type or unknown client_id", http.StatusBadRequest)
+		return
+	}
+	if query.Get("code_challenge") == "" || query.Get("code_challenge_method") != "S256" {
+		http.Error(w, "PKCE S256 is required", http.StatusBadRequest)
+		return
+	}
+
+	redirectURI, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || !redirectURI.IsAbs() {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := m.selectUser(query.Get("login_hint"))
+	if !ok {
+		redirectWithParams(w, r, redirectURI, url.Values{"error": {"access_denied"}, "state": {query.Get("state")}})
+		return
+	}
+
+	code := randomString()
+	m.mu.Lock()
+	m.codes[code] = &mockAuthCode{
+		clientID:      m.clientID,
+		redirectURI:   redirectURI.String(),
+		codeChallenge: query.Get("code_challenge"),
+		nonce:         query.Get("nonce"),
+		user:          user,
+		expiresAt:     time.Now().Add(mockCodeTTL),
+	}
+	m.mu.Unlock()
+
+	redirectWithParams(w, r, redirectURI, url.Values{"code": {code}, "state": {query.Get("state")}})
+}
+
+// handleToken обменивает код авторизации на ID токен, проверяя клиента, redirect_uri и code_verifier
+func (m *MockIdP) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ParseForm() != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeTokenError(w, "unsupported_grant_type", "only authorization_code is supported")
+		return
+	}
+
+	if !m.authenticateClient(r) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	m.mu.Lock()
+	code, ok := m.codes[r.PostForm.Get("code")]
+	delete(m.codes, r.PostForm.Get("code")) // код одноразовый
+	m.mu.Unlock()
+
+	switch {
+	case !ok || time.Now().After(code.expiresAt):
+		writeTokenError(w, "invalid_grant", "unknown or expired code")
+		return
+	case code.redirectURI != r.PostForm.Get("redirect_uri"):
+		writeTokenError(w, "invalid_grant", "redirect_uri mismatch")
+		return
+	case CodeChallengeS256(r.PostForm.Get("code_verifier")) != code.codeChallenge:
+		writeTokenError(w, "invalid_grant", "code_verifier mismatch")
+		return
+	}
+
+	now := time.Now()
+	idToken, err := m.SignIDToken(&IDTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    m.currentIssuer(),
+			Subject:   code.user.Subject,
+			Audience:  jwt.ClaimStrings{code.clientID},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(5 * time.Minute)),
+		},
+		Nonce:         code.nonce,
+		Email:         code.user.Email,
+		EmailVerified: code.user.EmailVerified,
+		Name:          code.user.Name,
+		Birthdate:     code.user.Birthdate,
+	})
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+// handleJWKS отдает открытый ключ подписи
+func (m *MockIdP) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": mockKeyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(m.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(m.key.E)).Bytes()),
+		}},
+	})
+}
+
+// ------------------------------------------------------------
+// Вспомогательные методы
+// ------------------------------------------------------------
+
+// currentIssuer возвращает издателя
+func (m *MockIdP) currentIssuer() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.issuer
+}
+
+// selectUser выбирает пользователя по login_hint или возвращает пользователя по умолчанию
+func (m *MockIdP) selectUser(hint string) (MockUser, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if hint == "" {
+		return m.defaultUser, true
+	}
+	user, ok := m.users[strings.ToLower(hint)]
+	return user, ok
+}
+
+// authenticateClient проверяет client_secret_basic или client_id публичного клиента
+func (m *MockIdP) authenticateClient(r *http.Request) bool {
+	id, secret, ok := r.BasicAuth()
+	if !ok {
+		return m.clientSecret == "" && r.PostForm.Get("client_id") == m.clientID
+	}
+
+	id, _ = url.QueryUnescape(id)
+	secret, _ = url.QueryUnescape(secret)
+	return id == m.clientID && subtle.ConstantTimeCompare([]byte(secret), []byte(m.clientSecret)) == 1
+}
+
+// redirectWithParams перенаправляет на адрес с дополнительными параметрами запроса
+func redirectWithParams(w http.ResponseWriter, r *http.Request, target *url.URL, params url.Values) {
+	redirect := *target
+	query := redirect.Query()
+	for name, values := range params {
+		query[name] = values
+	}
+	redirect.RawQuery = query.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+// writeTokenError отправляет ошибку token endpoint (RFC 6749, 5.2)
+func writeTokenError(w http.ResponseWriter, code, description string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{"error": code, "error_description": description})
+}
+
+// writeJSON отправляет JSON ответ
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// randomString создает случайную строку для кодов и токенов
+func randomString() string {
+	bytes := make([]byte, 24)
+	_, _ = rand.Read(bytes)
+	return base64.RawURLEncoding.EncodeToString(bytes)
+}
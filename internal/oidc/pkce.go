@@ -0,0 +1,25 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// codeVerifierSize количество случайных байт в code_verifier (43 символа base64url, RFC 7636)
+const codeVerifierSize = 32
+
+// NewCodeVerifier создает случайный PKCE code_verifier
+func NewCodeVerifier() (string, error) {
+	bytes := make([]byte, codeVerifierSize)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// CodeChallengeS256 вычисляет code_challenge для метода S256
+func CodeChallengeS256(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
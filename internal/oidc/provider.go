@@ -0,0 +1,271 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"khrllwTest/internal/models"
+)
+
+// httpTimeout время ожидания ответа провайдера
+const httpTimeout = 10 * time.Second
+
+// maxResponseSize ограничение размера ответа провайдера
+const maxResponseSize = 1 << 20
+
+// idTokenMethods алгоритмы подписи ID токена, которые принимаются от провайдера
+var idTokenMethods = []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// ------------------------------------------------------------
+// Структуры
+// ------------------------------------------------------------
+
+// discoveryDocument содержит используемые поля документа OpenID Provider Metadata
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// tokenResponse ответ token endpoint
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// IDTokenClaims содержимое проверенного ID токена
+type IDTokenClaims struct {
+	jwt.RegisteredClaims
+
+	// Значение nonce из запроса авторизации
+	Nonce string `json:"nonce"`
+
+	// Авторизованная сторона (обязательна при нескольких получателях)
+	AuthorizedParty string `json:"azp,omitempty"`
+
+	// Email пользователя
+	Email string `json:"email"`
+
+	// Подтвержден ли email провайдером
+	EmailVerified bool `json:"email_verified"`
+
+	// Полное имя пользователя
+	Name string `json:"name"`
+
+	// Дата рождения в формате YYYY-MM-DD (область profile; провайдер может не передавать ее
+	// или скрыть год: 0000-MM-DD)
+	Birthdate string `json:"birthdate,omitempty"`
+}
+
+// Provider выполняет вход через OpenID Connect по схеме authorization code с PKCE
+type Provider struct {
+	config *ProviderConfig
+	client *http.Client
+
+	mu        sync.Mutex
+	discovery *discoveryDocument
+	keys      *remoteKeySet
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewProvider создает новый экземпляр Provider. Документ discovery загружается при первом обращении,
+// поэтому недоступность провайдера не мешает запуску сервиса.
+func NewProvider(config *ProviderConfig, client *http.Client) *Provider {
+	if client == nil {
+		client = &http.Client{Timeout: httpTimeout}
+	}
+	return &Provider{config: config, client: client}
+}
+
+// ------------------------------------------------------------
+// Основные методы
+// ------------------------------------------------------------
+
+// Config возвращает настройки провайдера
+func (p *Provider) Config() *ProviderConfig {
+	return p.config
+}
+
+// AuthCodeURL возвращает адрес страницы входа провайдера с параметрами state, nonce и PKCE (S256)
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := url.Parse(doc.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("%w: неверный authorization_endpoint: %v", models.ErrOIDCProviderUnavailable, err)
+	}
+
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.config.ClientID)
+	query.Set("redirect_uri", p.config.RedirectURL)
+	query.Set("scope", strings.Join(p.config.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", CodeChallengeS256(codeVerifier))
+	query.Set("code_challenge_method", "S256")
+	authURL.RawQuery = query.Encode()
+
+	return authURL.String(), nil
+}
+
+// Exchange обменивает код авторизации на ID токен и проверяет его
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*IDTokenClaims, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"code_verifier": {codeVerifier},
+		"client_id":     {p.config.ClientID},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, doc.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrOIDCProviderUnavailable, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	// client_secret_basic: идентификатор и секрет кодируются как form-urlencoded (RFC 6749, 2.3.1)
+	if p.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrOIDCProviderUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	var token tokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&token); err != nil {
+		return nil, fmt.Errorf("%w: неверный ответ token endpoint (HTTP %d)", models.ErrOIDCLoginFailed, resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK || token.Error != "" {
+		return nil, fmt.Errorf("%w: token endpoint вернул %s: %s", models.ErrOIDCLoginFailed, token.Error, token.ErrorDescription)
+	}
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("%w: ответ token endpoint не содержит id_token", models.ErrOIDCLoginFailed)
+	}
+
+	return p.VerifyIDToken(ctx, token.IDToken, nonce)
+}
+
+// VerifyIDToken проверяет подпись ID токена по JWKS провайдера, издателя, получателя, срок действия и nonce
+func (p *Provider) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (*IDTokenClaims, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &IDTokenClaims{}
+	_, err = jwt.ParseWithClaims(rawIDToken, claims,
+		func(token *jwt.Token) (interface{}, error) {
+			kid, _ := token.Header["kid"].(string)
+			return p.keys.Lookup(ctx, kid)
+		},
+		jwt.WithValidMethods(idTokenMethods),
+		jwt.WithIssuer(doc.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(p.config.Leeway),
+	)
+	if err != nil {
+		if errors.Is(err, models.ErrOIDCProviderUnavailable) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: неверный ID токен: %v", models.ErrOIDCLoginFailed, err)
+	}
+
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: ID токен не содержит sub", models.ErrOIDCLoginFailed)
+	}
+	if nonce == "" || claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce ID токена не совпадает с запросом", models.ErrOIDCLoginFailed)
+	}
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.config.ClientID {
+		return nil, fmt.Errorf("%w: azp ID токена не совпадает с клиентом", models.ErrOIDCLoginFailed)
+	}
+
+	return claims, nil
+}
+
+// ------------------------------------------------------------
+// Вспомогательные методы
+// ------------------------------------------------------------
+
+// discover загружает и кэширует документ discovery провайдера
+func (p *Provider) discover(ctx context.Context) (*discoveryDocument, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	var doc discoveryDocument
+	if err := getJSON(ctx, p.client, p.config.Issuer+"/.well-known/openid-configuration", &doc); err != nil {
+		return nil, err
+	}
+
+	// Издатель в документе должен совпадать с настроенным (OpenID Connect Discovery, 4.3)
+	if strings.TrimSuffix(doc.Issuer, "/") != p.config.Issuer {
+		return nil, fmt.Errorf("%w: issuer %q в документе discovery не совпадает с %q",
+			models.ErrOIDCProviderUnavailable, doc.Issuer, p.config.Issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: в документе discovery отсутствуют обязательные адреса", models.ErrOIDCProviderUnavailable)
+	}
+
+	p.discovery = &doc
+	p.keys = newRemoteKeySet(p.client, doc.JWKSURI)
+	return p.discovery, nil
+}
+
+// getJSON выполняет GET запрос и разбирает JSON ответ
+func getJSON(ctx context.Context, client *http.Client, target string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", models.ErrOIDCProviderUnavailable, err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", models.ErrOIDCProviderUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s вернул HTTP %d", models.ErrOIDCProviderUnavailable, target, resp.StatusCode)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(out); err != nil {
+		return fmt.Errorf("%w: неверный JSON от %s: %v", models.ErrOIDCProviderUnavailable, target, err)
+	}
+	return nil
+}
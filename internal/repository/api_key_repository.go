@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"khrllwTest/internal/models"
+	"time"
+)
+
+// ------------------------------------------------------------
+// Интерфейсы
+// ------------------------------------------------------------
+
+// APIKeyRepository определяет контракт для работы с API ключами
+type APIKeyRepository interface {
+
+	// Create
+	// Сохранение нового API ключа
+	Create(key *models.APIKey) error
+
+	// FindByHash
+	// Поиск API ключа по хешу (возвращает ErrRecordNotFound, если не найден)
+	FindByHash(hash string) (*models.APIKey, error)
+
+	// FindByUserID
+	// Получение активных (не отозванных) ключей пользователя
+	FindByUserID(userID uint) ([]models.APIKey, error)
+
+	// Revoke
+	// Отзыв ключа пользователя (возвращает false, если активный ключ не найден)
+	Revoke(userID, keyID uint) (bool, error)
+
+	// TouchLastUsed
+	// Обновление времени последнего использования ключа
+	TouchLastUsed(id uint, usedAt time.Time) error
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewAPIKeyRepository создает новый экземпляр APIKeyRepository
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &APIKeyRepositoryImpl{db: db}
+}
+
+// ------------------------------------------------------------
+// Реализация
+// ------------------------------------------------------------
+
+// APIKeyRepositoryImpl - реализация для GORM
+type APIKeyRepositoryImpl struct {
+	db *gorm.DB // Экземпляр подключения к БД
+}
+
+// ------------------------------------------------------------
+// Методы APIKeyRepositoryImpl
+// ------------------------------------------------------------
+
+func (r *APIKeyRepositoryImpl) Create(key *models.APIKey) error {
+	// INSERT INTO api_keys (...) VALUES (...)
+	return r.db.Create(key).Error
+}
+
+func (r *APIKeyRepositoryImpl) FindByHash(hash string) (*models.APIKey, error) {
+	var key models.APIKey
+	// SELECT * FROM api_keys WHERE key_hash = ?
+	err := r.db.Where("key_hash = ?", hash).First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrRecordNotFound
+	}
+	return &key, err
+}
+
+func (r *APIKeyRepositoryImpl) FindByUserID(userID uint) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	// SELECT * FROM api_keys WHERE user_id = ? AND revoked_at IS NULL ORDER BY id
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL", userID).Order("id").Find(&keys).Error
+	return keys, err
+}
+
+func (r *APIKeyRepositoryImpl) Revoke(userID, keyID uint) (bool, error) {
+	// UPDATE api_keys SET revoked_at = ? WHERE id = ? AND user_id = ? AND revoked_at IS NULL
+	result := r.db.Model(&models.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", keyID, userID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *APIKeyRepositoryImpl) TouchLastUsed(id uint, usedAt time.Time) error {
+	// UPDATE api_keys SET last_used_at = ? WHERE id = ?
+	return r.db.Model(&models.APIKey{}).Where("id = ?", id).Update("last_used_at", usedAt).Error
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"khrllwTest/internal/models"
+)
+
+// ------------------------------------------------------------
+// Интерфейсы
+// ------------------------------------------------------------
+
+// AuditLogRepository определяет контракт для работы с журналом аудита
+type AuditLogRepository interface {
+
+	// Create
+	// Сохранение записи журнала
+	Create(entry *models.AuditLog) error
+
+	// FindAll
+	// Получение записей с пагинацией, новые первыми; нулевые actorID и userID не фильтруют
+	FindAll(offset, limit int, actorID, userID uint) ([]models.AuditLog, int64, error)
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewAuditLogRepository создает новый экземпляр AuditLogRepository
+func NewAuditLogRepository(db *gorm.DB) AuditLogRepository {
+	return &AuditLogRepositoryImpl{db: db}
+}
+
+// ------------------------------------------------------------
+// Реализация
+// ------------------------------------------------------------
+
+// AuditLogRepositoryImpl - реализация для GORM
+type AuditLogRepositoryImpl struct {
+	db *gorm.DB // Экземпляр подключения к БД
+}
+
+// ------------------------------------------------------------
+// Методы AuditLogRepositoryImpl
+// ------------------------------------------------------------
+
+func (r *AuditLogRepositoryImpl) Create(entry *models.AuditLog) error {
+	// INSERT INTO audit_logs (...) VALUES (...)
+	return r.db.Create(entry).Error
+}
+
+func (r *AuditLogRepositoryImpl) FindAll(offset, limit int, actorID, userID uint) ([]models.AuditLog, int64, error) {
+	var entries []models.AuditLog
+	var total int64
+	query := r.db.Model(&models.AuditLog{})
+	if actorID != 0 {
+		query = query.Where("actor_id = ?", actorID)
+	}
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// SELECT * FROM audit_logs WHERE ... ORDER BY id DESC OFFSET ? LIMIT ?
+	if err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
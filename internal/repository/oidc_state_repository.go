@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"khrllwTest/internal/models"
+	"time"
+)
+
+// ------------------------------------------------------------
+// Интерфейсы
+// ------------------------------------------------------------
+
+// OIDCStateRepository определяет контракт для работы с незавершенными входами через OpenID Connect
+type OIDCStateRepository interface {
+
+	// Create
+	// Сохранение нового запроса авторизации
+	Create(state *models.OIDCLoginState) error
+
+	// Consume
+	// Поиск запроса по хешу state и его удаление (возвращает ErrRecordNotFound, если запрос не найден
+	// или уже использован параллельно)
+	Consume(hash string) (*models.OIDCLoginState, error)
+
+	// DeleteExpired
+	// Удаление запросов, истекших до указанного момента
+	DeleteExpired(before time.Time) (int64, error)
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewOIDCStateRepository создает новый экземпляр OIDCStateRepository
+func NewOIDCStateRepository(db *gorm.DB) OIDCStateRepository {
+	return &OIDCStateRepositoryImpl{db: db}
+}
+
+// ------------------------------------------------------------
+// Реализация
+// ------------------------------------------------------------
+
+// OIDCStateRepositoryImpl - реализация для GORM
+type OIDCStateRepositoryImpl struct {
+	db *gorm.DB // Экземпляр подключения к БД
+}
+
+// ------------------------------------------------------------
+// Методы OIDCStateRepositoryImpl
+// ------------------------------------------------------------
+
+func (r *OIDCStateRepositoryImpl) Create(state *models.OIDCLoginState) error {
+	// INSERT INTO oidc_login_states (...) VALUES (...)
+	return r.db.Create(state).Error
+}
+
+func (r *OIDCStateRepositoryImpl) Consume(hash string) (*models.OIDCLoginState, error) {
+	var state models.OIDCLoginState
+	// SELECT * FROM oidc_login_states WHERE state_hash = ?
+	err := r.db.Where("state_hash = ?", hash).First(&state).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// DELETE FROM oidc_login_states WHERE id = ?
+	result := r.db.Delete(&models.OIDCLoginState{}, state.ID)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, models.ErrRecordNotFound
+	}
+	return &state, nil
+}
+
+func (r *OIDCStateRepositoryImpl) DeleteExpired(before time.Time) (int64, error) {
+	// DELETE FROM oidc_login_states WHERE expires_at < ?
+	result := r.db.Where("expires_at < ?", before).Delete(&models.OIDCLoginState{})
+	return result.RowsAffected, result.Error
+}
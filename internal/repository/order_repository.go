@@ -21,15 +21,27 @@ type OrderRepository interface {
 	// Поиск всех заказов пользователя по ID
 	FindByUserID(userID uint) ([]models.Order, error)
 
-	/* Update
-	// Обновление данных заказа
+	// FindPageByUserID
+	// Получение до limit заказов пользователя с ID больше afterID (меньше при backward) в порядке ID
+	FindPageByUserID(userID, afterID uint, backward bool, limit int) ([]models.Order, error)
+
+	// CountByUserID
+	// Количество заказов пользователя
+	CountByUserID(userID uint) (int64, error)
+
+	// FindByID
+	// Поиск заказа пользователя по ID (возвращает ErrOrderNotFound, если не найден)
+	FindByID(userID, id uint) (*models.Order, error)
+
+	// Update
+	// Сохранение данных заказа, если версия записи не изменилась с момента чтения
+	// (возвращает ErrVersionConflict, если изменилась). Версия заказа увеличивается
 	Update(order *models.Order) error
 
 	// Delete
-	// Удаление заказа по ID
-	Delete(id uint) error
-
-	*/
+	// Удаление заказа по ID. Если version не 0, заказ удаляется только при совпадении версии
+	// (иначе ErrVersionConflict)
+	Delete(id uint, version int) error
 }
 
 // ------------------------------------------------------------
@@ -69,16 +81,84 @@ func (r *OrderRepositoryImpl) FindByUserID(userID uint) ([]models.Order, error)
 	return orders, err
 }
 
-/*
+func (r *OrderRepositoryImpl) FindPageByUserID(userID, afterID uint, backward bool, limit int) ([]models.Order, error) {
+	var orders []models.Order
+	query := r.db.Where("user_id = ?", userID)
+	if backward {
+		// SELECT * FROM orders WHERE user_id = ? AND id < ? ORDER BY id DESC LIMIT ?
+		query = query.Where("id < ?", afterID).Order("id DESC")
+	} else {
+		// SELECT * FROM orders WHERE user_id = ? AND id > ? ORDER BY id ASC LIMIT ?
+		query = query.Where("id > ?", afterID).Order("id ASC")
+	}
+	if err := query.Limit(limit).Find(&orders).Error; err != nil {
+		return nil, err
+	}
+
+	// Предыдущая страница выбирается в обратном порядке от границы и затем разворачивается
+	if backward {
+		for i, j := 0, len(orders)-1; i < j; i, j = i+1, j-1 {
+			orders[i], orders[j] = orders[j], orders[i]
+		}
+	}
+	return orders, nil
+}
 
-func (r *OrderRepositoryImpl) Update(order *models.Order) error {
-	// UPDATE orders SET ... WHERE id = ?
-	return r.db.Save(order).Error
+func (r *OrderRepositoryImpl) CountByUserID(userID uint) (int64, error) {
+	var total int64
+	// SELECT count(*) FROM orders WHERE user_id = ?
+	err := r.db.Model(&models.Order{}).Where("user_id = ?", userID).Count(&total).Error
+	return total, err
+}
+
+func (r *OrderRepositoryImpl) FindByID(userID, id uint) (*models.Order, error) {
+	var order models.Order
+	// SELECT * FROM orders WHERE id = ? AND user_id = ?
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&order).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
 }
 
-func (r *OrderRepositoryImpl) Delete(id uint) error {
-	// DELETE FROM orders WHERE id = ?
-	return r.db.Delete(&models.Order{}, id).Error
+func (r *OrderRepositoryImpl) Update(order *models.Order) error {
+	version := order.Version
+	order.Version++
+	// UPDATE orders SET product = ?, quantity = ?, price = ?, version = ? WHERE id = ? AND version = ?
+	result := r.db.Model(order).
+		Select("product", "quantity", "price", "version").
+		Where("version = ?", version).
+		Updates(order)
+	if result.Error != nil || result.RowsAffected == 0 {
+		order.Version = version
+	}
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrVersionConflict
+	}
+	return nil
 }
 
-*/
+func (r *OrderRepositoryImpl) Delete(id uint, version int) error {
+	query := r.db.Where("id = ?", id)
+	if version != 0 {
+		query = query.Where("version = ?", version)
+	}
+	// DELETE FROM orders WHERE id = ? [AND version = ?]
+	result := query.Delete(&models.Order{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		if version != 0 {
+			return models.ErrVersionConflict
+		}
+		return models.ErrOrderNotFound
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"khrllwTest/internal/models"
+)
+
+// ------------------------------------------------------------
+// Интерфейсы
+// ------------------------------------------------------------
+
+// PasswordHistoryRepository определяет контракт для работы с историей паролей
+type PasswordHistoryRepository interface {
+
+	// Add
+	// Сохранение хеша прежнего пароля
+	Add(entry *models.PasswordHistory) error
+
+	// FindRecent
+	// Получение последних limit записей истории пользователя, новые первыми
+	FindRecent(userID uint, limit int) ([]models.PasswordHistory, error)
+
+	// Prune
+	// Удаление записей истории пользователя, кроме последних keep
+	Prune(userID uint, keep int) error
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewPasswordHistoryRepository создает новый экземпляр PasswordHistoryRepository
+func NewPasswordHistoryRepository(db *gorm.DB) PasswordHistoryRepository {
+	return &PasswordHistoryRepositoryImpl{db: db}
+}
+
+// ------------------------------------------------------------
+// Реализация
+// ------------------------------------------------------------
+
+// PasswordHistoryRepositoryImpl - реализация для GORM
+type PasswordHistoryRepositoryImpl struct {
+	db *gorm.DB // Экземпляр подключения к БД
+}
+
+// ------------------------------------------------------------
+// Методы PasswordHistoryRepositoryImpl
+// ------------------------------------------------------------
+
+func (r *PasswordHistoryRepositoryImpl) Add(entry *models.PasswordHistory) error {
+	// INSERT INTO password_history (...) VALUES (...)
+	return r.db.Create(entry).Error
+}
+
+func (r *PasswordHistoryRepositoryImpl) FindRecent(userID uint, limit int) ([]models.PasswordHistory, error) {
+	var entries []models.PasswordHistory
+	// SELECT * FROM password_history WHERE user_id = ? ORDER BY id DESC LIMIT ?
+	err := r.db.Where("user_id = ?", userID).
+		Order("id DESC").
+		Limit(limit).
+		Find(&entries).Error
+	return entries, err
+}
+
+func (r *PasswordHistoryRepositoryImpl) Prune(userID uint, keep int) error {
+	// DELETE FROM password_history WHERE user_id = ? AND id NOT IN (последние keep записей)
+	recent := r.db.Model(&models.PasswordHistory{}).
+		Select("id").
+		Where("user_id = ?", userID).
+		Order("id DESC").
+		Limit(keep)
+
+	return r.db.Where("user_id = ? AND id NOT IN (?)", userID, recent).
+		Delete(&models.PasswordHistory{}).Error
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"khrllwTest/internal/models"
+	"time"
+)
+
+// ------------------------------------------------------------
+// Интерфейсы
+// ------------------------------------------------------------
+
+// PasswordResetRepository определяет контракт для работы с токенами сброса пароля
+type PasswordResetRepository interface {
+
+	// Create
+	// Сохранение нового токена сброса
+	Create(token *models.PasswordResetToken) error
+
+	// FindByHash
+	// Поиск токена по хешу (возвращает ErrRecordNotFound, если не найден)
+	FindByHash(hash string) (*models.PasswordResetToken, error)
+
+	// MarkUsed
+	// Атомарная отметка токена использованным (возвращает false, если токен уже использован)
+	MarkUsed(id uint) (bool, error)
+
+	// InvalidateForUser
+	// Отметка всех неиспользованных токенов пользователя использованными
+	InvalidateForUser(userID uint) error
+
+	// DeleteExpired
+	// Удаление токенов, истекших до указанного момента
+	DeleteExpired(before time.Time) (int64, error)
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewPasswordResetRepository создает новый экземпляр PasswordResetRepository
+func NewPasswordResetRepository(db *gorm.DB) PasswordResetRepository {
+	return &PasswordResetRepositoryImpl{db: db}
+}
+
+// ------------------------------------------------------------
+// Реализация
+// ------------------------------------------------------------
+
+// PasswordResetRepositoryImpl - реализация для GORM
+type PasswordResetRepositoryImpl struct {
+	db *gorm.DB // Экземпляр подключения к БД
+}
+
+// ------------------------------------------------------------
+// Методы PasswordResetRepositoryImpl
+// ------------------------------------------------------------
+
+func (r *PasswordResetRepositoryImpl) Create(token *models.PasswordResetToken) error {
+	// INSERT INTO password_reset_tokens (...) VALUES (...)
+	return r.db.Create(token).Error
+}
+
+func (r *PasswordResetRepositoryImpl) FindByHash(hash string) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	// SELECT * FROM password_reset_tokens WHERE token_hash = ?
+	err := r.db.Where("token_hash = ?", hash).First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrRecordNotFound
+	}
+	return &token, err
+}
+
+func (r *PasswordResetRepositoryImpl) MarkUsed(id uint) (bool, error) {
+	// UPDATE password_reset_tokens SET used_at = ? WHERE id = ? AND used_at IS NULL
+	result := r.db.Model(&models.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *PasswordResetRepositoryImpl) InvalidateForUser(userID uint) error {
+	// UPDATE password_reset_tokens SET used_at = ? WHERE user_id = ? AND used_at IS NULL
+	return r.db.Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+}
+
+func (r *PasswordResetRepositoryImpl) DeleteExpired(before time.Time) (int64, error) {
+	// DELETE FROM password_reset_tokens WHERE expires_at < ?
+	result := r.db.Where("expires_at < ?", before).Delete(&models.PasswordResetToken{})
+	return result.RowsAffected, result.Error
+}
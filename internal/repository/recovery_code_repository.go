@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"khrllwTest/internal/models"
+	"time"
+)
+
+// ------------------------------------------------------------
+// Интерфейсы
+// ------------------------------------------------------------
+
+// RecoveryCodeRepository определяет контракт для работы с кодами восстановления 2FA
+type RecoveryCodeRepository interface {
+
+	// Replace
+	// Замена всех кодов пользователя новым набором
+	Replace(userID uint, codes []models.RecoveryCode) error
+
+	// Use
+	// Атомарная отметка кода использованным (возвращает false, если неиспользованный код не найден)
+	Use(userID uint, hash string) (bool, error)
+
+	// DeleteForUser
+	// Удаление всех кодов пользователя
+	DeleteForUser(userID uint) error
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewRecoveryCodeRepository создает новый экземпляр RecoveryCodeRepository
+func NewRecoveryCodeRepository(db *gorm.DB) RecoveryCodeRepository {
+	return &RecoveryCodeRepositoryImpl{db: db}
+}
+
+// ------------------------------------------------------------
+// Реализация
+// ------------------------------------------------------------
+
+// RecoveryCodeRepositoryImpl - реализация для GORM
+type RecoveryCodeRepositoryImpl struct {
+	db *gorm.DB // Экземпляр подключения к БД
+}
+
+// ------------------------------------------------------------
+// Методы RecoveryCodeRepositoryImpl
+// ------------------------------------------------------------
+
+func (r *RecoveryCodeRepositoryImpl) Replace(userID uint, codes []models.RecoveryCode) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// DELETE FROM recovery_codes WHERE user_id = ?
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		// INSERT INTO recovery_codes (...) VALUES (...), (...)
+		return tx.Create(&codes).Error
+	})
+}
+
+func (r *RecoveryCodeRepositoryImpl) Use(userID uint, hash string) (bool, error) {
+	// UPDATE recovery_codes SET used_at = ? WHERE user_id = ? AND code_hash = ? AND used_at IS NULL
+	result := r.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *RecoveryCodeRepositoryImpl) DeleteForUser(userID uint) error {
+	// DELETE FROM recovery_codes WHERE user_id = ?
+	return r.db.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+}
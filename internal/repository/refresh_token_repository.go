@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"khrllwTest/internal/models"
+	"time"
+)
+
+// ------------------------------------------------------------
+// Интерфейсы
+// ------------------------------------------------------------
+
+// RefreshTokenRepository определяет контракт для работы с refresh токенами
+type RefreshTokenRepository interface {
+
+	// Create
+	// Сохранение нового refresh токена
+	Create(token *models.RefreshToken) error
+
+	// FindByHash
+	// Поиск refresh токена по хешу (возвращает ErrRecordNotFound, если не найден)
+	FindByHash(hash string) (*models.RefreshToken, error)
+
+	// Revoke
+	// Отзыв активного токена по ID (возвращает false, если токен уже был отозван)
+	Revoke(id uint) (bool, error)
+
+	// RevokeFamily
+	// Отзыв всех активных токенов семейства
+	RevokeFamily(familyID string) error
+
+	// RevokeAllForUser
+	// Отзыв всех активных токенов пользователя
+	RevokeAllForUser(userID uint) error
+
+	// DeleteExpired
+	// Удаление токенов, истекших до указанного момента
+	DeleteExpired(before time.Time) (int64, error)
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewRefreshTokenRepository создает новый экземпляр RefreshTokenRepository
+func NewRefreshTokenRepository(db *gorm.DB) RefreshTokenRepository {
+	return &RefreshTokenRepositoryImpl{db: db}
+}
+
+// ------------------------------------------------------------
+// Реализация
+// ------------------------------------------------------------
+
+// RefreshTokenRepositoryImpl - реализация для GORM
+type RefreshTokenRepositoryImpl struct {
+	db *gorm.DB // Экземпляр подключения к БД
+}
+
+// ------------------------------------------------------------
+// Методы RefreshTokenRepositoryImpl
+// ------------------------------------------------------------
+
+func (r *RefreshTokenRepositoryImpl) Create(token *models.RefreshToken) error {
+	// INSERT INTO refresh_tokens (...) VALUES (...)
+	return r.db.Create(token).Error
+}
+
+func (r *RefreshTokenRepositoryImpl) FindByHash(hash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	// SELECT * FROM refresh_tokens WHERE token_hash = ?
+	err := r.db.Where("token_hash = ?", hash).First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrRecordNotFound
+	}
+	return &token, err
+}
+
+func (r *RefreshTokenRepositoryImpl) Revoke(id uint) (bool, error) {
+	// UPDATE refresh_tokens SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL
+	result := r.db.Model(&models.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *RefreshTokenRepositoryImpl) RevokeFamily(familyID string) error {
+	// UPDATE refresh_tokens SET revoked_at = ? WHERE family_id = ? AND revoked_at IS NULL
+	return r.db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *RefreshTokenRepositoryImpl) RevokeAllForUser(userID uint) error {
+	// UPDATE refresh_tokens SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL
+	return r.db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *RefreshTokenRepositoryImpl) DeleteExpired(before time.Time) (int64, error) {
+	// DELETE FROM refresh_tokens WHERE expires_at < ?
+	result := r.db.Where("expires_at < ?", before).Delete(&models.RefreshToken{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"khrllwTest/internal/models"
+	"time"
+)
+
+// ------------------------------------------------------------
+// Интерфейсы
+// ------------------------------------------------------------
+
+// RevokedTokenRepository определяет контракт хранилища отозванных access токенов
+type RevokedTokenRepository interface {
+
+	// Revoke
+	// Добавление токена в список отозванных до момента его истечения
+	Revoke(token *models.RevokedToken) error
+
+	// IsRevoked
+	// Проверка, отозван ли токен с указанным jti
+	IsRevoked(jti string) (bool, error)
+
+	// DeleteExpired
+	// Удаление записей о токенах, истекших до указанного момента
+	DeleteExpired(before time.Time) (int64, error)
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewRevokedTokenRepository создает новый экземпляр RevokedTokenRepository
+func NewRevokedTokenRepository(db *gorm.DB) RevokedTokenRepository {
+	return &RevokedTokenRepositoryImpl{db: db}
+}
+
+// ------------------------------------------------------------
+// Реализация
+// ------------------------------------------------------------
+
+// RevokedTokenRepositoryImpl - реализация для GORM
+type RevokedTokenRepositoryImpl struct {
+	db *gorm.DB // Экземпляр подключения к БД
+}
+
+// ------------------------------------------------------------
+// Методы RevokedTokenRepositoryImpl
+// ------------------------------------------------------------
+
+func (r *RevokedTokenRepositoryImpl) Revoke(token *models.RevokedToken) error {
+	// INSERT INTO revoked_tokens (...) VALUES (...) ON CONFLICT DO NOTHING
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(token).Error
+}
+
+func (r *RevokedTokenRepositoryImpl) IsRevoked(jti string) (bool, error) {
+	var count int64
+	// SELECT count(*) FROM revoked_tokens WHERE jti = ?
+	err := r.db.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *RevokedTokenRepositoryImpl) DeleteExpired(before time.Time) (int64, error) {
+	// DELETE FROM revoked_tokens WHERE expires_at < ?
+	result := r.db.Where("expires_at < ?", before).Delete(&models.RevokedToken{})
+	return result.RowsAffected, result.Error
+}
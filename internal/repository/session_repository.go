@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"khrllwTest/internal/models"
+	"time"
+)
+
+// ------------------------------------------------------------
+// Интерфейсы
+// ------------------------------------------------------------
+
+// SessionRepository определяет контракт для работы с сессиями пользователей
+type SessionRepository interface {
+
+	// Create
+	// Сохранение новой сессии
+	Create(session *models.Session) error
+
+	// FindByID
+	// Поиск сессии по ID (возвращает ErrRecordNotFound, если не найдена)
+	FindByID(id string) (*models.Session, error)
+
+	// FindActiveByUserID
+	// Получение активных сессий пользователя, последние активные первыми
+	FindActiveByUserID(userID uint) ([]models.Session, error)
+
+	// Revoke
+	// Завершение сессии пользователя (возвращает false, если активная сессия не найдена)
+	Revoke(userID uint, id string) (bool, error)
+
+	// RevokeAllForUser
+	// Завершение всех активных сессий пользователя
+	RevokeAllForUser(userID uint) error
+
+	// TouchLastSeen
+	// Обновление времени последней активности сессии
+	TouchLastSeen(id string, seenAt time.Time) error
+
+	// DeleteStale
+	// Удаление завершенных сессий и сессий без активности до указанного момента
+	DeleteStale(before time.Time) (int64, error)
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewSessionRepository создает новый экземпляр SessionRepository
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &SessionRepositoryImpl{db: db}
+}
+
+// ------------------------------------------------------------
+// Реализация
+// ------------------------------------------------------------
+
+// SessionRepositoryImpl - реализация для GORM
+type SessionRepositoryImpl struct {
+	db *gorm.DB // Экземпляр подключения к БД
+}
+
+// ------------------------------------------------------------
+// Методы SessionRepositoryImpl
+// ------------------------------------------------------------
+
+func (r *SessionRepositoryImpl) Create(session *models.Session) error {
+	// INSERT INTO sessions (...) VALUES (...)
+	return r.db.Create(session).Error
+}
+
+func (r *SessionRepositoryImpl) FindByID(id string) (*models.Session, error) {
+	var session models.Session
+	// SELECT * FROM sessions WHERE id = ?
+	err := r.db.Where("id = ?", id).First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrRecordNotFound
+	}
+	return &session, err
+}
+
+func (r *SessionRepositoryImpl) FindActiveByUserID(userID uint) ([]models.Session, error) {
+	var sessions []models.Session
+	// SELECT * FROM sessions WHERE user_id = ? AND revoked_at IS NULL ORDER BY last_seen_at DESC
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+func (r *SessionRepositoryImpl) Revoke(userID uint, id string) (bool, error) {
+	// UPDATE sessions SET revoked_at = ? WHERE id = ? AND user_id = ? AND revoked_at IS NULL
+	result := r.db.Model(&models.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *SessionRepositoryImpl) RevokeAllForUser(userID uint) error {
+	// UPDATE sessions SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL
+	return r.db.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *SessionRepositoryImpl) TouchLastSeen(id string, seenAt time.Time) error {
+	// UPDATE sessions SET last_seen_at = ? WHERE id = ?
+	return r.db.Model(&models.Session{}).
+		Where("id = ?", id).
+		Update("last_seen_at", seenAt).Error
+}
+
+func (r *SessionRepositoryImpl) DeleteStale(before time.Time) (int64, error) {
+	// DELETE FROM sessions WHERE revoked_at IS NOT NULL OR last_seen_at < ?
+	result := r.db.Where("revoked_at IS NOT NULL OR last_seen_at < ?", before).Delete(&models.Session{})
+	return result.RowsAffected, result.Error
+}
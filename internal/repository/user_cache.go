@@ -0,0 +1,369 @@
+package repository
+
+import (
+	"container/list"
+	"errors"
+	"khrllwTest/internal/models"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ------------------------------------------------------------
+// Конфигурация
+// ------------------------------------------------------------
+
+// UserCacheConfig содержит настройки кеша пользователей по ID
+type UserCacheConfig struct {
+	// Максимальное количество записей (0 - кеш выключен)
+	Size int
+
+	// Время жизни найденного пользователя
+	TTL time.Duration
+
+	// Время жизни отметки "пользователь не найден"
+	NegativeTTL time.Duration
+}
+
+// NewUserCacheConfig создает конфигурацию кеша пользователей из переменных окружения
+func NewUserCacheConfig() (*UserCacheConfig, error) {
+	config := &UserCacheConfig{
+		Size:        10000,
+		TTL:         30 * time.Second,
+		NegativeTTL: 5 * time.Second,
+	}
+
+	if value := os.Getenv("USER_CACHE_SIZE"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size < 0 {
+			return nil, errors.New("неверное значение USER_CACHE_SIZE. Ожидается неотрицательное число")
+		}
+		config.Size = size
+	}
+
+	for _, item := range []struct {
+		name  string
+		value *time.Duration
+	}{
+		{"USER_CACHE_TTL", &config.TTL},
+		{"USER_CACHE_NEGATIVE_TTL", &config.NegativeTTL},
+	} {
+		value := os.Getenv(item.name)
+		if value == "" {
+			continue
+		}
+		duration, err := time.ParseDuration(value)
+		if err != nil || duration < 0 {
+			return nil, errors.New("неверный формат " + item.name + ". Пример: 30s, 1m")
+		}
+		*item.value = duration
+	}
+
+	return config, nil
+}
+
+// ------------------------------------------------------------
+// Структуры
+// ------------------------------------------------------------
+
+// UserCacheStats счетчики кеша пользователей
+type UserCacheStats struct {
+	// Запросы, обслуженные из кеша (включая отметки "не найден")
+	Hits uint64 `json:"hits" example:"9500"`
+
+	// Запросы, отправленные в БД
+	Misses uint64 `json:"misses" example:"500"`
+
+	// Из них обслужено отметкой "не найден"
+	NegativeHits uint64 `json:"negative_hits" example:"12"`
+
+	// Записи, вытесненные при переполнении
+	Evictions uint64 `json:"evictions" example:"0"`
+
+	// Записи, сброшенные при изменении пользователя
+	Invalidations uint64 `json:"invalidations" example:"40"`
+
+	// Текущее и максимальное количество записей
+	Size     int `json:"size" example:"480"`
+	Capacity int `json:"capacity" example:"10000"`
+}
+
+// userCacheEntry запись кеша; user == nil - отметка "пользователь не найден"
+type userCacheEntry struct {
+	id        uint
+	user      *models.User
+	expiresAt time.Time
+}
+
+// UserCache ограниченный LRU кеш пользователей по ID с временем жизни записей.
+// Хранит копии: вызывающий код может изменять полученного пользователя, не затрагивая кеш.
+type UserCache struct {
+	config *UserCacheConfig
+
+	mu      sync.Mutex
+	entries map[uint]*list.Element
+	order   *list.List // начало списка - последние использованные записи
+
+	// generation увеличивается при каждом сбросе; загруженная из БД запись
+	// не сохраняется, если за время загрузки произошел сброс
+	generation atomic.Uint64
+
+	hits, misses, negativeHits, evictions, invalidations atomic.Uint64
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewUserCache создает новый экземпляр UserCache
+func NewUserCache(config *UserCacheConfig) *UserCache {
+	return &UserCache{
+		config:  config,
+		entries: make(map[uint]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// ------------------------------------------------------------
+// Основные методы
+// ------------------------------------------------------------
+
+// Enabled сообщает, включен ли кеш
+func (c *UserCache) Enabled() bool {
+	return c != nil && c.config.Size > 0
+}
+
+// Invalidate удаляет запись пользователя из кеша
+func (c *UserCache) Invalidate(id uint) {
+	if !c.Enabled() {
+		return
+	}
+	c.generation.Add(1)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[id]; ok {
+		c.removeElement(element)
+		c.invalidations.Add(1)
+	}
+}
+
+// Stats возвращает счетчики кеша
+func (c *UserCache) Stats() UserCacheStats {
+	if c == nil {
+		return UserCacheStats{}
+	}
+
+	c.mu.Lock()
+	size := c.order.Len()
+	c.mu.Unlock()
+
+	return UserCacheStats{
+		Hits:          c.hits.Load(),
+		Misses:        c.misses.Load(),
+		NegativeHits:  c.negativeHits.Load(),
+		Evictions:     c.evictions.Load(),
+		Invalidations: c.invalidations.Load(),
+		Size:          size,
+		Capacity:      c.config.Size,
+	}
+}
+
+// ------------------------------------------------------------
+// Вспомогательные методы
+// ------------------------------------------------------------
+
+// get возвращает копию пользователя из кеша; found == false - записи нет или она истекла.
+// Для отметки "не найден" возвращается found == true и user == nil.
+func (c *UserCache) get(id uint, now time.Time) (user *models.User, found bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[id]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*userCacheEntry)
+	if !now.Before(entry.expiresAt) {
+		c.removeElement(element)
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	if entry.user == nil {
+		return nil, true
+	}
+	copied := *entry.user
+	return &copied, true
+}
+
+// put сохраняет копию пользователя (nil - отметка "не найден"), если с момента generation не было сброса
+func (c *UserCache) put(id uint, user *models.User, generation uint64, now time.Time) {
+	ttl := c.config.TTL
+	if user == nil {
+		ttl = c.config.NegativeTTL
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	var stored *models.User
+	if user != nil {
+		copied := *user
+		stored = &copied
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generation.Load() != generation {
+		return
+	}
+
+	entry := &userCacheEntry{id: id, user: stored, expiresAt: now.Add(ttl)}
+	if element, ok := c.entries[id]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[id] = c.order.PushFront(entry)
+	for c.order.Len() > c.config.Size {
+		c.removeElement(c.order.Back())
+		c.evictions.Add(1)
+	}
+}
+
+// removeElement удаляет запись из списка и индекса (вызывается под блокировкой)
+func (c *UserCache) removeElement(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*userCacheEntry).id)
+}
+
+// ------------------------------------------------------------
+// Репозиторий с кешем
+// ------------------------------------------------------------
+
+// CachedUserRepository оборачивает UserRepository кешем поиска по ID.
+// Любое изменение пользователя через репозиторий сбрасывает его запись в кеше,
+// поэтому смена роли, версии токенов или подтверждение email действуют сразу.
+// Изменения, сделанные другими экземплярами сервиса, видны не позже чем через UserCacheConfig.TTL.
+type CachedUserRepository struct {
+	UserRepository
+	cache       *UserCache
+	cachedReads bool
+}
+
+// NewCachedUserRepository создает репозиторий пользователей, читающий по ID через кеш.
+// Предназначен для проверки токенов в middleware; при выключенном кеше возвращает repo.
+func NewCachedUserRepository(repo UserRepository, cache *UserCache) UserRepository {
+	if !cache.Enabled() {
+		return repo
+	}
+	return &CachedUserRepository{UserRepository: repo, cache: cache, cachedReads: true}
+}
+
+// NewInvalidatingUserRepository создает репозиторий пользователей, который читает из БД,
+// а при изменениях сбрасывает записи кеша. Предназначен для сервисов: чтение с последующим
+// сохранением всей записи не должно опираться на устаревшую копию.
+func NewInvalidatingUserRepository(repo UserRepository, cache *UserCache) UserRepository {
+	if !cache.Enabled() {
+		return repo
+	}
+	return &CachedUserRepository{UserRepository: repo, cache: cache}
+}
+
+func (r *CachedUserRepository) FindByID(id uint) (*models.User, error) {
+	if !r.cachedReads {
+		return r.UserRepository.FindByID(id)
+	}
+
+	now := time.Now()
+	if user, found := r.cache.get(id, now); found {
+		r.cache.hits.Add(1)
+		if user == nil {
+			r.cache.negativeHits.Add(1)
+			return nil, models.ErrUserNotFound
+		}
+		return user, nil
+	}
+
+	r.cache.misses.Add(1)
+	generation := r.cache.generation.Load()
+	user, err := r.UserRepository.FindByID(id)
+	switch {
+	case err == nil:
+		r.cache.put(id, user, generation, now)
+	case errors.Is(err, models.ErrUserNotFound):
+		r.cache.put(id, nil, generation, now)
+	}
+	return user, err
+}
+
+func (r *CachedUserRepository) Create(user *models.User) error {
+	err := r.UserRepository.Create(user)
+	r.cache.Invalidate(user.ID)
+	return err
+}
+
+func (r *CachedUserRepository) Update(user *models.User) error {
+	err := r.UserRepository.Update(user)
+	r.cache.Invalidate(user.ID)
+	return err
+}
+
+func (r *CachedUserRepository) UpdateFields(id uint, version int, fields map[string]interface{}) error {
+	err := r.UserRepository.UpdateFields(id, version, fields)
+	r.cache.Invalidate(id)
+	return err
+}
+
+func (r *CachedUserRepository) Delete(id uint, version int) error {
+	err := r.UserRepository.Delete(id, version)
+	r.cache.Invalidate(id)
+	return err
+}
+
+func (r *CachedUserRepository) Restore(id uint, deletedAfter time.Time) (bool, error) {
+	restored, err := r.UserRepository.Restore(id, deletedAfter)
+	r.cache.Invalidate(id)
+	return restored, err
+}
+
+func (r *CachedUserRepository) IncrementTokenVersion(id uint) error {
+	err := r.UserRepository.IncrementTokenVersion(id)
+	r.cache.Invalidate(id)
+	return err
+}
+
+func (r *CachedUserRepository) UpdateTwoFactor(id uint, secret string, enabled bool) error {
+	err := r.UserRepository.UpdateTwoFactor(id, secret, enabled)
+	r.cache.Invalidate(id)
+	return err
+}
+
+func (r *CachedUserRepository) UseTOTPStep(id uint, step int64) (bool, error) {
+	used, err := r.UserRepository.UseTOTPStep(id, step)
+	r.cache.Invalidate(id)
+	return used, err
+}
+
+func (r *CachedUserRepository) UpdatePassword(id uint, passwordHash string) error {
+	err := r.UserRepository.UpdatePassword(id, passwordHash)
+	r.cache.Invalidate(id)
+	return err
+}
+
+func (r *CachedUserRepository) RehashPassword(id uint, oldHash, newHash string) (bool, error) {
+	updated, err := r.UserRepository.RehashPassword(id, oldHash, newHash)
+	r.cache.Invalidate(id)
+	return updated, err
+}
+
+func (r *CachedUserRepository) MarkEmailVerified(id uint, email string) (bool, error) {
+	updated, err := r.UserRepository.MarkEmailVerified(id, email)
+	r.cache.Invalidate(id)
+	return updated, err
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"khrllwTest/internal/models"
+)
+
+// ------------------------------------------------------------
+// Интерфейсы
+// ------------------------------------------------------------
+
+// UserIdentityRepository определяет контракт для работы со связями пользователей и внешних провайдеров
+type UserIdentityRepository interface {
+
+	// Create
+	// Привязка аккаунта провайдера к существующему пользователю
+	Create(identity *models.UserIdentity) error
+
+	// CreateWithUser
+	// Создание пользователя и привязки к аккаунту провайдера в одной транзакции
+	CreateWithUser(user *models.User, identity *models.UserIdentity) error
+
+	// FindBySubject
+	// Поиск привязки по провайдеру и sub (возвращает ErrRecordNotFound, если не найдена)
+	FindBySubject(provider, subject string) (*models.UserIdentity, error)
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewUserIdentityRepository создает новый экземпляр UserIdentityRepository
+func NewUserIdentityRepository(db *gorm.DB) UserIdentityRepository {
+	return &UserIdentityRepositoryImpl{db: db}
+}
+
+// ------------------------------------------------------------
+// Реализация
+// ------------------------------------------------------------
+
+// UserIdentityRepositoryImpl - реализация для GORM
+type UserIdentityRepositoryImpl struct {
+	db *gorm.DB // Экземпляр подключения к БД
+}
+
+// ------------------------------------------------------------
+// Методы UserIdentityRepositoryImpl
+// ------------------------------------------------------------
+
+func (r *UserIdentityRepositoryImpl) Create(identity *models.UserIdentity) error {
+	// INSERT INTO user_identities (...) VALUES (...)
+	return r.db.Create(identity).Error
+}
+
+func (r *UserIdentityRepositoryImpl) CreateWithUser(user *models.User, identity *models.UserIdentity) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// INSERT INTO users (...) VALUES (...)
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+
+		// INSERT INTO user_identities (...) VALUES (...)
+		identity.UserID = user.ID
+		return tx.Create(identity).Error
+	})
+}
+
+func (r *UserIdentityRepositoryImpl) FindBySubject(provider, subject string) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	// SELECT * FROM user_identities WHERE provider = ? AND subject = ?
+	err := r.db.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrRecordNotFound
+	}
+	return &identity, err
+}
@@ -3,7 +3,10 @@ package repository
 import (
 	"errors"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"khrllwTest/internal/models"
+	"strings"
+	"time"
 )
 
 // ------------------------------------------------------------
@@ -22,7 +25,7 @@ type UserRepositoryImpl struct {
 // UserRepository определяет контракт для работы с пользователями в БД
 type UserRepository interface {
 	// Create
-	// Создание нового пользователя
+	// Создание нового пользователя (возвращает ErrEmailAlreadyExists, если email занят активным пользователем)
 	Create(user *models.User) error
 
 	// FindByID
@@ -34,16 +37,75 @@ type UserRepository interface {
 	FindByEmail(email string) (*models.User, error)
 
 	// Update
-	// Обновление данных пользователя
+	// Сохранение всех данных пользователя, если версия записи не изменилась с момента чтения
+	// (возвращает ErrVersionConflict, если изменилась). Версия пользователя увеличивается.
+	// Если новый email занят другим активным пользователем, возвращает ErrEmailAlreadyExists
 	Update(user *models.User) error
 
+	// UpdateFields
+	// Обновление только указанных колонок пользователя (UPDATE users SET <колонки> WHERE id = ?)
+	// с увеличением версии. Если version не 0, строка обновляется только при совпадении версии
+	// (иначе ErrVersionConflict). Занятый другим активным пользователем email - ErrEmailAlreadyExists
+	UpdateFields(id uint, version int, fields map[string]interface{}) error
+
 	// Delete
-	// Удаление пользователя по ID (мягкое удаление, если настроено в модели)
-	Delete(id uint) error
+	// Мягкое удаление пользователя по ID: заполняется deleted_at, пользователь скрывается из выборок.
+	// Если version не 0, пользователь удаляется только при совпадении версии (иначе ErrVersionConflict)
+	Delete(id uint, version int) error
+
+	// FindDeletedByID
+	// Поиск мягко удаленного пользователя по ID (возвращает ErrUserNotFound, если не найден)
+	FindDeletedByID(id uint) (*models.User, error)
+
+	// Restore
+	// Восстановление пользователя, удаленного позже deletedAfter, с увеличением версии токенов
+	// (возвращает false, если такого удаленного пользователя нет, и ErrEmailAlreadyExists,
+	// если его email уже занят активным пользователем)
+	Restore(id uint, deletedAfter time.Time) (bool, error)
+
+	// PurgeDeleted
+	// Окончательное удаление пользователей, удаленных до указанного момента (заказы удаляются каскадно)
+	PurgeDeleted(before time.Time) (int64, error)
 
 	// GetAll
-	// Получение списка пользователей с пагинацией и фильтрацией по возрасту
-	GetAll(offset, limit, minAge, maxAge int) ([]models.User, int64, error)
+	// Получение списка пользователей с пагинацией, поиском, фильтрацией и сортировкой.
+	// Поля сортировки проверяются по models.UserSortColumns (неизвестное поле - ErrInvalidSortParams)
+	GetAll(offset, limit int, filter *models.UserFilter) ([]models.User, int64, error)
+
+	// GetPage
+	// Получение до limit пользователей после границы keyset-пагинации (или перед ней при keyset.Backward).
+	// Пользователи возвращаются в порядке сортировки фильтра
+	GetPage(keyset *models.UserKeyset, limit int, filter *models.UserFilter) ([]models.User, error)
+
+	// Count
+	// Количество пользователей, соответствующих фильтру
+	Count(filter *models.UserFilter) (int64, error)
+
+	// IncrementTokenVersion
+	// Увеличение версии токенов пользователя (отзывает все выданные токены)
+	IncrementTokenVersion(id uint) error
+
+	// UpdateTwoFactor
+	// Обновление секрета TOTP и признака подключенной 2FA
+	UpdateTwoFactor(id uint, secret string, enabled bool) error
+
+	// UseTOTPStep
+	// Запоминание шага принятого кода TOTP. Выполняется, только если шаг больше последнего
+	// принятого (возвращает false, если код этого шага уже использован)
+	UseTOTPStep(id uint, step int64) (bool, error)
+
+	// UpdatePassword
+	// Установка нового хеша пароля с увеличением версии токенов
+	UpdatePassword(id uint, passwordHash string) error
+
+	// RehashPassword
+	// Замена хеша пароля на пересчитанный без изменения версии токенов.
+	// Выполняется, только если хеш не изменился с момента проверки (возвращает false, если изменился)
+	RehashPassword(id uint, oldHash, newHash string) (bool, error)
+
+	// MarkEmailVerified
+	// Отметка email подтвержденным, если он не изменился (возвращает false, если email уже другой или подтвержден)
+	MarkEmailVerified(id uint, email string) (bool, error)
 }
 
 // ------------------------------------------------------------
@@ -61,7 +123,7 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 
 func (r *UserRepositoryImpl) Create(user *models.User) error {
 	// Выполняет INSERT запрос
-	return r.db.Create(user).Error
+	return duplicateEmail(r.db.Create(user).Error)
 }
 
 func (r *UserRepositoryImpl) FindByID(id uint) (*models.User, error) {
@@ -86,33 +148,326 @@ func (r *UserRepositoryImpl) FindByEmail(email string) (*models.User, error) {
 }
 
 func (r *UserRepositoryImpl) Update(user *models.User) error {
-	// Выполняет UPDATE запрос
-	return r.db.Save(user).Error
+	version := user.Version
+	user.Version++
+	// UPDATE users SET <все колонки>, version = ? WHERE id = ? AND version = ? AND deleted_at IS NULL
+	result := r.db.Model(user).
+		Select("*").
+		Omit("id", "deleted_at", clause.Associations).
+		Where("version = ?", version).
+		Updates(user)
+	if result.Error != nil || result.RowsAffected == 0 {
+		user.Version = version
+	}
+	if result.Error != nil {
+		return duplicateEmail(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrVersionConflict
+	}
+	return nil
 }
 
-func (r *UserRepositoryImpl) Delete(id uint) error {
-	// DELETE FROM users WHERE id = ?
-	return r.db.Delete(&models.User{}, id).Error
+func (r *UserRepositoryImpl) UpdateFields(id uint, version int, fields map[string]interface{}) error {
+	columns := make(map[string]interface{}, len(fields)+1)
+	for column, value := range fields {
+		columns[column] = value
+	}
+	columns["version"] = gorm.Expr("version + 1")
+
+	// UPDATE users SET <колонки>, version = version + 1 WHERE id = ? [AND version = ?] AND deleted_at IS NULL
+	query := r.db.Model(&models.User{}).Where("id = ?", id)
+	if version != 0 {
+		query = query.Where("version = ?", version)
+	}
+	result := query.Updates(columns)
+	if result.Error != nil {
+		return duplicateEmail(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		if version != 0 {
+			return models.ErrVersionConflict
+		}
+		return models.ErrUserNotFound
+	}
+	return nil
 }
 
-func (r *UserRepositoryImpl) GetAll(offset, limit, minAge, maxAge int) ([]models.User, int64, error) {
-	var users []models.User
-	var total int64
-	query := r.db.Model(&models.User{})
-	// Добавление фильтрации
-	if minAge > 0 {
-		query = query.Where("age >= ?", minAge)
+func (r *UserRepositoryImpl) Delete(id uint, version int) error {
+	if version == 0 {
+		// UPDATE users SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL
+		return r.db.Delete(&models.User{}, id).Error
+	}
+
+	// UPDATE users SET deleted_at = ? WHERE id = ? AND version = ? AND deleted_at IS NULL
+	result := r.db.Where("version = ?", version).Delete(&models.User{}, id)
+	if result.Error != nil {
+		return result.Error
 	}
-	if maxAge > 0 {
-		query = query.Where("age <= ?", maxAge)
+	if result.RowsAffected == 0 {
+		return models.ErrVersionConflict
 	}
-	if err := query.Count(&total).Error; err != nil {
+	return nil
+}
+
+func (r *UserRepositoryImpl) FindDeletedByID(id uint) (*models.User, error) {
+	var user models.User
+	// SELECT * FROM users WHERE id = ? AND deleted_at IS NOT NULL
+	err := r.db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrUserNotFound
+	}
+	return &user, err
+}
+
+func (r *UserRepositoryImpl) Restore(id uint, deletedAfter time.Time) (bool, error) {
+	// UPDATE users SET deleted_at = NULL, token_version = token_version + 1
+	// WHERE id = ? AND deleted_at IS NOT NULL AND deleted_at > ?
+	result := r.db.Unscoped().Model(&models.User{}).
+		Where("id = ? AND deleted_at IS NOT NULL AND deleted_at > ?", id, deletedAfter).
+		Updates(map[string]interface{}{
+			"deleted_at":    nil,
+			"token_version": gorm.Expr("token_version + 1"),
+			"version":       gorm.Expr("version + 1"),
+		})
+	return result.RowsAffected > 0, duplicateEmail(result.Error)
+}
+
+func (r *UserRepositoryImpl) PurgeDeleted(before time.Time) (int64, error) {
+	// DELETE FROM users WHERE deleted_at IS NOT NULL AND deleted_at < ?
+	result := r.db.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", before).Delete(&models.User{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *UserRepositoryImpl) GetAll(offset, limit int, filter *models.UserFilter) ([]models.User, int64, error) {
+	// Порядок собирается до запроса: в ORDER BY попадают только колонки из белого списка
+	keys, err := userSortKeys(filter.Sort)
+	if err != nil {
 		return nil, 0, err
 	}
 
-	// Применение пагинации
-	if err := query.Offset(offset).Limit(limit).Find(&users).Error; err != nil {
+	var users []models.User
+	var total int64
+	query := r.filteredUsers(filter)
+	// Подсчет выполняется в отдельной сессии, чтобы не изменять условия запроса страницы
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Применение сортировки и пагинации
+	if err := query.Order(userOrderClause(keys, false)).Offset(offset).Limit(limit).Find(&users).Error; err != nil {
 		return nil, 0, err
 	}
 	return users, total, nil
 }
+
+func (r *UserRepositoryImpl) GetPage(keyset *models.UserKeyset, limit int, filter *models.UserFilter) ([]models.User, error) {
+	keys, err := userSortKeys(filter.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	// Предыдущая страница выбирается в обратном порядке от границы и затем разворачивается
+	condition, vars := userKeysetCondition(keys, &keyset.Boundary, keyset.Backward)
+	var users []models.User
+	err = r.filteredUsers(filter).
+		Where(condition, vars...).
+		Order(userOrderClause(keys, keyset.Backward)).
+		Limit(limit).
+		Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	if keyset.Backward {
+		for i, j := 0, len(users)-1; i < j; i, j = i+1, j-1 {
+			users[i], users[j] = users[j], users[i]
+		}
+	}
+	return users, nil
+}
+
+func (r *UserRepositoryImpl) Count(filter *models.UserFilter) (int64, error) {
+	var total int64
+	err := r.filteredUsers(filter).Count(&total).Error
+	return total, err
+}
+
+func (r *UserRepositoryImpl) IncrementTokenVersion(id uint) error {
+	// UPDATE users SET token_version = token_version + 1, version = version + 1 WHERE id = ?
+	return r.db.Model(&models.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"token_version": gorm.Expr("token_version + 1"),
+			"version":       gorm.Expr("version + 1"),
+		}).Error
+}
+
+func (r *UserRepositoryImpl) UpdateTwoFactor(id uint, secret string, enabled bool) error {
+	// UPDATE users SET totp_secret = ?, totp_enabled = ?, version = version + 1 WHERE id = ?
+	return r.db.Model(&models.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"totp_secret":  secret,
+			"totp_enabled": enabled,
+			"version":      gorm.Expr("version + 1"),
+		}).Error
+}
+
+func (r *UserRepositoryImpl) UseTOTPStep(id uint, step int64) (bool, error) {
+	// UPDATE users SET totp_last_step = ?, version = version + 1 WHERE id = ? AND totp_last_step < ?
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", id, step).
+		Updates(map[string]interface{}{
+			"totp_last_step": step,
+			"version":        gorm.Expr("version + 1"),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *UserRepositoryImpl) UpdatePassword(id uint, passwordHash string) error {
+	// UPDATE users SET password_hash = ?, token_version = token_version + 1, version = version + 1 WHERE id = ?
+	return r.db.Model(&models.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"password_hash": passwordHash,
+			"token_version": gorm.Expr("token_version + 1"),
+			"version":       gorm.Expr("version + 1"),
+		}).Error
+}
+
+func (r *UserRepositoryImpl) RehashPassword(id uint, oldHash, newHash string) (bool, error) {
+	// UPDATE users SET password_hash = ?, version = version + 1 WHERE id = ? AND password_hash = ?
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND password_hash = ?", id, oldHash).
+		Updates(map[string]interface{}{
+			"password_hash": newHash,
+			"version":       gorm.Expr("version + 1"),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *UserRepositoryImpl) MarkEmailVerified(id uint, email string) (bool, error) {
+	// UPDATE users SET email_verified_at = ?, version = version + 1 WHERE id = ? AND email = ? AND email_verified_at IS NULL
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND email = ? AND email_verified_at IS NULL", id, email).
+		Updates(map[string]interface{}{
+			"email_verified_at": time.Now(),
+			"version":           gorm.Expr("version + 1"),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// ------------------------------------------------------------
+// Вспомогательные функции
+// ------------------------------------------------------------
+
+// userSortKey колонка сортировки списка пользователей
+type userSortKey struct {
+	field  string
+	column string
+	desc   bool
+}
+
+// duplicateEmail переводит нарушение уникального индекса idx_users_email_active в ErrEmailAlreadyExists.
+// Проверка email в сервисе не защищает от регистрации между проверкой и записью, поэтому
+// окончательное решение принимает индекс. Других уникальных ограничений у таблицы users нет.
+func duplicateEmail(err error) error {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return models.ErrEmailAlreadyExists
+	}
+	return err
+}
+
+// filteredUsers применяет к выборке пользователей поиск и фильтрацию
+func (r *UserRepositoryImpl) filteredUsers(filter *models.UserFilter) *gorm.DB {
+	query := r.db.Model(&models.User{})
+	if filter.Search != "" {
+		pattern := "%" + escapeLike(filter.Search) + "%"
+		query = query.Where("(name ILIKE ? OR email ILIKE ?)", pattern, pattern)
+	}
+	if filter.EmailDomain != "" {
+		query = query.Where("LOWER(email) LIKE ?", "%@"+escapeLike(strings.ToLower(filter.EmailDomain)))
+	}
+	if filter.MinAge > 0 {
+		query = query.Where("age >= ?", filter.MinAge)
+	}
+	if filter.MaxAge > 0 {
+		query = query.Where("age <= ?", filter.MaxAge)
+	}
+	return query
+}
+
+// userSortKeys переводит поля сортировки в колонки белого списка.
+// В конец добавляется id, чтобы порядок страниц был однозначным при равных значениях.
+func userSortKeys(sort []models.UserSortField) ([]userSortKey, error) {
+	keys := make([]userSortKey, 0, len(sort)+1)
+	hasID := false
+	for _, field := range sort {
+		column, ok := models.UserSortColumns[field.Field]
+		if !ok {
+			return nil, models.ErrInvalidSortParams
+		}
+		keys = append(keys, userSortKey{field: field.Field, column: column, desc: field.Desc})
+		hasID = hasID || column == "id"
+	}
+	if !hasID {
+		keys = append(keys, userSortKey{field: "id", column: "id"})
+	}
+	return keys, nil
+}
+
+// userOrderClause собирает ORDER BY из колонок сортировки (reverse - в обратном направлении)
+func userOrderClause(keys []userSortKey, reverse bool) string {
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		if key.desc != reverse {
+			parts[i] = key.column + " DESC"
+		} else {
+			parts[i] = key.column + " ASC"
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// userKeysetCondition собирает условие "строка после границы в порядке сортировки"
+// (перед границей при backward): (c1 > v1) OR (c1 = v1 AND c2 > v2) OR ...
+func userKeysetCondition(keys []userSortKey, boundary *models.User, backward bool) (string, []interface{}) {
+	clauses := make([]string, len(keys))
+	var vars []interface{}
+	for i, key := range keys {
+		parts := make([]string, 0, i+1)
+		for _, prev := range keys[:i] {
+			parts = append(parts, prev.column+" = ?")
+			vars = append(vars, userSortValue(boundary, prev.field))
+		}
+		if key.desc != backward {
+			parts = append(parts, key.column+" < ?")
+		} else {
+			parts = append(parts, key.column+" > ?")
+		}
+		vars = append(vars, userSortValue(boundary, key.field))
+		clauses[i] = "(" + strings.Join(parts, " AND ") + ")"
+	}
+	return "(" + strings.Join(clauses, " OR ") + ")", vars
+}
+
+// userSortValue возвращает значение поля сортировки пользователя
+func userSortValue(user *models.User, field string) interface{} {
+	switch field {
+	case "name":
+		return user.Name
+	case "email":
+		return user.Email
+	case "age":
+		return user.Age
+	case "role":
+		return user.Role
+	default:
+		return user.ID
+	}
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE, чтобы строка искалась буквально
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
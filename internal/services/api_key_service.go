@@ -0,0 +1,143 @@
+package service
+
+import (
+	"errors"
+	"khrllwTest/internal/models"
+	"khrllwTest/internal/repository"
+	"khrllwTest/internal/utils"
+	"log"
+	"time"
+)
+
+// apiKeyTouchInterval минимальный интервал между обновлениями времени использования ключа
+const apiKeyTouchInterval = time.Minute
+
+// apiKeyVisiblePrefix количество символов секрета, сохраняемых для отображения
+const apiKeyVisiblePrefix = 6
+
+// ------------------------------------------------------------
+// Структуры
+// ------------------------------------------------------------
+
+// APIKeyService реализует бизнес-логику работы с API ключами
+type APIKeyService struct {
+	apiKeyRepo repository.APIKeyRepository
+	userRepo   repository.UserRepository
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewAPIKeyService создает новый экземпляр APIKeyService
+func NewAPIKeyService(
+	apiKeyRepo repository.APIKeyRepository,
+	userRepo repository.UserRepository,
+) *APIKeyService {
+	return &APIKeyService{
+		apiKeyRepo: apiKeyRepo,
+		userRepo:   userRepo,
+	}
+}
+
+// ------------------------------------------------------------
+// Основные методы
+// ------------------------------------------------------------
+
+// CreateAPIKey создает API ключ пользователя и возвращает его полное значение.
+// Значение ключа возвращается только здесь, в БД хранится лишь хеш.
+func (s *APIKeyService) CreateAPIKey(userID uint, req *models.CreateAPIKeyRequest) (*models.APIKey, string, error) {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, "", models.ErrInvalidAPIKeyExpiry
+	}
+
+	if _, err := s.userRepo.FindByID(userID); err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return nil, "", err
+		}
+		return nil, "", models.ErrDatabaseError
+	}
+
+	secret, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, "", models.ErrTokenGenerationFailed
+	}
+	rawKey := models.APIKeyPrefix + secret
+
+	key := &models.APIKey{
+		UserID:    userID,
+		Name:      req.Name,
+		Prefix:    rawKey[:len(models.APIKeyPrefix)+apiKeyVisiblePrefix],
+		KeyHash:   utils.HashOpaqueToken(rawKey),
+		Scopes:    uniqueScopes(req.Scopes),
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err := s.apiKeyRepo.Create(key); err != nil {
+		return nil, "", models.ErrDatabaseError
+	}
+
+	return key, rawKey, nil
+}
+
+// GetAPIKeys возвращает активные ключи пользователя
+func (s *APIKeyService) GetAPIKeys(userID uint) ([]models.APIKey, error) {
+	keys, err := s.apiKeyRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, models.ErrDatabaseError
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey отзывает ключ пользователя
+func (s *APIKeyService) RevokeAPIKey(userID, keyID uint) error {
+	revoked, err := s.apiKeyRepo.Revoke(userID, keyID)
+	if err != nil {
+		return models.ErrDatabaseError
+	}
+	if !revoked {
+		return models.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// Authenticate проверяет API ключ и возвращает его запись
+func (s *APIKeyService) Authenticate(rawKey string) (*models.APIKey, error) {
+	key, err := s.apiKeyRepo.FindByHash(utils.HashOpaqueToken(rawKey))
+	if err != nil {
+		if errors.Is(err, models.ErrRecordNotFound) {
+			return nil, models.ErrInvalidAPIKey
+		}
+		return nil, models.ErrDatabaseError
+	}
+
+	now := time.Now()
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && now.After(*key.ExpiresAt)) {
+		return nil, models.ErrInvalidAPIKey
+	}
+
+	// Время использования обновляется не чаще раза в минуту, чтобы не писать в БД на каждый запрос
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > apiKeyTouchInterval {
+		if err := s.apiKeyRepo.TouchLastUsed(key.ID, now); err != nil {
+			log.Printf("Не удалось обновить время использования API ключа %d: %v", key.ID, err)
+		}
+	}
+
+	return key, nil
+}
+
+// ------------------------------------------------------------
+// Вспомогательные методы
+// ------------------------------------------------------------
+
+// uniqueScopes удаляет повторяющиеся области доступа, сохраняя порядок
+func uniqueScopes(scopes []string) []string {
+	seen := make(map[string]bool, len(scopes))
+	result := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !seen[scope] {
+			seen[scope] = true
+			result = append(result, scope)
+		}
+	}
+	return result
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"khrllwTest/internal/models"
+	"khrllwTest/internal/repository"
+)
+
+// maxAuditPathLength ограничение длины сохраняемого пути запроса (размер колонки)
+const maxAuditPathLength = 512
+
+// ------------------------------------------------------------
+// Структуры
+// ------------------------------------------------------------
+
+// AuditService ведет журнал аудита действий администраторов от имени пользователей
+type AuditService struct {
+	auditRepo repository.AuditLogRepository
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewAuditService создает новый экземпляр AuditService
+func NewAuditService(auditRepo repository.AuditLogRepository) *AuditService {
+	return &AuditService{auditRepo: auditRepo}
+}
+
+// ------------------------------------------------------------
+// Основные методы
+// ------------------------------------------------------------
+
+// Record сохраняет запись журнала, обрезая поля до размера колонок
+func (s *AuditService) Record(entry *models.AuditLog) error {
+	entry.Path = truncateUTF8(entry.Path, maxAuditPathLength)
+	entry.UserAgent = truncateUTF8(entry.UserAgent, maxUserAgentLength)
+
+	if err := s.auditRepo.Create(entry); err != nil {
+		return models.ErrDatabaseError
+	}
+	return nil
+}
+
+// List возвращает записи журнала с пагинацией; нулевые actorID и userID не фильтруют
+func (s *AuditService) List(page, limit int, actorID, userID uint) ([]models.AuditLog, int64, error) {
+	offset := (page - 1) * limit
+	entries, total, err := s.auditRepo.FindAll(offset, limit, actorID, userID)
+	if err != nil {
+		return nil, 0, models.ErrDatabaseError
+	}
+	return entries, total, nil
+}
@@ -0,0 +1,267 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // регистрация декодера GIF
+	"image/jpeg"
+	_ "image/png" // регистрация декодера PNG
+	"khrllwTest/internal/models"
+	"khrllwTest/internal/repository"
+	"khrllwTest/internal/storage"
+	"khrllwTest/internal/utils"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// avatarJPEGQuality качество JPEG миниатюр аватара
+	avatarJPEGQuality = 85
+
+	// avatarMinSize и avatarMaxSize допустимые размеры миниатюр в AVATAR_SIZES
+	avatarMinSize = 16
+	avatarMaxSize = 1024
+)
+
+// avatarContentTypes типы изображений, принимаемые как аватар (определяются по содержимому файла)
+var avatarContentTypes = map[string]bool{"image/jpeg": true, "image/png": true, "image/gif": true}
+
+// ------------------------------------------------------------
+// Конфигурация
+// ------------------------------------------------------------
+
+// AvatarConfig содержит настройки загрузки аватаров
+type AvatarConfig struct {
+	// Максимальный размер загружаемого файла в байтах
+	MaxBytes int
+
+	// Максимальная ширина и высота загружаемого изображения в пикселях
+	MaxDimension int
+
+	// Стороны квадратных миниатюр по возрастанию
+	Sizes []int
+}
+
+// NewAvatarConfig создает конфигурацию аватаров из переменных окружения
+func NewAvatarConfig() (*AvatarConfig, error) {
+	maxBytes, err := envInt("AVATAR_MAX_BYTES", 5<<20)
+	if err != nil {
+		return nil, err
+	}
+	maxDimension, err := envInt("AVATAR_MAX_DIMENSION", 4096)
+	if err != nil {
+		return nil, err
+	}
+	sizes, err := parseAvatarSizes(os.Getenv("AVATAR_SIZES"))
+	if err != nil {
+		return nil, err
+	}
+	return &AvatarConfig{
+		MaxBytes:     maxBytes,
+		MaxDimension: maxDimension,
+		Sizes:        sizes,
+	}, nil
+}
+
+// ------------------------------------------------------------
+// Структуры
+// ------------------------------------------------------------
+
+// AvatarService реализует загрузку аватаров: проверку изображения, нарезку миниатюр
+// и их хранение в файловом хранилище
+type AvatarService struct {
+	userRepo repository.UserRepository
+	storage  storage.Storage
+	config   *AvatarConfig
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewAvatarService создает новый экземпляр AvatarService
+func NewAvatarService(userRepo repository.UserRepository, store storage.Storage, config *AvatarConfig) *AvatarService {
+	return &AvatarService{
+		userRepo: userRepo,
+		storage:  store,
+		config:   config,
+	}
+}
+
+// ------------------------------------------------------------
+// Основные методы
+// ------------------------------------------------------------
+
+// MaxBytes возвращает максимальный размер загружаемого файла аватара
+func (s *AvatarService) MaxBytes() int {
+	return s.config.MaxBytes
+}
+
+// UploadAvatar проверяет изображение, сохраняет его квадратные миниатюры в JPEG
+// и заменяет ими аватар пользователя. Тип файла определяется по содержимому, а не по заголовкам запроса.
+// Миниатюры прежнего аватара удаляются после успешной замены.
+// version - версия из If-Match (0 - без проверки).
+func (s *AvatarService) UploadAvatar(userID uint, data []byte, version int) (*models.User, error) {
+	if len(data) > s.config.MaxBytes {
+		return nil, models.ErrAvatarTooLarge
+	}
+	if !avatarContentTypes[http.DetectContentType(data)] {
+		return nil, models.ErrUnsupportedAvatarType
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return nil, models.ErrUserNotFound
+		}
+		return nil, models.ErrDatabaseError
+	}
+	if err := checkVersion(user.Version, version); err != nil {
+		return nil, err
+	}
+
+	thumbnails, err := s.renderThumbnails(data)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, models.ErrInternalServerError
+	}
+	prefix := fmt.Sprintf("avatars/%d/%s", userID, token[:16])
+
+	for i, size := range s.config.Sizes {
+		if err := s.storage.Put(avatarObjectKey(prefix, size), "image/jpeg", thumbnails[i]); err != nil {
+			log.Printf("Не удалось сохранить аватар пользователя %d: %v", userID, err)
+			s.deleteThumbnails(prefix)
+			return nil, models.ErrInternalServerError
+		}
+	}
+
+	if err := s.userRepo.UpdateFields(userID, version, map[string]interface{}{"avatar_key": prefix}); err != nil {
+		s.deleteThumbnails(prefix)
+		if errors.Is(err, models.ErrUserNotFound) {
+			return nil, models.ErrUserNotFound
+		}
+		return nil, versionConflict(err, version)
+	}
+	if user.AvatarKey != "" {
+		s.deleteThumbnails(user.AvatarKey)
+	}
+
+	user, err = s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, models.ErrDatabaseError
+	}
+	return user, nil
+}
+
+// OpenAvatar открывает миниатюру аватара пользователя (size 0 - наибольшая) и возвращает ее ETag.
+// ETag меняется с каждой загрузкой, так как ключи миниатюр уникальны.
+func (s *AvatarService) OpenAvatar(userID uint, size int) (*storage.Object, string, error) {
+	if size == 0 {
+		size = s.config.Sizes[len(s.config.Sizes)-1]
+	}
+	if !slices.Contains(s.config.Sizes, size) {
+		return nil, "", models.ErrInvalidAvatarSize
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return nil, "", models.ErrUserNotFound
+		}
+		return nil, "", models.ErrDatabaseError
+	}
+	if user.AvatarKey == "" {
+		return nil, "", models.ErrAvatarNotFound
+	}
+
+	object, err := s.storage.Get(avatarObjectKey(user.AvatarKey, size))
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			return nil, "", models.ErrAvatarNotFound
+		}
+		log.Printf("Не удалось прочитать аватар пользователя %d: %v", userID, err)
+		return nil, "", models.ErrInternalServerError
+	}
+	return object, path.Base(user.AvatarKey) + "-" + strconv.Itoa(size), nil
+}
+
+// ------------------------------------------------------------
+// Вспомогательные методы
+// ------------------------------------------------------------
+
+// renderThumbnails декодирует изображение и нарезает из его центра квадратные миниатюры в JPEG.
+// Размеры изображения проверяются до декодирования, чтобы маленький файл не развернулся в огромный растр.
+func (s *AvatarService) renderThumbnails(data []byte) ([][]byte, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, models.ErrInvalidAvatar
+	}
+	if config.Width > s.config.MaxDimension || config.Height > s.config.MaxDimension {
+		return nil, models.ErrAvatarDimensions
+	}
+	if config.Width == 0 || config.Height == 0 {
+		return nil, models.ErrInvalidAvatar
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, models.ErrInvalidAvatar
+	}
+
+	square := utils.CropSquare(img)
+	thumbnails := make([][]byte, len(s.config.Sizes))
+	for i, size := range s.config.Sizes {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, utils.ResizeSquare(square, size), &jpeg.Options{Quality: avatarJPEGQuality}); err != nil {
+			return nil, models.ErrInternalServerError
+		}
+		thumbnails[i] = buf.Bytes()
+	}
+	return thumbnails, nil
+}
+
+// deleteThumbnails удаляет миниатюры аватара текущих размеров (ошибки только логируются)
+func (s *AvatarService) deleteThumbnails(prefix string) {
+	for _, size := range s.config.Sizes {
+		if err := s.storage.Delete(avatarObjectKey(prefix, size)); err != nil {
+			log.Printf("Не удалось удалить миниатюру аватара %s: %v", prefix, err)
+		}
+	}
+}
+
+// avatarObjectKey возвращает ключ миниатюры аватара в хранилище (avatars/7/<токен>/128.jpg)
+func avatarObjectKey(prefix string, size int) string {
+	return prefix + "/" + strconv.Itoa(size) + ".jpg"
+}
+
+// parseAvatarSizes разбирает AVATAR_SIZES ("64,128,256") в отсортированный список размеров без повторов
+func parseAvatarSizes(value string) ([]int, error) {
+	if value == "" {
+		return []int{64, 128, 256}, nil // значение по умолчанию
+	}
+
+	var sizes []int
+	for _, part := range strings.Split(value, ",") {
+		size, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || size < avatarMinSize || size > avatarMaxSize {
+			return nil, fmt.Errorf("неверное значение AVATAR_SIZES. Ожидаются размеры от %d до %d через запятую, например: 64,128,256",
+				avatarMinSize, avatarMaxSize)
+		}
+		if !slices.Contains(sizes, size) {
+			sizes = append(sizes, size)
+		}
+	}
+	slices.Sort(sizes)
+	return sizes, nil
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"errors"
+	"khrllwTest/internal/mail"
+	"khrllwTest/internal/models"
+	"khrllwTest/internal/repository"
+	"khrllwTest/internal/utils"
+	"log"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// ------------------------------------------------------------
+// Конфигурация
+// ------------------------------------------------------------
+
+// EmailVerificationConfig содержит настройки подтверждения email
+type EmailVerificationConfig struct {
+	// Время жизни ссылки подтверждения
+	TokenTTL time.Duration
+
+	// Адрес страницы подтверждения; токен добавляется параметром token
+	VerifyURL string
+
+	// Минимальный интервал между письмами одному пользователю
+	ResendInterval time.Duration
+}
+
+// NewEmailVerificationConfig создает конфигурацию подтверждения email из переменных окружения
+func NewEmailVerificationConfig() (*EmailVerificationConfig, error) {
+	ttl, err := envDuration("EMAIL_VERIFICATION_TTL", 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	resendInterval, err := envDuration("EMAIL_VERIFICATION_RESEND_INTERVAL", time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	verifyURL := os.Getenv("EMAIL_VERIFY_URL")
+	if verifyURL == "" {
+		verifyURL = "http://localhost:8080/verify-email" // значение по умолчанию
+	}
+	if _, err := url.Parse(verifyURL); err != nil {
+		return nil, errors.New("неверный формат EMAIL_VERIFY_URL")
+	}
+
+	return &EmailVerificationConfig{
+		TokenTTL:       ttl,
+		VerifyURL:      verifyURL,
+		ResendInterval: resendInterval,
+	}, nil
+}
+
+// ------------------------------------------------------------
+// Структуры
+// ------------------------------------------------------------
+
+// EmailVerificationService отправляет и проверяет подписанные ссылки подтверждения email
+type EmailVerificationService struct {
+	userRepo     repository.UserRepository
+	tokenManager utils.TokenManager
+	sender       mail.Sender
+	config       *EmailVerificationConfig
+
+	// Время последней отправки письма по пользователям (для ограничения повторной отправки)
+	mu       sync.Mutex
+	lastSent map[uint]time.Time
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewEmailVerificationService создает новый экземпляр EmailVerificationService
+func NewEmailVerificationService(
+	userRepo repository.UserRepository,
+	tokenManager utils.TokenManager,
+	sender mail.Sender,
+	config *EmailVerificationConfig,
+) *EmailVerificationService {
+	return &EmailVerificationService{
+		userRepo:     userRepo,
+		tokenManager: tokenManager,
+		sender:       sender,
+		config:       config,
+		lastSent:     make(map[uint]time.Time),
+	}
+}
+
+// ------------------------------------------------------------
+// Основные методы
+// ------------------------------------------------------------
+
+// SendVerification отправляет ссылку подтверждения на текущий email пользователя.
+// Токен привязан к email, поэтому после смены адреса старые ссылки не действуют.
+func (s *EmailVerificationService) SendVerification(user *models.User) error {
+	s.mu.Lock()
+	sentAt := time.Now()
+	s.lastSent[user.ID] = sentAt
+	s.mu.Unlock()
+
+	return s.send(user, sentAt)
+}
+
+// Resend повторно отправляет ссылку подтверждения не чаще ResendInterval
+func (s *EmailVerificationService) Resend(userID uint) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return err
+		}
+		return models.ErrDatabaseError
+	}
+
+	if user.EmailVerifiedAt != nil {
+		return models.ErrEmailAlreadyVerified
+	}
+
+	// Место занимается до генерации и отправки письма: из параллельных запросов проходит только один
+	sentAt, wait := s.reserveResend(userID)
+	if wait > 0 {
+		return &models.RetryAfterError{Err: models.ErrVerificationResendLimit, RetryAfter: wait}
+	}
+
+	return s.send(user, sentAt)
+}
+
+// Verify проверяет токен из ссылки и отмечает email подтвержденным.
+// Повторный переход по ссылке для уже подтвержденного email не считается ошибкой.
+func (s *EmailVerificationService) Verify(rawToken string) error {
+	token, err := s.tokenManager.Parse(rawToken)
+	if err != nil || !token.Valid {
+		return models.ErrInvalidVerificationToken
+	}
+
+	claims, err := s.tokenManager.ExtractClaims(token)
+	if err != nil || claims.Purpose != utils.TokenPurposeVerifyEmail || claims.Email == "" {
+		return models.ErrInvalidVerificationToken
+	}
+
+	user, err := s.userRepo.FindByID(claims.UserID)
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return models.ErrInvalidVerificationToken
+		}
+		return models.ErrDatabaseError
+	}
+
+	// Ссылка, выданная для прежнего адреса, недействительна
+	if user.Email != claims.Email {
+		return models.ErrInvalidVerificationToken
+	}
+	if user.EmailVerifiedAt != nil {
+		return nil
+	}
+
+	if _, err := s.userRepo.MarkEmailVerified(user.ID, claims.Email); err != nil {
+		return models.ErrDatabaseError
+	}
+	return nil
+}
+
+// ------------------------------------------------------------
+// Вспомогательные методы
+// ------------------------------------------------------------
+
+// reserveResend проверяет интервал повторной отправки и, если он истек, сразу отмечает отправку.
+// Возвращает время отметки или время, оставшееся до разрешенной повторной отправки.
+func (s *EmailVerificationService) reserveResend(userID uint) (time.Time, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if last, ok := s.lastSent[userID]; ok {
+		if wait := s.config.ResendInterval - now.Sub(last); wait > 0 {
+			return time.Time{}, wait
+		}
+	}
+
+	s.lastSent[userID] = now
+	return now, 0
+}
+
+// releaseResend снимает отметку отправки, если письмо так и не было отправлено
+// и отметку не успел заменить другой запрос
+func (s *EmailVerificationService) releaseResend(userID uint, sentAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if last, ok := s.lastSent[userID]; ok && last.Equal(sentAt) {
+		delete(s.lastSent, userID)
+	}
+}
+
+// send выпускает токен подтверждения и отправляет письмо в фоне.
+// sentAt - отметка отправки, которая снимается при ошибке выпуска токена.
+func (s *EmailVerificationService) send(user *models.User, sentAt time.Time) error {
+	token, err := s.tokenManager.Generate(utils.TokenSubject{
+		UserID:    user.ID,
+		Role:      user.Role,
+		Purpose:   utils.TokenPurposeVerifyEmail,
+		Email:     user.Email,
+		ExpiresIn: s.config.TokenTTL,
+	})
+	if err != nil {
+		s.releaseResend(user.ID, sentAt)
+		return models.ErrTokenGenerationFailed
+	}
+
+	go s.sendVerificationMail(user.Email, token)
+	return nil
+}
+
+// sendVerificationMail отправляет письмо со ссылкой подтверждения
+func (s *EmailVerificationService) sendVerificationMail(email, token string) {
+	link := s.config.VerifyURL + "?token=" + url.QueryEscape(token)
+
+	msg := mail.Message{
+		To:      email,
+		Subject: "Подтверждение email",
+		Body: "Чтобы подтвердить адрес электронной почты, перейдите по ссылке:\n\n" + link +
+			"\n\nСсылка действует " + s.config.TokenTTL.String() + "." +
+			"\nЕсли вы не регистрировались, просто проигнорируйте это письмо.",
+	}
+
+	if err := s.sender.Send(msg); err != nil {
+		log.Printf("Ошибка отправки письма подтверждения email: %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"errors"
+	"khrllwTest/internal/models"
+	"khrllwTest/internal/repository"
+	"khrllwTest/internal/utils"
+	"time"
+)
+
+// maxImpersonationTTL верхняя граница времени жизни токена имперсонации
+const maxImpersonationTTL = time.Hour
+
+// ------------------------------------------------------------
+// Конфигурация
+// ------------------------------------------------------------
+
+// ImpersonationConfig содержит настройки входа администратора от имени пользователя
+type ImpersonationConfig struct {
+	// Время жизни токена имперсонации
+	TTL time.Duration
+}
+
+// NewImpersonationConfig создает конфигурацию имперсонации из переменных окружения
+func NewImpersonationConfig() (*ImpersonationConfig, error) {
+	ttl, err := envDuration("IMPERSONATION_TTL", 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	if ttl > maxImpersonationTTL {
+		return nil, errors.New("IMPERSONATION_TTL не может превышать 1h")
+	}
+	return &ImpersonationConfig{TTL: ttl}, nil
+}
+
+// ------------------------------------------------------------
+// Структуры
+// ------------------------------------------------------------
+
+// ImpersonationService выпускает токены входа администратора от имени пользователя.
+// Токен содержит sub пользователя и act администратора, не продлевается и не создает сессию.
+type ImpersonationService struct {
+	userRepo     repository.UserRepository
+	tokenManager utils.TokenManager
+	audit        *AuditService
+	config       *ImpersonationConfig
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewImpersonationService создает новый экземпляр ImpersonationService
+func NewImpersonationService(
+	userRepo repository.UserRepository,
+	tokenManager utils.TokenManager,
+	audit *AuditService,
+	config *ImpersonationConfig,
+) *ImpersonationService {
+	return &ImpersonationService{
+		userRepo:     userRepo,
+		tokenManager: tokenManager,
+		audit:        audit,
+		config:       config,
+	}
+}
+
+// ------------------------------------------------------------
+// Основные методы
+// ------------------------------------------------------------
+
+// Start выпускает токен имперсонации пользователя targetID администратором actorID
+// и записывает начало имперсонации в журнал аудита
+func (s *ImpersonationService) Start(actorID, targetID uint, reason, clientIP, userAgent string) (*models.ImpersonationResponse, error) {
+	if actorID == targetID {
+		return nil, models.ErrCannotImpersonate
+	}
+
+	target, err := s.userRepo.FindByID(targetID)
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return nil, err
+		}
+		return nil, models.ErrDatabaseError
+	}
+
+	// Вход от имени администратора дал бы его полномочия без следа в его собственных токенах
+	if target.Role == models.RoleAdmin {
+		return nil, models.ErrCannotImpersonate
+	}
+
+	token, err := s.tokenManager.Generate(utils.TokenSubject{
+		UserID:       target.ID,
+		Role:         target.Role,
+		TokenVersion: target.TokenVersion,
+		ExpiresIn:    s.config.TTL,
+		ActorID:      actorID,
+	})
+	if err != nil {
+		return nil, models.ErrTokenGenerationFailed
+	}
+
+	parsed, err := s.tokenManager.Parse(token)
+	if err != nil {
+		return nil, models.ErrTokenGenerationFailed
+	}
+	claims, err := s.tokenManager.ExtractClaims(parsed)
+	if err != nil {
+		return nil, models.ErrTokenGenerationFailed
+	}
+
+	// Без записи в журнале токен не выдается
+	if err := s.audit.Record(&models.AuditLog{
+		ActorID:   actorID,
+		UserID:    target.ID,
+		Action:    models.AuditActionImpersonationStart,
+		TokenID:   claims.ID,
+		Reason:    truncateUTF8(reason, 255),
+		IPAddress: clientIP,
+		UserAgent: userAgent,
+	}); err != nil {
+		return nil, err
+	}
+
+	return &models.ImpersonationResponse{
+		Token:     token,
+		ExpiresIn: int64(s.config.TTL.Seconds()),
+		UserID:    target.ID,
+		ActorID:   actorID,
+	}, nil
+}
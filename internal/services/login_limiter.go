@@ -0,0 +1,341 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"khrllwTest/internal/models"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxTrackedRecords количество записей, после которого устаревшие записи удаляются.
+// Если устаревших записей недостаточно, вытесняются самые старые незаблокированные,
+// чтобы перебор случайных email не расходовал память без ограничений
+const maxTrackedRecords = 10000
+
+// ------------------------------------------------------------
+// Конфигурация
+// ------------------------------------------------------------
+
+// LoginLimiterConfig содержит настройки защиты от перебора паролей
+type LoginLimiterConfig struct {
+	// Количество неудачных попыток для аккаунта до временной блокировки
+	MaxAttempts int
+
+	// Количество неудачных попыток с одного IP до временной блокировки
+	IPMaxAttempts int
+
+	// Количество неудачных попыток, после которого включаются задержки
+	DelayAfter int
+
+	// Начальная задержка, удваивается с каждой следующей неудачей
+	BaseDelay time.Duration
+
+	// Длительность блокировки и окно, в котором учитываются неудачи
+	LockoutDuration time.Duration
+}
+
+// NewLoginLimiterConfig создает конфигурацию защиты от перебора из переменных окружения
+func NewLoginLimiterConfig() (*LoginLimiterConfig, error) {
+	maxAttempts, err := envInt("LOGIN_MAX_ATTEMPTS", 5)
+	if err != nil {
+		return nil, err
+	}
+
+	ipMaxAttempts, err := envInt("LOGIN_IP_MAX_ATTEMPTS", 20)
+	if err != nil {
+		return nil, err
+	}
+
+	delayAfter, err := envInt("LOGIN_DELAY_AFTER", 3)
+	if err != nil {
+		return nil, err
+	}
+
+	baseDelay, err := envDuration("LOGIN_BASE_DELAY", time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	lockout, err := envDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	if baseDelay > lockout {
+		return nil, fmt.Errorf("LOGIN_BASE_DELAY (%s) больше LOGIN_LOCKOUT_DURATION (%s)", baseDelay, lockout)
+	}
+
+	return &LoginLimiterConfig{
+		MaxAttempts:     maxAttempts,
+		IPMaxAttempts:   ipMaxAttempts,
+		DelayAfter:      delayAfter,
+		BaseDelay:       baseDelay,
+		LockoutDuration: lockout,
+	}, nil
+}
+
+// ------------------------------------------------------------
+// Структуры
+// ------------------------------------------------------------
+
+// LoginLimiter отслеживает неудачные попытки входа по аккаунту и по IP клиента
+type LoginLimiter struct {
+	accounts *attemptTracker
+	ips      *attemptTracker
+}
+
+// attemptRecord состояние неудачных попыток для одного ключа
+type attemptRecord struct {
+	failures    int
+	lastFailure time.Time
+	nextAttempt time.Time
+	lockedUntil time.Time
+}
+
+// attemptTracker считает неудачные попытки с прогрессивной задержкой и блокировкой
+type attemptTracker struct {
+	mu          sync.Mutex
+	records     map[string]*attemptRecord
+	delayAfter  int
+	maxAttempts int
+	baseDelay   time.Duration
+	lockout     time.Duration
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewLoginLimiter создает новый экземпляр LoginLimiter.
+// Для IP задержки включаются после половины порога блокировки,
+// чтобы несколько пользователей за одним NAT не мешали друг другу.
+func NewLoginLimiter(config *LoginLimiterConfig) *LoginLimiter {
+	return &LoginLimiter{
+		accounts: newAttemptTracker(config.DelayAfter, config.MaxAttempts, config.BaseDelay, config.LockoutDuration),
+		ips:      newAttemptTracker(config.IPMaxAttempts/2, config.IPMaxAttempts, config.BaseDelay, config.LockoutDuration),
+	}
+}
+
+// newAttemptTracker создает счетчик попыток
+func newAttemptTracker(delayAfter, maxAttempts int, baseDelay, lockout time.Duration) *attemptTracker {
+	return &attemptTracker{
+		records:     make(map[string]*attemptRecord),
+		delayAfter:  delayAfter,
+		maxAttempts: maxAttempts,
+		baseDelay:   baseDelay,
+		lockout:     lockout,
+	}
+}
+
+// ------------------------------------------------------------
+// Основные методы
+// ------------------------------------------------------------
+
+// Allow проверяет, можно ли сейчас выполнить попытку входа.
+// Возвращает RetryAfterError с ErrAccountLocked или ErrTooManyLoginAttempts.
+func (l *LoginLimiter) Allow(email, clientIP string) error {
+	now := time.Now()
+
+	if wait, locked := l.accounts.check(normalizeEmail(email), now); wait > 0 {
+		if locked {
+			return &models.RetryAfterError{Err: models.ErrAccountLocked, RetryAfter: wait}
+		}
+		return &models.RetryAfterError{Err: models.ErrTooManyLoginAttempts, RetryAfter: wait}
+	}
+
+	if wait, _ := l.ips.check(clientIP, now); wait > 0 {
+		return &models.RetryAfterError{Err: models.ErrTooManyLoginAttempts, RetryAfter: wait}
+	}
+
+	return nil
+}
+
+// RecordFailure учитывает неудачную попытку для аккаунта и IP
+func (l *LoginLimiter) RecordFailure(email, clientIP string) {
+	now := time.Now()
+	l.accounts.fail(normalizeEmail(email), now)
+	l.ips.fail(clientIP, now)
+}
+
+// RecordSuccess сбрасывает счетчик аккаунта после успешного входа
+func (l *LoginLimiter) RecordSuccess(email string) {
+	l.accounts.reset(normalizeEmail(email))
+}
+
+// ------------------------------------------------------------
+// Методы attemptTracker
+// ------------------------------------------------------------
+
+// check возвращает время ожидания до следующей попытки и признак блокировки
+func (t *attemptTracker) check(key string, now time.Time) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	record, ok := t.records[key]
+	if !ok {
+		return 0, false
+	}
+
+	if now.Before(record.lockedUntil) {
+		return record.lockedUntil.Sub(now), true
+	}
+	if now.Before(record.nextAttempt) {
+		return record.nextAttempt.Sub(now), false
+	}
+	return 0, false
+}
+
+// fail учитывает неудачную попытку и вычисляет задержку или блокировку
+func (t *attemptTracker) fail(key string, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	record, ok := t.records[key]
+	if !ok || t.isStale(record, now) {
+		if len(t.records) >= maxTrackedRecords {
+			t.sweep(now)
+		}
+		if len(t.records) >= maxTrackedRecords {
+			// Все записи заблокированы: новый ключ не учитывается, остается ограничение по IP
+			return
+		}
+		record = &attemptRecord{}
+		t.records[key] = record
+	}
+
+	record.failures++
+	record.lastFailure = now
+
+	if record.failures >= t.maxAttempts {
+		record.lockedUntil = now.Add(t.lockout)
+		record.failures = 0
+		return
+	}
+
+	if record.failures > t.delayAfter {
+		// Удвоение останавливается на длительности блокировки, поэтому задержка не переполняется
+		// при большом пороге попыток
+		delay := t.baseDelay
+		for i := t.delayAfter + 1; i < record.failures && delay < t.lockout; i++ {
+			delay *= 2
+		}
+		if delay > t.lockout {
+			delay = t.lockout
+		}
+		record.nextAttempt = now.Add(delay)
+	}
+}
+
+// reset удаляет запись о неудачных попытках
+func (t *attemptTracker) reset(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.records, key)
+}
+
+// isStale проверяет, что запись вышла за окно учета и не заблокирована
+func (t *attemptTracker) isStale(record *attemptRecord, now time.Time) bool {
+	return now.After(record.lockedUntil) && now.Sub(record.lastFailure) > t.lockout
+}
+
+// sweep удаляет устаревшие записи и, если записей все еще больше 90% лимита, самые старые
+// незаблокированные (вызывается под блокировкой). Запас в 10% лимита избавляет от сортировки при каждой новой записи.
+// Заблокированные записи не вытесняются, иначе перебором можно было бы снять блокировку аккаунта.
+func (t *attemptTracker) sweep(now time.Time) {
+	for key, record := range t.records {
+		if t.isStale(record, now) {
+			delete(t.records, key)
+		}
+	}
+
+	target := maxTrackedRecords * 9 / 10
+	if len(t.records) <= target {
+		return
+	}
+
+	keys := make([]string, 0, len(t.records))
+	for key, record := range t.records {
+		if !now.Before(record.lockedUntil) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return t.records[keys[i]].lastFailure.Before(t.records[keys[j]].lastFailure)
+	})
+	for _, key := range keys {
+		if len(t.records) <= target {
+			break
+		}
+		delete(t.records, key)
+	}
+}
+
+// ------------------------------------------------------------
+// Вспомогательные функции
+// ------------------------------------------------------------
+
+// normalizeEmail приводит email к виду, используемому как ключ учета попыток
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// envInt читает положительное целое из переменной окружения
+func envInt(name string, fallback int) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed <= 0 {
+		return 0, errors.New("неверное значение " + name + ". Ожидается положительное целое число")
+	}
+	return parsed, nil
+}
+
+// envNonNegativeInt читает неотрицательное целое из переменной окружения (0 - ограничение отключено)
+func envNonNegativeInt(name string, fallback int) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 0 {
+		return 0, errors.New("неверное значение " + name + ". Ожидается неотрицательное целое число")
+	}
+	return parsed, nil
+}
+
+// envBool читает логическое значение из переменной окружения
+func envBool(name string, fallback bool) (bool, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errors.New("неверное значение " + name + ". Ожидается true или false")
+	}
+	return parsed, nil
+}
+
+// envDuration читает положительную длительность из переменной окружения
+func envDuration(name string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed <= 0 {
+		return 0, errors.New("неверный формат " + name + ". Пример: 15m, 1s")
+	}
+	return parsed, nil
+}
@@ -2,9 +2,12 @@ package service
 
 import (
 	"errors"
+	"github.com/google/uuid"
 	"khrllwTest/internal/models"
 	"khrllwTest/internal/repository"
 	"khrllwTest/internal/utils"
+	"log"
+	"time"
 )
 
 // ------------------------------------------------------------
@@ -14,8 +17,18 @@ import (
 // LoginService отвечает за бизнес-логику авторизации
 type LoginService struct {
 	userRepo     repository.UserRepository
+	refreshRepo  repository.RefreshTokenRepository
+	revokedRepo  repository.RevokedTokenRepository
 	tokenManager utils.TokenManager
 	passHasher   utils.PasswordHasher
+	authConfig   *utils.JWTConfig
+	limiter      *LoginLimiter
+	twoFactor    *TwoFactorService
+	sessions     *SessionService
+
+	// dummyHash хеш для проверки пароля при неизвестном email,
+	// чтобы время ответа не выдавало существование аккаунта
+	dummyHash string
 }
 
 // ------------------------------------------------------------
@@ -25,14 +38,30 @@ type LoginService struct {
 // NewLoginService создает новый экземпляр LoginService
 func NewLoginService(
 	userRepo repository.UserRepository,
+	refreshRepo repository.RefreshTokenRepository,
+	revokedRepo repository.RevokedTokenRepository,
 	tokenManager utils.TokenManager,
 	passHasher utils.PasswordHasher,
+	authConfig *utils.JWTConfig,
+	limiter *LoginLimiter,
+	twoFactor *TwoFactorService,
+	sessions *SessionService,
 ) *LoginService {
 
+	// Ошибка не критична: при пустом хеше проверка просто завершится неудачей
+	dummyHash, _ := passHasher.Hash(uuid.New().String())
+
 	return &LoginService{
 		userRepo:     userRepo,
+		refreshRepo:  refreshRepo,
+		revokedRepo:  revokedRepo,
 		tokenManager: tokenManager,
 		passHasher:   passHasher,
+		authConfig:   authConfig,
+		limiter:      limiter,
+		twoFactor:    twoFactor,
+		sessions:     sessions,
+		dummyHash:    dummyHash,
 	}
 }
 
@@ -40,23 +69,194 @@ func NewLoginService(
 // Методы реализации
 // ------------------------------------------------------------
 
-// Login выполняет авторизацию пользователя и возвращает JWT токен
-func (s *LoginService) Login(email, password string) (string, error) {
+// Login выполняет авторизацию пользователя и возвращает пару access/refresh токенов.
+// Неудачные попытки учитываются по аккаунту и IP клиента: после нескольких неудач
+// включаются нарастающие задержки, после порога - временная блокировка.
+// Для пользователей с 2FA вместо токенов возвращается challenge токен для LoginTwoFactor.
+// Успешный вход создает сессию с IP и User-Agent клиента.
+func (s *LoginService) Login(email, password, clientIP, userAgent string) (*models.LoginResponse, error) {
 	if email == "" || password == "" {
-		return "", models.ErrEmailPasswordRequired
+		return nil, models.ErrEmailPasswordRequired
+	}
+
+	if err := s.limiter.Allow(email, clientIP); err != nil {
+		return nil, err
 	}
 
 	user, err := s.authenticateUser(email, password)
 	if err != nil {
-		return "", err
+		if errors.Is(err, models.ErrInvalidCredentials) {
+			s.limiter.RecordFailure(email, clientIP)
+		}
+		return nil, err
+	}
+
+	// Счетчик неудач сбрасывается только после второго шага,
+	// иначе знание пароля позволяло бы перебирать коды 2FA без блокировки
+	if user.TOTPEnabled {
+		return s.issueChallenge(user)
+	}
+	s.limiter.RecordSuccess(email)
+
+	return s.startSession(user, clientIP, userAgent)
+}
+
+// LoginTwoFactor завершает вход с 2FA: обменивает challenge токен и код TOTP
+// или код восстановления на пару токенов. Challenge токен одноразовый.
+func (s *LoginService) LoginTwoFactor(challengeToken, code, clientIP, userAgent string) (*models.LoginResponse, error) {
+	claims, err := s.parseChallenge(challengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByID(claims.UserID)
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return nil, models.ErrInvalidChallengeToken
+		}
+		return nil, models.ErrDatabaseError
+	}
+
+	if claims.TokenVersion != user.TokenVersion || !user.TOTPEnabled {
+		return nil, models.ErrInvalidChallengeToken
+	}
+
+	if err := s.limiter.Allow(user.Email, clientIP); err != nil {
+		return nil, err
+	}
+
+	valid, err := s.twoFactor.Verify(user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		s.limiter.RecordFailure(user.Email, clientIP)
+		return nil, models.ErrInvalidTwoFactorCode
+	}
+
+	// Погашаем challenge токен, чтобы его нельзя было использовать повторно
+	revoked := &models.RevokedToken{
+		JTI:       claims.ID,
+		UserID:    user.ID,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}
+	if err := s.revokedRepo.Revoke(revoked); err != nil {
+		return nil, models.ErrDatabaseError
+	}
+	s.limiter.RecordSuccess(user.Email)
+
+	return s.startSession(user, clientIP, userAgent)
+}
+
+// LoginExternal выпускает токены пользователю, подтвердившему личность у внешнего провайдера.
+// Для пользователей с 2FA вместо токенов возвращается challenge токен для LoginTwoFactor.
+func (s *LoginService) LoginExternal(user *models.User, clientIP, userAgent string) (*models.LoginResponse, error) {
+	if user.TOTPEnabled {
+		return s.issueChallenge(user)
+	}
+	return s.startSession(user, clientIP, userAgent)
+}
+
+// Refresh обменивает refresh токен на новую пару токенов с ротацией.
+// Повторное использование уже ротированного токена отзывает все семейство.
+// Токены завершенной сессии не обмениваются.
+func (s *LoginService) Refresh(refreshToken, clientIP, userAgent string) (*models.LoginResponse, error) {
+	if refreshToken == "" {
+		return nil, models.ErrInvalidRefreshToken
+	}
+
+	stored, err := s.refreshRepo.FindByHash(utils.HashOpaqueToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, models.ErrRecordNotFound) {
+			return nil, models.ErrInvalidRefreshToken
+		}
+		return nil, models.ErrDatabaseError
+	}
+
+	if stored.RevokedAt != nil {
+		return nil, s.revokeFamily(stored.UserID, stored.FamilyID)
+	}
+
+	if time.Now().After(stored.ExpiresAt) {
+		return nil, models.ErrInvalidRefreshToken
 	}
 
-	token, err := s.tokenManager.Generate(user.ID)
+	if err := s.sessions.Resume(stored.FamilyID, stored.UserID, clientIP, userAgent); err != nil {
+		if errors.Is(err, models.ErrSessionRevoked) {
+			return nil, models.ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	// Ротация: токен помечается использованным атомарно, чтобы два
+	// параллельных запроса с одним токеном не получили две новые пары
+	rotated, err := s.refreshRepo.Revoke(stored.ID)
 	if err != nil {
-		return "", models.ErrTokenGenerationFailed
+		return nil, models.ErrDatabaseError
+	}
+	if !rotated {
+		return nil, s.revokeFamily(stored.UserID, stored.FamilyID)
 	}
 
-	return token, nil
+	user, err := s.userRepo.FindByID(stored.UserID)
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return nil, models.ErrInvalidRefreshToken
+		}
+		return nil, models.ErrDatabaseError
+	}
+
+	return s.issueTokens(user, stored.FamilyID)
+}
+
+// Logout отзывает текущий access токен до истечения его срока и завершает его сессию.
+// Если передан refresh токен пользователя, отзывается и его семейство.
+func (s *LoginService) Logout(userID uint, tokenID string, expiresAt time.Time, sessionID, refreshToken string) error {
+	revoked := &models.RevokedToken{
+		JTI:       tokenID,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}
+	if err := s.revokedRepo.Revoke(revoked); err != nil {
+		return models.ErrDatabaseError
+	}
+
+	if sessionID != "" {
+		if err := s.sessions.Revoke(userID, sessionID); err != nil && !errors.Is(err, models.ErrSessionNotFound) {
+			return err
+		}
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+
+	stored, err := s.refreshRepo.FindByHash(utils.HashOpaqueToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, models.ErrRecordNotFound) {
+			return nil
+		}
+		return models.ErrDatabaseError
+	}
+
+	// Чужой refresh токен не отзываем
+	if stored.UserID != userID {
+		return nil
+	}
+
+	if err := s.refreshRepo.RevokeFamily(stored.FamilyID); err != nil {
+		return models.ErrDatabaseError
+	}
+	return nil
+}
+
+// LogoutAll завершает все сессии пользователя: увеличивает версию токенов,
+// что делает недействительными все выданные access токены, и отзывает все сессии и refresh токены
+func (s *LoginService) LogoutAll(userID uint) error {
+	if err := s.userRepo.IncrementTokenVersion(userID); err != nil {
+		return models.ErrDatabaseError
+	}
+	return s.sessions.RevokeAll(userID)
 }
 
 // authenticateUser проверяет учетные данные пользователя
@@ -64,6 +264,8 @@ func (s *LoginService) authenticateUser(email, password string) (*models.User, e
 	user, err := s.userRepo.FindByEmail(email)
 	if err != nil {
 		if errors.Is(err, models.ErrRecordNotFound) {
+			// Выравниваем время ответа с проверкой существующего аккаунта
+			s.passHasher.Check(password, s.dummyHash)
 			return nil, models.ErrInvalidCredentials
 		}
 		return nil, models.ErrDatabaseError
@@ -73,5 +275,125 @@ func (s *LoginService) authenticateUser(email, password string) (*models.User, e
 		return nil, models.ErrInvalidCredentials
 	}
 
+	if s.passHasher.NeedsRehash(user.PasswordHash) {
+		s.rehashPassword(user, password)
+	}
+
 	return user, nil
 }
+
+// rehashPassword пересчитывает хеш пароля текущим алгоритмом и параметрами.
+// Ошибка не прерывает вход: хеш будет пересчитан при следующем входе.
+func (s *LoginService) rehashPassword(user *models.User, password string) {
+	newHash, err := s.passHasher.Hash(password)
+	if err != nil {
+		log.Printf("Ошибка пересчета хеша пароля пользователя %d: %v", user.ID, err)
+		return
+	}
+
+	// Хеш заменяется только если пароль не сменили параллельно
+	updated, err := s.userRepo.RehashPassword(user.ID, user.PasswordHash, newHash)
+	if err != nil {
+		log.Printf("Ошибка сохранения пересчитанного хеша пароля пользователя %d: %v", user.ID, err)
+		return
+	}
+	if updated {
+		user.PasswordHash = newHash
+	}
+}
+
+// startSession создает сессию нового входа и выпускает токены в ее семействе
+func (s *LoginService) startSession(user *models.User, clientIP, userAgent string) (*models.LoginResponse, error) {
+	sessionID, err := s.sessions.Start(user.ID, clientIP, userAgent)
+	if err != nil {
+		return nil, err
+	}
+	return s.issueTokens(user, sessionID)
+}
+
+// issueTokens выпускает access токен и новый refresh токен в указанном семействе.
+// Семейство refresh токенов совпадает с идентификатором сессии.
+func (s *LoginService) issueTokens(user *models.User, familyID string) (*models.LoginResponse, error) {
+	accessToken, err := s.tokenManager.Generate(utils.TokenSubject{
+		UserID:       user.ID,
+		Role:         user.Role,
+		TokenVersion: user.TokenVersion,
+		SessionID:    familyID,
+	})
+	if err != nil {
+		return nil, models.ErrTokenGenerationFailed
+	}
+
+	refreshToken, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, models.ErrTokenGenerationFailed
+	}
+
+	stored := &models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: utils.HashOpaqueToken(refreshToken),
+		ExpiresAt: time.Now().Add(s.authConfig.RefreshExpiration),
+	}
+	if err := s.refreshRepo.Create(stored); err != nil {
+		return nil, models.ErrDatabaseError
+	}
+
+	return &models.LoginResponse{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(s.authConfig.JWTExpiration.Seconds()),
+	}, nil
+}
+
+// issueChallenge выпускает короткоживущий токен второго шага входа
+func (s *LoginService) issueChallenge(user *models.User) (*models.LoginResponse, error) {
+	challenge, err := s.tokenManager.Generate(utils.TokenSubject{
+		UserID:       user.ID,
+		Role:         user.Role,
+		TokenVersion: user.TokenVersion,
+		Purpose:      utils.TokenPurposeTwoFactor,
+	})
+	if err != nil {
+		return nil, models.ErrTokenGenerationFailed
+	}
+
+	return &models.LoginResponse{
+		TwoFactorRequired: true,
+		ChallengeToken:    challenge,
+	}, nil
+}
+
+// parseChallenge проверяет подпись, назначение и одноразовость challenge токена
+func (s *LoginService) parseChallenge(challengeToken string) (*utils.Claims, error) {
+	token, err := s.tokenManager.Parse(challengeToken)
+	if err != nil || !token.Valid {
+		return nil, models.ErrInvalidChallengeToken
+	}
+
+	claims, err := s.tokenManager.ExtractClaims(token)
+	if err != nil || claims.Purpose != utils.TokenPurposeTwoFactor {
+		return nil, models.ErrInvalidChallengeToken
+	}
+
+	used, err := s.revokedRepo.IsRevoked(claims.ID)
+	if err != nil {
+		return nil, models.ErrDatabaseError
+	}
+	if used {
+		return nil, models.ErrInvalidChallengeToken
+	}
+
+	return claims, nil
+}
+
+// revokeFamily отзывает семейство токенов и его сессию при обнаружении повторного использования
+func (s *LoginService) revokeFamily(userID uint, familyID string) error {
+	if err := s.refreshRepo.RevokeFamily(familyID); err != nil {
+		return models.ErrDatabaseError
+	}
+	if err := s.sessions.Revoke(userID, familyID); err != nil && !errors.Is(err, models.ErrSessionNotFound) {
+		return err
+	}
+	return models.ErrRefreshTokenReused
+}
@@ -0,0 +1,314 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"khrllwTest/internal/models"
+	"khrllwTest/internal/oidc"
+	"khrllwTest/internal/repository"
+	"khrllwTest/internal/utils"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxUserNameLength максимальная длина имени пользователя в байтах (users.name VARCHAR(255))
+const maxUserNameLength = 255
+
+// ------------------------------------------------------------
+// Конфигурация
+// ------------------------------------------------------------
+
+// OIDCConfig содержит настройки входа через OpenID Connect
+type OIDCConfig struct {
+	// Время, за которое пользователь должен вернуться со страницы провайдера
+	StateTTL time.Duration
+}
+
+// NewOIDCConfig создает конфигурацию входа через OpenID Connect из переменных окружения
+func NewOIDCConfig() (*OIDCConfig, error) {
+	ttl, err := envDuration("OIDC_STATE_TTL", 10*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	return &OIDCConfig{StateTTL: ttl}, nil
+}
+
+// ------------------------------------------------------------
+// Структуры
+// ------------------------------------------------------------
+
+// OIDCService реализует вход через внешних OpenID Connect провайдеров (authorization code + PKCE)
+type OIDCService struct {
+	providers    map[string]*oidc.Provider
+	stateRepo    repository.OIDCStateRepository
+	identityRepo repository.UserIdentityRepository
+	userRepo     repository.UserRepository
+	login        *LoginService
+	passHasher   utils.PasswordHasher
+	config       *OIDCConfig
+	cache        *repository.UserCache
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewOIDCService создает новый экземпляр OIDCService
+func NewOIDCService(
+	providers []*oidc.Provider,
+	stateRepo repository.OIDCStateRepository,
+	identityRepo repository.UserIdentityRepository,
+	userRepo repository.UserRepository,
+	login *LoginService,
+	passHasher utils.PasswordHasher,
+	config *OIDCConfig,
+	cache *repository.UserCache,
+) *OIDCService {
+	byName := make(map[string]*oidc.Provider, len(providers))
+	for _, provider := range providers {
+		byName[provider.Config().Name] = provider
+	}
+
+	return &OIDCService{
+		providers:    byName,
+		stateRepo:    stateRepo,
+		identityRepo: identityRepo,
+		userRepo:     userRepo,
+		login:        login,
+		passHasher:   passHasher,
+		config:       config,
+		cache:        cache,
+	}
+}
+
+// ------------------------------------------------------------
+// Основные методы
+// ------------------------------------------------------------
+
+// Providers возвращает имена настроенных провайдеров
+func (s *OIDCService) Providers() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StateTTL возвращает время жизни незавершенного входа
+func (s *OIDCService) StateTTL() time.Duration {
+	return s.config.StateTTL
+}
+
+// Begin начинает вход: сохраняет state, nonce и PKCE code_verifier и возвращает адрес страницы
+// входа провайдера и значение state, которое нужно привязать к браузеру пользователя
+func (s *OIDCService) Begin(ctx context.Context, providerName string) (string, string, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return "", "", models.ErrOIDCProviderNotFound
+	}
+
+	state, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", "", models.ErrTokenGenerationFailed
+	}
+	nonce, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", "", models.ErrTokenGenerationFailed
+	}
+	verifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		return "", "", models.ErrTokenGenerationFailed
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		log.Printf("Ошибка обращения к провайдеру OIDC %s: %v", providerName, err)
+		return "", "", models.ErrOIDCProviderUnavailable
+	}
+
+	stored := &models.OIDCLoginState{
+		StateHash:    utils.HashOpaqueToken(state),
+		Provider:     providerName,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(s.config.StateTTL),
+	}
+	if err := s.stateRepo.Create(stored); err != nil {
+		return "", "", models.ErrDatabaseError
+	}
+
+	return authURL, state, nil
+}
+
+// Complete завершает вход по коду авторизации: проверяет state, обменивает код на ID токен,
+// находит, привязывает или создает пользователя и выпускает обычные токены сервиса.
+// Для пользователей с 2FA, как и при входе по паролю, возвращается challenge токен.
+func (s *OIDCService) Complete(ctx context.Context, providerName, code, state, clientIP, userAgent string) (*models.LoginResponse, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, models.ErrOIDCProviderNotFound
+	}
+	if code == "" || state == "" {
+		return nil, models.ErrOIDCInvalidState
+	}
+
+	// state одноразовый: запись удаляется до обращения к провайдеру
+	stored, err := s.stateRepo.Consume(utils.HashOpaqueToken(state))
+	if err != nil {
+		if errors.Is(err, models.ErrRecordNotFound) {
+			return nil, models.ErrOIDCInvalidState
+		}
+		return nil, models.ErrDatabaseError
+	}
+	if stored.Provider != providerName || time.Now().After(stored.ExpiresAt) {
+		return nil, models.ErrOIDCInvalidState
+	}
+
+	claims, err := provider.Exchange(ctx, code, stored.CodeVerifier, stored.Nonce)
+	if err != nil {
+		log.Printf("Ошибка входа через провайдер OIDC %s: %v", providerName, err)
+		if errors.Is(err, models.ErrOIDCProviderUnavailable) {
+			return nil, models.ErrOIDCProviderUnavailable
+		}
+		return nil, models.ErrOIDCLoginFailed
+	}
+
+	user, err := s.resolveUser(provider.Config(), claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.login.LoginExternal(user, clientIP, userAgent)
+}
+
+// ------------------------------------------------------------
+// Вспомогательные методы
+// ------------------------------------------------------------
+
+// resolveUser находит пользователя по привязке провайдера; при первом входе привязывает
+// аккаунт с тем же подтвержденным email или создает нового пользователя
+func (s *OIDCService) resolveUser(config *oidc.ProviderConfig, claims *oidc.IDTokenClaims) (*models.User, error) {
+	identity, err := s.identityRepo.FindBySubject(config.Name, claims.Subject)
+	if err == nil {
+		return s.findUser(identity.UserID)
+	}
+	if !errors.Is(err, models.ErrRecordNotFound) {
+		return nil, models.ErrDatabaseError
+	}
+
+	// Без подтвержденного провайдером email нельзя связать аккаунты: иначе можно войти в чужой аккаунт
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, models.ErrOIDCEmailNotVerified
+	}
+
+	identity = &models.UserIdentity{
+		Provider: config.Name,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	}
+
+	user, err := s.userRepo.FindByEmail(claims.Email)
+	if err == nil {
+		return s.linkUser(user, identity)
+	}
+	if !errors.Is(err, models.ErrRecordNotFound) {
+		return nil, models.ErrDatabaseError
+	}
+
+	if !config.AutoCreate {
+		return nil, models.ErrOIDCAccountNotFound
+	}
+	return s.createUser(claims, identity)
+}
+
+// findUser загружает пользователя по ID
+func (s *OIDCService) findUser(userID uint) (*models.User, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return nil, models.ErrOIDCAccountNotFound
+		}
+		return nil, models.ErrDatabaseError
+	}
+	return user, nil
+}
+
+// linkUser привязывает аккаунт провайдера к существующему пользователю
+func (s *OIDCService) linkUser(user *models.User, identity *models.UserIdentity) (*models.User, error) {
+	identity.UserID = user.ID
+	if err := s.identityRepo.Create(identity); err != nil {
+		return nil, models.ErrDatabaseError
+	}
+
+	// Провайдер подтвердил владение email, повторное подтверждение письмом не нужно
+	if user.EmailVerifiedAt == nil {
+		if _, err := s.userRepo.MarkEmailVerified(user.ID, user.Email); err != nil {
+			log.Printf("Не удалось отметить email пользователя %d подтвержденным: %v", user.ID, err)
+		} else {
+			now := time.Now()
+			user.EmailVerifiedAt = &now
+		}
+	}
+
+	return user, nil
+}
+
+// createUser создает пользователя по данным ID токена. Пароль задается случайным:
+// войти по паролю можно будет после его восстановления.
+// Возраст обязателен для всех пользователей, поэтому без даты рождения, дающей допустимый возраст,
+// пользователь не создается: он может зарегистрироваться сам, и аккаунт будет привязан при следующем входе.
+func (s *OIDCService) createUser(claims *oidc.IDTokenClaims, identity *models.UserIdentity) (*models.User, error) {
+	now := time.Now()
+	age, ok := ageFromBirthdate(claims.Birthdate, now)
+	if !ok || !validAge(age) {
+		return nil, models.ErrOIDCBirthdateRequired
+	}
+
+	randomPassword, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, models.ErrTokenGenerationFailed
+	}
+	passwordHash, err := s.passHasher.Hash(randomPassword)
+	if err != nil {
+		return nil, models.ErrPasswordHashFailed
+	}
+
+	name := strings.TrimSpace(claims.Name)
+	if name == "" {
+		name, _, _ = strings.Cut(claims.Email, "@")
+	}
+
+	user := &models.User{
+		Name:            truncateUTF8(name, maxUserNameLength),
+		Email:           claims.Email,
+		Age:             age,
+		PasswordHash:    passwordHash,
+		Role:            models.RoleUser,
+		EmailVerifiedAt: &now,
+	}
+
+	if err := s.identityRepo.CreateWithUser(user, identity); err != nil {
+		return nil, models.ErrDatabaseError
+	}
+	// Пользователь создается в обход репозитория пользователей: отметку "не найден" сбрасываем сами
+	s.cache.Invalidate(user.ID)
+	return user, nil
+}
+
+// ageFromBirthdate вычисляет полное число лет на момент now по claim birthdate (YYYY-MM-DD).
+// Дата без года (0000-MM-DD), только год и другие форматы возраста не дают.
+func ageFromBirthdate(birthdate string, now time.Time) (int, bool) {
+	born, err := time.Parse("2006-01-02", birthdate)
+	if err != nil || born.Year() == 0 || born.After(now) {
+		return 0, false
+	}
+
+	age := now.Year() - born.Year()
+	if now.Month() < born.Month() || (now.Month() == born.Month() && now.Day() < born.Day()) {
+		age--
+	}
+	return age, true
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"khrllwTest/internal/models"
 	"khrllwTest/internal/repository"
+	"khrllwTest/internal/utils"
+	"strconv"
 )
 
 // ------------------------------------------------------------
@@ -14,6 +16,19 @@ import (
 type OrderService struct {
 	orderRepo repository.OrderRepository
 	userRepo  repository.UserRepository
+	cursors   *utils.CursorSigner
+}
+
+// orderCursor содержимое курсора keyset-пагинации заказов пользователя
+type orderCursor struct {
+	// Отпечаток выборки (заказы конкретного пользователя)
+	Query string `json:"q"`
+
+	// Курсор предыдущей страницы
+	Backward bool `json:"b,omitempty"`
+
+	// ID крайнего заказа соседней страницы
+	ID uint `json:"id"`
 }
 
 // ------------------------------------------------------------
@@ -24,10 +39,12 @@ type OrderService struct {
 func NewOrderService(
 	orderRepo repository.OrderRepository,
 	userRepo repository.UserRepository,
+	cursors *utils.CursorSigner,
 ) *OrderService {
 	return &OrderService{
 		orderRepo: orderRepo,
 		userRepo:  userRepo,
+		cursors:   cursors,
 	}
 }
 
@@ -69,10 +86,145 @@ func (s *OrderService) GetUserOrders(userID uint) ([]models.Order, error) {
 	return orders, nil
 }
 
+// GetUserOrdersPage возвращает страницу заказов пользователя в порядке создания (keyset-пагинация по ID).
+// Выбирается на один заказ больше limit, чтобы узнать, есть ли страница дальше.
+func (s *OrderService) GetUserOrdersPage(userID uint, query *models.OrderListQuery) (*models.OrderPage, error) {
+	fingerprint := queryFingerprint("orders", strconv.FormatUint(uint64(userID), 10))
+
+	var cursor orderCursor
+	if query.Cursor != "" {
+		if err := s.cursors.Decode(query.Cursor, &cursor); err != nil || cursor.Query != fingerprint {
+			return nil, models.ErrInvalidCursor
+		}
+	}
+
+	if err := s.validateUserExists(userID); err != nil {
+		return nil, err
+	}
+
+	orders, err := s.orderRepo.FindPageByUserID(userID, cursor.ID, cursor.Backward, query.Limit+1)
+	if err != nil {
+		return nil, models.ErrDatabaseError
+	}
+
+	hasMore := len(orders) > query.Limit
+	if hasMore {
+		if cursor.Backward {
+			orders = orders[1:]
+		} else {
+			orders = orders[:query.Limit]
+		}
+	}
+
+	page := &models.OrderPage{Orders: orders}
+	if query.IncludeTotal {
+		total, err := s.orderRepo.CountByUserID(userID)
+		if err != nil {
+			return nil, models.ErrDatabaseError
+		}
+		page.Total = &total
+	}
+
+	// Первая страница (без курсора) не имеет предыдущей; со стороны границы курсора соседняя страница есть всегда
+	if len(orders) > 0 {
+		if cursor.Backward || hasMore {
+			if page.NextCursor, err = s.encodeOrderCursor(orders[len(orders)-1].ID, fingerprint, false); err != nil {
+				return nil, err
+			}
+		}
+		if (query.Cursor != "" && !cursor.Backward) || (cursor.Backward && hasMore) {
+			if page.PrevCursor, err = s.encodeOrderCursor(orders[0].ID, fingerprint, true); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return page, nil
+}
+
+// GetUserOrder возвращает заказ пользователя по ID
+func (s *OrderService) GetUserOrder(userID, orderID uint) (*models.Order, error) {
+	if err := s.validateUserExists(userID); err != nil {
+		return nil, err
+	}
+	return s.findOrder(userID, orderID)
+}
+
+// UpdateOrder заменяет данные заказа пользователя.
+// version - версия из If-Match (0 - без проверки); заказ, измененный после чтения, не перезаписывается.
+func (s *OrderService) UpdateOrder(userID, orderID uint, req *models.UpdateOrderRequest, version int) (*models.Order, error) {
+	if err := s.validateUserExists(userID); err != nil {
+		return nil, err
+	}
+	if err := s.validateOrderRequest((*models.CreateOrderRequest)(req)); err != nil {
+		return nil, err
+	}
+
+	order, err := s.findOrder(userID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(order.Version, version); err != nil {
+		return nil, err
+	}
+
+	order.Product = req.Product
+	order.Quantity = req.Quantity
+	order.Price = req.Price
+	if err := s.orderRepo.Update(order); err != nil {
+		return nil, versionConflict(err, version)
+	}
+	return order, nil
+}
+
+// DeleteOrder удаляет заказ пользователя.
+// version - версия из If-Match (0 - без проверки).
+func (s *OrderService) DeleteOrder(userID, orderID uint, version int) error {
+	if err := s.validateUserExists(userID); err != nil {
+		return err
+	}
+
+	order, err := s.findOrder(userID, orderID)
+	if err != nil {
+		return err
+	}
+	if err := checkVersion(order.Version, version); err != nil {
+		return err
+	}
+
+	if err := s.orderRepo.Delete(order.ID, version); err != nil {
+		if errors.Is(err, models.ErrOrderNotFound) {
+			return err
+		}
+		return versionConflict(err, version)
+	}
+	return nil
+}
+
 // ------------------------------------------------------------
 // Вспомогательные методы
 // ------------------------------------------------------------
 
+// findOrder ищет заказ пользователя по ID
+func (s *OrderService) findOrder(userID, orderID uint) (*models.Order, error) {
+	order, err := s.orderRepo.FindByID(userID, orderID)
+	if err != nil {
+		if errors.Is(err, models.ErrOrderNotFound) {
+			return nil, err
+		}
+		return nil, models.ErrDatabaseError
+	}
+	return order, nil
+}
+
+// encodeOrderCursor создает курсор страницы после (или перед) заказом
+func (s *OrderService) encodeOrderCursor(orderID uint, fingerprint string, backward bool) (string, error) {
+	encoded, err := s.cursors.Encode(orderCursor{Query: fingerprint, Backward: backward, ID: orderID})
+	if err != nil {
+		return "", models.ErrInternalServerError
+	}
+	return encoded, nil
+}
+
 // validateUserExists проверяет существование пользователя
 func (s *OrderService) validateUserExists(userID uint) error {
 	_, err := s.userRepo.FindByID(userID)
@@ -0,0 +1,14 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// queryFingerprint возвращает отпечаток выборки (список, фильтры и сортировка), к которой привязан курсор.
+// Курсор, выданный для другой выборки, отклоняется: его граница не имеет смысла в другом порядке строк.
+func queryFingerprint(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
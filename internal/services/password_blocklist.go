@@ -0,0 +1,233 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// blocklistPrefixLength длина префикса SHA-1 в именах файлов диапазонов Pwned Passwords
+	blocklistPrefixLength = 5
+
+	// blocklistSuffixLength длина суффикса SHA-1 в строках файла диапазона
+	blocklistSuffixLength = 2*sha1.Size - blocklistPrefixLength
+)
+
+// ------------------------------------------------------------
+// Интерфейсы
+// ------------------------------------------------------------
+
+// passwordBlocklist список скомпрометированных паролей
+type passwordBlocklist interface {
+	// Contains проверяет, есть ли пароль в списке
+	Contains(password string) bool
+}
+
+// ------------------------------------------------------------
+// Структуры
+// ------------------------------------------------------------
+
+// hashBlocklist список SHA-1 хешей, загруженный в память.
+// Хеши хранятся отсортированным срезом: это компактнее карты и позволяет искать бинарным поиском.
+type hashBlocklist struct {
+	hashes [][sha1.Size]byte
+}
+
+// rangeBlocklist каталог файлов диапазонов Pwned Passwords (k-anonymity): файл <ПРЕФИКС>.txt
+// содержит суффиксы хешей с этим префиксом.
+// Выгрузка в память не загружается: при проверке читается только файл диапазона префикса пароля.
+type rangeBlocklist struct {
+	dir string
+	ext string
+
+	// Префиксы в именах файлов записаны в нижнем регистре (5baa6.txt)
+	lower bool
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// loadPasswordBlocklist открывает список скомпрометированных паролей.
+// Каталог считается выгрузкой Pwned Passwords по диапазонам (как у PwnedPasswordsDownloader):
+// файлы с именем из 5 hex символов префикса SHA-1 и строками <суффикс из 35 hex>:<число утечек>.
+// Файл загружается в память целиком: по одному SHA-1 хешу (40 hex символов) в строке,
+// после двоеточия может идти число утечек. Если имя файла - префикс диапазона, строки могут
+// содержать только суффиксы. Пустые строки, строки с # и записи с нулевым числом утечек
+// (дополнение ответов HIBP) пропускаются.
+func loadPasswordBlocklist(path string) (passwordBlocklist, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть PASSWORD_BLOCKLIST_FILE: %w", err)
+	}
+	if info.IsDir() {
+		return openRangeBlocklist(path)
+	}
+	return loadHashBlocklist(path)
+}
+
+// openRangeBlocklist проверяет каталог диапазонов и определяет расширение и регистр имен его файлов.
+// Регистр определяется по первому имени с буквой: имена только из цифр подходят к обоим вариантам.
+func openRangeBlocklist(dir string) (*rangeBlocklist, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать каталог PASSWORD_BLOCKLIST_FILE: %w", err)
+	}
+
+	var blocklist *rangeBlocklist
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if _, ok := rangePrefix(entry.Name()); !ok {
+			continue
+		}
+		if blocklist == nil {
+			blocklist = &rangeBlocklist{dir: dir, ext: filepath.Ext(entry.Name())}
+		}
+		prefix := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		if strings.ToUpper(prefix) != strings.ToLower(prefix) {
+			blocklist.lower = prefix == strings.ToLower(prefix)
+			break
+		}
+	}
+	if blocklist == nil {
+		return nil, fmt.Errorf("в каталоге PASSWORD_BLOCKLIST_FILE нет файлов диапазонов (<префикс SHA-1>.txt)")
+	}
+
+	log.Printf("Список скомпрометированных паролей: каталог диапазонов %s", dir)
+	return blocklist, nil
+}
+
+// loadHashBlocklist загружает файл хешей в память
+func loadHashBlocklist(path string) (*hashBlocklist, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть PASSWORD_BLOCKLIST_FILE: %w", err)
+	}
+	defer file.Close()
+
+	// Файл диапазона: строки содержат только суффикс, префикс - в имени файла
+	prefix, _ := rangePrefix(filepath.Base(path))
+
+	blocklist := &hashBlocklist{}
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		entry, ok := parseBlocklistEntry(scanner.Text())
+		if !ok {
+			continue
+		}
+		if prefix != "" && len(entry) == blocklistSuffixLength {
+			entry = prefix + entry
+		}
+
+		var hash [sha1.Size]byte
+		if len(entry) != 2*sha1.Size {
+			return nil, fmt.Errorf("PASSWORD_BLOCKLIST_FILE, строка %d: ожидается SHA-1 хеш", line)
+		}
+		if _, err := hex.Decode(hash[:], []byte(entry)); err != nil {
+			return nil, fmt.Errorf("PASSWORD_BLOCKLIST_FILE, строка %d: ожидается SHA-1 хеш", line)
+		}
+		blocklist.hashes = append(blocklist.hashes, hash)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения PASSWORD_BLOCKLIST_FILE: %w", err)
+	}
+
+	// Выгрузка HIBP уже упорядочена по хешу, но сортировка не полагается на это
+	sort.Slice(blocklist.hashes, func(i, j int) bool {
+		return bytes.Compare(blocklist.hashes[i][:], blocklist.hashes[j][:]) < 0
+	})
+
+	log.Printf("Загружено хешей скомпрометированных паролей: %d", len(blocklist.hashes))
+	return blocklist, nil
+}
+
+// ------------------------------------------------------------
+// Методы
+// ------------------------------------------------------------
+
+// Contains проверяет, есть ли пароль в списке
+func (b *hashBlocklist) Contains(password string) bool {
+	hash := sha1.Sum([]byte(password))
+
+	i := sort.Search(len(b.hashes), func(i int) bool {
+		return bytes.Compare(b.hashes[i][:], hash[:]) >= 0
+	})
+	return i < len(b.hashes) && b.hashes[i] == hash
+}
+
+// Contains проверяет, есть ли суффикс хеша пароля в файле его диапазона.
+// Отсутствующий файл означает пустой диапазон; при ошибке чтения проверка пропускается
+// с записью в журнал, чтобы сбой диска не блокировал регистрацию и смену паролей.
+func (b *rangeBlocklist) Contains(password string) bool {
+	hash := sha1.Sum([]byte(password))
+	encoded := strings.ToUpper(hex.EncodeToString(hash[:]))
+	prefix, suffix := encoded[:blocklistPrefixLength], encoded[blocklistPrefixLength:]
+
+	name := prefix
+	if b.lower {
+		name = strings.ToLower(prefix)
+	}
+	file, err := os.Open(filepath.Join(b.dir, name+b.ext))
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("Не удалось прочитать диапазон %s списка скомпрометированных паролей: %v", prefix, err)
+		}
+		return false
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if entry, ok := parseBlocklistEntry(scanner.Text()); ok && strings.EqualFold(entry, suffix) {
+			return true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Printf("Не удалось прочитать диапазон %s списка скомпрометированных паролей: %v", prefix, err)
+	}
+	return false
+}
+
+// ------------------------------------------------------------
+// Вспомогательные функции
+// ------------------------------------------------------------
+
+// parseBlocklistEntry возвращает хеш (или суффикс) из строки выгрузки в формате <хеш>[:<число утечек>].
+// Пустые строки, комментарии и записи с нулевым числом утечек пропускаются.
+func parseBlocklistEntry(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", false
+	}
+
+	entry, count, _ := strings.Cut(line, ":")
+	if strings.TrimSpace(count) == "0" {
+		return "", false
+	}
+	return strings.TrimSpace(entry), true
+}
+
+// rangePrefix возвращает префикс SHA-1 из имени файла диапазона, например "5BAA6.txt"
+func rangePrefix(name string) (string, bool) {
+	prefix := strings.TrimSuffix(name, filepath.Ext(name))
+	if len(prefix) != blocklistPrefixLength {
+		return "", false
+	}
+	if _, err := hex.DecodeString(prefix + "0"); err != nil {
+		return "", false
+	}
+	return strings.ToUpper(prefix), true
+}
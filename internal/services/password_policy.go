@@ -0,0 +1,278 @@
+package service
+
+import (
+	"fmt"
+	"khrllwTest/internal/models"
+	"khrllwTest/internal/repository"
+	"khrllwTest/internal/utils"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// minPersonalTokenLength минимальная длина части имени или email, которую нельзя использовать в пароле.
+// Более короткие части встречаются в паролях случайно и не проверяются.
+const minPersonalTokenLength = 5
+
+// ------------------------------------------------------------
+// Конфигурация
+// ------------------------------------------------------------
+
+// PasswordPolicyConfig содержит правила политики паролей
+type PasswordPolicyConfig struct {
+	// Минимальная и максимальная длина пароля в символах
+	MinLength int
+	MaxLength int
+
+	// Обязательные классы символов
+	RequireUppercase bool
+	RequireLowercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+
+	// Число последних паролей (включая текущий), которые нельзя использовать повторно.
+	// 0 и 1 - история не ведется, запрещен только текущий пароль
+	HistorySize int
+
+	// Файл SHA-1 хешей или каталог диапазонов Pwned Passwords (пусто - проверка отключена)
+	BlocklistFile string
+}
+
+// NewPasswordPolicyConfig создает конфигурацию политики паролей из переменных окружения
+func NewPasswordPolicyConfig() (*PasswordPolicyConfig, error) {
+	minLength, err := envInt("PASSWORD_MIN_LENGTH", 8)
+	if err != nil {
+		return nil, err
+	}
+
+	maxLength, err := envInt("PASSWORD_MAX_LENGTH", 128)
+	if err != nil {
+		return nil, err
+	}
+	if maxLength < minLength {
+		return nil, fmt.Errorf("PASSWORD_MAX_LENGTH (%d) меньше PASSWORD_MIN_LENGTH (%d)", maxLength, minLength)
+	}
+
+	historySize, err := envNonNegativeInt("PASSWORD_HISTORY_SIZE", 5)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &PasswordPolicyConfig{
+		MinLength:     minLength,
+		MaxLength:     maxLength,
+		HistorySize:   historySize,
+		BlocklistFile: os.Getenv("PASSWORD_BLOCKLIST_FILE"),
+	}
+
+	for name, target := range map[string]*bool{
+		"PASSWORD_REQUIRE_UPPERCASE": &config.RequireUppercase,
+		"PASSWORD_REQUIRE_LOWERCASE": &config.RequireLowercase,
+		"PASSWORD_REQUIRE_DIGIT":     &config.RequireDigit,
+		"PASSWORD_REQUIRE_SYMBOL":    &config.RequireSymbol,
+	} {
+		if *target, err = envBool(name, false); err != nil {
+			return nil, err
+		}
+	}
+
+	return config, nil
+}
+
+// ------------------------------------------------------------
+// Структуры
+// ------------------------------------------------------------
+
+// PasswordPolicy проверяет пароли при регистрации, смене и сбросе пароля
+type PasswordPolicy struct {
+	config      *PasswordPolicyConfig
+	historyRepo repository.PasswordHistoryRepository
+	passHasher  utils.PasswordHasher
+	blocklist   passwordBlocklist
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewPasswordPolicy создает новый экземпляр PasswordPolicy и загружает список скомпрометированных паролей
+func NewPasswordPolicy(
+	config *PasswordPolicyConfig,
+	historyRepo repository.PasswordHistoryRepository,
+	passHasher utils.PasswordHasher,
+) (*PasswordPolicy, error) {
+	policy := &PasswordPolicy{
+		config:      config,
+		historyRepo: historyRepo,
+		passHasher:  passHasher,
+	}
+
+	if config.BlocklistFile != "" {
+		blocklist, err := loadPasswordBlocklist(config.BlocklistFile)
+		if err != nil {
+			return nil, err
+		}
+		policy.blocklist = blocklist
+	}
+
+	return policy, nil
+}
+
+// ------------------------------------------------------------
+// Основные методы
+// ------------------------------------------------------------
+
+// Validate проверяет пароль по всем правилам и возвращает *models.PasswordPolicyError
+// со списком всех нарушений. Для существующего пользователя (user.ID != 0)
+// дополнительно проверяется повторное использование последних паролей.
+func (p *PasswordPolicy) Validate(password string, user *models.User) error {
+	var violations []models.PasswordPolicyViolation
+	fail := func(rule, message string) {
+		violations = append(violations, models.PasswordPolicyViolation{Rule: rule, Message: message})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if length < p.config.MinLength {
+		fail(models.PasswordRuleMinLength, fmt.Sprintf("Пароль должен содержать не менее %d символов", p.config.MinLength))
+	}
+	tooLong := length > p.config.MaxLength
+	if tooLong {
+		fail(models.PasswordRuleMaxLength, fmt.Sprintf("Пароль должен содержать не более %d символов", p.config.MaxLength))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if p.config.RequireUppercase && !hasUpper {
+		fail(models.PasswordRuleUppercase, "Пароль должен содержать заглавную букву")
+	}
+	if p.config.RequireLowercase && !hasLower {
+		fail(models.PasswordRuleLowercase, "Пароль должен содержать строчную букву")
+	}
+	if p.config.RequireDigit && !hasDigit {
+		fail(models.PasswordRuleDigit, "Пароль должен содержать цифру")
+	}
+	if p.config.RequireSymbol && !hasSymbol {
+		fail(models.PasswordRuleSymbol, "Пароль должен содержать специальный символ")
+	}
+
+	if user != nil && containsPersonalInfo(password, user) {
+		fail(models.PasswordRulePersonalInfo, "Пароль не должен содержать имя или email")
+	}
+
+	if p.blocklist != nil && p.blocklist.Contains(password) {
+		fail(models.PasswordRuleBreached, "Пароль найден в базе утекших паролей")
+	}
+
+	// Проверка истории требует вычисления хешей, поэтому для слишком длинных паролей пропускается
+	if user != nil && user.ID != 0 && !tooLong {
+		reused, err := p.isReused(password, user)
+		if err != nil {
+			return err
+		}
+		if reused && p.config.HistorySize <= 1 {
+			fail(models.PasswordRuleReused, "Пароль не должен совпадать с текущим паролем")
+		} else if reused {
+			fail(models.PasswordRuleReused, fmt.Sprintf("Пароль не должен совпадать с %d последними паролями", p.config.HistorySize))
+		}
+	}
+
+	if len(violations) > 0 {
+		return &models.PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// RecordChange сохраняет хеш прежнего пароля в истории перед его заменой
+// и удаляет записи, которые больше не участвуют в проверке
+func (p *PasswordPolicy) RecordChange(userID uint, previousHash string) error {
+	// Текущий пароль проверяется по users.password_hash, поэтому в истории хранится HistorySize-1 записей
+	keep := p.config.HistorySize - 1
+	if keep <= 0 {
+		return nil
+	}
+
+	if err := p.historyRepo.Add(&models.PasswordHistory{UserID: userID, PasswordHash: previousHash}); err != nil {
+		return models.ErrDatabaseError
+	}
+	if err := p.historyRepo.Prune(userID, keep); err != nil {
+		return models.ErrDatabaseError
+	}
+	return nil
+}
+
+// ------------------------------------------------------------
+// Вспомогательные методы
+// ------------------------------------------------------------
+
+// isReused проверяет совпадение пароля с текущим и прежними паролями пользователя
+func (p *PasswordPolicy) isReused(password string, user *models.User) (bool, error) {
+	if user.PasswordHash != "" && p.passHasher.Check(password, user.PasswordHash) {
+		return true, nil
+	}
+
+	if p.config.HistorySize <= 1 {
+		return false, nil
+	}
+
+	history, err := p.historyRepo.FindRecent(user.ID, p.config.HistorySize-1)
+	if err != nil {
+		return false, models.ErrDatabaseError
+	}
+
+	for _, entry := range history {
+		if p.passHasher.Check(password, entry.PasswordHash) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// containsPersonalInfo проверяет, содержит ли пароль имя пользователя, email или их части
+func containsPersonalInfo(password string, user *models.User) bool {
+	normalized := normalizeForComparison(password)
+	if normalized == "" {
+		return false
+	}
+
+	localPart, _, _ := strings.Cut(normalizeEmail(user.Email), "@")
+
+	candidates := []string{
+		normalizeForComparison(user.Name),
+		normalizeForComparison(localPart),
+	}
+	candidates = append(candidates, strings.FieldsFunc(strings.ToLower(user.Name+" "+localPart), isSeparator)...)
+
+	for _, candidate := range candidates {
+		if utf8.RuneCountInString(candidate) >= minPersonalTokenLength && strings.Contains(normalized, candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeForComparison приводит строку к нижнему регистру и оставляет только буквы и цифры
+func normalizeForComparison(value string) string {
+	return strings.Map(func(r rune) rune {
+		if isSeparator(r) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, value)
+}
+
+// isSeparator сообщает, что символ не является буквой или цифрой
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"errors"
+	"khrllwTest/internal/mail"
+	"khrllwTest/internal/models"
+	"khrllwTest/internal/repository"
+	"khrllwTest/internal/utils"
+	"log"
+	"net/url"
+	"os"
+	"time"
+)
+
+// ------------------------------------------------------------
+// Конфигурация
+// ------------------------------------------------------------
+
+// PasswordResetConfig содержит настройки сброса пароля
+type PasswordResetConfig struct {
+	// Время жизни токена сброса
+	TokenTTL time.Duration
+
+	// Адрес страницы сброса пароля; токен добавляется параметром token
+	ResetURL string
+}
+
+// NewPasswordResetConfig создает конфигурацию сброса пароля из переменных окружения
+func NewPasswordResetConfig() (*PasswordResetConfig, error) {
+	ttl, err := envDuration("PASSWORD_RESET_TTL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	resetURL := os.Getenv("PASSWORD_RESET_URL")
+	if resetURL == "" {
+		resetURL = "http://localhost:8080/reset-password" // значение по умолчанию
+	}
+	if _, err := url.Parse(resetURL); err != nil {
+		return nil, errors.New("неверный формат PASSWORD_RESET_URL")
+	}
+
+	return &PasswordResetConfig{
+		TokenTTL: ttl,
+		ResetURL: resetURL,
+	}, nil
+}
+
+// ------------------------------------------------------------
+// Структуры
+// ------------------------------------------------------------
+
+// PasswordResetService реализует восстановление доступа через одноразовые токены
+type PasswordResetService struct {
+	userRepo   repository.UserRepository
+	resetRepo  repository.PasswordResetRepository
+	sessions   *SessionService
+	passHasher utils.PasswordHasher
+	sender     mail.Sender
+	limiter    *LoginLimiter
+	policy     *PasswordPolicy
+	config     *PasswordResetConfig
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewPasswordResetService создает новый экземпляр PasswordResetService
+func NewPasswordResetService(
+	userRepo repository.UserRepository,
+	resetRepo repository.PasswordResetRepository,
+	sessions *SessionService,
+	passHasher utils.PasswordHasher,
+	sender mail.Sender,
+	limiter *LoginLimiter,
+	policy *PasswordPolicy,
+	config *PasswordResetConfig,
+) *PasswordResetService {
+	return &PasswordResetService{
+		userRepo:   userRepo,
+		resetRepo:  resetRepo,
+		sessions:   sessions,
+		passHasher: passHasher,
+		sender:     sender,
+		limiter:    limiter,
+		policy:     policy,
+		config:     config,
+	}
+}
+
+// ------------------------------------------------------------
+// Основные методы
+// ------------------------------------------------------------
+
+// ForgotPassword выпускает токен сброса и отправляет ссылку на email.
+// Для неизвестного email ошибка не возвращается, чтобы не раскрывать наличие аккаунта.
+// Новый токен делает недействительными ранее выданные.
+func (s *PasswordResetService) ForgotPassword(email string) error {
+	user, err := s.userRepo.FindByEmail(email)
+	if err != nil {
+		if errors.Is(err, models.ErrRecordNotFound) {
+			return nil
+		}
+		return models.ErrDatabaseError
+	}
+
+	if err := s.resetRepo.InvalidateForUser(user.ID); err != nil {
+		return models.ErrDatabaseError
+	}
+
+	rawToken, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return models.ErrTokenGenerationFailed
+	}
+
+	token := &models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: utils.HashOpaqueToken(rawToken),
+		ExpiresAt: time.Now().Add(s.config.TokenTTL),
+	}
+	if err := s.resetRepo.Create(token); err != nil {
+		return models.ErrDatabaseError
+	}
+
+	// Письмо отправляется в фоне: время ответа не должно зависеть от наличия аккаунта
+	go s.sendResetMail(user.Email, rawToken)
+
+	return nil
+}
+
+// ResetPassword устанавливает новый пароль по токену сброса.
+// Все сессии пользователя завершаются, выданные ранее access и refresh токены становятся недействительными.
+func (s *PasswordResetService) ResetPassword(rawToken, password string) error {
+	stored, err := s.resetRepo.FindByHash(utils.HashOpaqueToken(rawToken))
+	if err != nil {
+		if errors.Is(err, models.ErrRecordNotFound) {
+			return models.ErrInvalidResetToken
+		}
+		return models.ErrDatabaseError
+	}
+
+	if stored.UsedAt != nil || time.Now().After(stored.ExpiresAt) {
+		return models.ErrInvalidResetToken
+	}
+
+	user, err := s.userRepo.FindByID(stored.UserID)
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return models.ErrInvalidResetToken
+		}
+		return models.ErrDatabaseError
+	}
+
+	// Пароль проверяется до погашения токена, чтобы можно было повторить попытку с другим паролем
+	if err := s.policy.Validate(password, user); err != nil {
+		return err
+	}
+
+	// Токен погашается атомарно, чтобы его нельзя было использовать дважды
+	used, err := s.resetRepo.MarkUsed(stored.ID)
+	if err != nil {
+		return models.ErrDatabaseError
+	}
+	if !used {
+		return models.ErrInvalidResetToken
+	}
+
+	passwordHash, err := s.passHasher.Hash(password)
+	if err != nil {
+		return models.ErrPasswordHashFailed
+	}
+
+	if err := s.policy.RecordChange(user.ID, user.PasswordHash); err != nil {
+		return err
+	}
+	if err := s.userRepo.UpdatePassword(user.ID, passwordHash); err != nil {
+		return models.ErrDatabaseError
+	}
+	if err := s.sessions.RevokeAll(user.ID); err != nil {
+		return err
+	}
+
+	// Владелец восстановил доступ - снимаем блокировку входа
+	s.limiter.RecordSuccess(user.Email)
+
+	return nil
+}
+
+// ------------------------------------------------------------
+// Вспомогательные методы
+// ------------------------------------------------------------
+
+// sendResetMail отправляет письмо со ссылкой для сброса пароля
+func (s *PasswordResetService) sendResetMail(email, rawToken string) {
+	link := s.config.ResetURL + "?token=" + url.QueryEscape(rawToken)
+
+	msg := mail.Message{
+		To:      email,
+		Subject: "Сброс пароля",
+		Body: "Для установки нового пароля перейдите по ссылке:\n\n" + link +
+			"\n\nСсылка действует " + s.config.TokenTTL.String() + " и может быть использована один раз." +
+			"\nЕсли вы не запрашивали сброс пароля, просто проигнорируйте это письмо.",
+	}
+
+	if err := s.sender.Send(msg); err != nil {
+		log.Printf("Ошибка отправки письма сброса пароля: %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"errors"
+	"khrllwTest/internal/models"
+	"khrllwTest/internal/repository"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// maxUserAgentLength ограничение длины сохраняемого User-Agent (размер колонки)
+const maxUserAgentLength = 512
+
+// ------------------------------------------------------------
+// Структуры
+// ------------------------------------------------------------
+
+// SessionService управляет сессиями входа пользователей.
+// Сессия соответствует семейству refresh токенов и завершается вместе с ним.
+type SessionService struct {
+	sessionRepo repository.SessionRepository
+	refreshRepo repository.RefreshTokenRepository
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewSessionService создает новый экземпляр SessionService
+func NewSessionService(
+	sessionRepo repository.SessionRepository,
+	refreshRepo repository.RefreshTokenRepository,
+) *SessionService {
+	return &SessionService{
+		sessionRepo: sessionRepo,
+		refreshRepo: refreshRepo,
+	}
+}
+
+// ------------------------------------------------------------
+// Основные методы
+// ------------------------------------------------------------
+
+// Start создает сессию для нового входа и возвращает ее идентификатор
+func (s *SessionService) Start(userID uint, clientIP, userAgent string) (string, error) {
+	return s.create(uuid.New().String(), userID, clientIP, userAgent)
+}
+
+// Resume проверяет, что сессия семейства refresh токенов активна.
+// Для семейств, выпущенных до появления сессий, сессия создается.
+func (s *SessionService) Resume(sessionID string, userID uint, clientIP, userAgent string) error {
+	session, err := s.sessionRepo.FindByID(sessionID)
+	if err != nil {
+		if errors.Is(err, models.ErrRecordNotFound) {
+			_, err := s.create(sessionID, userID, clientIP, userAgent)
+			return err
+		}
+		return models.ErrDatabaseError
+	}
+
+	if session.UserID != userID || session.RevokedAt != nil {
+		return models.ErrSessionRevoked
+	}
+	return nil
+}
+
+// List возвращает активные сессии пользователя.
+// currentID - сессия, из которой выполнен запрос (отмечается в ответе).
+func (s *SessionService) List(userID uint, currentID string) ([]models.SessionResponse, error) {
+	sessions, err := s.sessionRepo.FindActiveByUserID(userID)
+	if err != nil {
+		return nil, models.ErrDatabaseError
+	}
+
+	response := make([]models.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, models.SessionResponse{
+			ID:         session.ID,
+			IPAddress:  session.IPAddress,
+			UserAgent:  session.UserAgent,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			Current:    session.ID == currentID,
+		})
+	}
+	return response, nil
+}
+
+// Revoke завершает сессию пользователя и отзывает ее refresh токены.
+// Access токены сессии перестают приниматься middleware сразу.
+func (s *SessionService) Revoke(userID uint, sessionID string) error {
+	revoked, err := s.sessionRepo.Revoke(userID, sessionID)
+	if err != nil {
+		return models.ErrDatabaseError
+	}
+	if !revoked {
+		return models.ErrSessionNotFound
+	}
+
+	if err := s.refreshRepo.RevokeFamily(sessionID); err != nil {
+		return models.ErrDatabaseError
+	}
+	return nil
+}
+
+// RevokeAll завершает все сессии пользователя и отзывает все его refresh токены
+func (s *SessionService) RevokeAll(userID uint) error {
+	if err := s.sessionRepo.RevokeAllForUser(userID); err != nil {
+		return models.ErrDatabaseError
+	}
+	if err := s.refreshRepo.RevokeAllForUser(userID); err != nil {
+		return models.ErrDatabaseError
+	}
+	return nil
+}
+
+// ------------------------------------------------------------
+// Вспомогательные методы
+// ------------------------------------------------------------
+
+// create сохраняет сессию с указанным идентификатором
+func (s *SessionService) create(sessionID string, userID uint, clientIP, userAgent string) (string, error) {
+	now := time.Now()
+	session := &models.Session{
+		ID:         sessionID,
+		UserID:     userID,
+		IPAddress:  clientIP,
+		UserAgent:  truncateUTF8(userAgent, maxUserAgentLength),
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+	if err := s.sessionRepo.Create(session); err != nil {
+		return "", models.ErrDatabaseError
+	}
+	return session.ID, nil
+}
+
+// truncateUTF8 обрезает строку до limit байт, не разрывая символы
+func truncateUTF8(value string, limit int) string {
+	if len(value) <= limit {
+		return value
+	}
+	value = value[:limit]
+	for !utf8.ValidString(value) {
+		value = value[:len(value)-1]
+	}
+	return value
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"khrllwTest/internal/repository"
+	"log"
+	"time"
+)
+
+// ------------------------------------------------------------
+// Структуры
+// ------------------------------------------------------------
+
+// TokenCleanupService периодически удаляет истекшие записи о токенах
+type TokenCleanupService struct {
+	revokedRepo repository.RevokedTokenRepository
+	refreshRepo repository.RefreshTokenRepository
+	resetRepo   repository.PasswordResetRepository
+	sessionRepo repository.SessionRepository
+	oidcRepo    repository.OIDCStateRepository
+	interval    time.Duration
+
+	// Сессии без активности дольше этого срока удаляются (срок жизни refresh токена)
+	sessionTTL time.Duration
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewTokenCleanupService создает новый экземпляр TokenCleanupService
+func NewTokenCleanupService(
+	revokedRepo repository.RevokedTokenRepository,
+	refreshRepo repository.RefreshTokenRepository,
+	resetRepo repository.PasswordResetRepository,
+	sessionRepo repository.SessionRepository,
+	oidcRepo repository.OIDCStateRepository,
+	interval time.Duration,
+	sessionTTL time.Duration,
+) *TokenCleanupService {
+	return &TokenCleanupService{
+		revokedRepo: revokedRepo,
+		refreshRepo: refreshRepo,
+		resetRepo:   resetRepo,
+		sessionRepo: sessionRepo,
+		oidcRepo:    oidcRepo,
+		interval:    interval,
+		sessionTTL:  sessionTTL,
+	}
+}
+
+// ------------------------------------------------------------
+// Основные методы
+// ------------------------------------------------------------
+
+// Start запускает фоновую очистку, которая работает до отмены контекста
+func (s *TokenCleanupService) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.Cleanup()
+			}
+		}
+	}()
+}
+
+// Cleanup удаляет отозванные, refresh токены и токены сброса пароля, срок действия которых истек,
+// а также завершенные и неактивные сессии и незавершенные входы через OpenID Connect
+func (s *TokenCleanupService) Cleanup() {
+	now := time.Now()
+
+	if removed, err := s.revokedRepo.DeleteExpired(now); err != nil {
+		log.Printf("Ошибка очистки отозванных токенов: %v", err)
+	} else if removed > 0 {
+		log.Printf("Удалено истекших отозванных токенов: %d", removed)
+	}
+
+	if removed, err := s.refreshRepo.DeleteExpired(now); err != nil {
+		log.Printf("Ошибка очистки refresh токенов: %v", err)
+	} else if removed > 0 {
+		log.Printf("Удалено истекших refresh токенов: %d", removed)
+	}
+
+	if removed, err := s.resetRepo.DeleteExpired(now); err != nil {
+		log.Printf("Ошибка очистки токенов сброса пароля: %v", err)
+	} else if removed > 0 {
+		log.Printf("Удалено истекших токенов сброса пароля: %d", removed)
+	}
+
+	if removed, err := s.sessionRepo.DeleteStale(now.Add(-s.sessionTTL)); err != nil {
+		log.Printf("Ошибка очистки сессий: %v", err)
+	} else if removed > 0 {
+		log.Printf("Удалено завершенных сессий: %d", removed)
+	}
+
+	if removed, err := s.oidcRepo.DeleteExpired(now); err != nil {
+		log.Printf("Ошибка очистки незавершенных входов OIDC: %v", err)
+	} else if removed > 0 {
+		log.Printf("Удалено незавершенных входов OIDC: %d", removed)
+	}
+}
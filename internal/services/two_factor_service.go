@@ -0,0 +1,223 @@
+package service
+
+import (
+	"errors"
+	"khrllwTest/internal/models"
+	"khrllwTest/internal/repository"
+	"khrllwTest/internal/utils"
+	"time"
+)
+
+// recoveryCodesCount количество выдаваемых кодов восстановления
+const recoveryCodesCount = 10
+
+// ------------------------------------------------------------
+// Структуры
+// ------------------------------------------------------------
+
+// TwoFactorService реализует подключение и проверку TOTP 2FA
+type TwoFactorService struct {
+	userRepo     repository.UserRepository
+	recoveryRepo repository.RecoveryCodeRepository
+	issuer       string
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewTwoFactorService создает новый экземпляр TwoFactorService.
+// issuer отображается в приложении-аутентификаторе рядом с email пользователя.
+func NewTwoFactorService(
+	userRepo repository.UserRepository,
+	recoveryRepo repository.RecoveryCodeRepository,
+	issuer string,
+) *TwoFactorService {
+	return &TwoFactorService{
+		userRepo:     userRepo,
+		recoveryRepo: recoveryRepo,
+		issuer:       issuer,
+	}
+}
+
+// ------------------------------------------------------------
+// Основные методы
+// ------------------------------------------------------------
+
+// Enroll создает новый секрет TOTP. 2FA включается только после Confirm,
+// поэтому повторный вызов до подтверждения заменяет секрет.
+func (s *TwoFactorService) Enroll(userID uint) (*models.TwoFactorEnrollResponse, error) {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, models.ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, models.ErrInternalServerError
+	}
+
+	if err := s.userRepo.UpdateTwoFactor(userID, secret, false); err != nil {
+		return nil, models.ErrDatabaseError
+	}
+
+	return &models.TwoFactorEnrollResponse{
+		Secret:     secret,
+		OTPAuthURI: utils.TOTPURI(s.issuer, user.Email, secret),
+	}, nil
+}
+
+// Confirm проверяет первый код из приложения, включает 2FA и возвращает коды восстановления
+func (s *TwoFactorService) Confirm(userID uint, code string) ([]string, error) {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, models.ErrTwoFactorAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, models.ErrTwoFactorNotEnrolled
+	}
+
+	valid, err := s.validateTOTP(user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, models.ErrInvalidTwoFactorCode
+	}
+
+	codes, err := s.issueRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.UpdateTwoFactor(userID, user.TOTPSecret, true); err != nil {
+		return nil, models.ErrDatabaseError
+	}
+
+	return codes, nil
+}
+
+// RegenerateRecoveryCodes заменяет коды восстановления новыми.
+// Требует код из приложения-аутентификатора, чтобы старые коды нельзя было использовать для замены.
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, models.ErrTwoFactorNotEnrolled
+	}
+
+	valid, err := s.validateTOTP(user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, models.ErrInvalidTwoFactorCode
+	}
+
+	return s.issueRecoveryCodes(userID)
+}
+
+// Disable отключает 2FA после проверки кода TOTP или кода восстановления
+func (s *TwoFactorService) Disable(userID uint, code string) error {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return models.ErrTwoFactorNotEnrolled
+	}
+
+	valid, err := s.Verify(user, code)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return models.ErrInvalidTwoFactorCode
+	}
+
+	if err := s.userRepo.UpdateTwoFactor(userID, "", false); err != nil {
+		return models.ErrDatabaseError
+	}
+	if err := s.recoveryRepo.DeleteForUser(userID); err != nil {
+		return models.ErrDatabaseError
+	}
+	return nil
+}
+
+// Verify проверяет код TOTP, а если он не подошел - одноразовый код восстановления.
+// Использованный код восстановления погашается, код TOTP повторно не принимается.
+func (s *TwoFactorService) Verify(user *models.User, code string) (bool, error) {
+	valid, err := s.validateTOTP(user, code)
+	if err != nil {
+		return false, err
+	}
+	if valid {
+		return true, nil
+	}
+
+	used, err := s.recoveryRepo.Use(user.ID, utils.HashOpaqueToken(utils.NormalizeRecoveryCode(code)))
+	if err != nil {
+		return false, models.ErrDatabaseError
+	}
+	return used, nil
+}
+
+// ------------------------------------------------------------
+// Вспомогательные методы
+// ------------------------------------------------------------
+
+// findUser загружает пользователя и приводит ошибки к ошибкам сервиса
+func (s *TwoFactorService) findUser(userID uint) (*models.User, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return nil, err
+		}
+		return nil, models.ErrDatabaseError
+	}
+	return user, nil
+}
+
+// validateTOTP проверяет код TOTP и запоминает его шаг. Код шага, не большего последнего
+// принятого, отклоняется; из двух параллельных запросов с одним кодом принимается только один.
+func (s *TwoFactorService) validateTOTP(user *models.User, code string) (bool, error) {
+	step, ok := utils.ValidateTOTP(user.TOTPSecret, code, time.Now(), user.TOTPLastStep)
+	if !ok {
+		return false, nil
+	}
+
+	used, err := s.userRepo.UseTOTPStep(user.ID, step)
+	if err != nil {
+		return false, models.ErrDatabaseError
+	}
+	return used, nil
+}
+
+// issueRecoveryCodes создает и сохраняет новый набор кодов восстановления
+func (s *TwoFactorService) issueRecoveryCodes(userID uint) ([]string, error) {
+	codes, err := utils.GenerateRecoveryCodes(recoveryCodesCount)
+	if err != nil {
+		return nil, models.ErrInternalServerError
+	}
+
+	records := make([]models.RecoveryCode, 0, len(codes))
+	for _, code := range codes {
+		records = append(records, models.RecoveryCode{
+			UserID:   userID,
+			CodeHash: utils.HashOpaqueToken(code),
+		})
+	}
+
+	if err := s.recoveryRepo.Replace(userID, records); err != nil {
+		return nil, models.ErrDatabaseError
+	}
+	return codes, nil
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"khrllwTest/internal/repository"
+	"log"
+	"time"
+)
+
+// ------------------------------------------------------------
+// Конфигурация
+// ------------------------------------------------------------
+
+// UserDeletionConfig содержит настройки мягкого удаления пользователей
+type UserDeletionConfig struct {
+	// Срок, в течение которого администратор может восстановить удаленного пользователя
+	GracePeriod time.Duration
+
+	// Интервал запуска окончательного удаления пользователей с истекшим сроком восстановления
+	PurgeInterval time.Duration
+}
+
+// NewUserDeletionConfig создает конфигурацию удаления пользователей из переменных окружения
+func NewUserDeletionConfig() (*UserDeletionConfig, error) {
+	gracePeriod, err := envDuration("USER_DELETE_GRACE_PERIOD", 30*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	purgeInterval, err := envDuration("USER_PURGE_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	return &UserDeletionConfig{
+		GracePeriod:   gracePeriod,
+		PurgeInterval: purgeInterval,
+	}, nil
+}
+
+// ------------------------------------------------------------
+// Структуры
+// ------------------------------------------------------------
+
+// UserPurgeService периодически окончательно удаляет пользователей,
+// срок восстановления которых истек (заказы удаляются каскадно).
+// Кеш пользователей не сбрасывается: запись удаляемого пользователя сброшена еще при мягком удалении,
+// а поиск по ID не видит его с того момента, поэтому в кеше может быть только отметка "не найден".
+type UserPurgeService struct {
+	userRepo repository.UserRepository
+	config   *UserDeletionConfig
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewUserPurgeService создает новый экземпляр UserPurgeService
+func NewUserPurgeService(userRepo repository.UserRepository, config *UserDeletionConfig) *UserPurgeService {
+	return &UserPurgeService{
+		userRepo: userRepo,
+		config:   config,
+	}
+}
+
+// ------------------------------------------------------------
+// Основные методы
+// ------------------------------------------------------------
+
+// Start запускает фоновое удаление, которое работает до отмены контекста
+func (s *UserPurgeService) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.config.PurgeInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.Purge()
+			}
+		}
+	}()
+}
+
+// Purge окончательно удаляет пользователей, удаленных раньше срока восстановления
+func (s *UserPurgeService) Purge() {
+	removed, err := s.userRepo.PurgeDeleted(time.Now().Add(-s.config.GracePeriod))
+	if err != nil {
+		log.Printf("Ошибка окончательного удаления пользователей: %v", err)
+	} else if removed > 0 {
+		log.Printf("Окончательно удалено пользователей: %d", removed)
+	}
+}
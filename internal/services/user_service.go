@@ -5,16 +5,58 @@ import (
 	"khrllwTest/internal/models"
 	"khrllwTest/internal/repository"
 	"khrllwTest/internal/utils"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 )
 
+const (
+	// maxUserSearchLength ограничение длины строки поиска пользователей
+	maxUserSearchLength = 100
+
+	// maxUserSortFields ограничение количества полей сортировки
+	maxUserSortFields = 5
+)
+
+// emailDomainPattern допустимый домен email в фильтре списка пользователей
+var emailDomainPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*$`)
+
 // ------------------------------------------------------------
 // Структуры
 // ------------------------------------------------------------
 
-// UserService реализует бизнес-логику работы с пользователями
+// UserService реализует бизнес-логику работы с пользователями.
+// После каждого изменения пользователя сервис сам сбрасывает его запись в кеше middleware,
+// поэтому userRepo не обязан быть обернут NewInvalidatingUserRepository.
 type UserService struct {
 	userRepo   repository.UserRepository
+	sessions   *SessionService
 	passHasher utils.PasswordHasher
+	limiter    *LoginLimiter
+	verifier   *EmailVerificationService
+	policy     *PasswordPolicy
+	deletion   *UserDeletionConfig
+	cursors    *utils.CursorSigner
+	cache      *repository.UserCache
+}
+
+// userCursor содержимое курсора keyset-пагинации списка пользователей
+type userCursor struct {
+	// Отпечаток фильтров и сортировки, для которых выдан курсор
+	Query string `json:"q"`
+
+	// Курсор предыдущей страницы
+	Backward bool `json:"b,omitempty"`
+
+	// Значения полей сортировки крайней строки соседней страницы (заполняются только поля сортировки)
+	ID    uint   `json:"id"`
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email,omitempty"`
+	Age   int    `json:"age,omitempty"`
+	Role  string `json:"role,omitempty"`
 }
 
 // ------------------------------------------------------------
@@ -24,11 +66,25 @@ type UserService struct {
 // NewUserService создает новый экземпляр UserService
 func NewUserService(
 	userRepo repository.UserRepository,
+	sessions *SessionService,
 	passHasher utils.PasswordHasher,
+	limiter *LoginLimiter,
+	verifier *EmailVerificationService,
+	policy *PasswordPolicy,
+	deletion *UserDeletionConfig,
+	cursors *utils.CursorSigner,
+	cache *repository.UserCache,
 ) *UserService {
 	return &UserService{
 		userRepo:   userRepo,
+		sessions:   sessions,
 		passHasher: passHasher,
+		limiter:    limiter,
+		verifier:   verifier,
+		policy:     policy,
+		deletion:   deletion,
+		cursors:    cursors,
+		cache:      cache,
 	}
 }
 
@@ -36,12 +92,17 @@ func NewUserService(
 // Основные методы
 // ------------------------------------------------------------
 
-// CreateUser создает нового пользователя
+// CreateUser создает нового пользователя с неподтвержденным email
+// и отправляет ему ссылку подтверждения
 func (s *UserService) CreateUser(req *models.CreateUserRequest) (*models.User, error) {
 	if err := s.validateCreateRequest(req); err != nil {
 		return nil, err
 	}
 
+	if err := s.policy.Validate(req.Password, &models.User{Name: req.Name, Email: req.Email}); err != nil {
+		return nil, err
+	}
+
 	hashedPassword, err := s.passHasher.Hash(req.Password)
 	if err != nil {
 		return nil, models.ErrPasswordHashFailed
@@ -52,35 +113,76 @@ func (s *UserService) CreateUser(req *models.CreateUserRequest) (*models.User, e
 		Email:        req.Email,
 		Age:          req.Age,
 		PasswordHash: hashedPassword,
+		Role:         models.RoleUser,
 	}
 
 	if err := s.userRepo.Create(user); err != nil {
+		if errors.Is(err, models.ErrEmailAlreadyExists) {
+			return nil, models.ErrEmailAlreadyExists
+		}
 		return nil, models.ErrDatabaseError
 	}
+	// Сбрасывает отметку "не найден", если токен с этим ID уже проверялся
+	s.cache.Invalidate(user.ID)
+
+	// Аккаунт уже создан: письмо можно запросить повторно, поэтому ошибка не критична
+	if err := s.verifier.SendVerification(user); err != nil {
+		log.Printf("Не удалось отправить ссылку подтверждения email: %v", err)
+	}
 
 	return user, nil
 }
 
-// GetUsers возвращает список пользователей с пагинацией и фильтрацией
-func (s *UserService) GetUsers(page, limit, minAge, maxAge int) ([]models.User, int64, error) {
+// GetUsers возвращает страницу списка пользователей с поиском, фильтрацией и сортировкой.
+// Без курсора страница выбирается по номеру (OFFSET) с подсчетом total, с курсором - по границе
+// соседней страницы (keyset), а total подсчитывается только по запросу. Курсоры следующей
+// и предыдущей страницы возвращаются в обоих режимах.
+// Фильтр нормализуется на месте: строка поиска обрезается, домен приводится к нижнему регистру.
+func (s *UserService) GetUsers(query *models.UserListQuery) (*models.UserPage, error) {
+	filter := &query.Filter
+	if err := normalizeUserFilter(filter); err != nil {
+		return nil, err
+	}
+	fingerprint := userQueryFingerprint(filter)
+
+	if query.Cursor != "" {
+		return s.getUsersByCursor(query, fingerprint)
+	}
+
+	offset := (query.Page - 1) * query.Limit
 	users, total, err := s.userRepo.GetAll(
-		(page-1)*limit,
-		limit,
-		minAge,
-		maxAge,
+		offset,
+		query.Limit,
+		filter,
 	)
 	if err != nil {
-		return nil, 0, models.ErrDatabaseError
+		if errors.Is(err, models.ErrInvalidSortParams) {
+			return nil, err
+		}
+		return nil, models.ErrDatabaseError
 	}
 
-	return users, total, nil
+	page := &models.UserPage{Users: users, Total: &total}
+	if len(users) > 0 {
+		if int64(offset+len(users)) < total {
+			if page.NextCursor, err = s.encodeUserCursor(&users[len(users)-1], filter.Sort, fingerprint, false); err != nil {
+				return nil, err
+			}
+		}
+		if offset > 0 {
+			if page.PrevCursor, err = s.encodeUserCursor(&users[0], filter.Sort, fingerprint, true); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return page, nil
 }
 
 // GetUserByID возвращает пользователя по ID
 func (s *UserService) GetUserByID(userID uint) (*models.User, error) {
 	user, err := s.userRepo.FindByID(userID)
 	if err != nil {
-		if errors.Is(err, models.ErrRecordNotFound) {
+		if errors.Is(err, models.ErrUserNotFound) {
 			return nil, models.ErrUserNotFound
 		}
 		return nil, models.ErrDatabaseError
@@ -88,42 +190,248 @@ func (s *UserService) GetUserByID(userID uint) (*models.User, error) {
 	return user, nil
 }
 
-// UpdateUser обновляет данные пользователя
-func (s *UserService) UpdateUser(userID uint, req *models.UpdateUserRequest) (*models.User, error) {
+// UpdateUser обновляет данные пользователя.
+// Новый email требует повторного подтверждения.
+// Запись пользователя в кеше middleware сбрасывается после сохранения.
+// version - версия из If-Match (0 - без проверки); запись, измененная после чтения, не перезаписывается.
+func (s *UserService) UpdateUser(userID uint, req *models.UpdateUserRequest, version int) (*models.User, error) {
 	user, err := s.userRepo.FindByID(userID)
 	if err != nil {
-		if errors.Is(err, models.ErrRecordNotFound) {
+		if errors.Is(err, models.ErrUserNotFound) {
 			return nil, models.ErrUserNotFound
 		}
 		return nil, models.ErrDatabaseError
 	}
+	if err := checkVersion(user.Version, version); err != nil {
+		return nil, err
+	}
 
+	previousEmail := user.Email
 	if err := s.updateUserFields(user, req); err != nil {
 		return nil, err
 	}
 
+	emailChanged := user.Email != previousEmail
+	if emailChanged {
+		user.EmailVerifiedAt = nil
+	}
+
 	if err := s.userRepo.Update(user); err != nil {
+		if errors.Is(err, models.ErrEmailAlreadyExists) {
+			return nil, models.ErrEmailAlreadyExists
+		}
+		return nil, versionConflict(err, version)
+	}
+	s.cache.Invalidate(userID)
+
+	if emailChanged {
+		if err := s.verifier.SendVerification(user); err != nil {
+			log.Printf("Не удалось отправить ссылку подтверждения email: %v", err)
+		}
+	}
+
+	return user, nil
+}
+
+// PatchUser частично обновляет пользователя: проверяются и записываются только переданные поля,
+// поэтому одновременные изменения разных полей не затирают друг друга.
+// Изменение email сбрасывает его подтверждение и отправляет новую ссылку.
+// version - версия из If-Match (0 - без проверки): с ней патч применяется только к этой версии пользователя.
+func (s *UserService) PatchUser(userID uint, patch *models.PatchUserRequest, version int) (*models.User, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return nil, models.ErrUserNotFound
+		}
 		return nil, models.ErrDatabaseError
 	}
+	if err := checkVersion(user.Version, version); err != nil {
+		return nil, err
+	}
+
+	fields, emailChanged, err := s.patchFields(user, patch)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return user, nil
+	}
+
+	if err := s.userRepo.UpdateFields(userID, version, fields); err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return nil, models.ErrUserNotFound
+		}
+		if errors.Is(err, models.ErrEmailAlreadyExists) {
+			return nil, models.ErrEmailAlreadyExists
+		}
+		return nil, versionConflict(err, version)
+	}
+	s.cache.Invalidate(userID)
+
+	user, err = s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, models.ErrDatabaseError
+	}
+
+	if emailChanged {
+		if err := s.verifier.SendVerification(user); err != nil {
+			log.Printf("Не удалось отправить ссылку подтверждения email: %v", err)
+		}
+	}
+
+	return user, nil
+}
+
+// UpdateUserRole изменяет роль пользователя.
+// Версия токенов увеличивается, чтобы токены со старой ролью перестали действовать.
+// version - версия из If-Match (0 - без проверки).
+func (s *UserService) UpdateUserRole(userID uint, role string, version int) (*models.User, error) {
+	if role != models.RoleUser && role != models.RoleAdmin {
+		return nil, models.ErrInvalidUserRole
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return nil, models.ErrUserNotFound
+		}
+		return nil, models.ErrDatabaseError
+	}
+	if err := checkVersion(user.Version, version); err != nil {
+		return nil, err
+	}
+
+	if user.Role == role {
+		return user, nil
+	}
+
+	user.Role = role
+	user.TokenVersion++
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, versionConflict(err, version)
+	}
+	s.cache.Invalidate(userID)
 
 	return user, nil
 }
 
-// DeleteUser удаляет пользователя по ID
-func (s *UserService) DeleteUser(userID uint) error {
-	_, err := s.userRepo.FindByID(userID)
+// ChangePassword меняет пароль после проверки текущего и политики паролей.
+// Версия учетных данных увеличивается, а сессии и refresh токены отзываются,
+// поэтому все выданные ранее токены, включая текущий, перестают действовать.
+func (s *UserService) ChangePassword(userID uint, req *models.ChangePasswordRequest, clientIP string) error {
+	user, err := s.userRepo.FindByID(userID)
 	if err != nil {
-		if errors.Is(err, models.ErrRecordNotFound) {
+		if errors.Is(err, models.ErrUserNotFound) {
 			return models.ErrUserNotFound
 		}
 		return models.ErrDatabaseError
 	}
-	if err := s.userRepo.Delete(userID); err != nil {
+
+	// Неверный текущий пароль учитывается защитой от перебора вместе с неудачными входами,
+	// иначе украденный access токен позволял бы подбирать пароль без ограничений
+	if err := s.limiter.Allow(user.Email, clientIP); err != nil {
+		return err
+	}
+	if !s.passHasher.Check(req.CurrentPassword, user.PasswordHash) {
+		s.limiter.RecordFailure(user.Email, clientIP)
+		return models.ErrInvalidCurrentPassword
+	}
+	s.limiter.RecordSuccess(user.Email)
+	if req.NewPassword == req.CurrentPassword {
+		return models.ErrPasswordUnchanged
+	}
+
+	if err := s.policy.Validate(req.NewPassword, user); err != nil {
+		return err
+	}
+
+	passwordHash, err := s.passHasher.Hash(req.NewPassword)
+	if err != nil {
+		return models.ErrPasswordHashFailed
+	}
+
+	if err := s.policy.RecordChange(user.ID, user.PasswordHash); err != nil {
+		return err
+	}
+	if err := s.userRepo.UpdatePassword(user.ID, passwordHash); err != nil {
+		return models.ErrDatabaseError
+	}
+	s.cache.Invalidate(user.ID)
+	if err := s.sessions.RevokeAll(user.ID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteUser мягко удаляет пользователя по ID и завершает все его сессии.
+// Запись пользователя в кеше middleware сбрасывается, поэтому его токены перестают приниматься сразу.
+// Окончательно пользователь и его заказы удаляются UserPurgeService после срока восстановления.
+// version - версия из If-Match (0 - без проверки).
+func (s *UserService) DeleteUser(userID uint, version int) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return models.ErrUserNotFound
+		}
 		return models.ErrDatabaseError
 	}
+	if err := checkVersion(user.Version, version); err != nil {
+		return err
+	}
+	if err := s.userRepo.Delete(userID, version); err != nil {
+		return versionConflict(err, version)
+	}
+	s.cache.Invalidate(userID)
+	if err := s.sessions.RevokeAll(userID); err != nil {
+		return err
+	}
 	return nil
 }
 
+// RestoreUser восстанавливает мягко удаленного пользователя, если срок восстановления не истек
+// и его email не занят другим пользователем. Версия токенов увеличивается, поэтому токены,
+// выданные до удаления, не начинают действовать снова.
+func (s *UserService) RestoreUser(userID uint) (*models.User, error) {
+	user, err := s.userRepo.FindDeletedByID(userID)
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return nil, models.ErrUserNotFound
+		}
+		return nil, models.ErrDatabaseError
+	}
+
+	deletedAfter := time.Now().Add(-s.deletion.GracePeriod)
+	if !user.DeletedAt.Time.After(deletedAfter) {
+		return nil, models.ErrUserRestoreExpired
+	}
+	if _, err := s.userRepo.FindByEmail(user.Email); err == nil {
+		return nil, models.ErrEmailAlreadyExists
+	} else if !errors.Is(err, models.ErrRecordNotFound) {
+		return nil, models.ErrDatabaseError
+	}
+
+	restored, err := s.userRepo.Restore(userID, deletedAfter)
+	if err != nil {
+		// Email мог быть занят после проверки выше: это решает уникальный индекс
+		if errors.Is(err, models.ErrEmailAlreadyExists) {
+			return nil, models.ErrEmailAlreadyExists
+		}
+		return nil, models.ErrDatabaseError
+	}
+	if !restored {
+		// Пользователь успел быть удален окончательно или восстановлен параллельным запросом
+		return nil, models.ErrUserRestoreExpired
+	}
+	s.cache.Invalidate(userID)
+
+	user, err = s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, models.ErrDatabaseError
+	}
+	return user, nil
+}
+
 // ------------------------------------------------------------
 // Вспомогательные методы
 // ------------------------------------------------------------
@@ -139,7 +447,7 @@ func (s *UserService) validateCreateRequest(req *models.CreateUserRequest) error
 	if req.Password == "" {
 		return models.ErrInvalidUserPassword
 	}
-	if req.Age <= 0 || req.Age > 150 {
+	if !validAge(req.Age) {
 		return models.ErrInvalidUserAge
 	}
 	if _, err := s.userRepo.FindByEmail(req.Email); err == nil {
@@ -148,6 +456,51 @@ func (s *UserService) validateCreateRequest(req *models.CreateUserRequest) error
 	return nil
 }
 
+// patchFields проверяет переданные поля частичного обновления и возвращает изменяемые колонки.
+// Поля, значение которых не меняется, не записываются.
+func (s *UserService) patchFields(user *models.User, patch *models.PatchUserRequest) (map[string]interface{}, bool, error) {
+	fields := make(map[string]interface{})
+	emailChanged := false
+
+	if patch.Name != nil {
+		if strings.TrimSpace(*patch.Name) == "" {
+			return nil, false, models.ErrInvalidUserName
+		}
+		if *patch.Name != user.Name {
+			fields["name"] = *patch.Name
+		}
+	}
+	if patch.Age != nil {
+		if !validAge(*patch.Age) {
+			return nil, false, models.ErrInvalidUserAge
+		}
+		if *patch.Age != user.Age {
+			fields["age"] = *patch.Age
+		}
+	}
+	if patch.Email != nil {
+		if *patch.Email == "" {
+			return nil, false, models.ErrInvalidUserEmail
+		}
+		if *patch.Email != user.Email {
+			if _, err := s.userRepo.FindByEmail(*patch.Email); err == nil {
+				return nil, false, models.ErrEmailAlreadyExists
+			} else if !errors.Is(err, models.ErrRecordNotFound) {
+				return nil, false, models.ErrDatabaseError
+			}
+			fields["email"] = *patch.Email
+			fields["email_verified_at"] = nil
+			emailChanged = true
+		}
+	}
+	return fields, emailChanged, nil
+}
+
+// validAge проверяет возраст пользователя при создании, обновлении и частичном обновлении
+func validAge(age int) bool {
+	return age >= models.MinUserAge && age <= models.MaxUserAge
+}
+
 // updateUserFields обновляет поля пользователя
 func (s *UserService) updateUserFields(user *models.User, req *models.UpdateUserRequest) error {
 	if req.Name == "" {
@@ -156,7 +509,7 @@ func (s *UserService) updateUserFields(user *models.User, req *models.UpdateUser
 	if req.Email == "" {
 		return models.ErrInvalidUserEmail
 	}
-	if req.Age <= 0 || req.Age > 150 {
+	if !validAge(req.Age) {
 		return models.ErrInvalidUserAge
 	}
 	user.Name = req.Name
@@ -164,3 +517,155 @@ func (s *UserService) updateUserFields(user *models.User, req *models.UpdateUser
 	user.Age = req.Age
 	return nil
 }
+
+// ------------------------------------------------------------
+// Поиск и сортировка
+// ------------------------------------------------------------
+
+// ParseUserSort разбирает параметр sort списка пользователей, например "-age,name":
+// поля через запятую, "-" перед полем - по убыванию. Поля проверяются по белому списку
+// models.UserSortColumns и не повторяются. Пустая строка - сортировка по ID.
+func ParseUserSort(raw string) ([]models.UserSortField, error) {
+	if strings.TrimSpace(raw) == "" {
+		return []models.UserSortField{{Field: "id"}}, nil
+	}
+
+	parts := strings.Split(raw, ",")
+	if len(parts) > maxUserSortFields {
+		return nil, models.ErrInvalidSortParams
+	}
+
+	fields := make([]models.UserSortField, 0, len(parts))
+	seen := make(map[string]bool, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		field := models.UserSortField{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+		if _, ok := models.UserSortColumns[field.Field]; !ok || seen[field.Field] {
+			return nil, models.ErrInvalidSortParams
+		}
+		seen[field.Field] = true
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// FormatUserSort возвращает сортировку в формате параметра sort
+func FormatUserSort(fields []models.UserSortField) string {
+	parts := make([]string, len(fields))
+	for i, field := range fields {
+		if field.Desc {
+			parts[i] = "-" + field.Field
+		} else {
+			parts[i] = field.Field
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// getUsersByCursor выбирает страницу пользователей после (или перед) границей из курсора.
+// Выбирается на одну строку больше limit, чтобы узнать, есть ли страница дальше.
+func (s *UserService) getUsersByCursor(query *models.UserListQuery, fingerprint string) (*models.UserPage, error) {
+	var cursor userCursor
+	if err := s.cursors.Decode(query.Cursor, &cursor); err != nil || cursor.Query != fingerprint {
+		return nil, models.ErrInvalidCursor
+	}
+
+	keyset := &models.UserKeyset{
+		Boundary: models.User{ID: cursor.ID, Name: cursor.Name, Email: cursor.Email, Age: cursor.Age, Role: cursor.Role},
+		Backward: cursor.Backward,
+	}
+	users, err := s.userRepo.GetPage(keyset, query.Limit+1, &query.Filter)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidSortParams) {
+			return nil, err
+		}
+		return nil, models.ErrDatabaseError
+	}
+
+	hasMore := len(users) > query.Limit
+	if hasMore {
+		if cursor.Backward {
+			users = users[1:]
+		} else {
+			users = users[:query.Limit]
+		}
+	}
+
+	page := &models.UserPage{Users: users}
+	if query.IncludeTotal {
+		total, err := s.userRepo.Count(&query.Filter)
+		if err != nil {
+			return nil, models.ErrDatabaseError
+		}
+		page.Total = &total
+	}
+
+	// Со стороны границы курсора соседняя страница есть всегда, с другой стороны - если выбрано больше limit строк
+	if len(users) > 0 {
+		if cursor.Backward || hasMore {
+			if page.NextCursor, err = s.encodeUserCursor(&users[len(users)-1], query.Filter.Sort, fingerprint, false); err != nil {
+				return nil, err
+			}
+		}
+		if !cursor.Backward || hasMore {
+			if page.PrevCursor, err = s.encodeUserCursor(&users[0], query.Filter.Sort, fingerprint, true); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return page, nil
+}
+
+// encodeUserCursor создает курсор страницы после (или перед) пользователем.
+// В курсор попадают только поля сортировки и ID.
+func (s *UserService) encodeUserCursor(user *models.User, sort []models.UserSortField, fingerprint string, backward bool) (string, error) {
+	cursor := userCursor{Query: fingerprint, Backward: backward, ID: user.ID}
+	for _, field := range sort {
+		switch field.Field {
+		case "name":
+			cursor.Name = user.Name
+		case "email":
+			cursor.Email = user.Email
+		case "age":
+			cursor.Age = user.Age
+		case "role":
+			cursor.Role = user.Role
+		}
+	}
+	encoded, err := s.cursors.Encode(cursor)
+	if err != nil {
+		return "", models.ErrInternalServerError
+	}
+	return encoded, nil
+}
+
+// userQueryFingerprint возвращает отпечаток фильтров и сортировки списка пользователей
+func userQueryFingerprint(filter *models.UserFilter) string {
+	return queryFingerprint("users", FormatUserSort(filter.Sort), filter.Search, filter.EmailDomain,
+		strconv.Itoa(filter.MinAge), strconv.Itoa(filter.MaxAge))
+}
+
+// normalizeUserFilter проверяет и нормализует параметры поиска и фильтрации
+func normalizeUserFilter(filter *models.UserFilter) error {
+	filter.Search = strings.TrimSpace(filter.Search)
+	if utf8.RuneCountInString(filter.Search) > maxUserSearchLength {
+		return models.ErrInvalidFilterParams
+	}
+
+	filter.EmailDomain = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(filter.EmailDomain), "@"))
+	if filter.EmailDomain != "" && !emailDomainPattern.MatchString(filter.EmailDomain) {
+		return models.ErrInvalidFilterParams
+	}
+
+	if filter.MinAge < 0 || filter.MaxAge < 0 {
+		return models.ErrInvalidFilterParams
+	}
+	if filter.MinAge > 0 && filter.MaxAge > 0 && filter.MinAge > filter.MaxAge {
+		return models.ErrInvalidFilterParams
+	}
+
+	if len(filter.Sort) == 0 {
+		filter.Sort = []models.UserSortField{{Field: "id"}}
+	}
+	return nil
+}
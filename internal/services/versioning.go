@@ -0,0 +1,27 @@
+package service
+
+import (
+	"errors"
+	"khrllwTest/internal/models"
+)
+
+// checkVersion сверяет текущую версию записи с версией из If-Match (0 - заголовок не передан)
+func checkVersion(current, expected int) error {
+	if expected != 0 && current != expected {
+		return models.ErrPreconditionFailed
+	}
+	return nil
+}
+
+// versionConflict переводит ошибку записи с проверкой версии в ошибку сервиса.
+// Запись успели изменить между чтением и обновлением: при переданном If-Match это несовпадение
+// условия запроса, без него - конфликт параллельных изменений.
+func versionConflict(err error, expected int) error {
+	if !errors.Is(err, models.ErrVersionConflict) {
+		return models.ErrDatabaseError
+	}
+	if expected != 0 {
+		return models.ErrPreconditionFailed
+	}
+	return models.ErrVersionConflict
+}
@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// ------------------------------------------------------------
+// Реализация
+// ------------------------------------------------------------
+
+// localStorage хранит объекты файлами в каталоге локальной файловой системы (ключ - относительный путь)
+type localStorage struct {
+	root string
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewLocalStorage создает хранилище в каталоге root (каталог создается, если его нет)
+func NewLocalStorage(root string) (Storage, error) {
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, fmt.Errorf("не удалось создать каталог хранилища: %w", err)
+	}
+	return &localStorage{root: root}, nil
+}
+
+// ------------------------------------------------------------
+// Методы реализации
+// ------------------------------------------------------------
+
+// Put записывает объект во временный файл и переименовывает его,
+// чтобы читатели не видели частично записанный объект
+func (s *localStorage) Put(key, contentType string, data []byte) error {
+	filePath, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o750); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filePath)
+}
+
+// Get открывает файл объекта; тип содержимого определяется по расширению ключа
+func (s *localStorage) Get(key string) (*Object, error) {
+	filePath, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return &Object{Body: file, ContentType: contentType, Size: info.Size()}, nil
+}
+
+func (s *localStorage) Delete(key string) error {
+	filePath, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// path возвращает путь файла объекта внутри каталога хранилища
+func (s *localStorage) path(key string) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// s3RequestTimeout максимальная длительность запроса к S3
+const s3RequestTimeout = 30 * time.Second
+
+// ------------------------------------------------------------
+// Конфигурация
+// ------------------------------------------------------------
+
+// S3Config содержит параметры подключения к S3-совместимому хранилищу (AWS S3, MinIO)
+type S3Config struct {
+	// Адрес сервиса со схемой (https://s3.eu-central-1.amazonaws.com, http://minio:9000)
+	Endpoint string
+
+	// Регион для подписи запросов
+	Region string
+
+	// Имя бакета (адресуется в пути: <endpoint>/<bucket>/<key>)
+	Bucket string
+
+	// Ключ доступа и секретный ключ
+	AccessKey string
+	SecretKey string
+}
+
+// ------------------------------------------------------------
+// Реализация
+// ------------------------------------------------------------
+
+// s3Storage хранит объекты в бакете S3-совместимого хранилища; запросы подписываются SigV4
+type s3Storage struct {
+	config   *S3Config
+	endpoint *url.URL
+	client   *http.Client
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewS3Storage создает хранилище в бакете S3 (бакет должен существовать)
+func NewS3Storage(config *S3Config) (Storage, error) {
+	endpoint, err := url.Parse(strings.TrimSuffix(config.Endpoint, "/"))
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return nil, fmt.Errorf("неверное значение S3_ENDPOINT: %q. Пример: http://minio:9000", config.Endpoint)
+	}
+	return &s3Storage{
+		config:   config,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: s3RequestTimeout},
+	}, nil
+}
+
+// ------------------------------------------------------------
+// Методы реализации
+// ------------------------------------------------------------
+
+func (s *s3Storage) Put(key, contentType string, data []byte) error {
+	// PUT /<bucket>/<key>
+	resp, err := s.do(http.MethodPut, key, contentType, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return s.responseError(http.MethodPut, key, resp)
+	}
+	return nil
+}
+
+func (s *s3Storage) Get(key string) (*Object, error) {
+	// GET /<bucket>/<key>
+	resp, err := s.do(http.MethodGet, key, "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return &Object{Body: resp.Body, ContentType: resp.Header.Get("Content-Type"), Size: resp.ContentLength}, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, ErrObjectNotFound
+	default:
+		defer resp.Body.Close()
+		return nil, s.responseError(http.MethodGet, key, resp)
+	}
+}
+
+func (s *s3Storage) Delete(key string) error {
+	// DELETE /<bucket>/<key> (S3 отвечает 204 и для отсутствующего объекта)
+	resp, err := s.do(http.MethodDelete, key, "", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return s.responseError(http.MethodDelete, key, resp)
+	}
+	return nil
+}
+
+// ------------------------------------------------------------
+// Вспомогательные методы
+// ------------------------------------------------------------
+
+// do выполняет подписанный запрос к объекту бакета
+func (s *s3Storage) do(method, key, contentType string, data []byte) (*http.Response, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	target := *s.endpoint
+	target.Path = s.endpoint.Path + "/" + s.config.Bucket + "/" + key
+	req, err := http.NewRequest(method, target.String(), bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	SignRequestV4(req, s.config.AccessKey, s.config.SecretKey, s.config.Region, "s3", HashPayload(data), time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к S3: %w", err)
+	}
+	return resp, nil
+}
+
+// responseError формирует ошибку из ответа S3 (код ошибки содержится в XML теле)
+func (s *s3Storage) responseError(method, key string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("S3 %s %s: статус %d: %s", method, key, resp.StatusCode, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ------------------------------------------------------------
+// Подпись запросов AWS Signature Version 4
+// ------------------------------------------------------------
+
+const (
+	// sigV4Algorithm алгоритм подписи в заголовке Authorization
+	sigV4Algorithm = "AWS4-HMAC-SHA256"
+
+	// sigV4TimeFormat формат времени X-Amz-Date
+	sigV4TimeFormat = "20060102T150405Z"
+)
+
+// EmptyPayloadHash SHA-256 пустого тела запроса (GET, DELETE)
+var EmptyPayloadHash = HashPayload(nil)
+
+// HashPayload возвращает SHA-256 тела запроса в hex для заголовка X-Amz-Content-Sha256
+func HashPayload(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// SignRequestV4 подписывает запрос по AWS Signature Version 4: устанавливает X-Amz-Date,
+// X-Amz-Content-Sha256 и Authorization. Подписываются Host, Content-Type, Range и все заголовки X-Amz-*.
+func SignRequestV4(req *http.Request, accessKey, secretKey, region, service, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format(sigV4TimeFormat)
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if strings.HasPrefix(name, "x-amz-") || name == "content-type" || name == "range" {
+			headers[name] = strings.Join(strings.Fields(strings.Join(values, ",")), " ")
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI(req.URL.Path),
+		canonicalQuery(req),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := strings.Join([]string{sigV4Algorithm, amzDate, scope, HashPayload([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", sigV4Algorithm+" Credential="+accessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+// canonicalURI кодирует каждый сегмент пути по правилам SigV4 (S3 не нормализует путь)
+func canonicalURI(path string) string {
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = sigV4Escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery сортирует и кодирует параметры строки запроса
+func canonicalQuery(req *http.Request) string {
+	query := req.URL.Query()
+	pairs := make([]string, 0, len(query))
+	for name, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, sigV4Escape(name)+"="+sigV4Escape(value))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// sigV4Escape кодирует строку в формате URI-encode SigV4: без изменения остаются только A-Z, a-z, 0-9, '-', '.', '_', '~'
+func sigV4Escape(value string) string {
+	var builder strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~' {
+			builder.WriteByte(c)
+			continue
+		}
+		builder.WriteString("%" + strings.ToUpper(hex.EncodeToString([]byte{c})))
+	}
+	return builder.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path"
+	"strings"
+)
+
+// ------------------------------------------------------------
+// Ошибки
+// ------------------------------------------------------------
+
+var (
+	// ErrObjectNotFound объект с указанным ключом не найден
+	ErrObjectNotFound = errors.New("объект не найден в хранилище")
+
+	// ErrInvalidKey ключ объекта пустой или выходит за пределы хранилища ("..", абсолютный путь)
+	ErrInvalidKey = errors.New("некорректный ключ объекта")
+)
+
+// ------------------------------------------------------------
+// Структуры
+// ------------------------------------------------------------
+
+// Object объект, открытый для чтения (Body закрывает вызывающий)
+type Object struct {
+	// Содержимое объекта
+	Body io.ReadCloser
+
+	// MIME-тип содержимого
+	ContentType string
+
+	// Размер в байтах (-1 - неизвестен)
+	Size int64
+}
+
+// ------------------------------------------------------------
+// Интерфейс
+// ------------------------------------------------------------
+
+// Storage определяет контракт хранилища файлов по ключу ("avatars/7/abc/128.jpg")
+type Storage interface {
+	// Put сохраняет объект, заменяя существующий с тем же ключом
+	Put(key, contentType string, data []byte) error
+
+	// Get открывает объект для чтения (ErrObjectNotFound, если объекта нет)
+	Get(key string) (*Object, error)
+
+	// Delete удаляет объект (отсутствие объекта ошибкой не считается)
+	Delete(key string) error
+}
+
+// ------------------------------------------------------------
+// Выбор реализации
+// ------------------------------------------------------------
+
+// NewStorageFromEnv создает хранилище по STORAGE_BACKEND: s3 - S3-совместимое хранилище,
+// иначе - каталог локальной файловой системы STORAGE_LOCAL_DIR
+func NewStorageFromEnv() (Storage, error) {
+	switch backend := os.Getenv("STORAGE_BACKEND"); backend {
+	case "", "local":
+		dir := os.Getenv("STORAGE_LOCAL_DIR")
+		if dir == "" {
+			dir = "data/uploads" // значение по умолчанию
+		}
+		log.Printf("Файлы сохраняются в локальный каталог %s", dir)
+		return NewLocalStorage(dir)
+	case "s3":
+		config := &S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		}
+		if config.Region == "" {
+			config.Region = "us-east-1" // значение по умолчанию
+		}
+		if config.Endpoint == "" || config.Bucket == "" || config.AccessKey == "" || config.SecretKey == "" {
+			return nil, errors.New("для STORAGE_BACKEND=s3 необходимы S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY_ID и S3_SECRET_ACCESS_KEY")
+		}
+		return NewS3Storage(config)
+	default:
+		return nil, errors.New("неверное значение STORAGE_BACKEND: " + backend + ". Ожидается local или s3")
+	}
+}
+
+// ------------------------------------------------------------
+// Вспомогательные функции
+// ------------------------------------------------------------
+
+// cleanKey проверяет ключ объекта: относительный путь через "/" без "." и ".." сегментов
+func cleanKey(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, `\`) || path.Clean(key) != key {
+		return "", ErrInvalidKey
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == ".." || segment == "." {
+			return "", ErrInvalidKey
+		}
+	}
+	return key, nil
+}
@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"khrllwTest/internal/models"
+	"log"
+	"os"
+	"strings"
+)
+
+const (
+	// minCursorSecretLength минимальная длина секрета подписи курсоров
+	minCursorSecretLength = 32
+
+	// maxCursorLength ограничение длины курсора, принимаемого от клиента
+	maxCursorLength = 1024
+)
+
+// ------------------------------------------------------------
+// Структуры
+// ------------------------------------------------------------
+
+// CursorSigner кодирует состояние keyset-пагинации в непрозрачный курсор
+// и проверяет его подпись (HMAC-SHA256), чтобы клиент не мог подменить границу страницы
+type CursorSigner struct {
+	secret []byte
+}
+
+// ------------------------------------------------------------
+// Конструктор
+// ------------------------------------------------------------
+
+// NewCursorSigner создает подписчик курсоров с указанным секретом
+func NewCursorSigner(secret []byte) *CursorSigner {
+	return &CursorSigner{secret: secret}
+}
+
+// NewCursorSignerFromEnv создает подписчик курсоров с секретом из CURSOR_SECRET.
+// Если секрет не задан, он генерируется при запуске: курсоры перестают действовать
+// после перезапуска и не принимаются другими экземплярами сервиса.
+func NewCursorSignerFromEnv() (*CursorSigner, error) {
+	secret := os.Getenv("CURSOR_SECRET")
+	if secret == "" {
+		random := make([]byte, minCursorSecretLength)
+		if _, err := rand.Read(random); err != nil {
+			return nil, err
+		}
+		log.Println("CURSOR_SECRET не задан: курсоры пагинации подписываются случайным ключом")
+		return NewCursorSigner(random), nil
+	}
+	if len(secret) < minCursorSecretLength {
+		return nil, errors.New("CURSOR_SECRET должен содержать не менее 32 байт")
+	}
+	return NewCursorSigner([]byte(secret)), nil
+}
+
+// ------------------------------------------------------------
+// Основные методы
+// ------------------------------------------------------------
+
+// Encode сериализует состояние в JSON и возвращает курсор вида <данные>.<подпись> в base64url
+func (s *CursorSigner) Encode(state interface{}) (string, error) {
+	payload, err := json.Marshal(state)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(s.sign(encoded)), nil
+}
+
+// Decode проверяет подпись курсора и восстанавливает состояние.
+// Любая ошибка формата или подписи возвращается как ErrInvalidCursor.
+func (s *CursorSigner) Decode(cursor string, state interface{}) error {
+	if len(cursor) > maxCursorLength {
+		return models.ErrInvalidCursor
+	}
+	encoded, signature, ok := strings.Cut(cursor, ".")
+	if !ok {
+		return models.ErrInvalidCursor
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, s.sign(encoded)) {
+		return models.ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return models.ErrInvalidCursor
+	}
+	if err := json.Unmarshal(payload, state); err != nil {
+		return models.ErrInvalidCursor
+	}
+	return nil
+}
+
+// ------------------------------------------------------------
+// Вспомогательные методы
+// ------------------------------------------------------------
+
+// sign вычисляет HMAC-SHA256 закодированных данных курсора
+func (s *CursorSigner) sign(encoded string) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"image"
+	"image/draw"
+)
+
+// CropSquare вырезает из центра изображения квадрат со стороной по меньшей стороне.
+// Прозрачные области заливаются белым, поэтому результат можно сохранить в JPEG.
+func CropSquare(src image.Image) *image.RGBA {
+	bounds := src.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	offset := image.Pt(bounds.Min.X+(bounds.Dx()-side)/2, bounds.Min.Y+(bounds.Dy()-side)/2)
+
+	square := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(square, square.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(square, square.Bounds(), src, offset, draw.Over)
+	return square
+}
+
+// ResizeSquare масштабирует квадратное непрозрачное изображение до size x size.
+// Каждый пиксель результата - среднее покрываемой им области исходника (box-фильтр),
+// что при уменьшении не дает ступенчатых краев; при увеличении повторяются ближайшие пиксели.
+func ResizeSquare(src *image.RGBA, size int) *image.RGBA {
+	side := src.Bounds().Dx()
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+
+	for y := 0; y < size; y++ {
+		y0, y1 := boxRange(y, size, side)
+		for x := 0; x < size; x++ {
+			x0, x1 := boxRange(x, size, side)
+
+			var r, g, b, count int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					r += int(row[sx*4])
+					g += int(row[sx*4+1])
+					b += int(row[sx*4+2])
+					count++
+				}
+			}
+
+			i := y*dst.Stride + x*4
+			dst.Pix[i] = uint8(r / count)
+			dst.Pix[i+1] = uint8(g / count)
+			dst.Pix[i+2] = uint8(b / count)
+			dst.Pix[i+3] = 0xff
+		}
+	}
+	return dst
+}
+
+// boxRange возвращает диапазон [from, to) пикселей исходника, покрываемый пикселем i результата
+func boxRange(i, size, side int) (int, int) {
+	from := i * side / size
+	to := (i + 1) * side / size
+	if to <= from {
+		to = from + 1
+	}
+	return from, to
+}
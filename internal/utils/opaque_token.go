@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// opaqueTokenSize количество случайных байт в непрозрачном токене
+const opaqueTokenSize = 32
+
+// GenerateOpaqueToken создает криптографически стойкий случайный токен
+func GenerateOpaqueToken() (string, error) {
+	bytes := make([]byte, opaqueTokenSize)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// HashOpaqueToken возвращает SHA-256 хеш токена для хранения в БД
+func HashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
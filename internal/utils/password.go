@@ -1,6 +1,129 @@
 package utils
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// PasswordAlgorithmArgon2id хеширование паролей Argon2id (формат PHC)
+	PasswordAlgorithmArgon2id = "argon2id"
+
+	// PasswordAlgorithmBcrypt хеширование паролей bcrypt
+	PasswordAlgorithmBcrypt = "bcrypt"
+)
+
+// argon2idPrefix префикс закодированного хеша Argon2id
+const argon2idPrefix = "$argon2id$"
+
+// ------------------------------------------------------------
+// Конфигурация
+// ------------------------------------------------------------
+
+// Argon2Params параметры Argon2id
+type Argon2Params struct {
+	// Объем памяти в КиБ
+	Memory uint32
+
+	// Число проходов
+	Time uint32
+
+	// Степень параллелизма
+	Parallelism uint8
+
+	// Длина соли в байтах
+	SaltLength uint32
+
+	// Длина хеша в байтах
+	KeyLength uint32
+}
+
+// PasswordHasherConfig содержит настройки хеширования паролей
+type PasswordHasherConfig struct {
+	// Алгоритм для новых хешей: argon2id или bcrypt
+	Algorithm string
+
+	// Стоимость bcrypt
+	BcryptCost int
+
+	// Параметры Argon2id
+	Argon2 Argon2Params
+}
+
+// NewPasswordHasherConfig создает конфигурацию хеширования паролей из переменных окружения.
+// Значения Argon2id по умолчанию соответствуют рекомендациям OWASP (19 МиБ, 2 прохода, 1 поток).
+func NewPasswordHasherConfig() (*PasswordHasherConfig, error) {
+	algorithm := os.Getenv("PASSWORD_HASH_ALGORITHM")
+	if algorithm == "" {
+		algorithm = PasswordAlgorithmArgon2id // значение по умолчанию
+	}
+	if algorithm != PasswordAlgorithmArgon2id && algorithm != PasswordAlgorithmBcrypt {
+		return nil, errors.New("неверное значение PASSWORD_HASH_ALGORITHM. Допустимо: argon2id, bcrypt")
+	}
+
+	cost, err := envUint("BCRYPT_COST", uint64(bcrypt.DefaultCost), 31)
+	if err != nil || cost < uint64(bcrypt.MinCost) {
+		return nil, errors.New("неверное значение BCRYPT_COST. Допустимо: 4-31")
+	}
+
+	memory, err := envUint("ARGON2_MEMORY", 19*1024, 1<<32-1)
+	if err != nil || memory < 8 {
+		return nil, errors.New("неверное значение ARGON2_MEMORY (КиБ). Пример: 19456, 65536")
+	}
+
+	iterations, err := envUint("ARGON2_TIME", 2, 1<<32-1)
+	if err != nil || iterations == 0 {
+		return nil, errors.New("неверное значение ARGON2_TIME. Пример: 2, 3")
+	}
+
+	parallelism, err := envUint("ARGON2_PARALLELISM", 1, 255)
+	if err != nil || parallelism == 0 {
+		return nil, errors.New("неверное значение ARGON2_PARALLELISM. Допустимо: 1-255")
+	}
+
+	// Argon2 требует не менее 8 КиБ памяти на поток
+	if memory < 8*parallelism {
+		return nil, errors.New("ARGON2_MEMORY должно быть не меньше 8 КиБ на каждый поток ARGON2_PARALLELISM")
+	}
+
+	return &PasswordHasherConfig{
+		Algorithm:  algorithm,
+		BcryptCost: int(cost),
+		Argon2: Argon2Params{
+			Memory:      uint32(memory),
+			Time:        uint32(iterations),
+			Parallelism: uint8(parallelism),
+			SaltLength:  16,
+			KeyLength:   32,
+		},
+	}, nil
+}
+
+// envUint читает целое неотрицательное значение не больше max из переменной окружения
+func envUint(name string, fallback, max uint64) (uint64, error) {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return fallback, nil
+	}
+
+	value, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if value > max {
+		return 0, fmt.Errorf("значение %s больше %d", name, max)
+	}
+	return value, nil
+}
 
 // ------------------------------------------------------------
 // Интерфейс
@@ -12,8 +135,13 @@ type PasswordHasher interface {
 	// Hash хэширует пароль
 	Hash(password string) (string, error)
 
-	// Check проверяет, соответствует ли пароль его хешу
+	// Check проверяет, соответствует ли пароль его хешу.
+	// Поддерживаются хеши всех известных алгоритмов, независимо от текущего.
 	Check(password, hash string) bool
+
+	// NeedsRehash сообщает, что хеш создан другим алгоритмом или более слабыми параметрами
+	// и после успешной проверки пароля его следует пересчитать
+	NeedsRehash(hash string) bool
 }
 
 // ------------------------------------------------------------
@@ -26,6 +154,11 @@ type bcryptHasher struct {
 	cost int
 }
 
+// argon2Hasher реализует PasswordHasher используя Argon2id
+type argon2Hasher struct {
+	params Argon2Params
+}
+
 // ------------------------------------------------------------
 // Конструктор
 // ------------------------------------------------------------
@@ -38,6 +171,19 @@ func NewPasswordHasher(cost int) PasswordHasher {
 	return &bcryptHasher{cost: cost}
 }
 
+// NewArgon2Hasher создает новый argon2Hasher
+func NewArgon2Hasher(params Argon2Params) PasswordHasher {
+	return &argon2Hasher{params: params}
+}
+
+// NewPasswordHasherFromConfig создает хешер выбранного в конфигурации алгоритма
+func NewPasswordHasherFromConfig(config *PasswordHasherConfig) PasswordHasher {
+	if config.Algorithm == PasswordAlgorithmBcrypt {
+		return NewPasswordHasher(config.BcryptCost)
+	}
+	return NewArgon2Hasher(config.Argon2)
+}
+
 // ------------------------------------------------------------
 // Методы реализации
 // ------------------------------------------------------------
@@ -50,6 +196,105 @@ func (h *bcryptHasher) Hash(password string) (string, error) {
 
 // Check сравнивает пароль с его хешем
 func (h *bcryptHasher) Check(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+	return checkPassword(password, hash)
+}
+
+// NeedsRehash возвращает true для хешей не bcrypt и bcrypt с меньшей стоимостью
+func (h *bcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost < h.cost
+}
+
+// Hash создает хеш Argon2id в формате PHC: $argon2id$v=19$m=...,t=...,p=...$соль$хеш
+func (h *argon2Hasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.params.Time, h.params.Memory, h.params.Parallelism, h.params.KeyLength)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		h.params.Memory,
+		h.params.Time,
+		h.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Check сравнивает пароль с его хешем
+func (h *argon2Hasher) Check(password, hash string) bool {
+	return checkPassword(password, hash)
+}
+
+// NeedsRehash возвращает true для хешей не Argon2id и Argon2id с более слабыми параметрами
+func (h *argon2Hasher) NeedsRehash(hash string) bool {
+	params, _, _, err := decodeArgon2Hash(hash)
+	if err != nil {
+		return true
+	}
+
+	return params.Memory < h.params.Memory ||
+		params.Time < h.params.Time ||
+		params.Parallelism < h.params.Parallelism ||
+		params.KeyLength < h.params.KeyLength
+}
+
+// ------------------------------------------------------------
+// Вспомогательные функции
+// ------------------------------------------------------------
+
+// checkPassword проверяет пароль по хешу, определяя алгоритм по формату хеша
+func checkPassword(password, hash string) bool {
+	if !strings.HasPrefix(hash, argon2idPrefix) {
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+	}
+
+	params, salt, key, err := decodeArgon2Hash(hash)
+	if err != nil {
+		return false
+	}
+
+	candidate := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Parallelism, params.KeyLength)
+	return subtle.ConstantTimeCompare(candidate, key) == 1
+}
+
+// decodeArgon2Hash разбирает хеш Argon2id в формате PHC
+func decodeArgon2Hash(hash string) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", соль, хеш
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != PasswordAlgorithmArgon2id {
+		return params, nil, nil, errors.New("неверный формат хеша argon2id")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, errors.New("неподдерживаемая версия argon2")
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Parallelism); err != nil {
+		return params, nil, nil, errors.New("неверные параметры хеша argon2id")
+	}
+	if params.Time == 0 || params.Parallelism == 0 {
+		return params, nil, nil, errors.New("неверные параметры хеша argon2id")
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, err
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, errors.New("неверный хеш argon2id")
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
 }
@@ -0,0 +1,235 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"khrllwTest/internal/models"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ------------------------------------------------------------
+// Алгоритмы подписи
+// ------------------------------------------------------------
+
+const (
+	// SigningMethodHS256 симметричная подпись общим секретом JWT_KEY
+	SigningMethodHS256 = "HS256"
+
+	// SigningMethodRS256 асимметричная подпись ключом RSA
+	SigningMethodRS256 = "RS256"
+
+	// SigningMethodEdDSA асимметричная подпись ключом Ed25519
+	SigningMethodEdDSA = "EdDSA"
+)
+
+// ------------------------------------------------------------
+// Структуры
+// ------------------------------------------------------------
+
+// SigningKey ключ подписи JWT с идентификатором kid
+type SigningKey struct {
+	// Идентификатор ключа (заголовок kid)
+	ID string
+
+	// Закрытый ключ для подписи
+	PrivateKey crypto.Signer
+
+	// Время окончания периода ротации (нулевое - ключ не выведен из оборота)
+	RetiredUntil time.Time
+}
+
+// KeySet набор ключей подписи: один активный и несколько ключей в периоде ротации
+type KeySet struct {
+	method jwt.SigningMethod
+	active *SigningKey
+	keys   map[string]*SigningKey
+}
+
+// ------------------------------------------------------------
+// Загрузка ключей
+// ------------------------------------------------------------
+
+// LoadKeySet загружает ключи подписи из спецификации вида
+// "kid1=/keys/new.pem,kid0=/keys/old.pem@2025-06-01T00:00:00Z".
+// Ключ с суффиксом @<RFC3339> выведен из оборота и принимается только до указанного времени.
+// Активным становится activeID либо первый ключ без срока вывода.
+func LoadKeySet(method, spec, activeID string) (*KeySet, error) {
+	signingMethod := jwt.GetSigningMethod(method)
+	if signingMethod == nil || method == SigningMethodHS256 {
+		return nil, fmt.Errorf("алгоритм %s не поддерживает набор ключей", method)
+	}
+
+	set := &KeySet{
+		method: signingMethod,
+		keys:   make(map[string]*SigningKey),
+	}
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		key, err := parseKeyEntry(method, entry)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := set.keys[key.ID]; exists {
+			return nil, fmt.Errorf("ключ с kid %q указан повторно", key.ID)
+		}
+		set.keys[key.ID] = key
+
+		if set.active == nil && key.RetiredUntil.IsZero() && (activeID == "" || activeID == key.ID) {
+			set.active = key
+		}
+	}
+
+	if set.active == nil {
+		return nil, errors.New("не найден активный ключ подписи JWT")
+	}
+
+	return set, nil
+}
+
+// parseKeyEntry разбирает одну запись "kid=path[@until]" и читает PEM файл
+func parseKeyEntry(method, entry string) (*SigningKey, error) {
+	kid, rest, ok := strings.Cut(entry, "=")
+	if !ok || kid == "" || rest == "" {
+		return nil, fmt.Errorf("неверный формат ключа %q. Пример: kid=/path/key.pem", entry)
+	}
+
+	key := &SigningKey{ID: kid}
+
+	path, until, retired := strings.Cut(rest, "@")
+	if retired {
+		deadline, err := time.Parse(time.RFC3339, until)
+		if err != nil {
+			return nil, fmt.Errorf("неверный срок вывода ключа %q: %v", kid, err)
+		}
+		key.RetiredUntil = deadline
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать ключ %q: %v", kid, err)
+	}
+
+	signer, err := parsePrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось разобрать ключ %q: %v", kid, err)
+	}
+
+	switch signer.(type) {
+	case *rsa.PrivateKey:
+		if method != SigningMethodRS256 {
+			return nil, fmt.Errorf("ключ %q (RSA) не подходит для %s", kid, method)
+		}
+	case ed25519.PrivateKey:
+		if method != SigningMethodEdDSA {
+			return nil, fmt.Errorf("ключ %q (Ed25519) не подходит для %s", kid, method)
+		}
+	default:
+		return nil, fmt.Errorf("неподдерживаемый тип ключа %q", kid)
+	}
+
+	key.PrivateKey = signer
+	return key, nil
+}
+
+// parsePrivateKey разбирает закрытый ключ в формате PEM (PKCS#8 или PKCS#1)
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("PEM блок не найден")
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("ключ не поддерживает подпись")
+		}
+		return signer, nil
+	}
+
+	return x509.ParsePKCS1PrivateKey(block.Bytes)
+}
+
+// ------------------------------------------------------------
+// Методы KeySet
+// ------------------------------------------------------------
+
+// Method возвращает алгоритм подписи набора
+func (s *KeySet) Method() jwt.SigningMethod {
+	return s.method
+}
+
+// Active возвращает ключ, которым подписываются новые токены
+func (s *KeySet) Active() *SigningKey {
+	return s.active
+}
+
+// Lookup возвращает ключ проверки по kid, если он еще принимается в момент now
+func (s *KeySet) Lookup(kid string, now time.Time) (*SigningKey, bool) {
+	key, ok := s.keys[kid]
+	if !ok {
+		return nil, false
+	}
+	if !key.RetiredUntil.IsZero() && now.After(key.RetiredUntil) {
+		return nil, false
+	}
+	return key, true
+}
+
+// JWKS возвращает открытые части принимаемых ключей в формате JSON Web Key Set
+func (s *KeySet) JWKS(now time.Time) models.JWKSResponse {
+	response := models.JWKSResponse{Keys: make([]models.JWK, 0, len(s.keys))}
+
+	kids := make([]string, 0, len(s.keys))
+	for kid := range s.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	for _, kid := range kids {
+		key, ok := s.Lookup(kid, now)
+		if !ok {
+			continue
+		}
+		response.Keys = append(response.Keys, publicJWK(key, s.method.Alg()))
+	}
+
+	return response
+}
+
+// publicJWK преобразует открытый ключ в JWK
+func publicJWK(key *SigningKey, alg string) models.JWK {
+	jwk := models.JWK{
+		KeyID:     key.ID,
+		Use:       "sig",
+		Algorithm: alg,
+	}
+
+	switch pub := key.PrivateKey.Public().(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.Modulus = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.Exponent = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	}
+
+	return jwk
+}
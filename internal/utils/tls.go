@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ------------------------------------------------------------
+// Конфигурация
+// ------------------------------------------------------------
+
+// TLSConfig содержит настройки HTTPS и проверки клиентских сертификатов (mTLS)
+type TLSConfig struct {
+	// Сертификат и закрытый ключ сервера в формате PEM (пусто - сервер работает по HTTP)
+	CertFile string
+	KeyFile  string
+
+	// Корневые сертификаты для проверки клиентских сертификатов (пусто - сертификаты не запрашиваются)
+	ClientCAFile string
+
+	// Режим запроса клиентского сертификата
+	ClientAuth tls.ClientAuthType
+}
+
+// NewTLSConfigFromEnv создает конфигурацию TLS из переменных окружения.
+// Возвращает nil, если TLS_CERT_FILE и TLS_KEY_FILE не заданы.
+func NewTLSConfigFromEnv() (*TLSConfig, error) {
+	config := &TLSConfig{
+		CertFile:     os.Getenv("TLS_CERT_FILE"),
+		KeyFile:      os.Getenv("TLS_KEY_FILE"),
+		ClientCAFile: os.Getenv("TLS_CLIENT_CA_FILE"),
+		ClientAuth:   tls.NoClientCert,
+	}
+
+	if config.CertFile == "" && config.KeyFile == "" {
+		if config.ClientCAFile != "" {
+			return nil, errors.New("TLS_CLIENT_CA_FILE требует TLS_CERT_FILE и TLS_KEY_FILE")
+		}
+		return nil, nil
+	}
+	if config.CertFile == "" || config.KeyFile == "" {
+		return nil, errors.New("TLS_CERT_FILE и TLS_KEY_FILE задаются вместе")
+	}
+
+	if config.ClientCAFile != "" {
+		// По умолчанию сертификат необязателен: клиенты с access токенами и API ключами продолжают работать
+		switch strings.ToLower(os.Getenv("TLS_CLIENT_AUTH")) {
+		case "", "optional":
+			config.ClientAuth = tls.VerifyClientCertIfGiven
+		case "required":
+			config.ClientAuth = tls.RequireAndVerifyClientCert
+		default:
+			return nil, errors.New("неверное значение TLS_CLIENT_AUTH. Допустимо: optional, required")
+		}
+	}
+
+	return config, nil
+}
+
+// ------------------------------------------------------------
+// Основные методы
+// ------------------------------------------------------------
+
+// ServerConfig создает конфигурацию tls.Config сервера с сертификатом и пулом корневых сертификатов клиентов
+func (c *TLSConfig) ServerConfig() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка загрузки сертификата сервера: %w", err)
+	}
+
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   c.ClientAuth,
+	}
+
+	if c.ClientCAFile != "" {
+		pem, err := os.ReadFile(c.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения TLS_CLIENT_CA_FILE: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("TLS_CLIENT_CA_FILE не содержит сертификатов в формате PEM")
+		}
+		config.ClientCAs = pool
+	}
+
+	return config, nil
+}
+
+// ClientCertsEnabled сообщает, запрашивает ли сервер клиентские сертификаты
+func (c *TLSConfig) ClientCertsEnabled() bool {
+	return c != nil && c.ClientCAFile != ""
+}
@@ -3,7 +3,10 @@ package utils
 import (
 	"errors"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"khrllwTest/internal/models"
 	"os"
+	"strconv"
 	"time"
 )
 
@@ -13,20 +16,58 @@ import (
 
 // JWTConfig содержит конфигурацию для JWT аутентификации
 type JWTConfig struct {
-	JWTKey        string
-	JWTExpiration time.Duration
+	JWTKey            string
+	SigningMethod     string
+	SigningKeys       *KeySet
+	JWTExpiration     time.Duration
+	RefreshExpiration time.Duration
+	CleanupInterval   time.Duration
+
+	// Время жизни промежуточного токена второго шага входа
+	ChallengeExpiration time.Duration
+
+	// Издатель (iss) и получатель (aud) токенов
+	Issuer   string
+	Audience string
+
+	// Допустимое расхождение часов при проверке exp, nbf и iat
+	Leeway time.Duration
 }
 
 // NewJWTConfig создает конфигурацию аутентификации из переменных окружения
 func NewJWTConfig() (*JWTConfig, error) {
-	key := os.Getenv("JWT_KEY")
-	if key == "" {
-		return nil, errors.New("JWT_KEY переменная окружения не установлена")
+	method := os.Getenv("JWT_SIGNING_METHOD")
+	if method == "" {
+		method = SigningMethodHS256 // значение по умолчанию
+	}
+
+	var key string
+	var signingKeys *KeySet
+
+	switch method {
+	case SigningMethodHS256:
+		key = os.Getenv("JWT_KEY")
+		if key == "" {
+			return nil, errors.New("JWT_KEY переменная окружения не установлена")
+		}
+	case SigningMethodRS256, SigningMethodEdDSA:
+		spec := os.Getenv("JWT_SIGNING_KEYS")
+		if spec == "" {
+			return nil, errors.New("JWT_SIGNING_KEYS переменная окружения не установлена")
+		}
+
+		var err error
+		signingKeys, err = LoadKeySet(method, spec, os.Getenv("JWT_ACTIVE_KEY_ID"))
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("неверное значение JWT_SIGNING_METHOD. Допустимо: HS256, RS256, EdDSA")
 	}
 
 	exp := os.Getenv("JWT_EXPIRATION")
 	if exp == "" {
-		exp = "24h" // значение по умолчанию
+		exp = "15m" // значение по умолчанию
 	}
 
 	duration, err := time.ParseDuration(exp)
@@ -34,12 +75,142 @@ func NewJWTConfig() (*JWTConfig, error) {
 		return nil, errors.New("неверный формат JWT_EXPIRATION. Пример: 24h, 60m, 3600s")
 	}
 
+	refreshExp := os.Getenv("REFRESH_TOKEN_EXPIRATION")
+	if refreshExp == "" {
+		refreshExp = "720h" // значение по умолчанию
+	}
+
+	refreshDuration, err := time.ParseDuration(refreshExp)
+	if err != nil {
+		return nil, errors.New("неверный формат REFRESH_TOKEN_EXPIRATION. Пример: 720h, 168h")
+	}
+
+	cleanup := os.Getenv("TOKEN_CLEANUP_INTERVAL")
+	if cleanup == "" {
+		cleanup = "1h" // значение по умолчанию
+	}
+
+	cleanupInterval, err := time.ParseDuration(cleanup)
+	if err != nil || cleanupInterval <= 0 {
+		return nil, errors.New("неверный формат TOKEN_CLEANUP_INTERVAL. Пример: 1h, 30m")
+	}
+
+	challenge := os.Getenv("TWO_FACTOR_CHALLENGE_TTL")
+	if challenge == "" {
+		challenge = "5m" // значение по умолчанию
+	}
+
+	challengeDuration, err := time.ParseDuration(challenge)
+	if err != nil || challengeDuration <= 0 {
+		return nil, errors.New("неверный формат TWO_FACTOR_CHALLENGE_TTL. Пример: 5m, 300s")
+	}
+
+	issuer := os.Getenv("JWT_ISSUER")
+	if issuer == "" {
+		issuer = "khrllwTest" // значение по умолчанию
+	}
+
+	audience := os.Getenv("JWT_AUDIENCE")
+	if audience == "" {
+		audience = "khrllwTest-api" // значение по умолчанию
+	}
+
+	leewayValue := os.Getenv("JWT_LEEWAY")
+	if leewayValue == "" {
+		leewayValue = "30s" // значение по умолчанию
+	}
+
+	leeway, err := time.ParseDuration(leewayValue)
+	if err != nil || leeway < 0 {
+		return nil, errors.New("неверный формат JWT_LEEWAY. Пример: 30s, 1m, 0s")
+	}
+
 	return &JWTConfig{
-		JWTKey:        key,
-		JWTExpiration: duration,
+		JWTKey:              key,
+		SigningMethod:       method,
+		SigningKeys:         signingKeys,
+		JWTExpiration:       duration,
+		RefreshExpiration:   refreshDuration,
+		CleanupInterval:     cleanupInterval,
+		ChallengeExpiration: challengeDuration,
+		Issuer:              issuer,
+		Audience:            audience,
+		Leeway:              leeway,
 	}, nil
 }
 
+// ------------------------------------------------------------
+// Содержимое токена
+// ------------------------------------------------------------
+
+const (
+	// TokenPurposeTwoFactor назначение промежуточного токена между шагами входа с 2FA
+	TokenPurposeTwoFactor = "2fa"
+
+	// TokenPurposeVerifyEmail назначение токена из ссылки подтверждения email
+	TokenPurposeVerifyEmail = "verify_email"
+)
+
+// TokenSubject содержит данные пользователя, для которого выпускается токен
+type TokenSubject struct {
+	// Идентификатор пользователя
+	UserID uint
+
+	// Роль пользователя
+	Role string
+
+	// Текущая версия токенов пользователя (увеличивается при "выходе везде")
+	TokenVersion int
+
+	// Назначение токена (пусто - access токен)
+	Purpose string
+
+	// Email, к которому привязан токен (для подтверждения email)
+	Email string
+
+	// Время жизни токена (ноль - значение из конфигурации)
+	ExpiresIn time.Duration
+
+	// Идентификатор сессии, к которой относится access токен
+	SessionID string
+
+	// Администратор, выполняющий вход от имени пользователя (ноль - обычный токен)
+	ActorID uint
+}
+
+// ActorClaim описывает субъекта, действующего от имени пользователя (claim act, RFC 8693)
+type ActorClaim struct {
+	// Идентификатор действующего субъекта
+	Subject string `json:"sub"`
+}
+
+// Claims описывает содержимое токена.
+// Помимо собственных полей токен содержит стандартные iss, aud, sub, iat, nbf, exp и jti.
+type Claims struct {
+	// Идентификатор пользователя
+	UserID uint `json:"user_id"`
+
+	// Роль пользователя
+	Role string `json:"role"`
+
+	// Версия токенов пользователя на момент выпуска
+	TokenVersion int `json:"ver"`
+
+	// Назначение токена; access токены его не содержат
+	Purpose string `json:"purpose,omitempty"`
+
+	// Email, к которому привязан токен подтверждения
+	Email string `json:"email,omitempty"`
+
+	// Идентификатор сессии; токены без сессии (выпущенные ранее) его не содержат
+	SessionID string `json:"sid,omitempty"`
+
+	// Действующий субъект токена имперсонации; sub при этом - пользователь, от имени которого выполняется вход
+	Actor *ActorClaim `json:"act,omitempty"`
+
+	jwt.RegisteredClaims
+}
+
 // ------------------------------------------------------------
 // Интерфейс
 // ------------------------------------------------------------
@@ -47,13 +218,20 @@ func NewJWTConfig() (*JWTConfig, error) {
 // TokenManager определяет контракт для работы с JWT токенами
 type TokenManager interface {
 	// Generate создает новый JWT токен для пользователя
-	Generate(userID uint) (string, error)
+	Generate(subject TokenSubject) (string, error)
 
-	// Parse парсит и проверяет JWT токен
+	// Parse парсит и проверяет JWT токен: подпись, iss, aud, sub, iat, nbf и exp.
+	// Ошибка указывает конкретную причину отказа (models.ErrToken*)
 	Parse(tokenString string) (*jwt.Token, error)
 
 	// ExtractUserID извлекает userID из JWT токена
 	ExtractUserID(token *jwt.Token) (uint, error)
+
+	// ExtractClaims извлекает содержимое JWT токена
+	ExtractClaims(token *jwt.Token) (*Claims, error)
+
+	// JWKS возвращает открытые ключи для проверки токенов другими сервисами
+	JWKS() models.JWKSResponse
 }
 
 // ------------------------------------------------------------
@@ -78,40 +256,179 @@ func NewTokenManager(config *JWTConfig) TokenManager {
 // Методы реализации
 // ------------------------------------------------------------
 
-// Generate создает JWT токен для пользователя
-func (m *jwtManager) Generate(userID uint) (string, error) {
-	expirationTime := time.Now().Add(m.config.JWTExpiration)
+// Generate создает JWT токен для пользователя с уникальным идентификатором (jti).
+// Токены с назначением живут ChallengeExpiration вместо JWTExpiration,
+// если время жизни не задано явно в subject.ExpiresIn.
+func (m *jwtManager) Generate(subject TokenSubject) (string, error) {
+	ttl := m.config.JWTExpiration
+	if subject.Purpose != "" {
+		ttl = m.config.ChallengeExpiration
+	}
+	if subject.ExpiresIn > 0 {
+		ttl = subject.ExpiresIn
+	}
+	now := time.Now()
+	expirationTime := now.Add(ttl)
 
-	claims := jwt.MapClaims{
-		"user_id": userID,
-		"exp":     expirationTime.Unix(),
+	claims := &Claims{
+		UserID:       subject.UserID,
+		Role:         subject.Role,
+		TokenVersion: subject.TokenVersion,
+		Purpose:      subject.Purpose,
+		Email:        subject.Email,
+		SessionID:    subject.SessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Issuer:    m.config.Issuer,
+			Audience:  jwt.ClaimStrings{m.config.Audience},
+			Subject:   strconv.FormatUint(uint64(subject.UserID), 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expirationTime),
+		},
+	}
+	if subject.ActorID != 0 {
+		claims.Actor = &ActorClaim{Subject: strconv.FormatUint(uint64(subject.ActorID), 10)}
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(m.config.JWTKey))
+	if m.config.SigningKeys == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString([]byte(m.config.JWTKey))
+	}
+
+	// Асимметричная подпись активным ключом, kid указывает ключ для проверки
+	active := m.config.SigningKeys.Active()
+	token := jwt.NewWithClaims(m.config.SigningKeys.Method(), claims)
+	token.Header["kid"] = active.ID
+	return token.SignedString(active.PrivateKey)
 }
 
-// Parse проверяет и парсит JWT токен
+// Parse проверяет и парсит JWT токен. Время проверяется с допуском Leeway
 func (m *jwtManager) Parse(tokenString string) (*jwt.Token, error) {
-	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, m.verificationKey,
+		jwt.WithValidMethods([]string{m.config.SigningMethod}),
+		jwt.WithIssuer(m.config.Issuer),
+		jwt.WithAudience(m.config.Audience),
+		jwt.WithIssuedAt(),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(m.config.Leeway),
+	)
+	if err != nil {
+		return token, tokenError(err)
+	}
+	return token, nil
+}
+
+// Validate проверяет наличие обязательных стандартных полей и соответствие sub пользователю.
+// Вызывается библиотекой jwt после проверки подписи и сроков.
+func (c *Claims) Validate() error {
+	if c.ID == "" || c.Subject == "" || c.IssuedAt == nil || c.NotBefore == nil {
+		return models.ErrTokenMissingClaims
+	}
+	if c.Subject != strconv.FormatUint(uint64(c.UserID), 10) {
+		return models.ErrTokenInvalidSubject
+	}
+	if c.Actor != nil {
+		if _, ok := c.ActorID(); !ok {
+			return models.ErrTokenInvalidSubject
+		}
+	}
+	return nil
+}
+
+// ActorID возвращает идентификатор администратора из claim act; false - токен не является токеном имперсонации
+func (c *Claims) ActorID() (uint, bool) {
+	if c.Actor == nil {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(c.Actor.Subject, 10, 64)
+	if err != nil || id == 0 {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// tokenError сопоставляет ошибку библиотеки jwt с ошибкой, описывающей причину отказа.
+// Если нарушено несколько проверок, возвращается первая по порядку проверки.
+func tokenError(err error) error {
+	reasons := []struct {
+		cause  error
+		result error
+	}{
+		{jwt.ErrTokenMalformed, models.ErrTokenMalformed},
+		{jwt.ErrTokenUnverifiable, models.ErrTokenSignatureInvalid},
+		{jwt.ErrTokenSignatureInvalid, models.ErrTokenSignatureInvalid},
+		{jwt.ErrTokenRequiredClaimMissing, models.ErrTokenMissingClaims},
+		{models.ErrTokenMissingClaims, models.ErrTokenMissingClaims},
+		{jwt.ErrTokenExpired, models.ErrTokenExpired},
+		{jwt.ErrTokenNotValidYet, models.ErrTokenNotYetValid},
+		{jwt.ErrTokenUsedBeforeIssued, models.ErrTokenIssuedInFuture},
+		{jwt.ErrTokenInvalidIssuer, models.ErrTokenInvalidIssuer},
+		{jwt.ErrTokenInvalidAudience, models.ErrTokenInvalidAudience},
+		{models.ErrTokenInvalidSubject, models.ErrTokenInvalidSubject},
+	}
+
+	for _, reason := range reasons {
+		if errors.Is(err, reason.cause) {
+			return reason.result
+		}
+	}
+	return models.ErrInvalidToken
+}
+
+// verificationKey выбирает ключ проверки подписи по алгоритму и заголовку kid
+func (m *jwtManager) verificationKey(token *jwt.Token) (interface{}, error) {
+	if m.config.SigningKeys == nil {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, jwt.ErrSignatureInvalid
 		}
 		return []byte(m.config.JWTKey), nil
-	})
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := m.config.SigningKeys.Lookup(kid, time.Now())
+	if !ok {
+		return nil, jwt.ErrTokenUnverifiable
+	}
+	return key.PrivateKey.Public(), nil
+}
+
+// JWKS возвращает открытые ключи; при подписи HS256 набор пуст
+func (m *jwtManager) JWKS() models.JWKSResponse {
+	if m.config.SigningKeys == nil {
+		return models.JWKSResponse{Keys: []models.JWK{}}
+	}
+	return m.config.SigningKeys.JWKS(time.Now())
 }
 
 // ExtractUserID извлекает user_id из JWT токена
 func (m *jwtManager) ExtractUserID(token *jwt.Token) (uint, error) {
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return 0, jwt.ErrTokenInvalidClaims
+	claims, err := m.ExtractClaims(token)
+	if err != nil {
+		return 0, err
 	}
+	return claims.UserID, nil
+}
 
-	userID, ok := claims["user_id"].(float64)
+// ExtractClaims извлекает содержимое JWT токена и проверяет обязательные поля
+func (m *jwtManager) ExtractClaims(token *jwt.Token) (*Claims, error) {
+	claims, ok := token.Claims.(*Claims)
 	if !ok {
-		return 0, jwt.ErrTokenInvalidId
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+
+	if claims.UserID == 0 {
+		return nil, jwt.ErrTokenInvalidId
+	}
+
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+
+	// Токены, выпущенные до появления ролей, считаются пользовательскими
+	if claims.Role == "" {
+		claims.Role = models.RoleUser
 	}
 
-	return uint(userID), nil
+	return claims, nil
 }
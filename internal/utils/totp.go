@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ------------------------------------------------------------
+// Параметры TOTP (RFC 6238)
+// ------------------------------------------------------------
+
+const (
+	// totpPeriod длительность шага TOTP
+	totpPeriod = 30 * time.Second
+
+	// totpDigits количество цифр в коде
+	totpDigits = 6
+
+	// totpSkew допустимое отклонение в шагах для компенсации расхождения часов
+	totpSkew = 1
+
+	// totpSecretSize длина секрета в байтах
+	totpSecretSize = 20
+
+	// recoveryCodeSize длина кода восстановления в байтах
+	recoveryCodeSize = 5
+)
+
+// totpEncoding base32 без выравнивания, как ожидают приложения-аутентификаторы
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// ------------------------------------------------------------
+// Секреты и коды
+// ------------------------------------------------------------
+
+// GenerateTOTPSecret создает случайный секрет TOTP в кодировке base32
+func GenerateTOTPSecret() (string, error) {
+	secret := make([]byte, totpSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// TOTPURI формирует otpauth URI для добавления секрета в приложение-аутентификатор
+func TOTPURI(issuer, account, secret string) string {
+	label := url.PathEscape(issuer + ":" + account)
+
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(int(totpPeriod.Seconds())))
+
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// GenerateTOTPCode вычисляет текущий код для секрета (используется клиентами и тестами)
+func GenerateTOTPCode(secret string, now time.Time) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+	return totpCode(key, uint64(now.Unix()/int64(totpPeriod.Seconds()))), nil
+}
+
+// ValidateTOTP проверяет код для момента now с допуском в один шаг в обе стороны и возвращает
+// шаг, которому он соответствует. Шаги не больше lastStep (последнего принятого) не принимаются,
+// поэтому перехваченный или уже введенный код нельзя использовать повторно.
+func ValidateTOTP(secret, code string, now time.Time, lastStep int64) (int64, bool) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil || len(code) != totpDigits {
+		return 0, false
+	}
+
+	counter := now.Unix() / int64(totpPeriod.Seconds())
+	for offset := -totpSkew; offset <= totpSkew; offset++ {
+		step := counter + int64(offset)
+		if step <= lastStep {
+			continue
+		}
+		expected := totpCode(key, uint64(step))
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// GenerateRecoveryCodes создает одноразовые коды восстановления вида "abcd-efgh"
+func GenerateRecoveryCodes(count int) ([]string, error) {
+	codes := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		raw := make([]byte, recoveryCodeSize)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(totpEncoding.EncodeToString(raw))
+		codes = append(codes, code[:4]+"-"+code[4:])
+	}
+	return codes, nil
+}
+
+// NormalizeRecoveryCode приводит введенный код восстановления к хранимому виду
+func NormalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.TrimSpace(code))
+}
+
+// ------------------------------------------------------------
+// Вспомогательные функции
+// ------------------------------------------------------------
+
+// totpCode вычисляет HOTP код (RFC 4226) для счетчика
+func totpCode(key []byte, counter uint64) string {
+	var message [8]byte
+	binary.BigEndian.PutUint64(message[:], counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(message[:])
+	sum := mac.Sum(nil)
+
+	// Динамическое усечение
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
@@ -1,4 +1,42 @@
 -- Откатываем изменения в обратном порядке
+ALTER TABLE IF EXISTS users DROP COLUMN IF EXISTS avatar_key;
+ALTER TABLE IF EXISTS orders DROP COLUMN IF EXISTS version;
+ALTER TABLE IF EXISTS users DROP COLUMN IF EXISTS version;
+DROP INDEX IF EXISTS idx_users_email_active;
+DELETE FROM users WHERE deleted_at IS NOT NULL;
+ALTER TABLE IF EXISTS users ADD CONSTRAINT users_email_key UNIQUE (email);
+DROP INDEX IF EXISTS idx_users_deleted_at;
+ALTER TABLE IF EXISTS users DROP COLUMN IF EXISTS deleted_at;
+DROP INDEX IF EXISTS idx_audit_logs_created_at;
+DROP INDEX IF EXISTS idx_audit_logs_token_id;
+DROP INDEX IF EXISTS idx_audit_logs_user_id;
+DROP INDEX IF EXISTS idx_audit_logs_actor_id;
+DROP TABLE IF EXISTS audit_logs;
+DROP INDEX IF EXISTS idx_user_identities_user_id;
+DROP INDEX IF EXISTS idx_user_identities_provider_subject;
+DROP TABLE IF EXISTS user_identities;
+DROP TABLE IF EXISTS oidc_login_states;
+DROP INDEX IF EXISTS idx_password_history_user_id;
+DROP TABLE IF EXISTS password_history;
+DROP INDEX IF EXISTS idx_sessions_user_id;
+DROP TABLE IF EXISTS sessions;
+ALTER TABLE IF EXISTS users DROP COLUMN IF EXISTS email_verified_at;
+DROP INDEX IF EXISTS idx_password_reset_tokens_user_id;
+DROP TABLE IF EXISTS password_reset_tokens;
+DROP INDEX IF EXISTS idx_recovery_codes_user_id;
+DROP TABLE IF EXISTS recovery_codes;
+ALTER TABLE IF EXISTS users DROP COLUMN IF EXISTS totp_last_step;
+ALTER TABLE IF EXISTS users DROP COLUMN IF EXISTS totp_enabled;
+ALTER TABLE IF EXISTS users DROP COLUMN IF EXISTS totp_secret;
+DROP INDEX IF EXISTS idx_api_keys_user_id;
+DROP TABLE IF EXISTS api_keys;
+ALTER TABLE IF EXISTS users DROP COLUMN IF EXISTS role;
+DROP INDEX IF EXISTS idx_revoked_tokens_expires_at;
+DROP TABLE IF EXISTS revoked_tokens;
+ALTER TABLE IF EXISTS users DROP COLUMN IF EXISTS token_version;
+DROP INDEX IF EXISTS idx_refresh_tokens_user_id;
+DROP INDEX IF EXISTS idx_refresh_tokens_family_id;
+DROP TABLE IF EXISTS refresh_tokens;
 DROP INDEX IF EXISTS idx_orders_user_id;
 DROP INDEX IF EXISTS idx_users_email;
 DROP TABLE IF EXISTS orders;
@@ -24,4 +24,209 @@ CREATE TABLE IF NOT EXISTS orders
 CREATE INDEX IF NOT EXISTS idx_users_email ON users (email);
 
 -- Индекс для ускорения поиска заказов по пользователю
-CREATE INDEX IF NOT EXISTS idx_orders_user_id ON orders (user_id);
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS idx_orders_user_id ON orders (user_id);
+
+-- Создаем таблицу refresh_tokens (хранятся только хеши токенов)
+CREATE TABLE IF NOT EXISTS refresh_tokens
+(
+    id         SERIAL PRIMARY KEY,
+    user_id    INT                      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    family_id  VARCHAR(64)              NOT NULL,
+    token_hash VARCHAR(64) UNIQUE       NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Индексы для отзыва семейства токенов и поиска токенов пользователя
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens (user_id);
+
+-- Версия токенов пользователя (увеличение отзывает все выданные токены)
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS token_version INT NOT NULL DEFAULT 0;
+
+-- Создаем таблицу revoked_tokens (отозванные до истечения access токены)
+CREATE TABLE IF NOT EXISTS revoked_tokens
+(
+    jti        VARCHAR(64) PRIMARY KEY,
+    user_id    INT                      NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Индекс для очистки истекших записей
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
+
+-- Роль пользователя (user или admin)
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS role VARCHAR(32) NOT NULL DEFAULT 'user';
+
+-- Создаем таблицу api_keys (хранятся только хеши ключей)
+CREATE TABLE IF NOT EXISTS api_keys
+(
+    id           SERIAL PRIMARY KEY,
+    user_id      INT                NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name         VARCHAR(255)       NOT NULL,
+    prefix       VARCHAR(32)        NOT NULL,
+    key_hash     VARCHAR(64) UNIQUE NOT NULL,
+    scopes       TEXT               NOT NULL,
+    expires_at   TIMESTAMP WITH TIME ZONE,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at   TIMESTAMP WITH TIME ZONE,
+    created_at   TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Индекс для получения ключей пользователя
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys (user_id);
+
+-- Двухфакторная аутентификация (TOTP)
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS totp_secret VARCHAR(64);
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+-- Шаг последнего принятого кода TOTP (защита от повторного использования кода)
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS totp_last_step BIGINT NOT NULL DEFAULT 0;
+
+-- Создаем таблицу recovery_codes (хранятся только хеши кодов)
+CREATE TABLE IF NOT EXISTS recovery_codes
+(
+    id         SERIAL PRIMARY KEY,
+    user_id    INT         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    code_hash  VARCHAR(64) NOT NULL,
+    used_at    TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Индекс для проверки кодов пользователя
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user_id ON recovery_codes (user_id);
+
+-- Создаем таблицу password_reset_tokens (хранятся только хеши токенов)
+CREATE TABLE IF NOT EXISTS password_reset_tokens
+(
+    id         SERIAL PRIMARY KEY,
+    user_id    INT                      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) UNIQUE       NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at    TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Индекс для отзыва токенов пользователя
+CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_user_id ON password_reset_tokens (user_id);
+
+-- Подтверждение email. Пользователи, созданные до появления подтверждения, считаются подтвержденными
+DO
+$$
+    BEGIN
+        IF NOT EXISTS (SELECT 1
+                       FROM information_schema.columns
+                       WHERE table_name = 'users'
+                         AND column_name = 'email_verified_at') THEN
+            ALTER TABLE users
+                ADD COLUMN email_verified_at TIMESTAMP WITH TIME ZONE;
+            UPDATE users SET email_verified_at = CURRENT_TIMESTAMP;
+        END IF;
+    END
+$$;
+
+-- Создаем таблицу sessions (id совпадает с семейством refresh токенов)
+CREATE TABLE IF NOT EXISTS sessions
+(
+    id           VARCHAR(64) PRIMARY KEY,
+    user_id      INT                      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    ip_address   VARCHAR(64),
+    user_agent   VARCHAR(512),
+    created_at   TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    revoked_at   TIMESTAMP WITH TIME ZONE
+);
+
+-- Индекс для получения сессий пользователя
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions (user_id);
+
+-- Создаем таблицу password_history (хеши прежних паролей для запрета повторного использования)
+CREATE TABLE IF NOT EXISTS password_history
+(
+    id            SERIAL PRIMARY KEY,
+    user_id       INT          NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    password_hash VARCHAR(255) NOT NULL,
+    created_at    TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Индекс для получения истории пользователя
+CREATE INDEX IF NOT EXISTS idx_password_history_user_id ON password_history (user_id);
+
+-- Создаем таблицу oidc_login_states (незавершенные входы через OpenID Connect)
+CREATE TABLE IF NOT EXISTS oidc_login_states
+(
+    id            SERIAL PRIMARY KEY,
+    state_hash    VARCHAR(64)              NOT NULL UNIQUE,
+    provider      VARCHAR(32)              NOT NULL,
+    nonce         VARCHAR(64)              NOT NULL,
+    code_verifier VARCHAR(128)             NOT NULL,
+    expires_at    TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at    TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Создаем таблицу user_identities (связь пользователя с аккаунтом внешнего провайдера)
+CREATE TABLE IF NOT EXISTS user_identities
+(
+    id         SERIAL PRIMARY KEY,
+    user_id    INT          NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    provider   VARCHAR(32)  NOT NULL,
+    subject    VARCHAR(255) NOT NULL,
+    email      VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Индексы для поиска по аккаунту провайдера и по пользователю
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_identities_provider_subject ON user_identities (provider, subject);
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities (user_id);
+
+-- Создаем таблицу audit_logs (журнал действий администраторов от имени пользователей).
+-- Внешних ключей нет: записи сохраняются после удаления пользователей
+CREATE TABLE IF NOT EXISTS audit_logs
+(
+    id         SERIAL PRIMARY KEY,
+    actor_id   INT         NOT NULL,
+    user_id    INT         NOT NULL,
+    action     VARCHAR(64) NOT NULL,
+    token_id   VARCHAR(64),
+    method     VARCHAR(16),
+    path       VARCHAR(512),
+    status     INT,
+    reason     VARCHAR(255),
+    ip_address VARCHAR(64),
+    user_agent VARCHAR(512),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Индексы для выборки по администратору, пользователю, токену и времени
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor_id ON audit_logs (actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_user_id ON audit_logs (user_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_token_id ON audit_logs (token_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs (created_at);
+
+-- Мягкое удаление пользователей (пусто - аккаунт активен)
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+-- Индекс для скрытия удаленных пользователей и окончательного удаления по сроку
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+-- Email уникален только среди неудаленных пользователей: удаленный аккаунт не блокирует регистрацию
+ALTER TABLE users
+    DROP CONSTRAINT IF EXISTS users_email_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_active ON users (email) WHERE deleted_at IS NULL;
+
+-- Версии записей для оптимистичной блокировки (ETag / If-Match)
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+
+-- Префикс ключей миниатюр аватара в файловом хранилище
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS avatar_key VARCHAR(255);
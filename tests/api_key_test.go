@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+type APIKeyResponse struct {
+	ID     int      `json:"id"`
+	Name   string   `json:"name"`
+	Prefix string   `json:"prefix"`
+	Scopes []string `json:"scopes"`
+	Key    string   `json:"key"`
+}
+
+func createTestAPIKey(t *testing.T, userID int, token string, scopes ...string) APIKeyResponse {
+	payload := map[string]interface{}{
+		"name":   "test-key",
+		"scopes": scopes,
+	}
+	resp := doRequest(t, "POST", fmt.Sprintf("%s/users/%d/api-keys", baseURL, userID), token, payload)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var key APIKeyResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&key))
+	return key
+}
+
+func TestAPIKey1_CreateAndUseWithinScope(t *testing.T) {
+	user, token := createTestUser(t)
+	defer deleteTestUser(t, user.ID, token)
+
+	key := createTestAPIKey(t, user.ID, token, "orders:read")
+	require.NotEmpty(t, key.Key)
+
+	resp := doRequest(t, "GET", fmt.Sprintf("%s/users/%d/orders", baseURL, user.ID), key.Key, nil)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestAPIKey2_ScopeIsEnforced(t *testing.T) {
+	user, token := createTestUser(t)
+	defer deleteTestUser(t, user.ID, token)
+
+	key := createTestAPIKey(t, user.ID, token, "orders:read")
+
+	order := map[string]interface{}{"product": "Cable", "quantity": 1, "price": 5.0}
+	resp := doRequest(t, "POST", fmt.Sprintf("%s/users/%d/orders", baseURL, user.ID), key.Key, order)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestAPIKey3_CannotManageKeysWithKey(t *testing.T) {
+	user, token := createTestUser(t)
+	defer deleteTestUser(t, user.ID, token)
+
+	key := createTestAPIKey(t, user.ID, token, "users:read", "orders:read")
+
+	resp := doRequest(t, "GET", fmt.Sprintf("%s/users/%d/api-keys", baseURL, user.ID), key.Key, nil)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestAPIKey4_RevokedKeyRejected(t *testing.T) {
+	user, token := createTestUser(t)
+	defer deleteTestUser(t, user.ID, token)
+
+	key := createTestAPIKey(t, user.ID, token, "users:read")
+
+	revoke := doRequest(t, "DELETE", fmt.Sprintf("%s/users/%d/api-keys/%d", baseURL, user.ID, key.ID), token, nil)
+	defer revoke.Body.Close()
+	require.Equal(t, http.StatusNoContent, revoke.StatusCode)
+
+	resp := doRequest(t, "GET", fmt.Sprintf("%s/users/%d", baseURL, user.ID), key.Key, nil)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestAPIKey5_ListDoesNotExposeKey(t *testing.T) {
+	user, token := createTestUser(t)
+	defer deleteTestUser(t, user.ID, token)
+
+	createTestAPIKey(t, user.ID, token, "users:read")
+
+	resp := doRequest(t, "GET", fmt.Sprintf("%s/users/%d/api-keys", baseURL, user.ID), token, nil)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var keys []APIKeyResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&keys))
+	require.Len(t, keys, 1)
+	assert.Empty(t, keys[0].Key)
+	assert.NotEmpty(t, keys[0].Prefix)
+}
@@ -0,0 +1,226 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"khrllwTest/internal/models"
+	service "khrllwTest/internal/services"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func refreshTokens(t *testing.T, refreshToken string) *http.Response {
+	return doRequest(t, "POST", baseURL+"/auth/refresh", "", map[string]string{
+		"refresh_token": refreshToken,
+	})
+}
+
+func TestAuth1_LoginReturnsRefreshToken(t *testing.T) {
+	user, token := createTestUser(t)
+	defer deleteTestUser(t, user.ID, token)
+
+	user.Password = "testpassword"
+	auth := loginTestUser(t, user)
+
+	assert.NotEmpty(t, auth.Token)
+	assert.NotEmpty(t, auth.RefreshToken)
+	assert.Positive(t, auth.ExpiresIn)
+}
+
+func TestAuth2_RefreshRotatesToken(t *testing.T) {
+	user, token := createTestUser(t)
+	defer deleteTestUser(t, user.ID, token)
+
+	user.Password = "testpassword"
+	auth := loginTestUser(t, user)
+
+	resp := refreshTokens(t, auth.RefreshToken)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var rotated AuthResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&rotated))
+	assert.NotEmpty(t, rotated.Token)
+	assert.NotEqual(t, auth.RefreshToken, rotated.RefreshToken)
+}
+
+func TestAuth3_RefreshTokenReuseRevokesFamily(t *testing.T) {
+	user, token := createTestUser(t)
+	defer deleteTestUser(t, user.ID, token)
+
+	user.Password = "testpassword"
+	auth := loginTestUser(t, user)
+
+	first := refreshTokens(t, auth.RefreshToken)
+	defer first.Body.Close()
+	require.Equal(t, http.StatusOK, first.StatusCode)
+
+	var rotated AuthResponse
+	require.NoError(t, json.NewDecoder(first.Body).Decode(&rotated))
+
+	// Повторное использование старого токена
+	reused := refreshTokens(t, auth.RefreshToken)
+	defer reused.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, reused.StatusCode)
+
+	// Новый токен из того же семейства тоже отозван
+	revoked := refreshTokens(t, rotated.RefreshToken)
+	defer revoked.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, revoked.StatusCode)
+}
+
+func TestAuth4_RefreshWithUnknownToken(t *testing.T) {
+	resp := refreshTokens(t, "unknown-refresh-token")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestAuth5_LogoutRevokesAccessToken(t *testing.T) {
+	user, token := createTestUser(t)
+
+	user.Password = "testpassword"
+	auth := loginTestUser(t, user)
+	defer deleteTestUser(t, user.ID, auth.Token)
+
+	resp := doRequest(t, "POST", baseURL+"/auth/logout", token, map[string]string{})
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	after := doRequest(t, "GET", baseURL+"/users", token, nil)
+	defer after.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, after.StatusCode)
+}
+
+func TestAuth6_LogoutRevokesRefreshFamily(t *testing.T) {
+	user, token := createTestUser(t)
+	defer deleteTestUser(t, user.ID, token)
+
+	user.Password = "testpassword"
+	auth := loginTestUser(t, user)
+
+	resp := doRequest(t, "POST", baseURL+"/auth/logout", auth.Token, map[string]string{
+		"refresh_token": auth.RefreshToken,
+	})
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	refresh := refreshTokens(t, auth.RefreshToken)
+	defer refresh.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, refresh.StatusCode)
+}
+
+func TestAuth7_LogoutEverywhere(t *testing.T) {
+	user, token := createTestUser(t)
+
+	user.Password = "testpassword"
+	other := loginTestUser(t, user)
+
+	resp := doRequest(t, "POST", baseURL+"/auth/logout-all", token, nil)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	for _, revoked := range []string{token, other.Token} {
+		after := doRequest(t, "GET", baseURL+"/users", revoked, nil)
+		after.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, after.StatusCode)
+	}
+
+	refresh := refreshTokens(t, other.RefreshToken)
+	defer refresh.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, refresh.StatusCode)
+
+	fresh := loginTestUser(t, user)
+	deleteTestUser(t, user.ID, fresh.Token)
+}
+
+func TestAuth8_AccountLockoutAfterFailedLogins(t *testing.T) {
+	user, token := createTestUser(t)
+	defer deleteTestUser(t, user.ID, token)
+
+	wrong := map[string]string{"email": user.Email, "password": "wrongpassword"}
+
+	// Повторяем неверный пароль, выдерживая задержки, пока аккаунт не будет заблокирован
+	locked := false
+	for i := 0; i < 10 && !locked; i++ {
+		resp := doRequest(t, "POST", baseURL+"/auth/login", "", wrong)
+		resp.Body.Close()
+
+		switch resp.StatusCode {
+		case http.StatusLocked:
+			assert.NotEmpty(t, resp.Header.Get("Retry-After"))
+			locked = true
+		case http.StatusTooManyRequests:
+			seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+			require.NoError(t, err)
+			time.Sleep(time.Duration(seconds) * time.Second)
+		default:
+			require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		}
+	}
+	require.True(t, locked, "аккаунт должен быть заблокирован")
+
+	// Во время блокировки не проходит даже верный пароль
+	user.Password = "testpassword"
+	resp := doRequest(t, "POST", baseURL+"/auth/login", "", userToLoginPayload(user))
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusLocked, resp.StatusCode)
+}
+
+func TestAuth9_DelayDoesNotOverflowWithLargeThreshold(t *testing.T) {
+	t.Setenv("LOGIN_MAX_ATTEMPTS", "100")
+	t.Setenv("LOGIN_IP_MAX_ATTEMPTS", "200")
+	t.Setenv("LOGIN_DELAY_AFTER", "3")
+	t.Setenv("LOGIN_BASE_DELAY", "1s")
+	t.Setenv("LOGIN_LOCKOUT_DURATION", "15m")
+
+	config, err := service.NewLoginLimiterConfig()
+	require.NoError(t, err)
+	limiter := service.NewLoginLimiter(config)
+
+	// Без ограничения сдвига задержка 1s << 34 и больше переполняет time.Duration
+	for i := 0; i < 90; i++ {
+		limiter.RecordFailure("victim@example.com", "203.0.113.10")
+
+		if i >= 3 {
+			var retry *models.RetryAfterError
+			require.True(t, errors.As(limiter.Allow("victim@example.com", "203.0.113.10"), &retry), "неудача %d", i+1)
+			assert.ErrorIs(t, retry, models.ErrTooManyLoginAttempts)
+			assert.Greater(t, retry.RetryAfter, time.Duration(0))
+			assert.LessOrEqual(t, retry.RetryAfter, 15*time.Minute)
+		}
+	}
+
+	t.Setenv("LOGIN_BASE_DELAY", "1h")
+	_, err = service.NewLoginLimiterConfig()
+	assert.Error(t, err, "задержка больше блокировки")
+}
+
+func TestAuth10_TrackedAccountsBounded(t *testing.T) {
+	limiter := service.NewLoginLimiter(&service.LoginLimiterConfig{
+		MaxAttempts:     3,
+		IPMaxAttempts:   1 << 30,
+		DelayAfter:      1,
+		BaseDelay:       time.Minute,
+		LockoutDuration: 15 * time.Minute,
+	})
+
+	for i := 0; i < 3; i++ {
+		limiter.RecordFailure("victim@example.com", "203.0.113.10")
+	}
+	limiter.RecordFailure("delayed@example.com", "203.0.113.10")
+	limiter.RecordFailure("delayed@example.com", "203.0.113.10")
+	require.Error(t, limiter.Allow("delayed@example.com", "198.51.100.7"))
+
+	// Перебор случайных email вытесняет старые записи вместо неограниченного роста
+	for i := 0; i < 10000; i++ {
+		limiter.RecordFailure("spray-"+strconv.Itoa(i)+"@example.com", "203.0.113.10")
+	}
+
+	assert.NoError(t, limiter.Allow("delayed@example.com", "198.51.100.7"), "самая старая запись вытеснена")
+	assert.ErrorIs(t, limiter.Allow("victim@example.com", "198.51.100.7"), models.ErrAccountLocked, "блокировка не снимается перебором")
+}
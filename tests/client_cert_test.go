@@ -0,0 +1,307 @@
+package tests
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"khrllwTest/internal/middleware"
+	"khrllwTest/internal/models"
+	"khrllwTest/internal/utils"
+)
+
+// testCA тестовый удостоверяющий центр для выпуска сертификатов сервера и клиентов
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Internal CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue выпускает сертификат по шаблону и возвращает его вместе с ключом в формате PEM
+func (ca *testCA) issue(t *testing.T, template *x509.Certificate) (*x509.Certificate, []byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	template.SerialNumber = serial
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return cert,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// issueClient выпускает клиентский сертификат
+func (ca *testCA) issueClient(t *testing.T, template *x509.Certificate) tls.Certificate {
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	_, certPEM, keyPEM := ca.issue(t, template)
+
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+	return pair
+}
+
+// writeFile записывает файл во временный каталог теста
+func writeFile(t *testing.T, dir, name string, data []byte) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+// startMTLSServer запускает HTTPS сервер с проверкой клиентских сертификатов и защищенными маршрутами
+func startMTLSServer(t *testing.T, ca *testCA, mapper *middleware.ClientCertMapper) *httptest.Server {
+	dir := t.TempDir()
+	_, serverPEM, serverKey := ca.issue(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "localhost"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+	})
+
+	tlsConfig := &utils.TLSConfig{
+		CertFile:     writeFile(t, dir, "server.pem", serverPEM),
+		KeyFile:      writeFile(t, dir, "server-key.pem", serverKey),
+		ClientCAFile: writeFile(t, dir, "ca.pem", ca.pem),
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+	}
+	serverTLS, err := tlsConfig.ServerConfig()
+	require.NoError(t, err)
+
+	// Для сервисных учетных записей репозитории не используются
+	authorization := middleware.NewAuthorization(nil, nil, nil, nil, nil, nil, nil, mapper)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/admin", authorization.Middleware(), authorization.Allow(models.RoleAdmin), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"method":    c.GetString(middleware.ContextAuthMethodKey),
+			"principal": c.GetString(middleware.ContextPrincipalKey),
+		})
+	})
+	router.GET("/users", authorization.Middleware(), authorization.Allow(models.RoleUser, models.RoleAdmin), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	router.POST("/auth/logout", authorization.Middleware(), authorization.RequireToken(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	server := httptest.NewUnstartedServer(router)
+	server.TLS = serverTLS
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server
+}
+
+// mtlsClient создает HTTPS клиента, доверяющего тестовому CA, с необязательным клиентским сертификатом
+func mtlsClient(ca *testCA, certs ...tls.Certificate) *http.Client {
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		RootCAs:      roots,
+		Certificates: certs,
+	}}}
+}
+
+// mtlsStatus выполняет запрос и возвращает код ответа
+func mtlsStatus(t *testing.T, client *http.Client, method, target string) int {
+	req, err := http.NewRequest(method, target, nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestClientCert1_MapperMatchesSubjectAndSANs(t *testing.T) {
+	ca := newTestCA(t)
+	spiffe, _ := url.Parse("spiffe://internal/ns/jobs/sa/worker")
+
+	mapper, err := middleware.NewClientCertMapper([]middleware.ClientCertIdentity{
+		{Subject: "CN=billing,O=Internal", Name: "billing", Roles: []string{models.RoleAdmin}},
+		{CommonName: "reports", Name: "reports", Roles: []string{models.RoleUser}},
+		{DNSName: "gateway.internal", Name: "gateway", Roles: []string{models.RoleUser}},
+		{URI: spiffe.String(), Name: "worker", Roles: []string{models.RoleUser}},
+		{Email: "ops@example.com", UserID: 7},
+	})
+	require.NoError(t, err)
+
+	cases := []struct {
+		name     string
+		template *x509.Certificate
+		expected string
+		userID   uint
+	}{
+		{"subject", &x509.Certificate{Subject: pkix.Name{CommonName: "billing", Organization: []string{"Internal"}}}, "billing", 0},
+		{"common name", &x509.Certificate{Subject: pkix.Name{CommonName: "reports", Organization: []string{"Other"}}}, "reports", 0},
+		{"dns", &x509.Certificate{Subject: pkix.Name{CommonName: "gw"}, DNSNames: []string{"Gateway.Internal"}}, "gateway", 0},
+		{"uri", &x509.Certificate{URIs: []*url.URL{spiffe}}, "worker", 0},
+		{"email", &x509.Certificate{Subject: pkix.Name{CommonName: "ops"}, EmailAddresses: []string{"ops@example.com"}}, "", 7},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cert, _, _ := ca.issue(t, tc.template)
+			identity, ok := mapper.Match(cert)
+			require.True(t, ok)
+			assert.Equal(t, tc.expected, identity.Name)
+			assert.Equal(t, tc.userID, identity.UserID)
+		})
+	}
+
+	cert, _, _ := ca.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "unknown"}})
+	_, ok := mapper.Match(cert)
+	assert.False(t, ok, "сертификат без строки в таблице не сопоставляется")
+}
+
+func TestClientCert2_MapperRejectsInvalidRows(t *testing.T) {
+	cases := []struct {
+		name     string
+		identity middleware.ClientCertIdentity
+	}{
+		{"no condition", middleware.ClientCertIdentity{Name: "svc", Roles: []string{models.RoleUser}}},
+		{"two conditions", middleware.ClientCertIdentity{CommonName: "svc", DNSName: "svc.internal", Name: "svc", Roles: []string{models.RoleUser}}},
+		{"user and name", middleware.ClientCertIdentity{CommonName: "svc", UserID: 1, Name: "svc"}},
+		{"no principal", middleware.ClientCertIdentity{CommonName: "svc"}},
+		{"service without roles", middleware.ClientCertIdentity{CommonName: "svc", Name: "svc"}},
+		{"unknown role", middleware.ClientCertIdentity{CommonName: "svc", Name: "svc", Roles: []string{"root"}}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := middleware.NewClientCertMapper([]middleware.ClientCertIdentity{tc.identity})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestClientCert3_AuthorizationOverMutualTLS(t *testing.T) {
+	ca := newTestCA(t)
+	mapper, err := middleware.NewClientCertMapper([]middleware.ClientCertIdentity{
+		{CommonName: "billing", Name: "billing", Roles: []string{models.RoleAdmin}},
+		{CommonName: "reports", Name: "reports", Roles: []string{models.RoleUser}},
+	})
+	require.NoError(t, err)
+	server := startMTLSServer(t, ca, mapper)
+
+	billing := mtlsClient(ca, ca.issueClient(t, &x509.Certificate{Subject: pkix.Name{CommonName: "billing"}}))
+	resp, err := billing.Get(server.URL + "/admin")
+	require.NoError(t, err)
+	var body map[string]string
+	defer resp.Body.Close()
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, middleware.AuthMethodClientCert, body["method"])
+	assert.Equal(t, "billing", body["principal"])
+
+	// Роли берутся из таблицы: сервис с ролью user не получает доступ к маршрутам администратора
+	reports := mtlsClient(ca, ca.issueClient(t, &x509.Certificate{Subject: pkix.Name{CommonName: "reports"}}))
+	assert.Equal(t, http.StatusOK, mtlsStatus(t, reports, http.MethodGet, server.URL+"/users"))
+	assert.Equal(t, http.StatusForbidden, mtlsStatus(t, reports, http.MethodGet, server.URL+"/admin"))
+
+	// Маршруты управления сессией требуют access токен
+	assert.Equal(t, http.StatusForbidden, mtlsStatus(t, billing, http.MethodPost, server.URL+"/auth/logout"))
+
+	// Проверенный, но не описанный в таблице сертификат не аутентифицирует
+	unmapped := mtlsClient(ca, ca.issueClient(t, &x509.Certificate{Subject: pkix.Name{CommonName: "unknown"}}))
+	assert.Equal(t, http.StatusUnauthorized, mtlsStatus(t, unmapped, http.MethodGet, server.URL+"/users"))
+
+	// Без сертификата соединение допускается, но нужен токен
+	assert.Equal(t, http.StatusUnauthorized, mtlsStatus(t, mtlsClient(ca), http.MethodGet, server.URL+"/users"))
+}
+
+func TestClientCert4_UntrustedCertificateRejected(t *testing.T) {
+	ca := newTestCA(t)
+	mapper, err := middleware.NewClientCertMapper([]middleware.ClientCertIdentity{
+		{CommonName: "billing", Name: "billing", Roles: []string{models.RoleAdmin}},
+	})
+	require.NoError(t, err)
+	server := startMTLSServer(t, ca, mapper)
+
+	// Сертификат с тем же CN, выпущенный чужим CA, отклоняется при рукопожатии
+	foreign := newTestCA(t)
+	client := mtlsClient(ca, foreign.issueClient(t, &x509.Certificate{Subject: pkix.Name{CommonName: "billing"}}))
+	_, err = client.Get(server.URL + "/admin")
+	assert.Error(t, err)
+}
+
+func TestClientCert5_TLSConfigFromEnvironment(t *testing.T) {
+	t.Setenv("TLS_CERT_FILE", "")
+	t.Setenv("TLS_KEY_FILE", "")
+	t.Setenv("TLS_CLIENT_CA_FILE", "")
+	t.Setenv("TLS_CLIENT_AUTH", "")
+
+	config, err := utils.NewTLSConfigFromEnv()
+	require.NoError(t, err)
+	assert.Nil(t, config, "без сертификата сервер работает по HTTP")
+
+	t.Setenv("TLS_CLIENT_CA_FILE", "ca.pem")
+	_, err = utils.NewTLSConfigFromEnv()
+	assert.Error(t, err, "клиентские сертификаты требуют HTTPS")
+
+	t.Setenv("TLS_CERT_FILE", "server.pem")
+	_, err = utils.NewTLSConfigFromEnv()
+	assert.Error(t, err, "сертификат задается вместе с ключом")
+
+	t.Setenv("TLS_KEY_FILE", "server-key.pem")
+	config, err = utils.NewTLSConfigFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, tls.VerifyClientCertIfGiven, config.ClientAuth)
+	assert.True(t, config.ClientCertsEnabled())
+
+	t.Setenv("TLS_CLIENT_AUTH", "required")
+	config, err = utils.NewTLSConfigFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, config.ClientAuth)
+
+	t.Setenv("TLS_CLIENT_AUTH", "sometimes")
+	_, err = utils.NewTLSConfigFromEnv()
+	assert.Error(t, err)
+}
@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"khrllwTest/internal/middleware"
+	"khrllwTest/internal/models"
+)
+
+func cookieTestAuth(enabled bool) *middleware.CookieAuth {
+	return middleware.NewCookieAuth(&middleware.CookieAuthConfig{
+		Enabled:    enabled,
+		Secure:     true,
+		SameSite:   http.SameSiteStrictMode,
+		RefreshTTL: 7 * 24 * time.Hour,
+	})
+}
+
+// cookieTestContext создает контекст запроса с переданными cookie и заголовком CSRF
+func cookieTestContext(method string, cookies map[string]string, csrfHeader string) *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	req := httptest.NewRequest(method, "/users/1", nil)
+	for name, value := range cookies {
+		req.AddCookie(&http.Cookie{Name: name, Value: value})
+	}
+	if csrfHeader != "" {
+		req.Header.Set(middleware.CSRFHeader, csrfHeader)
+	}
+	c.Request = req
+	return c
+}
+
+func TestCookieAuth1_SetTokensMovesTokensToHttpOnlyCookies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+
+	tokens := &models.LoginResponse{Token: "access", RefreshToken: "refresh", ExpiresIn: 900}
+	require.NoError(t, cookieTestAuth(true).SetTokens(c, tokens))
+
+	assert.Empty(t, tokens.Token)
+	assert.Empty(t, tokens.RefreshToken)
+	assert.NotEmpty(t, tokens.CSRFToken)
+
+	cookies := map[string]*http.Cookie{}
+	for _, cookie := range recorder.Result().Cookies() {
+		cookies[cookie.Name] = cookie
+	}
+
+	access := cookies[middleware.AccessTokenCookie]
+	require.NotNil(t, access)
+	assert.Equal(t, "access", access.Value)
+	assert.True(t, access.HttpOnly)
+	assert.True(t, access.Secure)
+	assert.Equal(t, http.SameSiteStrictMode, access.SameSite)
+
+	refresh := cookies[middleware.RefreshTokenCookie]
+	require.NotNil(t, refresh)
+	assert.Equal(t, "refresh", refresh.Value)
+	assert.True(t, refresh.HttpOnly)
+	assert.Equal(t, "/auth", refresh.Path)
+
+	csrf := cookies[middleware.CSRFTokenCookie]
+	require.NotNil(t, csrf)
+	assert.Equal(t, tokens.CSRFToken, csrf.Value)
+	assert.False(t, csrf.HttpOnly, "CSRF cookie должна быть доступна JavaScript")
+}
+
+func TestCookieAuth2_DoubleSubmitCSRFValidation(t *testing.T) {
+	auth := cookieTestAuth(true)
+	cookies := map[string]string{middleware.CSRFTokenCookie: "csrf-value"}
+
+	assert.True(t, auth.ValidCSRF(cookieTestContext(http.MethodPost, cookies, "csrf-value")))
+	assert.False(t, auth.ValidCSRF(cookieTestContext(http.MethodPost, cookies, "")), "заголовок отсутствует")
+	assert.False(t, auth.ValidCSRF(cookieTestContext(http.MethodDelete, cookies, "other")), "значения не совпадают")
+	assert.False(t, auth.ValidCSRF(cookieTestContext(http.MethodPut, nil, "csrf-value")), "cookie отсутствует")
+
+	// Безопасные методы не требуют CSRF токена
+	assert.True(t, auth.ValidCSRF(cookieTestContext(http.MethodGet, nil, "")))
+}
+
+func TestCookieAuth3_DisabledModeIgnoresCookies(t *testing.T) {
+	cookies := map[string]string{
+		middleware.AccessTokenCookie:  "access",
+		middleware.RefreshTokenCookie: "refresh",
+	}
+
+	disabled := cookieTestAuth(false)
+	assert.False(t, disabled.Enabled())
+	assert.Empty(t, disabled.AccessToken(cookieTestContext(http.MethodGet, cookies, "")))
+	assert.Empty(t, disabled.RefreshToken(cookieTestContext(http.MethodGet, cookies, "")))
+
+	enabled := cookieTestAuth(true)
+	assert.Equal(t, "access", enabled.AccessToken(cookieTestContext(http.MethodGet, cookies, "")))
+	assert.Equal(t, "refresh", enabled.RefreshToken(cookieTestContext(http.MethodGet, cookies, "")))
+}
+
+func TestCookieAuth4_ConfigFromEnvironment(t *testing.T) {
+	t.Setenv("AUTH_COOKIE_ENABLED", "true")
+	t.Setenv("AUTH_COOKIE_SAMESITE", "lax")
+
+	config, err := middleware.NewCookieAuthConfig(time.Hour)
+	require.NoError(t, err)
+	assert.True(t, config.Enabled)
+	assert.True(t, config.Secure, "Secure включен по умолчанию")
+	assert.Equal(t, http.SameSiteLaxMode, config.SameSite)
+
+	// SameSite=None без Secure отвергается браузерами
+	t.Setenv("AUTH_COOKIE_SAMESITE", "none")
+	t.Setenv("AUTH_COOKIE_SECURE", "false")
+	_, err = middleware.NewCookieAuthConfig(time.Hour)
+	assert.Error(t, err)
+
+	t.Setenv("AUTH_COOKIE_SAMESITE", "relaxed")
+	_, err = middleware.NewCookieAuthConfig(time.Hour)
+	assert.Error(t, err)
+}
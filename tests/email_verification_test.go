@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"khrllwTest/internal/mail"
+	"khrllwTest/internal/models"
+	service "khrllwTest/internal/services"
+	"khrllwTest/internal/utils"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEmailVerification1_NewUserIsUnverified(t *testing.T) {
+	user, token := createTestUser(t)
+	defer deleteTestUser(t, user.ID, token)
+
+	resp := doRequest(t, "GET", fmt.Sprintf("%s/users/%d", baseURL, user.ID), token, nil)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body struct {
+		EmailVerified bool `json:"email_verified"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.False(t, body.EmailVerified)
+}
+
+func TestEmailVerification2_VerifyWithInvalidToken(t *testing.T) {
+	resp := doRequest(t, "POST", baseURL+"/auth/verify-email", "", map[string]string{
+		"token": "invalid-verification-token",
+	})
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestEmailVerification3_AccessTokenIsNotVerificationToken(t *testing.T) {
+	user, token := createTestUser(t)
+	defer deleteTestUser(t, user.ID, token)
+
+	resp := doRequest(t, "POST", baseURL+"/auth/verify-email", "", map[string]string{
+		"token": token,
+	})
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestEmailVerification4_ResendIsRateLimited(t *testing.T) {
+	user, token := createTestUser(t)
+	defer deleteTestUser(t, user.ID, token)
+
+	// Письмо уже отправлено при регистрации
+	resp := doRequest(t, "POST", baseURL+"/auth/verify-email/resend", token, nil)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.NotEmpty(t, resp.Header.Get("Retry-After"))
+}
+
+// slowTokenManager замедляет выпуск токенов, чтобы параллельные запросы гарантированно пересеклись
+type slowTokenManager struct {
+	utils.TokenManager
+}
+
+func (m slowTokenManager) Generate(subject utils.TokenSubject) (string, error) {
+	time.Sleep(20 * time.Millisecond)
+	return m.TokenManager.Generate(subject)
+}
+
+func TestEmailVerification5_ConcurrentResendSendsOnce(t *testing.T) {
+	users := newMemoryUserRepo(models.User{ID: 1, Email: "resend@example.com", Role: models.RoleUser})
+	sender := mail.NewMemorySender()
+	verifier := service.NewEmailVerificationService(users, slowTokenManager{utils.NewTokenManager(&utils.JWTConfig{
+		JWTKey:        "verification-test-key",
+		SigningMethod: utils.SigningMethodHS256,
+		JWTExpiration: 15 * time.Minute,
+	})}, sender, &service.EmailVerificationConfig{
+		TokenTTL:       time.Hour,
+		VerifyURL:      "https://app.example.com/verify-email",
+		ResendInterval: time.Minute,
+	})
+
+	const requests = 20
+	var wg sync.WaitGroup
+	results := make(chan error, requests)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results <- verifier.Resend(1)
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	sent := 0
+	for err := range results {
+		if err == nil {
+			sent++
+			continue
+		}
+		var retry *models.RetryAfterError
+		require.True(t, errors.As(err, &retry), "остальные запросы ограничены: %v", err)
+		assert.ErrorIs(t, err, models.ErrVerificationResendLimit)
+	}
+	assert.Equal(t, 1, sent, "интервал проверяется и занимается атомарно")
+
+	assert.Eventually(t, func() bool { return len(sender.Messages()) == 1 }, time.Second, 10*time.Millisecond)
+}
@@ -6,9 +6,14 @@ import (
 	"fmt"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 	"io"
+	"khrllwTest/internal/models"
+	"khrllwTest/internal/repository"
 	"math/rand"
 	"net/http"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -24,7 +29,9 @@ type User struct {
 }
 
 type AuthResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
 }
 
 type Order struct {
@@ -112,16 +119,54 @@ func doRequest(t *testing.T, method, url, token string, body interface{}) *http.
 	return resp
 }
 
+// doRawRequest отправляет тело как есть с указанными Content-Type и If-Match (пустые заголовки не передаются)
+func doRawRequest(t *testing.T, method, url, token, contentType, ifMatch, body string) *http.Response {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+
+	return resp
+}
+
+// getJSON выполняет GET запрос и при ответе 200 разбирает тело в target; возвращает код ответа
+func getJSON(t *testing.T, url, token string, target interface{}) int {
+	resp := doRequest(t, "GET", url, token, nil)
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(target))
+	}
+	return resp.StatusCode
+}
+
 // --------------------------------- User/Test Helpers ---------------------------------
 
 func createTestUser(t *testing.T) (User, string) {
+	return createNamedTestUser(t, "Test User", 30)
+}
+
+// createNamedTestUser регистрирует и авторизует пользователя с указанными именем и возрастом
+func createNamedTestUser(t *testing.T, name string, age int) (User, string) {
 	email := randomEmail()
 	password := "testpassword"
 
 	newUser := User{
-		Name:     "Test User",
+		Name:     name,
 		Email:    email,
-		Age:      30,
+		Age:      age,
 		Password: password,
 	}
 
@@ -146,6 +191,18 @@ func createTestUser(t *testing.T) (User, string) {
 	return createdUser, auth.Token
 }
 
+func loginTestUser(t *testing.T, user User) AuthResponse {
+	resp := doRequest(t, "POST", baseURL+"/auth/login", "", userToLoginPayload(user))
+	defer resp.Body.Close()
+	require.Equal(t, 200, resp.StatusCode)
+
+	var auth AuthResponse
+	err := json.NewDecoder(resp.Body).Decode(&auth)
+	require.NoError(t, err)
+
+	return auth
+}
+
 func deleteTestUser(t *testing.T, userID int, token string) {
 	req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/users/%d", baseURL, userID), nil)
 	require.NoError(t, err)
@@ -159,8 +216,273 @@ func deleteTestUser(t *testing.T, userID int, token string) {
 	require.Equal(t, 204, resp.StatusCode)
 }
 
-func createTestOrder(t *testing.T, userID int, token string, order map[string]interface{}) {
+func createTestOrder(t *testing.T, userID int, token string, order map[string]interface{}) Order {
 	resp := doRequest(t, "POST", fmt.Sprintf("%s/users/%d/orders", baseURL, userID), token, order)
 	defer resp.Body.Close()
 	require.Equal(t, 201, resp.StatusCode)
+
+	var created Order
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	return created
+}
+
+// --------------------------------- In-Memory Repositories ---------------------------------
+
+// deletionGracePeriod срок восстановления удаленных пользователей в тестах
+const deletionGracePeriod = 24 * time.Hour
+
+// memoryUserRepo хранит пользователей в памяти и повторяет поведение репозитория GORM:
+// мягко удаленные пользователи не находятся по ID и email, а записи с версией выполняются
+// только при ее совпадении. Чтения возвращают копии, поэтому изменения видны только после записи
+type memoryUserRepo struct {
+	repository.UserRepository
+
+	mu    sync.Mutex
+	users map[uint]*models.User
+
+	// Число обращений к FindByID
+	reads int
+
+	// Имитация запроса, изменяющего пользователя между чтением и записью
+	concurrentWrite bool
+
+	// Имитация регистрации между проверкой email и записью: FindByEmail не находит пользователей,
+	// занятый email обнаруживается только уникальным индексом при записи
+	concurrentSignup bool
+}
+
+func newMemoryUserRepo(users ...models.User) *memoryUserRepo {
+	r := &memoryUserRepo{users: map[uint]*models.User{}}
+	r.add(users...)
+	return r
+}
+
+// add сохраняет копии пользователей
+func (r *memoryUserRepo) add(users ...models.User) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range users {
+		user := users[i]
+		r.users[user.ID] = &user
+	}
+}
+
+// emailTaken проверяет уникальный индекс idx_users_email_active: email занят другим
+// неудаленным пользователем (вызывается под блокировкой)
+func (r *memoryUserRepo) emailTaken(id uint, email string) bool {
+	for _, user := range r.users {
+		if user.ID != id && user.Email == email && !user.DeletedAt.Valid {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *memoryUserRepo) Create(user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if user.ID == 0 {
+		for id := range r.users {
+			if id > user.ID {
+				user.ID = id
+			}
+		}
+		user.ID++
+	}
+	if r.emailTaken(user.ID, user.Email) {
+		return models.ErrEmailAlreadyExists
+	}
+	copied := *user
+	r.users[user.ID] = &copied
+	return nil
+}
+
+func (r *memoryUserRepo) FindByID(id uint) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.reads++
+	user, ok := r.users[id]
+	if !ok || user.DeletedAt.Valid {
+		return nil, models.ErrUserNotFound
+	}
+	copied := *user
+	if r.concurrentWrite {
+		user.Version++
+	}
+	return &copied, nil
+}
+
+func (r *memoryUserRepo) FindByEmail(email string) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.concurrentSignup {
+		return nil, models.ErrRecordNotFound
+	}
+	for _, user := range r.users {
+		if user.Email == email && !user.DeletedAt.Valid {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, models.ErrRecordNotFound
+}
+
+func (r *memoryUserRepo) Update(user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[user.ID]
+	if !ok || stored.DeletedAt.Valid || stored.Version != user.Version {
+		return models.ErrVersionConflict
+	}
+	if r.emailTaken(user.ID, user.Email) {
+		return models.ErrEmailAlreadyExists
+	}
+	user.Version++
+	copied := *user
+	r.users[user.ID] = &copied
+	return nil
+}
+
+func (r *memoryUserRepo) UpdateFields(id uint, version int, fields map[string]interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || user.DeletedAt.Valid || (version != 0 && user.Version != version) {
+		if version != 0 {
+			return models.ErrVersionConflict
+		}
+		return models.ErrUserNotFound
+	}
+	if email, ok := fields["email"].(string); ok && r.emailTaken(id, email) {
+		return models.ErrEmailAlreadyExists
+	}
+	for column, value := range fields {
+		switch column {
+		case "name":
+			user.Name = value.(string)
+		case "email":
+			user.Email = value.(string)
+		case "age":
+			user.Age = value.(int)
+		case "email_verified_at":
+			user.EmailVerifiedAt, _ = value.(*time.Time)
+		case "avatar_key":
+			user.AvatarKey = value.(string)
+		}
+	}
+	user.Version++
+	return nil
+}
+
+func (r *memoryUserRepo) Delete(id uint, version int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || user.DeletedAt.Valid || (version != 0 && user.Version != version) {
+		if version != 0 {
+			return models.ErrVersionConflict
+		}
+		return nil
+	}
+	user.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	return nil
+}
+
+func (r *memoryUserRepo) FindDeletedByID(id uint) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || !user.DeletedAt.Valid {
+		return nil, models.ErrUserNotFound
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *memoryUserRepo) Restore(id uint, deletedAfter time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || !user.DeletedAt.Valid || !user.DeletedAt.Time.After(deletedAfter) {
+		return false, nil
+	}
+	if r.emailTaken(id, user.Email) {
+		return false, models.ErrEmailAlreadyExists
+	}
+	user.DeletedAt = gorm.DeletedAt{}
+	user.TokenVersion++
+	user.Version++
+	return true, nil
+}
+
+func (r *memoryUserRepo) UseTOTPStep(id uint, step int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || user.TOTPLastStep >= step {
+		return false, nil
+	}
+	user.TOTPLastStep = step
+	user.Version++
+	return true, nil
+}
+
+func (r *memoryUserRepo) PurgeDeleted(before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var removed int64
+	for id, user := range r.users {
+		if user.DeletedAt.Valid && user.DeletedAt.Time.Before(before) {
+			delete(r.users, id)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+func (r *memoryUserRepo) IncrementTokenVersion(id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if user, ok := r.users[id]; ok {
+		user.TokenVersion++
+		user.Version++
+	}
+	return nil
+}
+
+// revocationCounter считает отзывы сессий и refresh токенов пользователя
+type revocationCounter struct {
+	revoked map[uint]int
+}
+
+type countingSessionRepo struct {
+	repository.SessionRepository
+	*revocationCounter
+}
+
+func (r countingSessionRepo) RevokeAllForUser(userID uint) error {
+	r.revoked[userID]++
+	return nil
+}
+
+type countingRefreshRepo struct {
+	repository.RefreshTokenRepository
+	*revocationCounter
+}
+
+func (r countingRefreshRepo) RevokeAllForUser(userID uint) error {
+	r.revoked[userID]++
+	return nil
 }
@@ -0,0 +1,212 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"khrllwTest/internal/middleware"
+	"khrllwTest/internal/models"
+	"khrllwTest/internal/repository"
+	service "khrllwTest/internal/services"
+	"khrllwTest/internal/utils"
+)
+
+const (
+	impersonationAdminID = 1
+	impersonationOtherID = 2
+	impersonationUserID  = 42
+)
+
+// noRevokedTokens список отозванных токенов, в котором нет ни одного токена
+type noRevokedTokens struct {
+	repository.RevokedTokenRepository
+}
+
+func (noRevokedTokens) IsRevoked(string) (bool, error) {
+	return false, nil
+}
+
+// memoryAuditRepo хранит журнал аудита в памяти
+type memoryAuditRepo struct {
+	entries []models.AuditLog
+}
+
+func (r *memoryAuditRepo) Create(entry *models.AuditLog) error {
+	entry.ID = uint(len(r.entries) + 1)
+	r.entries = append(r.entries, *entry)
+	return nil
+}
+
+func (r *memoryAuditRepo) FindAll(offset, limit int, actorID, userID uint) ([]models.AuditLog, int64, error) {
+	return r.entries, int64(len(r.entries)), nil
+}
+
+// impersonationFixture окружение теста: пользователи, журнал, сервис имперсонации и роутер
+type impersonationFixture struct {
+	users   *memoryUserRepo
+	audit   *memoryAuditRepo
+	tokens  utils.TokenManager
+	service *service.ImpersonationService
+	router  *gin.Engine
+}
+
+func newImpersonationFixture() *impersonationFixture {
+	f := &impersonationFixture{
+		users: newMemoryUserRepo(
+			models.User{ID: impersonationAdminID, Role: models.RoleAdmin},
+			models.User{ID: impersonationOtherID, Role: models.RoleAdmin},
+			models.User{ID: impersonationUserID, Role: models.RoleUser},
+		),
+		audit:  &memoryAuditRepo{},
+		tokens: claimsTestManager(),
+	}
+
+	auditService := service.NewAuditService(f.audit)
+	f.service = service.NewImpersonationService(f.users, f.tokens, auditService,
+		&service.ImpersonationConfig{TTL: 10 * time.Minute})
+
+	authorization := middleware.NewAuthorization(f.tokens, f.users, noRevokedTokens{}, nil, nil, nil, nil, nil)
+
+	gin.SetMode(gin.TestMode)
+	f.router = gin.New()
+	f.router.Use(middleware.ImpersonationAudit(auditService))
+
+	users := f.router.Group("/users/:user_id", authorization.Middleware())
+	users.GET("/orders", authorization.Allow(middleware.Self, models.RoleAdmin), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	users.PUT("/password", authorization.DenyImpersonation(), authorization.Allow(middleware.Self), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	return f
+}
+
+// request выполняет запрос к роутеру с access токеном
+func (f *impersonationFixture) request(method, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	recorder := httptest.NewRecorder()
+	f.router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+// impersonate выпускает токен имперсонации пользователя impersonationUserID
+func (f *impersonationFixture) impersonate(t *testing.T) string {
+	response, err := f.service.Start(impersonationAdminID, impersonationUserID, "Тикет #1234", "203.0.113.10", "support-console")
+	require.NoError(t, err)
+	return response.Token
+}
+
+func TestImpersonation1_TokenCarriesActorAndSubject(t *testing.T) {
+	f := newImpersonationFixture()
+
+	response, err := f.service.Start(impersonationAdminID, impersonationUserID, "Тикет #1234", "203.0.113.10", "support-console")
+	require.NoError(t, err)
+	assert.Equal(t, uint(impersonationUserID), response.UserID)
+	assert.Equal(t, uint(impersonationAdminID), response.ActorID)
+	assert.Equal(t, int64(600), response.ExpiresIn)
+
+	token, err := f.tokens.Parse(response.Token)
+	require.NoError(t, err)
+	claims, err := f.tokens.ExtractClaims(token)
+	require.NoError(t, err)
+
+	assert.Equal(t, strconv.Itoa(impersonationUserID), claims.Subject, "sub - пользователь")
+	require.NotNil(t, claims.Actor)
+	assert.Equal(t, strconv.Itoa(impersonationAdminID), claims.Actor.Subject, "act.sub - администратор")
+	assert.Empty(t, claims.SessionID, "токен имперсонации не создает сессию")
+	assert.WithinDuration(t, time.Now().Add(10*time.Minute), claims.ExpiresAt.Time, 5*time.Second)
+
+	require.Len(t, f.audit.entries, 1)
+	entry := f.audit.entries[0]
+	assert.Equal(t, models.AuditActionImpersonationStart, entry.Action)
+	assert.Equal(t, uint(impersonationAdminID), entry.ActorID)
+	assert.Equal(t, uint(impersonationUserID), entry.UserID)
+	assert.Equal(t, claims.ID, entry.TokenID)
+	assert.Equal(t, "Тикет #1234", entry.Reason)
+}
+
+func TestImpersonation2_CannotImpersonateSelfAdminOrMissingUser(t *testing.T) {
+	f := newImpersonationFixture()
+
+	_, err := f.service.Start(impersonationAdminID, impersonationAdminID, "reason", "", "")
+	assert.ErrorIs(t, err, models.ErrCannotImpersonate)
+
+	_, err = f.service.Start(impersonationAdminID, impersonationOtherID, "reason", "", "")
+	assert.ErrorIs(t, err, models.ErrCannotImpersonate, "другого администратора нельзя")
+
+	_, err = f.service.Start(impersonationAdminID, 999, "reason", "", "")
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+
+	assert.Empty(t, f.audit.entries, "отклоненные попытки токен не выпускают")
+}
+
+func TestImpersonation3_RequestsAuditedAndSensitiveActionsDenied(t *testing.T) {
+	f := newImpersonationFixture()
+	token := f.impersonate(t)
+	path := "/users/" + strconv.Itoa(impersonationUserID)
+
+	assert.Equal(t, http.StatusOK, f.request(http.MethodGet, path+"/orders", token).Code)
+
+	denied := f.request(http.MethodPut, path+"/password", token)
+	assert.Equal(t, http.StatusForbidden, denied.Code)
+	assert.Contains(t, denied.Body.String(), models.ErrImpersonationDenied.Error())
+
+	require.Len(t, f.audit.entries, 3, "начало имперсонации и два запроса")
+	for i, expected := range []struct {
+		method string
+		path   string
+		status int
+	}{
+		{http.MethodGet, path + "/orders", http.StatusOK},
+		{http.MethodPut, path + "/password", http.StatusForbidden},
+	} {
+		entry := f.audit.entries[i+1]
+		assert.Equal(t, models.AuditActionImpersonatedRequest, entry.Action)
+		assert.Equal(t, uint(impersonationAdminID), entry.ActorID)
+		assert.Equal(t, uint(impersonationUserID), entry.UserID)
+		assert.Equal(t, f.audit.entries[0].TokenID, entry.TokenID)
+		assert.Equal(t, expected.method, entry.Method)
+		assert.Equal(t, expected.path, entry.Path)
+		assert.Equal(t, expected.status, entry.Status)
+	}
+
+	// Запросы с обычным токеном пользователя в журнал не попадают
+	own, err := f.tokens.Generate(utils.TokenSubject{UserID: impersonationUserID, Role: models.RoleUser})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, f.request(http.MethodPut, path+"/password", own).Code)
+	assert.Len(t, f.audit.entries, 3)
+}
+
+func TestImpersonation4_TokenInvalidAfterActorDemoted(t *testing.T) {
+	f := newImpersonationFixture()
+	token := f.impersonate(t)
+	path := "/users/" + strconv.Itoa(impersonationUserID) + "/orders"
+
+	f.users.users[impersonationAdminID].Role = models.RoleUser
+	assert.Equal(t, http.StatusUnauthorized, f.request(http.MethodGet, path, token).Code)
+
+	delete(f.users.users, impersonationAdminID)
+	assert.Equal(t, http.StatusUnauthorized, f.request(http.MethodGet, path, token).Code)
+}
+
+func TestImpersonation5_MalformedActorClaimRejected(t *testing.T) {
+	manager := claimsTestManager()
+
+	for _, act := range []interface{}{
+		map[string]interface{}{"sub": "admin"},
+		map[string]interface{}{"sub": "0"},
+		map[string]interface{}{},
+	} {
+		raw := signClaims(t, func(claims jwt.MapClaims) { claims["act"] = act })
+		_, err := manager.Parse(raw)
+		assert.ErrorIs(t, err, models.ErrTokenInvalidSubject, "act: %v", act)
+	}
+}
@@ -0,0 +1,314 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"khrllwTest/internal/models"
+	"khrllwTest/internal/oidc"
+	service "khrllwTest/internal/services"
+	"khrllwTest/internal/utils"
+)
+
+const (
+	oidcTestClientID    = "khrllwTest"
+	oidcTestSecret      = "mock-secret"
+	oidcTestRedirectURL = "http://localhost:8080/auth/oidc/mock/callback"
+)
+
+// startMockIdP запускает тестовый провайдер и возвращает его вместе с настроенным клиентом
+func startMockIdP(t *testing.T) (*oidc.MockIdP, *oidc.Provider) {
+	return startMockIdPFor(t, oidc.MockUser{
+		Subject:       "mock-user-1",
+		Email:         "sso.user@example.com",
+		EmailVerified: true,
+		Name:          "SSO User",
+	})
+}
+
+// startMockIdPFor запускает тестовый провайдер, который выдает токены для указанного пользователя
+func startMockIdPFor(t *testing.T, user oidc.MockUser) (*oidc.MockIdP, *oidc.Provider) {
+	idp, err := oidc.NewMockIdP("", oidcTestClientID, oidcTestSecret, user)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(idp.Handler())
+	t.Cleanup(server.Close)
+	idp.SetIssuer(server.URL)
+
+	provider := oidc.NewProvider(&oidc.ProviderConfig{
+		Name:         "mock",
+		Issuer:       server.URL,
+		ClientID:     oidcTestClientID,
+		ClientSecret: oidcTestSecret,
+		RedirectURL:  oidcTestRedirectURL,
+		Scopes:       []string{"openid", "email", "profile"},
+		Leeway:       30 * time.Second,
+	}, server.Client())
+
+	return idp, provider
+}
+
+// memoryOIDCStates хранит запросы авторизации в памяти
+type memoryOIDCStates struct {
+	states map[string]*models.OIDCLoginState
+}
+
+func (r *memoryOIDCStates) Create(state *models.OIDCLoginState) error {
+	r.states[state.StateHash] = state
+	return nil
+}
+
+func (r *memoryOIDCStates) Consume(hash string) (*models.OIDCLoginState, error) {
+	state, ok := r.states[hash]
+	if !ok {
+		return nil, models.ErrRecordNotFound
+	}
+	delete(r.states, hash)
+	return state, nil
+}
+
+func (r *memoryOIDCStates) DeleteExpired(before time.Time) (int64, error) {
+	return 0, nil
+}
+
+// rejectingIdentities не находит привязок и запоминает пользователя, которого пытались создать.
+// Создание завершается ошибкой, чтобы вход не доходил до выпуска токенов.
+type rejectingIdentities struct {
+	created *models.User
+}
+
+func (r *rejectingIdentities) Create(identity *models.UserIdentity) error {
+	return models.ErrDatabaseError
+}
+
+func (r *rejectingIdentities) CreateWithUser(user *models.User, identity *models.UserIdentity) error {
+	r.created = user
+	return models.ErrDatabaseError
+}
+
+func (r *rejectingIdentities) FindBySubject(provider, subject string) (*models.UserIdentity, error) {
+	return nil, models.ErrRecordNotFound
+}
+
+// authorize открывает страницу входа провайдера и возвращает параметры перенаправления на callback
+func authorize(t *testing.T, authURL string) url.Values {
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	resp, err := client.Get(authURL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	return location.Query()
+}
+
+func TestOIDC1_AuthorizationCodeFlowWithPKCE(t *testing.T) {
+	_, provider := startMockIdP(t)
+	ctx := context.Background()
+
+	verifier, err := oidc.NewCodeVerifier()
+	require.NoError(t, err)
+
+	authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", verifier)
+	require.NoError(t, err)
+
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "S256", parsed.Query().Get("code_challenge_method"))
+	assert.Equal(t, oidc.CodeChallengeS256(verifier), parsed.Query().Get("code_challenge"))
+	assert.Equal(t, oidcTestRedirectURL, parsed.Query().Get("redirect_uri"))
+
+	callback := authorize(t, authURL)
+	assert.Equal(t, "state-1", callback.Get("state"))
+	require.NotEmpty(t, callback.Get("code"))
+
+	claims, err := provider.Exchange(ctx, callback.Get("code"), verifier, "nonce-1")
+	require.NoError(t, err)
+	assert.Equal(t, "mock-user-1", claims.Subject)
+	assert.Equal(t, "sso.user@example.com", claims.Email)
+	assert.True(t, claims.EmailVerified)
+	assert.Equal(t, "SSO User", claims.Name)
+}
+
+func TestOIDC2_ExchangeRejectsWrongVerifierAndReusedCode(t *testing.T) {
+	_, provider := startMockIdP(t)
+	ctx := context.Background()
+
+	verifier, err := oidc.NewCodeVerifier()
+	require.NoError(t, err)
+	authURL, err := provider.AuthCodeURL(ctx, "state", "nonce", verifier)
+	require.NoError(t, err)
+	code := authorize(t, authURL).Get("code")
+
+	otherVerifier, err := oidc.NewCodeVerifier()
+	require.NoError(t, err)
+	_, err = provider.Exchange(ctx, code, otherVerifier, "nonce")
+	assert.ErrorIs(t, err, models.ErrOIDCLoginFailed, "code_verifier не совпадает с code_challenge")
+
+	// Код одноразовый: после неудачной попытки он больше не действует
+	_, err = provider.Exchange(ctx, code, verifier, "nonce")
+	assert.ErrorIs(t, err, models.ErrOIDCLoginFailed)
+}
+
+func TestOIDC3_IDTokenValidation(t *testing.T) {
+	idp, provider := startMockIdP(t)
+	ctx := context.Background()
+	issuer := provider.Config().Issuer
+	now := time.Now()
+
+	valid := func() *oidc.IDTokenClaims {
+		return &oidc.IDTokenClaims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    issuer,
+				Subject:   "mock-user-1",
+				Audience:  jwt.ClaimStrings{oidcTestClientID},
+				IssuedAt:  jwt.NewNumericDate(now),
+				ExpiresAt: jwt.NewNumericDate(now.Add(5 * time.Minute)),
+			},
+			Nonce: "nonce",
+		}
+	}
+
+	cases := []struct {
+		name   string
+		mutate func(claims *oidc.IDTokenClaims)
+		nonce  string
+	}{
+		{"wrong nonce", func(c *oidc.IDTokenClaims) {}, "other-nonce"},
+		{"wrong audience", func(c *oidc.IDTokenClaims) { c.Audience = jwt.ClaimStrings{"other-client"} }, "nonce"},
+		{"wrong issuer", func(c *oidc.IDTokenClaims) { c.Issuer = "https://evil.example.com" }, "nonce"},
+		{"expired", func(c *oidc.IDTokenClaims) { c.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Hour)) }, "nonce"},
+		{"missing subject", func(c *oidc.IDTokenClaims) { c.Subject = "" }, "nonce"},
+		{"foreign azp", func(c *oidc.IDTokenClaims) {
+			c.Audience = jwt.ClaimStrings{oidcTestClientID, "other-client"}
+			c.AuthorizedParty = "other-client"
+		}, "nonce"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			claims := valid()
+			tc.mutate(claims)
+			raw, err := idp.SignIDToken(claims)
+			require.NoError(t, err)
+
+			_, err = provider.VerifyIDToken(ctx, raw, tc.nonce)
+			assert.ErrorIs(t, err, models.ErrOIDCLoginFailed)
+		})
+	}
+
+	raw, err := idp.SignIDToken(valid())
+	require.NoError(t, err)
+	_, err = provider.VerifyIDToken(ctx, raw, "nonce")
+	assert.NoError(t, err)
+}
+
+func TestOIDC4_ProviderConfigFromEnvironment(t *testing.T) {
+	t.Setenv("OIDC_PROVIDERS", "corp-sso")
+	t.Setenv("OIDC_CORP_SSO_ISSUER", "https://sso.example.com/")
+	t.Setenv("OIDC_CORP_SSO_CLIENT_ID", "api")
+	t.Setenv("OIDC_CORP_SSO_REDIRECT_URL", "https://api.example.com/auth/oidc/corp-sso/callback")
+	t.Setenv("OIDC_CORP_SSO_SCOPES", "email profile")
+	t.Setenv("OIDC_CORP_SSO_AUTO_CREATE", "false")
+
+	configs, err := oidc.LoadProviderConfigs(time.Minute)
+	require.NoError(t, err)
+	require.Len(t, configs, 1)
+
+	config := configs[0]
+	assert.Equal(t, "corp-sso", config.Name)
+	assert.Equal(t, "https://sso.example.com", config.Issuer)
+	assert.Equal(t, []string{"openid", "email", "profile"}, config.Scopes, "openid добавляется автоматически")
+	assert.False(t, config.AutoCreate)
+
+	// Без обязательных параметров конфигурация отклоняется
+	t.Setenv("OIDC_CORP_SSO_CLIENT_ID", "")
+	_, err = oidc.LoadProviderConfigs(time.Minute)
+	assert.Error(t, err)
+}
+
+func TestOIDC5_UnavailableProvider(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	provider := oidc.NewProvider(&oidc.ProviderConfig{
+		Name:        "down",
+		Issuer:      server.URL,
+		ClientID:    oidcTestClientID,
+		RedirectURL: oidcTestRedirectURL,
+		Scopes:      []string{"openid"},
+	}, nil)
+
+	_, err := provider.AuthCodeURL(context.Background(), "state", "nonce", "verifier")
+	assert.ErrorIs(t, err, models.ErrOIDCProviderUnavailable)
+}
+
+func TestOIDC6_AutoCreateRequiresBirthdate(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	cases := []struct {
+		name      string
+		birthdate string
+		age       int
+	}{
+		{"missing", "", 0},
+		{"year hidden", "0000-03-15", 0},
+		{"in the future", now.AddDate(1, 0, 0).Format("2006-01-02"), 0},
+		{"too old", now.AddDate(-200, 0, 0).Format("2006-01-02"), 0},
+		{"valid", now.AddDate(-30, 0, -1).Format("2006-01-02"), 30},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, provider := startMockIdPFor(t, oidc.MockUser{
+				Subject:       "mock-user-" + tc.name,
+				Email:         "new.sso.user@example.com",
+				EmailVerified: true,
+				Name:          "New SSO User",
+				Birthdate:     tc.birthdate,
+			})
+			provider.Config().AutoCreate = true
+
+			identities := &rejectingIdentities{}
+			oidcService := service.NewOIDCService(
+				[]*oidc.Provider{provider},
+				&memoryOIDCStates{states: map[string]*models.OIDCLoginState{}},
+				identities,
+				newMemoryUserRepo(),
+				nil,
+				utils.NewPasswordHasher(bcrypt.MinCost),
+				&service.OIDCConfig{StateTTL: time.Minute},
+				nil,
+			)
+
+			authURL, state, err := oidcService.Begin(ctx, "mock")
+			require.NoError(t, err)
+			callback := authorize(t, authURL)
+
+			_, err = oidcService.Complete(ctx, "mock", callback.Get("code"), state, "127.0.0.1", "test")
+			if tc.age == 0 {
+				assert.ErrorIs(t, err, models.ErrOIDCBirthdateRequired)
+				assert.Nil(t, identities.created, "пользователь без допустимого возраста не создается")
+				return
+			}
+
+			// Ошибку возвращает тестовое хранилище привязок, пользователь до него дошел
+			assert.ErrorIs(t, err, models.ErrDatabaseError)
+			require.NotNil(t, identities.created)
+			assert.Equal(t, tc.age, identities.created.Age)
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"khrllwTest/internal/models"
+	"khrllwTest/internal/utils"
+)
+
+// testCursorSecret секрет подписи курсоров пагинации в тестах
+var testCursorSecret = []byte("test-cursor-secret-of-32-bytes!!")
+
+// createTaggedUsers регистрирует count пользователей с меткой в имени, чтобы отобрать их поиском.
+// Возвращает ID в порядке создания и токен последнего пользователя.
+func createTaggedUsers(t *testing.T, tag string, count int) ([]uint, string) {
+	ids := make([]uint, count)
+	var token string
+	for i := range ids {
+		user, userToken := createNamedTestUser(t, "User "+tag, 30)
+		t.Cleanup(func() { deleteTestUser(t, user.ID, userToken) })
+		ids[i], token = uint(user.ID), userToken
+	}
+	return ids, token
+}
+
+func userIDs(users []models.UserResponse) []uint {
+	ids := make([]uint, len(users))
+	for i, user := range users {
+		ids[i] = user.ID
+	}
+	return ids
+}
+
+func TestPagination1_CursorSignatureVerified(t *testing.T) {
+	signer := utils.NewCursorSigner(testCursorSecret)
+	type state struct {
+		ID uint `json:"id"`
+	}
+
+	cursor, err := signer.Encode(state{ID: 42})
+	require.NoError(t, err)
+
+	var decoded state
+	require.NoError(t, signer.Decode(cursor, &decoded))
+	assert.Equal(t, uint(42), decoded.ID)
+
+	payload, signature, _ := strings.Cut(cursor, ".")
+	forged, err := signer.Encode(state{ID: 1})
+	require.NoError(t, err)
+	forgedPayload, _, _ := strings.Cut(forged, ".")
+
+	for name, bad := range map[string]string{
+		"подмена данных":   forgedPayload + "." + signature,
+		"без подписи":      payload,
+		"пустая подпись":   payload + ".",
+		"мусор":            "not-a-cursor",
+		"слишком длинный":  strings.Repeat("a", 2000) + "." + signature,
+		"не base64 данные": "!!!." + signature,
+	} {
+		assert.ErrorIs(t, signer.Decode(bad, &decoded), models.ErrInvalidCursor, name)
+	}
+
+	other := utils.NewCursorSigner([]byte("another-cursor-secret-of-32-bytes!"))
+	assert.ErrorIs(t, other.Decode(cursor, &decoded), models.ErrInvalidCursor, "чужой секрет")
+
+	t.Setenv("CURSOR_SECRET", "short")
+	_, err = utils.NewCursorSignerFromEnv()
+	assert.Error(t, err)
+
+	t.Setenv("CURSOR_SECRET", "")
+	generated, err := utils.NewCursorSignerFromEnv()
+	require.NoError(t, err)
+	assert.ErrorIs(t, generated.Decode(cursor, &decoded), models.ErrInvalidCursor, "случайный секрет")
+}
+
+func TestPagination2_KeysetQueryFollowsSortOrder(t *testing.T) {
+	repo, recorder := dryRunUserRepository(t)
+	filter := &models.UserFilter{Sort: []models.UserSortField{{Field: "age", Desc: true}, {Field: "name"}}}
+	boundary := models.User{ID: 7, Name: "Bob", Age: 30}
+
+	_, err := repo.GetPage(&models.UserKeyset{Boundary: boundary}, 11, filter)
+	require.NoError(t, err)
+	_, err = repo.GetPage(&models.UserKeyset{Boundary: boundary, Backward: true}, 11, filter)
+	require.NoError(t, err)
+	require.Len(t, recorder.queries, 2)
+
+	forward, backward := recorder.queries[0], recorder.queries[1]
+	assert.Contains(t, forward, "((age < 30) OR (age = 30 AND name > 'Bob') OR (age = 30 AND name = 'Bob' AND id > 7))")
+	assert.Contains(t, forward, "ORDER BY age DESC, name ASC, id ASC LIMIT 11")
+	assert.NotContains(t, forward, "OFFSET")
+	assert.Contains(t, backward, "((age > 30) OR (age = 30 AND name < 'Bob') OR (age = 30 AND name = 'Bob' AND id < 7))")
+	assert.Contains(t, backward, "ORDER BY age ASC, name DESC, id DESC LIMIT 11")
+}
+
+func TestPagination3_CursorsWalkPagesWithoutDuplicatesOnInsert(t *testing.T) {
+	tag := fmt.Sprintf("keyset%d", time.Now().UnixNano())
+	ids, token := createTaggedUsers(t, tag, 5)
+	list := baseURL + "/users?search=" + tag + "&sort=-id&limit=2"
+
+	// Первая страница выбирается по номеру и уже содержит курсор следующей
+	var first models.UsersListResponse
+	require.Equal(t, http.StatusOK, getJSON(t, list, token, &first))
+	assert.Equal(t, []uint{ids[4], ids[3]}, userIDs(first.Users))
+	require.NotNil(t, first.Total)
+	assert.Equal(t, int64(5), *first.Total)
+	assert.Empty(t, first.PrevCursor)
+	require.NotEmpty(t, first.NextCursor)
+
+	// Новый пользователь встает перед границей, но не сдвигает следующую страницу, в отличие от OFFSET
+	inserted, _ := createTaggedUsers(t, tag, 1)
+
+	var second models.UsersListResponse
+	require.Equal(t, http.StatusOK, getJSON(t, list+"&cursor="+url.QueryEscape(first.NextCursor), token, &second))
+	assert.Equal(t, []uint{ids[2], ids[1]}, userIDs(second.Users))
+	assert.Zero(t, second.Page)
+	assert.Nil(t, second.Total, "total подсчитывается только по запросу")
+
+	var last models.UsersListResponse
+	require.Equal(t, http.StatusOK, getJSON(t, list+"&include_total=true&cursor="+url.QueryEscape(second.NextCursor), token, &last))
+	assert.Equal(t, []uint{ids[0]}, userIDs(last.Users))
+	assert.Empty(t, last.NextCursor)
+	require.NotNil(t, last.Total)
+	assert.Equal(t, int64(6), *last.Total)
+
+	// Назад от второй страницы: вставленный пользователь попадает на предыдущую страницу
+	var back models.UsersListResponse
+	require.Equal(t, http.StatusOK, getJSON(t, list+"&cursor="+url.QueryEscape(second.PrevCursor), token, &back))
+	assert.Equal(t, []uint{ids[4], ids[3]}, userIDs(back.Users))
+	assert.NotEmpty(t, back.PrevCursor, "перед страницей остался вставленный пользователь")
+
+	var start models.UsersListResponse
+	require.Equal(t, http.StatusOK, getJSON(t, list+"&cursor="+url.QueryEscape(back.PrevCursor), token, &start))
+	assert.Equal(t, inserted, userIDs(start.Users))
+	assert.Empty(t, start.PrevCursor)
+	assert.NotEmpty(t, start.NextCursor)
+}
+
+func TestPagination4_CursorBoundToFiltersAndSort(t *testing.T) {
+	tag := fmt.Sprintf("keyset%d", time.Now().UnixNano())
+	ids, token := createTaggedUsers(t, tag, 3)
+	list := baseURL + "/users?search=" + tag
+
+	var first models.UsersListResponse
+	require.Equal(t, http.StatusOK, getJSON(t, list+"&limit=2", token, &first))
+	cursor := url.QueryEscape(first.NextCursor)
+
+	var page models.UsersListResponse
+	assert.Equal(t, http.StatusOK, getJSON(t, list+"&limit=4&sort=id&cursor="+cursor, token, &page), "limit можно менять")
+	assert.Equal(t, http.StatusBadRequest, getJSON(t, list+"&limit=2&sort=-age&cursor="+cursor, token, &page))
+	assert.Equal(t, http.StatusBadRequest, getJSON(t, baseURL+"/users?search=bob&limit=2&cursor="+cursor, token, &page))
+	assert.Equal(t, http.StatusBadRequest, getJSON(t, list+"&limit=2&cursor=x"+cursor, token, &page))
+	assert.Equal(t, http.StatusBadRequest, getJSON(t, list+"&limit=2&include_total=maybe&cursor="+cursor, token, &page))
+
+	// Курсор заказов одного пользователя не принимается ни для списка пользователей, ни для заказов другого
+	owner, ownerToken := createTestUser(t)
+	defer deleteTestUser(t, owner.ID, ownerToken)
+	for i := 0; i < 2; i++ {
+		createTestOrder(t, owner.ID, ownerToken, map[string]interface{}{"product": "Laptop", "quantity": 1, "price": 10.0})
+	}
+
+	var ordersPage models.OrdersListResponse
+	require.Equal(t, http.StatusOK, getJSON(t, fmt.Sprintf("%s/users/%d/orders?limit=1", baseURL, owner.ID), ownerToken, &ordersPage))
+	require.NotEmpty(t, ordersPage.NextCursor)
+	ordersCursor := url.QueryEscape(ordersPage.NextCursor)
+	assert.Equal(t, http.StatusBadRequest, getJSON(t, baseURL+"/users?cursor="+ordersCursor, ownerToken, &page))
+	assert.Equal(t, http.StatusBadRequest, getJSON(t, fmt.Sprintf("%s/users/%d/orders?cursor=%s", baseURL, ids[2], ordersCursor), token, &ordersPage))
+}
+
+func TestPagination5_OrdersPagedOnlyOnRequest(t *testing.T) {
+	user, token := createTestUser(t)
+	defer deleteTestUser(t, user.ID, token)
+	for i := 0; i < 5; i++ {
+		createTestOrder(t, user.ID, token, map[string]interface{}{"product": "Laptop", "quantity": 1, "price": 10.0})
+	}
+	orders := fmt.Sprintf("%s/users/%d/orders", baseURL, user.ID)
+
+	var all []models.OrderResponse
+	require.Equal(t, http.StatusOK, getJSON(t, orders, token, &all), "без параметров - прежний массив")
+	require.Len(t, all, 5)
+	var expected []uint
+	for _, order := range all {
+		expected = append(expected, order.ID)
+	}
+
+	var ids []uint
+	var page models.OrdersListResponse
+	path := orders + "?limit=2&include_total=true"
+	for pages := 0; path != ""; pages++ {
+		require.Less(t, pages, 5)
+		page = models.OrdersListResponse{}
+		require.Equal(t, http.StatusOK, getJSON(t, path, token, &page))
+		require.NotNil(t, page.Total)
+		assert.Equal(t, int64(5), *page.Total)
+		for _, order := range page.Orders {
+			ids = append(ids, order.ID)
+		}
+		path = ""
+		if page.NextCursor != "" {
+			path = orders + "?limit=2&include_total=true&cursor=" + url.QueryEscape(page.NextCursor)
+		}
+	}
+	assert.Equal(t, expected, ids)
+
+	var back models.OrdersListResponse
+	require.Equal(t, http.StatusOK, getJSON(t, orders+"?limit=2&cursor="+url.QueryEscape(page.PrevCursor), token, &back))
+	require.Len(t, back.Orders, 2)
+	assert.Equal(t, expected[2], back.Orders[0].ID)
+	assert.Equal(t, expected[3], back.Orders[1].ID)
+	assert.Nil(t, back.Total)
+
+	assert.Equal(t, http.StatusBadRequest, getJSON(t, orders+"?limit=101", token, &page))
+	assert.Equal(t, http.StatusBadRequest, getJSON(t, orders+"?limit=0", token, &page))
+}
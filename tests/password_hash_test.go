@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"khrllwTest/internal/utils"
+	"strings"
+	"testing"
+)
+
+var testArgon2Params = utils.Argon2Params{
+	Memory:      19 * 1024,
+	Time:        2,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func TestPasswordHash1_Argon2idRoundTrip(t *testing.T) {
+	hasher := utils.NewArgon2Hasher(testArgon2Params)
+
+	hash, err := hasher.Hash("testpassword")
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=19456,t=2,p=1$"))
+	assert.True(t, hasher.Check("testpassword", hash))
+	assert.False(t, hasher.Check("wrongpassword", hash))
+	assert.False(t, hasher.NeedsRehash(hash))
+}
+
+func TestPasswordHash2_BcryptHashNeedsRehash(t *testing.T) {
+	legacy, err := utils.NewPasswordHasher(4).Hash("testpassword")
+	require.NoError(t, err)
+
+	hasher := utils.NewArgon2Hasher(testArgon2Params)
+	assert.True(t, hasher.Check("testpassword", legacy))
+	assert.True(t, hasher.NeedsRehash(legacy))
+}
+
+func TestPasswordHash3_WeakerArgon2ParamsNeedRehash(t *testing.T) {
+	weak := testArgon2Params
+	weak.Memory = 8 * 1024
+	weak.Time = 1
+
+	hash, err := utils.NewArgon2Hasher(weak).Hash("testpassword")
+	require.NoError(t, err)
+
+	hasher := utils.NewArgon2Hasher(testArgon2Params)
+	assert.True(t, hasher.Check("testpassword", hash))
+	assert.True(t, hasher.NeedsRehash(hash))
+
+	// Более сильные параметры пересчета не требуют
+	assert.False(t, utils.NewArgon2Hasher(weak).NeedsRehash(hash))
+}
+
+func TestPasswordHash4_MalformedHashIsRejected(t *testing.T) {
+	hasher := utils.NewArgon2Hasher(testArgon2Params)
+
+	for _, hash := range []string{
+		"",
+		"$argon2id$v=19$m=19456,t=2,p=1$",
+		"$argon2id$v=18$m=19456,t=2,p=1$c2FsdA$aGFzaA",
+		"$argon2id$v=19$m=19456,t=0,p=1$c2FsdA$aGFzaA",
+	} {
+		assert.False(t, hasher.Check("testpassword", hash), hash)
+		assert.True(t, hasher.NeedsRehash(hash), hash)
+	}
+}
@@ -0,0 +1,165 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"khrllwTest/internal/models"
+	service "khrllwTest/internal/services"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type PasswordPolicyError struct {
+	Error      string `json:"error"`
+	Violations []struct {
+		Rule    string `json:"rule"`
+		Message string `json:"message"`
+	} `json:"violations"`
+}
+
+func decodePolicyViolations(t *testing.T, resp *http.Response) []string {
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	var body PasswordPolicyError
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+
+	rules := make([]string, 0, len(body.Violations))
+	for _, violation := range body.Violations {
+		assert.NotEmpty(t, violation.Message)
+		rules = append(rules, violation.Rule)
+	}
+	return rules
+}
+
+func changePassword(t *testing.T, userID int, token, current, next string) *http.Response {
+	return doRequest(t, "PUT", fmt.Sprintf("%s/users/%d/password", baseURL, userID), token, map[string]string{
+		"current_password": current,
+		"new_password":     next,
+	})
+}
+
+func TestPasswordPolicy1_ListsEveryViolation(t *testing.T) {
+	payload := map[string]interface{}{
+		"name":     "Policy Tester",
+		"email":    "policytester_" + randomEmail(),
+		"age":      30,
+		"password": "policy",
+	}
+	resp := doRequest(t, "POST", baseURL+"/users", "", payload)
+	defer resp.Body.Close()
+
+	rules := decodePolicyViolations(t, resp)
+	assert.Contains(t, rules, "min_length")
+	assert.Contains(t, rules, "personal_info")
+}
+
+func TestPasswordPolicy2_PasswordWithEmailRejected(t *testing.T) {
+	email := randomEmail()
+	payload := map[string]interface{}{
+		"name":     "Test User",
+		"email":    email,
+		"age":      30,
+		"password": "x" + email,
+	}
+	resp := doRequest(t, "POST", baseURL+"/users", "", payload)
+	defer resp.Body.Close()
+
+	assert.Equal(t, []string{"personal_info"}, decodePolicyViolations(t, resp))
+}
+
+func TestPasswordPolicy3_PreviousPasswordCannotBeReused(t *testing.T) {
+	user, token := createTestUser(t)
+
+	resp := changePassword(t, user.ID, token, "testpassword", "firstnewpassword")
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	user.Password = "firstnewpassword"
+	auth := loginTestUser(t, user)
+
+	reused := changePassword(t, user.ID, auth.Token, "firstnewpassword", "testpassword")
+	defer reused.Body.Close()
+	assert.Equal(t, []string{"reused"}, decodePolicyViolations(t, reused))
+
+	deleteTestUser(t, user.ID, auth.Token)
+}
+
+func TestPasswordPolicy4_ChangePasswordTooShort(t *testing.T) {
+	user, token := createTestUser(t)
+	defer deleteTestUser(t, user.ID, token)
+
+	resp := changePassword(t, user.ID, token, "testpassword", "short")
+	defer resp.Body.Close()
+
+	assert.Contains(t, decodePolicyViolations(t, resp), "min_length")
+}
+
+// breachedRule возвращает, отклонен ли пароль политикой как утекший
+func breachedRule(t *testing.T, policy *service.PasswordPolicy, password string) bool {
+	var policyErr *models.PasswordPolicyError
+	err := policy.Validate(password, nil)
+	if err == nil {
+		return false
+	}
+	require.True(t, errors.As(err, &policyErr), err)
+	for _, violation := range policyErr.Violations {
+		if violation.Rule == models.PasswordRuleBreached {
+			return true
+		}
+	}
+	return false
+}
+
+func TestPasswordPolicy5_BlocklistRangeFiles(t *testing.T) {
+	// SHA-1("password") = 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8, SHA-1("letmein") = B7A875FC1EA228B9061041B7CEC4BD3C52AB3CE3
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "5BAA6.txt"),
+		[]byte("003D68EB55068C33ACE09247EE4C639306B:3\r\n1E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824\r\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "B7A87.txt"),
+		[]byte("5FC1EA228B9061041B7CEC4BD3C52AB3CE3:0\r\n"), 0o644))
+
+	config := &service.PasswordPolicyConfig{MinLength: 1, MaxLength: 128, BlocklistFile: dir}
+	policy, err := service.NewPasswordPolicy(config, nil, nil)
+	require.NoError(t, err)
+	assert.True(t, breachedRule(t, policy, "password"))
+	assert.False(t, breachedRule(t, policy, "letmein"), "запись дополнения с нулевым числом утечек")
+	assert.False(t, breachedRule(t, policy, "correct horse battery staple"), "файла диапазона нет")
+
+	// Отдельный файл диапазона: префикс берется из имени файла
+	config.BlocklistFile = filepath.Join(dir, "5BAA6.txt")
+	policy, err = service.NewPasswordPolicy(config, nil, nil)
+	require.NoError(t, err)
+	assert.True(t, breachedRule(t, policy, "password"))
+
+	config.BlocklistFile = t.TempDir()
+	_, err = service.NewPasswordPolicy(config, nil, nil)
+	assert.Error(t, err, "каталог без файлов диапазонов")
+}
+
+func TestPasswordPolicy6_BlocklistLowercaseRangeFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00000.txt"), []byte("0005AD76BD555C1D6D771DE417A4B87E4B4:10\r\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "5baa6.txt"),
+		[]byte("1e4c9b93f3f0682250b6cf8331b7ee68fd8:9545824\r\n"), 0o644))
+
+	policy, err := service.NewPasswordPolicy(&service.PasswordPolicyConfig{MinLength: 1, MaxLength: 128, BlocklistFile: dir}, nil, nil)
+	require.NoError(t, err)
+	assert.True(t, breachedRule(t, policy, "password"), "имена файлов диапазонов в нижнем регистре")
+	assert.False(t, breachedRule(t, policy, "letmein"))
+}
+
+func TestPasswordPolicy7_HistoryCanBeDisabled(t *testing.T) {
+	t.Setenv("PASSWORD_HISTORY_SIZE", "0")
+	config, err := service.NewPasswordPolicyConfig()
+	require.NoError(t, err)
+	assert.Equal(t, 0, config.HistorySize)
+
+	t.Setenv("PASSWORD_HISTORY_SIZE", "-1")
+	_, err = service.NewPasswordPolicyConfig()
+	assert.Error(t, err)
+}
@@ -0,0 +1,48 @@
+package tests
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestPasswordReset1_ForgotKnownEmail(t *testing.T) {
+	user, token := createTestUser(t)
+	defer deleteTestUser(t, user.ID, token)
+
+	resp := doRequest(t, "POST", baseURL+"/auth/password/forgot", "", map[string]string{
+		"email": user.Email,
+	})
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+}
+
+func TestPasswordReset2_ForgotUnknownEmailLooksTheSame(t *testing.T) {
+	resp := doRequest(t, "POST", baseURL+"/auth/password/forgot", "", map[string]string{
+		"email": randomEmail(),
+	})
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+}
+
+func TestPasswordReset3_ResetWithInvalidToken(t *testing.T) {
+	resp := doRequest(t, "POST", baseURL+"/auth/password/reset", "", map[string]string{
+		"token":    "invalid-reset-token",
+		"password": "newtestpassword",
+	})
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestPasswordReset4_ResetWithShortPassword(t *testing.T) {
+	resp := doRequest(t, "POST", baseURL+"/auth/password/reset", "", map[string]string{
+		"token":    "invalid-reset-token",
+		"password": "short",
+	})
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+type Session struct {
+	ID         string `json:"id"`
+	IPAddress  string `json:"ip_address"`
+	UserAgent  string `json:"user_agent"`
+	CreatedAt  string `json:"created_at"`
+	LastSeenAt string `json:"last_seen_at"`
+	Current    bool   `json:"current"`
+}
+
+func getSessions(t *testing.T, userID int, token string) []Session {
+	resp := doRequest(t, "GET", fmt.Sprintf("%s/users/%d/sessions", baseURL, userID), token, nil)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var sessions []Session
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&sessions))
+	return sessions
+}
+
+func TestSession1_ListActiveSessions(t *testing.T) {
+	user, token := createTestUser(t)
+	defer deleteTestUser(t, user.ID, token)
+
+	user.Password = "testpassword"
+	auth := loginTestUser(t, user)
+
+	sessions := getSessions(t, user.ID, auth.Token)
+	require.Len(t, sessions, 2)
+
+	current := 0
+	for _, session := range sessions {
+		assert.NotEmpty(t, session.ID)
+		assert.NotEmpty(t, session.IPAddress)
+		assert.NotEmpty(t, session.UserAgent)
+		assert.NotEmpty(t, session.LastSeenAt)
+		if session.Current {
+			current++
+		}
+	}
+	assert.Equal(t, 1, current)
+}
+
+func TestSession2_RevokeSessionRejectsItsTokens(t *testing.T) {
+	user, token := createTestUser(t)
+	defer deleteTestUser(t, user.ID, token)
+
+	user.Password = "testpassword"
+	other := loginTestUser(t, user)
+
+	// Сессия other - единственная, не являющаяся текущей для token
+	var otherID string
+	for _, session := range getSessions(t, user.ID, token) {
+		if !session.Current && otherID == "" {
+			otherID = session.ID
+		}
+	}
+	require.NotEmpty(t, otherID)
+
+	resp := doRequest(t, "DELETE", fmt.Sprintf("%s/users/%d/sessions/%s", baseURL, user.ID, otherID), token, nil)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	after := doRequest(t, "GET", fmt.Sprintf("%s/users/%d", baseURL, user.ID), other.Token, nil)
+	defer after.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, after.StatusCode)
+
+	refresh := refreshTokens(t, other.RefreshToken)
+	defer refresh.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, refresh.StatusCode)
+
+	// Текущая сессия продолжает работать
+	for _, session := range getSessions(t, user.ID, token) {
+		assert.NotEqual(t, otherID, session.ID)
+	}
+}
+
+func TestSession3_RevokeUnknownSession(t *testing.T) {
+	user, token := createTestUser(t)
+	defer deleteTestUser(t, user.ID, token)
+
+	resp := doRequest(t, "DELETE", fmt.Sprintf("%s/users/%d/sessions/unknown-session", baseURL, user.ID), token, nil)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestSession4_CannotListForeignSessions(t *testing.T) {
+	owner, ownerToken := createTestUser(t)
+	defer deleteTestUser(t, owner.ID, ownerToken)
+
+	intruder, intruderToken := createTestUser(t)
+	defer deleteTestUser(t, intruder.ID, intruderToken)
+
+	resp := doRequest(t, "GET", fmt.Sprintf("%s/users/%d/sessions", baseURL, owner.ID), intruderToken, nil)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestSession5_LogoutEndsSession(t *testing.T) {
+	user, token := createTestUser(t)
+	defer deleteTestUser(t, user.ID, token)
+
+	user.Password = "testpassword"
+	other := loginTestUser(t, user)
+
+	resp := doRequest(t, "POST", baseURL+"/auth/logout", other.Token, map[string]string{})
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	sessions := getSessions(t, user.ID, token)
+	require.Len(t, sessions, 1)
+	assert.True(t, sessions[0].Current)
+}
@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"khrllwTest/internal/models"
+	"khrllwTest/internal/utils"
+	"testing"
+	"time"
+)
+
+const claimsTestKey = "claims-test-signing-key"
+
+func claimsTestManager() utils.TokenManager {
+	return utils.NewTokenManager(&utils.JWTConfig{
+		JWTKey:        claimsTestKey,
+		SigningMethod: utils.SigningMethodHS256,
+		JWTExpiration: 15 * time.Minute,
+		Issuer:        "khrllwTest",
+		Audience:      "khrllwTest-api",
+		Leeway:        30 * time.Second,
+	})
+}
+
+// signClaims подписывает токен с корректными полями, измененными функцией mutate
+func signClaims(t *testing.T, mutate func(claims jwt.MapClaims)) string {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"user_id": 42,
+		"role":    "user",
+		"ver":     0,
+		"jti":     "test-jti",
+		"iss":     "khrllwTest",
+		"aud":     []string{"khrllwTest-api"},
+		"sub":     "42",
+		"iat":     now.Unix(),
+		"nbf":     now.Unix(),
+		"exp":     now.Add(time.Hour).Unix(),
+	}
+	mutate(claims)
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(claimsTestKey))
+	require.NoError(t, err)
+	return token
+}
+
+func TestTokenClaims1_GeneratedTokenCarriesRegisteredClaims(t *testing.T) {
+	manager := claimsTestManager()
+
+	raw, err := manager.Generate(utils.TokenSubject{UserID: 42, Role: models.RoleUser})
+	require.NoError(t, err)
+
+	token, err := manager.Parse(raw)
+	require.NoError(t, err)
+
+	claims, err := manager.ExtractClaims(token)
+	require.NoError(t, err)
+	assert.Equal(t, "khrllwTest", claims.Issuer)
+	assert.Equal(t, jwt.ClaimStrings{"khrllwTest-api"}, claims.Audience)
+	assert.Equal(t, "42", claims.Subject)
+	assert.NotEmpty(t, claims.ID)
+	assert.NotNil(t, claims.IssuedAt)
+	assert.NotNil(t, claims.NotBefore)
+}
+
+func TestTokenClaims2_EachViolationHasDistinctError(t *testing.T) {
+	manager := claimsTestManager()
+	now := time.Now()
+
+	cases := []struct {
+		name   string
+		mutate func(claims jwt.MapClaims)
+		want   error
+	}{
+		{"wrong issuer", func(c jwt.MapClaims) { c["iss"] = "other-service" }, models.ErrTokenInvalidIssuer},
+		{"wrong audience", func(c jwt.MapClaims) { c["aud"] = []string{"other-api"} }, models.ErrTokenInvalidAudience},
+		{"subject mismatch", func(c jwt.MapClaims) { c["sub"] = "7" }, models.ErrTokenInvalidSubject},
+		{"future iat", func(c jwt.MapClaims) { c["iat"] = now.Add(time.Hour).Unix() }, models.ErrTokenIssuedInFuture},
+		{"future nbf", func(c jwt.MapClaims) { c["nbf"] = now.Add(time.Hour).Unix() }, models.ErrTokenNotYetValid},
+		{"expired", func(c jwt.MapClaims) { c["exp"] = now.Add(-time.Hour).Unix() }, models.ErrTokenExpired},
+		{"missing iat", func(c jwt.MapClaims) { delete(c, "iat") }, models.ErrTokenMissingClaims},
+		{"missing issuer", func(c jwt.MapClaims) { delete(c, "iss") }, models.ErrTokenMissingClaims},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := manager.Parse(signClaims(t, tc.mutate))
+			assert.ErrorIs(t, err, tc.want)
+		})
+	}
+}
+
+func TestTokenClaims3_ClockSkewWithinLeewayAccepted(t *testing.T) {
+	manager := claimsTestManager()
+	skewed := time.Now().Add(10 * time.Second).Unix()
+
+	_, err := manager.Parse(signClaims(t, func(c jwt.MapClaims) {
+		c["iat"] = skewed
+		c["nbf"] = skewed
+	}))
+	assert.NoError(t, err)
+}
+
+func TestTokenClaims4_InvalidSignatureRejected(t *testing.T) {
+	token := signClaims(t, func(jwt.MapClaims) {})
+
+	_, err := claimsTestManager().Parse(token[:len(token)-2] + "xx")
+	assert.ErrorIs(t, err, models.ErrTokenSignatureInvalid)
+}
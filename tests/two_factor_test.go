@@ -0,0 +1,209 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"khrllwTest/internal/models"
+	"khrllwTest/internal/repository"
+	service "khrllwTest/internal/services"
+	"khrllwTest/internal/utils"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type TwoFactorEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type TwoFactorLoginResponse struct {
+	AuthResponse
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+}
+
+// noRecoveryCodes хранилище кодов восстановления, в котором нет ни одного кода
+type noRecoveryCodes struct {
+	repository.RecoveryCodeRepository
+}
+
+func (noRecoveryCodes) Use(uint, string) (bool, error) {
+	return false, nil
+}
+
+func currentTOTP(t *testing.T, secret string) string {
+	code, err := utils.GenerateTOTPCode(secret, time.Now())
+	require.NoError(t, err)
+	return code
+}
+
+// enableTwoFactor подключает 2FA и возвращает секрет и коды восстановления
+func enableTwoFactor(t *testing.T, userID int, token string) (string, []string) {
+	resp := doRequest(t, "POST", fmt.Sprintf("%s/users/%d/2fa/enroll", baseURL, userID), token, nil)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var enrollment TwoFactorEnrollResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&enrollment))
+
+	confirm := doRequest(t, "POST", fmt.Sprintf("%s/users/%d/2fa/confirm", baseURL, userID), token,
+		map[string]string{"code": currentTOTP(t, enrollment.Secret)})
+	defer confirm.Body.Close()
+	require.Equal(t, http.StatusOK, confirm.StatusCode)
+
+	var codes struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	require.NoError(t, json.NewDecoder(confirm.Body).Decode(&codes))
+
+	return enrollment.Secret, codes.RecoveryCodes
+}
+
+func loginFirstStep(t *testing.T, user User) TwoFactorLoginResponse {
+	resp := doRequest(t, "POST", baseURL+"/auth/login", "", userToLoginPayload(user))
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var login TwoFactorLoginResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&login))
+	return login
+}
+
+func loginSecondStep(t *testing.T, challenge, code string) *http.Response {
+	return doRequest(t, "POST", baseURL+"/auth/login/2fa", "", map[string]string{
+		"challenge_token": challenge,
+		"code":            code,
+	})
+}
+
+func TestTwoFactor1_EnrollReturnsOTPAuthURI(t *testing.T) {
+	user, token := createTestUser(t)
+	defer deleteTestUser(t, user.ID, token)
+
+	resp := doRequest(t, "POST", fmt.Sprintf("%s/users/%d/2fa/enroll", baseURL, user.ID), token, nil)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var enrollment TwoFactorEnrollResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&enrollment))
+
+	assert.NotEmpty(t, enrollment.Secret)
+	assert.True(t, strings.HasPrefix(enrollment.OTPAuthURI, "otpauth://totp/"))
+	assert.Contains(t, enrollment.OTPAuthURI, "secret="+enrollment.Secret)
+}
+
+func TestTwoFactor2_ConfirmWithInvalidCode(t *testing.T) {
+	user, token := createTestUser(t)
+	defer deleteTestUser(t, user.ID, token)
+
+	enroll := doRequest(t, "POST", fmt.Sprintf("%s/users/%d/2fa/enroll", baseURL, user.ID), token, nil)
+	enroll.Body.Close()
+	require.Equal(t, http.StatusOK, enroll.StatusCode)
+
+	resp := doRequest(t, "POST", fmt.Sprintf("%s/users/%d/2fa/confirm", baseURL, user.ID), token,
+		map[string]string{"code": "000000"})
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestTwoFactor3_LoginRequiresSecondStep(t *testing.T) {
+	user, token := createTestUser(t)
+	defer deleteTestUser(t, user.ID, token)
+
+	secret, _ := enableTwoFactor(t, user.ID, token)
+
+	user.Password = "testpassword"
+	login := loginFirstStep(t, user)
+	require.True(t, login.TwoFactorRequired)
+	require.NotEmpty(t, login.ChallengeToken)
+	assert.Empty(t, login.Token)
+
+	// Challenge токен не дает доступа к API
+	denied := doRequest(t, "GET", fmt.Sprintf("%s/users/%d", baseURL, user.ID), login.ChallengeToken, nil)
+	defer denied.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, denied.StatusCode)
+
+	resp := loginSecondStep(t, login.ChallengeToken, currentTOTP(t, secret))
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var auth AuthResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&auth))
+	assert.NotEmpty(t, auth.Token)
+	assert.NotEmpty(t, auth.RefreshToken)
+
+	// Challenge токен одноразовый
+	reused := loginSecondStep(t, login.ChallengeToken, currentTOTP(t, secret))
+	defer reused.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, reused.StatusCode)
+}
+
+func TestTwoFactor4_RecoveryCodeIsSingleUse(t *testing.T) {
+	user, token := createTestUser(t)
+	defer deleteTestUser(t, user.ID, token)
+
+	_, recoveryCodes := enableTwoFactor(t, user.ID, token)
+	require.NotEmpty(t, recoveryCodes)
+
+	user.Password = "testpassword"
+
+	first := loginSecondStep(t, loginFirstStep(t, user).ChallengeToken, recoveryCodes[0])
+	defer first.Body.Close()
+	assert.Equal(t, http.StatusOK, first.StatusCode)
+
+	second := loginSecondStep(t, loginFirstStep(t, user).ChallengeToken, recoveryCodes[0])
+	defer second.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, second.StatusCode)
+}
+
+func TestTwoFactor5_DisableRestoresPasswordLogin(t *testing.T) {
+	user, token := createTestUser(t)
+	defer deleteTestUser(t, user.ID, token)
+
+	secret, _ := enableTwoFactor(t, user.ID, token)
+
+	resp := doRequest(t, "POST", fmt.Sprintf("%s/users/%d/2fa/disable", baseURL, user.ID), token,
+		map[string]string{"code": currentTOTP(t, secret)})
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	user.Password = "testpassword"
+	auth := loginTestUser(t, user)
+	assert.NotEmpty(t, auth.Token)
+}
+
+func TestTwoFactor6_TOTPCodeAcceptedOnce(t *testing.T) {
+	secret, err := utils.GenerateTOTPSecret()
+	require.NoError(t, err)
+	users := newMemoryUserRepo(models.User{ID: 7, TOTPSecret: secret, TOTPEnabled: true})
+	twoFactor := service.NewTwoFactorService(users, noRecoveryCodes{}, "khrllwTest")
+
+	previous, err := utils.GenerateTOTPCode(secret, time.Now().Add(-30*time.Second))
+	require.NoError(t, err)
+	current := currentTOTP(t, secret)
+
+	// Пользователь, прочитанный параллельным запросом до принятия кода
+	stale, err := users.FindByID(7)
+	require.NoError(t, err)
+
+	verify := func(user *models.User, code string) bool {
+		valid, err := twoFactor.Verify(user, code)
+		require.NoError(t, err)
+		return valid
+	}
+
+	fresh := func() *models.User {
+		user, err := users.FindByID(7)
+		require.NoError(t, err)
+		return user
+	}
+
+	assert.True(t, verify(fresh(), current))
+	assert.False(t, verify(fresh(), current), "код уже использован")
+	assert.False(t, verify(stale, current), "код принимается один раз и при параллельных запросах")
+	assert.False(t, verify(fresh(), previous), "код более раннего шага")
+}
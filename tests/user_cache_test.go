@@ -0,0 +1,181 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"khrllwTest/internal/models"
+	"khrllwTest/internal/repository"
+	service "khrllwTest/internal/services"
+)
+
+func userCacheForTest(size int, ttl, negativeTTL time.Duration) *repository.UserCache {
+	return repository.NewUserCache(&repository.UserCacheConfig{Size: size, TTL: ttl, NegativeTTL: negativeTTL})
+}
+
+func TestUserCache1_HitsMissesAndCopies(t *testing.T) {
+	inner := newMemoryUserRepo(models.User{ID: 1, Name: "Alice", Role: models.RoleUser})
+	cache := userCacheForTest(10, time.Minute, time.Minute)
+	repo := repository.NewCachedUserRepository(inner, cache)
+
+	user, err := repo.FindByID(1)
+	require.NoError(t, err)
+	user.Name = "changed by caller"
+
+	for i := 0; i < 3; i++ {
+		user, err = repo.FindByID(1)
+		require.NoError(t, err)
+		assert.Equal(t, "Alice", user.Name, "кеш хранит копию")
+	}
+
+	assert.Equal(t, 1, inner.reads)
+	stats := cache.Stats()
+	assert.Equal(t, uint64(3), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.Equal(t, 1, stats.Size)
+	assert.Equal(t, 10, stats.Capacity)
+}
+
+func TestUserCache2_NegativeCachingAndCreate(t *testing.T) {
+	inner := newMemoryUserRepo()
+	cache := userCacheForTest(10, time.Minute, time.Minute)
+	repo := repository.NewCachedUserRepository(inner, cache)
+	writer := repository.NewInvalidatingUserRepository(inner, cache)
+
+	for i := 0; i < 3; i++ {
+		_, err := repo.FindByID(7)
+		assert.ErrorIs(t, err, models.ErrUserNotFound)
+	}
+	assert.Equal(t, 1, inner.reads, "отсутствие пользователя кешируется")
+	assert.Equal(t, uint64(2), cache.Stats().NegativeHits)
+
+	require.NoError(t, writer.Create(&models.User{ID: 7, Name: "Bob"}))
+	user, err := repo.FindByID(7)
+	require.NoError(t, err)
+	assert.Equal(t, "Bob", user.Name, "создание сбрасывает отметку 'не найден'")
+}
+
+func TestUserCache3_WritesInvalidate(t *testing.T) {
+	inner := newMemoryUserRepo(models.User{ID: 1, Role: models.RoleUser})
+	cache := userCacheForTest(10, time.Hour, time.Hour)
+	repo := repository.NewCachedUserRepository(inner, cache)
+	writer := repository.NewInvalidatingUserRepository(inner, cache)
+
+	_, err := repo.FindByID(1)
+	require.NoError(t, err)
+
+	// "Выход везде" через репозиторий сервисов сразу виден middleware
+	require.NoError(t, writer.IncrementTokenVersion(1))
+	user, err := repo.FindByID(1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, user.TokenVersion)
+
+	// Сервисы читают из БД, минуя кеш
+	readsBefore := inner.reads
+	_, err = writer.FindByID(1)
+	require.NoError(t, err)
+	assert.Equal(t, readsBefore+1, inner.reads)
+
+	require.NoError(t, writer.Delete(1, 0))
+	_, err = repo.FindByID(1)
+	assert.ErrorIs(t, err, models.ErrUserNotFound, "удаленный пользователь не остается в кеше")
+	assert.Equal(t, uint64(2), cache.Stats().Invalidations)
+}
+
+func TestUserCache4_TTLAndLRUEviction(t *testing.T) {
+	inner := newMemoryUserRepo(models.User{ID: 1}, models.User{ID: 2}, models.User{ID: 3})
+	cache := userCacheForTest(2, 50*time.Millisecond, time.Minute)
+	repo := repository.NewCachedUserRepository(inner, cache)
+
+	for _, id := range []uint{1, 2, 1, 3} {
+		_, err := repo.FindByID(id)
+		require.NoError(t, err)
+	}
+	// Емкость 2: вытеснен давно не использованный пользователь 2, а не 1
+	assert.Equal(t, uint64(1), cache.Stats().Evictions)
+	assert.Equal(t, 2, cache.Stats().Size)
+
+	reads := inner.reads
+	_, _ = repo.FindByID(1)
+	assert.Equal(t, reads, inner.reads)
+	_, _ = repo.FindByID(2)
+	assert.Equal(t, reads+1, inner.reads)
+
+	time.Sleep(60 * time.Millisecond)
+	reads = inner.reads
+	_, _ = repo.FindByID(2)
+	assert.Equal(t, reads+1, inner.reads, "истекшая запись загружается заново")
+}
+
+func TestUserCache5_ConfigFromEnvironment(t *testing.T) {
+	t.Setenv("USER_CACHE_SIZE", "")
+	t.Setenv("USER_CACHE_TTL", "")
+	t.Setenv("USER_CACHE_NEGATIVE_TTL", "")
+
+	config, err := repository.NewUserCacheConfig()
+	require.NoError(t, err)
+	assert.Equal(t, 10000, config.Size)
+	assert.Equal(t, 30*time.Second, config.TTL)
+	assert.Equal(t, 5*time.Second, config.NegativeTTL)
+
+	// Нулевой размер выключает кеш: возвращается исходный репозиторий
+	t.Setenv("USER_CACHE_SIZE", "0")
+	config, err = repository.NewUserCacheConfig()
+	require.NoError(t, err)
+	inner := newMemoryUserRepo()
+	assert.Same(t, repository.UserRepository(inner), repository.NewCachedUserRepository(inner, repository.NewUserCache(config)))
+
+	t.Setenv("USER_CACHE_SIZE", "-1")
+	_, err = repository.NewUserCacheConfig()
+	assert.Error(t, err)
+
+	t.Setenv("USER_CACHE_SIZE", "")
+	t.Setenv("USER_CACHE_TTL", "soon")
+	_, err = repository.NewUserCacheConfig()
+	assert.Error(t, err)
+}
+
+func TestUserCache6_UserServiceInvalidates(t *testing.T) {
+	inner := newMemoryUserRepo(models.User{ID: 1, Name: "Alice", Email: "alice@example.com", Age: 30, Role: models.RoleUser})
+	cache := userCacheForTest(10, time.Hour, time.Hour)
+	repo := repository.NewCachedUserRepository(inner, cache)
+
+	revocations := &revocationCounter{revoked: map[uint]int{}}
+	sessions := service.NewSessionService(countingSessionRepo{revocationCounter: revocations}, countingRefreshRepo{revocationCounter: revocations})
+	// Сервис работает с репозиторием без обертки: кеш сбрасывает он сам
+	userService := service.NewUserService(inner, sessions, nil, nil, nil, nil, nil, nil, cache)
+
+	_, err := repo.FindByID(1)
+	require.NoError(t, err)
+
+	_, err = userService.UpdateUser(1, &models.UpdateUserRequest{Name: "Alice Smith", Email: "alice@example.com", Age: 31}, 0)
+	require.NoError(t, err)
+	user, err := repo.FindByID(1)
+	require.NoError(t, err)
+	assert.Equal(t, "Alice Smith", user.Name, "изменение через сервис сразу видно middleware")
+
+	require.NoError(t, userService.DeleteUser(1, 0))
+	_, err = repo.FindByID(1)
+	assert.ErrorIs(t, err, models.ErrUserNotFound, "токены удаленного пользователя перестают приниматься сразу")
+	assert.Equal(t, uint64(2), cache.Stats().Invalidations)
+}
+
+func TestUserCache7_DeletedUserTokenRejectedImmediately(t *testing.T) {
+	user, token := createTestUser(t)
+	userURL := fmt.Sprintf("%s/users/%d", baseURL, user.ID)
+
+	// Первый запрос загружает пользователя в кеш middleware
+	resp := doRequest(t, "GET", userURL, token, nil)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	deleteTestUser(t, user.ID, token)
+
+	resp = doRequest(t, "GET", userURL, token, nil)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "удаление сбрасывает запись в кеше")
+}
@@ -0,0 +1,163 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"khrllwTest/internal/handlers"
+	"khrllwTest/internal/models"
+	service "khrllwTest/internal/services"
+)
+
+// newDeletionService создает сервис пользователей для проверок, недоступных через API без прав администратора
+func newDeletionService(repo *memoryUserRepo) *service.UserService {
+	deletion := &service.UserDeletionConfig{GracePeriod: deletionGracePeriod, PurgeInterval: time.Hour}
+	return service.NewUserService(repo, nil, nil, nil, nil, nil, deletion, nil, nil)
+}
+
+// deletedAgo помечает пользователя удаленным указанное время назад
+func deletedAgo(d time.Duration) gorm.DeletedAt {
+	return gorm.DeletedAt{Time: time.Now().Add(-d), Valid: true}
+}
+
+func TestUserDeletion1_DeleteHidesUserAndRevokesSessions(t *testing.T) {
+	user, token := createTestUser(t)
+	user.Password = "testpassword"
+	auth := loginTestUser(t, user)
+
+	deleteTestUser(t, user.ID, token)
+
+	resp := doRequest(t, "GET", fmt.Sprintf("%s/users/%d", baseURL, user.ID), auth.Token, nil)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "токены удаленного пользователя не принимаются")
+
+	resp = refreshTokens(t, auth.RefreshToken)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "refresh токены отозваны")
+
+	resp = doRequest(t, "POST", baseURL+"/auth/login", "", userToLoginPayload(user))
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "удаленный пользователь не может войти")
+
+	// Email удаленного пользователя можно зарегистрировать заново
+	resp = doRequest(t, "POST", baseURL+"/users", "", userToRegisterPayload(user))
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var registered User
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&registered))
+	assert.NotEqual(t, user.ID, registered.ID)
+	deleteTestUser(t, registered.ID, loginTestUser(t, user).Token)
+}
+
+func TestUserDeletion2_RestoreWithinGracePeriod(t *testing.T) {
+	users := newDeletionService(newMemoryUserRepo(
+		models.User{ID: 7, Email: "soft@example.com", TokenVersion: 3, DeletedAt: deletedAgo(time.Hour)},
+	))
+
+	user, err := users.RestoreUser(7)
+	require.NoError(t, err)
+	assert.Equal(t, uint(7), user.ID)
+	assert.False(t, user.DeletedAt.Valid)
+	assert.Equal(t, 4, user.TokenVersion, "токены, выданные до удаления, не оживают")
+
+	_, err = users.RestoreUser(7)
+	assert.ErrorIs(t, err, models.ErrUserNotFound, "активного пользователя восстановить нельзя")
+}
+
+func TestUserDeletion3_RestoreRejectedAfterGracePeriodOrEmailReuse(t *testing.T) {
+	repo := newMemoryUserRepo(
+		models.User{ID: 7, Email: "expired@example.com", DeletedAt: deletedAgo(deletionGracePeriod + time.Minute)},
+		models.User{ID: 8, Email: "taken@example.com", DeletedAt: deletedAgo(time.Hour)},
+		models.User{ID: 9, Email: "taken@example.com"},
+	)
+	users := newDeletionService(repo)
+
+	_, err := users.RestoreUser(7)
+	assert.ErrorIs(t, err, models.ErrUserRestoreExpired)
+
+	_, err = users.RestoreUser(8)
+	assert.ErrorIs(t, err, models.ErrEmailAlreadyExists, "email занят зарегистрированным после удаления пользователем")
+	assert.True(t, repo.users[8].DeletedAt.Valid)
+}
+
+func TestUserDeletion4_PurgeRemovesOnlyExpiredUsers(t *testing.T) {
+	repo := newMemoryUserRepo(
+		models.User{ID: 1, Email: "active@example.com"},
+		models.User{ID: 2, Email: "recent@example.com", DeletedAt: deletedAgo(time.Hour)},
+		models.User{ID: 3, Email: "expired@example.com", DeletedAt: deletedAgo(deletionGracePeriod + time.Minute)},
+	)
+
+	purge := service.NewUserPurgeService(repo, &service.UserDeletionConfig{GracePeriod: deletionGracePeriod, PurgeInterval: time.Hour})
+	purge.Purge()
+
+	assert.Contains(t, repo.users, uint(1))
+	assert.Contains(t, repo.users, uint(2), "срок восстановления не истек")
+	assert.NotContains(t, repo.users, uint(3))
+}
+
+func TestUserDeletion5_ConfigFromEnvironment(t *testing.T) {
+	t.Setenv("USER_DELETE_GRACE_PERIOD", "")
+	t.Setenv("USER_PURGE_INTERVAL", "")
+
+	config, err := service.NewUserDeletionConfig()
+	require.NoError(t, err)
+	assert.Equal(t, 30*24*time.Hour, config.GracePeriod)
+	assert.Equal(t, time.Hour, config.PurgeInterval)
+
+	t.Setenv("USER_DELETE_GRACE_PERIOD", "72h")
+	t.Setenv("USER_PURGE_INTERVAL", "10m")
+	config, err = service.NewUserDeletionConfig()
+	require.NoError(t, err)
+	assert.Equal(t, 72*time.Hour, config.GracePeriod)
+	assert.Equal(t, 10*time.Minute, config.PurgeInterval)
+
+	t.Setenv("USER_DELETE_GRACE_PERIOD", "-1h")
+	_, err = service.NewUserDeletionConfig()
+	assert.Error(t, err)
+}
+
+func TestUserDeletion6_DeletedUserHiddenFromHandlers(t *testing.T) {
+	repo := newMemoryUserRepo(models.User{ID: 7, Name: "John", Email: "soft@example.com", Age: 30, DeletedAt: deletedAgo(time.Hour)})
+	userHandler := handlers.NewUserHandler(newDeletionService(repo))
+
+	// Чужого пользователя может запросить только администратор, поэтому обработчики вызываются напрямую
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/users/:user_id", userHandler.GetUserByID)
+	router.PUT("/users/:user_id", userHandler.UpdateUser)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/users/7", nil),
+		httptest.NewRequest(http.MethodPut, "/users/7", strings.NewReader(`{"name": "John", "email": "soft@example.com", "age": 31}`)),
+	} {
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), strings.TrimSuffix(models.ErrUserNotFound.Error(), " "))
+	}
+	assert.Equal(t, 30, repo.users[7].Age)
+}
+
+func TestUserDeletion7_RestoreConflictsWithConcurrentSignup(t *testing.T) {
+	repo := newMemoryUserRepo(
+		models.User{ID: 8, Email: "taken@example.com", DeletedAt: deletedAgo(time.Hour)},
+		models.User{ID: 9, Email: "taken@example.com"},
+	)
+	repo.concurrentSignup = true
+	users := newDeletionService(repo)
+
+	_, err := users.RestoreUser(8)
+	assert.ErrorIs(t, err, models.ErrEmailAlreadyExists, "email занят между проверкой и восстановлением")
+	assert.True(t, repo.users[8].DeletedAt.Valid)
+}
@@ -0,0 +1,155 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"khrllwTest/internal/models"
+	service "khrllwTest/internal/services"
+)
+
+const mergePatch = "application/merge-patch+json"
+
+// patchUser отправляет PATCH /users/<userID> и возвращает ответ
+func patchUser(t *testing.T, userID int, token, contentType, body string) *http.Response {
+	return doRawRequest(t, "PATCH", fmt.Sprintf("%s/users/%d", baseURL, userID), token, contentType, "", body)
+}
+
+// decodeUser разбирает ответ с пользователем и закрывает тело
+func decodeUser(t *testing.T, resp *http.Response) models.UserResponse {
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var user models.UserResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&user))
+	return user
+}
+
+func TestUserPatch1_OnlyPresentFieldsUpdated(t *testing.T) {
+	user, token := createTestUser(t)
+	defer deleteTestUser(t, user.ID, token)
+
+	resp := patchUser(t, user.ID, token, mergePatch, `{"age": 31}`)
+	etag := resp.Header.Get("ETag")
+	patched := decodeUser(t, resp)
+	assert.Equal(t, 31, patched.Age)
+	assert.Equal(t, user.Name, patched.Name)
+	assert.Equal(t, user.Email, patched.Email)
+
+	// Неизменившиеся значения и пустой объект не приводят к UPDATE: версия не меняется
+	for _, body := range []string{`{"name": "` + user.Name + `", "age": 31}`, `{}`} {
+		resp = patchUser(t, user.ID, token, mergePatch+"; charset=utf-8", body)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode, body)
+		assert.Equal(t, etag, resp.Header.Get("ETag"), body)
+	}
+
+	// В БД уходит UPDATE только переданных колонок вместо сохранения всей строки
+	repo, recorder := dryRunUserRepository(t)
+	_ = repo.UpdateFields(7, 0, map[string]interface{}{"age": 31})
+	require.Len(t, recorder.queries, 1)
+	assert.Equal(t, `UPDATE "users" SET "age"=31,"version"=version + 1 WHERE id = 7 AND "users"."deleted_at" IS NULL`, recorder.queries[0])
+}
+
+func TestUserPatch2_ImmutableAndUnknownFieldsRejected(t *testing.T) {
+	user, token := createTestUser(t)
+	defer deleteTestUser(t, user.ID, token)
+
+	for _, body := range []string{
+		`{"id": 99}`,
+		`{"age": 40, "role": "admin"}`,
+		`{"email_verified": true}`,
+		`{"password": "new-password"}`,
+		`{"nickname": "johnny"}`,
+	} {
+		resp := patchUser(t, user.ID, token, mergePatch, body)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+		assert.Contains(t, readAndCloseBody(t, resp.Body), strings.TrimSuffix(models.ErrImmutableUserField.Error(), " "), body)
+	}
+
+	current := decodeUser(t, doRequest(t, "GET", fmt.Sprintf("%s/users/%d", baseURL, user.ID), token, nil))
+	assert.Equal(t, user.Age, current.Age)
+	assert.Equal(t, models.RoleUser, current.Role)
+}
+
+func TestUserPatch3_PresentFieldsValidated(t *testing.T) {
+	user, token := createTestUser(t)
+	defer deleteTestUser(t, user.ID, token)
+
+	for _, body := range []string{
+		`{"name": null}`,
+		`{"name": ""}`,
+		`{"age": 0}`,
+		`{"age": 151}`,
+		`{"age": "31"}`,
+		`{"email": "not-an-email"}`,
+		`[{"age": 31}]`,
+		`null`,
+		`{"age": 31`,
+	} {
+		resp := patchUser(t, user.ID, token, mergePatch, body)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+	}
+
+	current := decodeUser(t, doRequest(t, "GET", fmt.Sprintf("%s/users/%d", baseURL, user.ID), token, nil))
+	assert.Equal(t, user.Age, current.Age)
+	assert.Equal(t, user.Name, current.Name)
+}
+
+func TestUserPatch4_MergePatchContentTypeRequired(t *testing.T) {
+	user, token := createTestUser(t)
+	defer deleteTestUser(t, user.ID, token)
+
+	for _, contentType := range []string{"application/json", "text/plain", ""} {
+		resp := patchUser(t, user.ID, token, contentType, `{"age": 31}`)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode, contentType)
+		assert.Equal(t, mergePatch, resp.Header.Get("Accept-Patch"))
+	}
+}
+
+func TestUserPatch5_EmailChangeResetsVerification(t *testing.T) {
+	user, token := createTestUser(t)
+	defer deleteTestUser(t, user.ID, token)
+	other, otherToken := createTestUser(t)
+	defer deleteTestUser(t, other.ID, otherToken)
+
+	resp := patchUser(t, user.ID, token, mergePatch, `{"email": "`+other.Email+`"}`)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "email занят другим пользователем")
+
+	newEmail := randomEmail()
+	patched := decodeUser(t, patchUser(t, user.ID, token, mergePatch, `{"email": "`+newEmail+`"}`))
+	assert.Equal(t, newEmail, patched.Email)
+	assert.False(t, patched.EmailVerified)
+
+	// Вход выполняется по новому адресу
+	user.Email = newEmail
+	user.Password = "testpassword"
+	assert.NotEmpty(t, loginTestUser(t, user).Token)
+}
+
+func TestUserPatch6_EmailTakenAfterCheckReturnsConflict(t *testing.T) {
+	// Гонку между проверкой email и записью через API не воспроизвести, поэтому она задается репозиторием
+	repo := newMemoryUserRepo(
+		models.User{ID: 7, Name: "John", Email: "john@example.com", Age: 30, Role: models.RoleUser},
+		models.User{ID: 8, Name: "Jane", Email: "jane@example.com", Age: 25, Role: models.RoleUser},
+	)
+	repo.concurrentSignup = true
+	users := service.NewUserService(repo, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	email := "jane@example.com"
+	_, err := users.PatchUser(7, &models.PatchUserRequest{Email: &email}, 0)
+	assert.ErrorIs(t, err, models.ErrEmailAlreadyExists, "email занят между проверкой и записью")
+
+	_, err = users.UpdateUser(7, &models.UpdateUserRequest{Name: "John", Email: email, Age: 30}, 0)
+	assert.ErrorIs(t, err, models.ErrEmailAlreadyExists)
+
+	assert.Equal(t, "john@example.com", repo.users[7].Email)
+}
@@ -0,0 +1,144 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"khrllwTest/internal/models"
+	"khrllwTest/internal/repository"
+	service "khrllwTest/internal/services"
+)
+
+// sqlRecorder запоминает SQL запросы GORM, выполненные в режиме DryRun
+type sqlRecorder struct {
+	logger.Interface
+	queries []string
+}
+
+func (r *sqlRecorder) Trace(_ context.Context, _ time.Time, fc func() (string, int64), _ error) {
+	sql, _ := fc()
+	r.queries = append(r.queries, sql)
+}
+
+// dryRunDB создает подключение GORM, которое только формирует SQL без подключения к БД
+func dryRunDB(t *testing.T) (*gorm.DB, *sqlRecorder) {
+	recorder := &sqlRecorder{Interface: logger.Discard}
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1 port=1"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		Logger:                 recorder,
+	})
+	require.NoError(t, err)
+	return db, recorder
+}
+
+// dryRunUserRepository создает репозиторий пользователей поверх dryRunDB
+func dryRunUserRepository(t *testing.T) (repository.UserRepository, *sqlRecorder) {
+	db, recorder := dryRunDB(t)
+	return repository.NewUserRepository(db), recorder
+}
+
+func TestUserSearch1_ParseSortWhitelist(t *testing.T) {
+	fields, err := service.ParseUserSort("-age, name")
+	require.NoError(t, err)
+	assert.Equal(t, []models.UserSortField{{Field: "age", Desc: true}, {Field: "name"}}, fields)
+	assert.Equal(t, "-age,name", service.FormatUserSort(fields))
+
+	fields, err = service.ParseUserSort("")
+	require.NoError(t, err)
+	assert.Equal(t, "id", service.FormatUserSort(fields), "по умолчанию - по ID")
+
+	for _, raw := range []string{
+		"password_hash",
+		"name;DROP TABLE users",
+		"age,-age",
+		"--age",
+		"name,",
+		"id,name,email,age,role,id",
+	} {
+		_, err := service.ParseUserSort(raw)
+		assert.ErrorIs(t, err, models.ErrInvalidSortParams, raw)
+	}
+}
+
+func TestUserSearch2_QueryUsesEscapedPatternsAndStableOrder(t *testing.T) {
+	repo, recorder := dryRunUserRepository(t)
+
+	_, _, err := repo.GetAll(20, 10, &models.UserFilter{
+		Search:      "50%_off",
+		EmailDomain: "example.com",
+		MinAge:      18,
+		Sort:        []models.UserSortField{{Field: "age", Desc: true}, {Field: "name"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, recorder.queries, 2, "COUNT и выборка страницы")
+
+	count, page := recorder.queries[0], recorder.queries[1]
+	assert.Contains(t, count, `(name ILIKE '%50\%\_off%' OR email ILIKE '%50\%\_off%')`)
+	assert.Contains(t, count, `LOWER(email) LIKE '%@example.com'`)
+	assert.Contains(t, count, `"users"."deleted_at" IS NULL`)
+	assert.NotContains(t, count, "ORDER BY")
+	assert.Contains(t, page, "ORDER BY age DESC, name ASC, id ASC LIMIT 10 OFFSET 20")
+}
+
+func TestUserSearch3_UnknownSortFieldNeverReachesSQL(t *testing.T) {
+	repo, recorder := dryRunUserRepository(t)
+
+	_, _, err := repo.GetAll(0, 10, &models.UserFilter{
+		Sort: []models.UserSortField{{Field: "id; DROP TABLE users"}},
+	})
+	assert.ErrorIs(t, err, models.ErrInvalidSortParams)
+	assert.Empty(t, recorder.queries)
+}
+
+func TestUserSearch4_ResponseEchoesAppliedFiltersAndSort(t *testing.T) {
+	tag := fmt.Sprintf("search%d", time.Now().UnixNano())
+	younger, token := createNamedTestUser(t, "Alice "+tag, 30)
+	defer deleteTestUser(t, younger.ID, token)
+	older, olderToken := createNamedTestUser(t, "Bob "+tag, 40)
+	defer deleteTestUser(t, older.ID, olderToken)
+
+	var response models.UsersListResponse
+	query := "/users?search=%20" + tag + "%20&email_domain=@Example.COM&min_age=18&max_age=65&sort=-age,name"
+	require.Equal(t, http.StatusOK, getJSON(t, baseURL+query, token, &response))
+
+	assert.Equal(t, models.UsersListFilters{Search: tag, EmailDomain: "example.com", MinAge: 18, MaxAge: 65}, response.Filters)
+	assert.Equal(t, "-age,name", response.Sort)
+	assert.Equal(t, []uint{uint(older.ID), uint(younger.ID)}, userIDs(response.Users), "старший пользователь первым")
+
+	response = models.UsersListResponse{}
+	require.Equal(t, http.StatusOK, getJSON(t, baseURL+"/users?search="+strings.ToUpper(tag)+"&max_age=35", token, &response))
+	assert.Equal(t, []uint{uint(younger.ID)}, userIDs(response.Users), "поиск без учета регистра, фильтр по возрасту")
+
+	response = models.UsersListResponse{}
+	require.Equal(t, http.StatusOK, getJSON(t, baseURL+"/users", token, &response))
+	assert.Equal(t, models.UsersListFilters{}, response.Filters)
+	assert.Equal(t, "id", response.Sort)
+}
+
+func TestUserSearch5_InvalidParametersRejected(t *testing.T) {
+	user, token := createTestUser(t)
+	defer deleteTestUser(t, user.ID, token)
+
+	for _, query := range []string{
+		"?sort=password_hash",
+		"?sort=age,age",
+		"?email_domain=example.com%27%20OR%201=1",
+		"?min_age=40&max_age=20",
+		"?min_age=abc",
+	} {
+		resp := doRequest(t, "GET", baseURL+"/users"+query, token, nil)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+	}
+}
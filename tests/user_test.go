@@ -1,11 +1,17 @@
 package tests
 
 import (
+	"errors"
 	"fmt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"khrllwTest/internal/models"
+	service "khrllwTest/internal/services"
+	"khrllwTest/internal/utils"
 	"net/http"
 	"testing"
+	"time"
 )
 
 func TestUser1_CreateValidUser(t *testing.T) {
@@ -238,3 +244,94 @@ func TestUser20_UpdateUserAgeToZero(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 }
+
+func TestUser21_RegularUserCannotChangeRole(t *testing.T) {
+	user, token := createTestUser(t)
+	defer deleteTestUser(t, user.ID, token)
+
+	payload := map[string]interface{}{
+		"role": "admin",
+	}
+	resp := doRequest(t, "PUT", fmt.Sprintf("%s/users/%d/role", baseURL, user.ID), token, payload)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestUser22_ChangePasswordInvalidatesTokens(t *testing.T) {
+	user, token := createTestUser(t)
+
+	user.Password = "testpassword"
+	auth := loginTestUser(t, user)
+
+	payload := map[string]string{
+		"current_password": "testpassword",
+		"new_password":     "newtestpassword",
+	}
+	resp := doRequest(t, "PUT", fmt.Sprintf("%s/users/%d/password", baseURL, user.ID), token, payload)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	// Все ранее выданные токены отклоняются
+	oldAccess := doRequest(t, "GET", fmt.Sprintf("%s/users/%d", baseURL, user.ID), token, nil)
+	defer oldAccess.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, oldAccess.StatusCode)
+
+	oldRefresh := refreshTokens(t, auth.RefreshToken)
+	defer oldRefresh.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, oldRefresh.StatusCode)
+
+	// Вход работает только с новым паролем
+	user.Password = "newtestpassword"
+	fresh := loginTestUser(t, user)
+	deleteTestUser(t, user.ID, fresh.Token)
+}
+
+func TestUser23_ChangePasswordWithWrongCurrent(t *testing.T) {
+	user, token := createTestUser(t)
+	defer deleteTestUser(t, user.ID, token)
+
+	payload := map[string]string{
+		"current_password": "wrongpassword",
+		"new_password":     "newtestpassword",
+	}
+	resp := doRequest(t, "PUT", fmt.Sprintf("%s/users/%d/password", baseURL, user.ID), token, payload)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestUser24_ChangePasswordWrongCurrentThrottled(t *testing.T) {
+	hasher := utils.NewPasswordHasher(bcrypt.MinCost)
+	hash, err := hasher.Hash("testpassword")
+	require.NoError(t, err)
+
+	users := newMemoryUserRepo(models.User{ID: 7, Email: "victim@example.com", PasswordHash: hash})
+	limiter := service.NewLoginLimiter(&service.LoginLimiterConfig{
+		MaxAttempts:     3,
+		IPMaxAttempts:   20,
+		DelayAfter:      5,
+		BaseDelay:       time.Second,
+		LockoutDuration: 15 * time.Minute,
+	})
+	userService := service.NewUserService(users, nil, hasher, limiter, nil, nil, nil, nil, nil)
+
+	change := func(current string) error {
+		return userService.ChangePassword(7, &models.ChangePasswordRequest{
+			CurrentPassword: current,
+			NewPassword:     "newtestpassword",
+		}, "203.0.113.10")
+	}
+
+	for i := 0; i < 3; i++ {
+		assert.ErrorIs(t, change("wrongpassword"), models.ErrInvalidCurrentPassword)
+	}
+
+	// После порога неудач аккаунт заблокирован и для входа, и для смены пароля
+	var retry *models.RetryAfterError
+	require.True(t, errors.As(change("testpassword"), &retry))
+	assert.ErrorIs(t, retry, models.ErrAccountLocked)
+	assert.Greater(t, retry.RetryAfter, time.Duration(0))
+	assert.ErrorIs(t, limiter.Allow("victim@example.com", "198.51.100.7"), models.ErrAccountLocked)
+	assert.Equal(t, hash, users.users[7].PasswordHash)
+}